@@ -0,0 +1,347 @@
+package syncplan
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// FieldDiff is a single field that differs between the remote and the desired
+// definition of a resource. Old is nil for added fields and New is nil for
+// removed ones.
+type FieldDiff struct {
+	// Path locates the field, for example "model.thresholds[high].values" or
+	// "preset.widgets[0].name". List items are identified by name or by
+	// index, depending on how the trees were compared.
+	Path string
+	Old  interface{}
+	New  interface{}
+}
+
+// String formats the difference as "path: old -> new".
+func (d FieldDiff) String() string {
+	switch {
+	case d.Old == nil:
+		return fmt.Sprintf("+ %s: %s", d.Path, formatValue(d.New))
+	case d.New == nil:
+		return fmt.Sprintf("- %s: %s", d.Path, formatValue(d.Old))
+	}
+	return fmt.Sprintf("~ %s: %s -> %s", d.Path, formatValue(d.Old), formatValue(d.New))
+}
+
+// Conflict is a field that was changed on the backend since the definitions
+// were last applied, to a value other than the desired one. Applying the
+// definition would overwrite the change. Base is the value last applied,
+// Remote the value on the backend and Local the desired value; nil means the
+// field is not set.
+type Conflict struct {
+	Path   string
+	Base   interface{}
+	Remote interface{}
+	Local  interface{}
+}
+
+// String formats the conflict as "path: base ..., remote ..., local ...".
+func (c Conflict) String() string {
+	return fmt.Sprintf("! %s: base %s, remote %s, local %s",
+		c.Path, formatValue(c.Base), formatValue(c.Remote), formatValue(c.Local))
+}
+
+func formatValue(v interface{}) string {
+	out, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(out)
+}
+
+// Encode converts a value into a normalized tree using its JSON form, see
+// Normalize, and sorts the lists at the given paths.
+func Encode(v interface{}, unordered ...[]string) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var tree interface{}
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return nil, err
+	}
+	root, _ := Normalize(tree).(map[string]interface{})
+	if root == nil {
+		root = map[string]interface{}{}
+	}
+	SortLists(root, unordered...)
+	return root, nil
+}
+
+// Normalize converts a decoded JSON or YAML document into a tree that
+// compares reliably: maps become map[string]interface{}, and zero values,
+// empty lists and empty maps are dropped so that omitted and empty fields
+// compare equal. List items keep their position.
+func Normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := map[string]interface{}{}
+		for k, value := range v {
+			if n := Normalize(value); n != nil {
+				out[k] = n
+			}
+		}
+		if len(out) == 0 {
+			return nil
+		}
+		return out
+	case map[interface{}]interface{}:
+		out := map[string]interface{}{}
+		for k, value := range v {
+			if n := Normalize(value); n != nil {
+				out[fmt.Sprint(k)] = n
+			}
+		}
+		if len(out) == 0 {
+			return nil
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, 0, len(v))
+		for _, value := range v {
+			switch value.(type) {
+			case map[string]interface{}, map[interface{}]interface{}, []interface{}:
+				value = Normalize(value)
+			}
+			out = append(out, value)
+		}
+		if len(out) == 0 {
+			return nil
+		}
+		return out
+	case string:
+		if v == "" {
+			return nil
+		}
+	case bool:
+		if !v {
+			return nil
+		}
+	case int:
+		if v == 0 {
+			return nil
+		}
+	case float64:
+		if v == 0 {
+			return nil
+		}
+	}
+	return v
+}
+
+// SortLists sorts the lists at the given paths of a normalized tree, for
+// lists whose order has no meaning.
+func SortLists(root map[string]interface{}, paths ...[]string) {
+	for _, path := range paths {
+		sortList(root, path)
+	}
+}
+
+func sortList(m map[string]interface{}, path []string) {
+	if len(path) > 1 {
+		if child, ok := m[path[0]].(map[string]interface{}); ok {
+			sortList(child, path[1:])
+		}
+		return
+	}
+	list, ok := m[path[0]].([]interface{})
+	if !ok {
+		return
+	}
+	sort.SliceStable(list, func(i, j int) bool { return fmt.Sprint(list[i]) < fmt.Sprint(list[j]) })
+}
+
+// Diff returns the differences between two normalized trees, sorted by path.
+// List items are compared by index.
+func Diff(old, new interface{}) []FieldDiff {
+	return diffTrees(old, new, false)
+}
+
+// DiffByName returns the differences between two normalized trees, sorted by
+// path. The items of two lists are matched by their "name" field when every
+// item has a unique one; other lists that differ are reported as a whole.
+func DiffByName(old, new interface{}) []FieldDiff {
+	return diffTrees(old, new, true)
+}
+
+func diffTrees(old, new interface{}, byName bool) []FieldDiff {
+	var diffs []FieldDiff
+	collectDiffs("", old, new, byName, &diffs)
+	sort.SliceStable(diffs, func(i, j int) bool { return diffs[i].Path < diffs[j].Path })
+	return diffs
+}
+
+func collectDiffs(path string, old, new interface{}, byName bool, diffs *[]FieldDiff) {
+	if reflect.DeepEqual(old, new) {
+		return
+	}
+	oldMap, oldIsMap := old.(map[string]interface{})
+	newMap, newIsMap := new.(map[string]interface{})
+	if oldIsMap && newIsMap {
+		keys := map[string]bool{}
+		for k := range oldMap {
+			keys[k] = true
+		}
+		for k := range newMap {
+			keys[k] = true
+		}
+		for k := range keys {
+			collectDiffs(joinPath(path, k), oldMap[k], newMap[k], byName, diffs)
+		}
+		return
+	}
+
+	oldList, oldIsList := old.([]interface{})
+	newList, newIsList := new.([]interface{})
+	switch {
+	case oldIsList && newIsList && byName:
+		oldItems, oldIDs := listItems(oldList)
+		newItems, newIDs := listItems(newList)
+		if oldIDs != nil && newIDs != nil {
+			seen := map[string]bool{}
+			for _, id := range append(append([]string(nil), oldIDs...), newIDs...) {
+				if seen[id] {
+					continue
+				}
+				seen[id] = true
+				collectDiffs(path+"["+id+"]", oldItems[id], newItems[id], byName, diffs)
+			}
+			return
+		}
+	case oldIsList && newIsList:
+		for i := 0; i < len(oldList) || i < len(newList); i++ {
+			var o, n interface{}
+			if i < len(oldList) {
+				o = oldList[i]
+			}
+			if i < len(newList) {
+				n = newList[i]
+			}
+			collectDiffs(path+"["+strconv.Itoa(i)+"]", o, n, byName, diffs)
+		}
+		return
+	}
+
+	*diffs = append(*diffs, FieldDiff{Path: path, Old: old, New: new})
+}
+
+// listItems indexes a list of maps by their names. It returns nil ids unless
+// every item has a unique name.
+func listItems(list []interface{}) (map[string]interface{}, []string) {
+	items := map[string]interface{}{}
+	var ids []string
+	for _, item := range list {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, nil
+		}
+		name, ok := m["name"].(string)
+		if !ok || name == "" {
+			return nil, nil
+		}
+		if _, dup := items[name]; dup {
+			return nil, nil
+		}
+		items[name] = item
+		ids = append(ids, name)
+	}
+	return items, ids
+}
+
+// ThreeWay compares the remote and the desired tree with the base they both
+// derive from. It returns the fields the backend changed since the base to a
+// value other than the desired one, sorted by path. Fields only the
+// definition changed, and fields both sides changed alike, are not
+// conflicts.
+func ThreeWay(base, remote, local map[string]interface{}) []Conflict {
+	var conflicts []Conflict
+	for _, d := range Diff(base, remote) {
+		l := Lookup(local, d.Path)
+		if reflect.DeepEqual(d.New, l) {
+			continue
+		}
+		conflicts = append(conflicts, Conflict{Path: d.Path, Base: d.Old, Remote: d.New, Local: l})
+	}
+	return conflicts
+}
+
+// Lookup returns the value at a path as Diff reports it, or nil.
+func Lookup(tree map[string]interface{}, path string) interface{} {
+	var v interface{} = tree
+	for path != "" {
+		switch node := v.(type) {
+		case map[string]interface{}:
+			key := path
+			for i, r := range path {
+				if r == '.' || r == '[' {
+					key = path[:i]
+					break
+				}
+			}
+			v = node[key]
+			path = path[len(key):]
+		case []interface{}:
+			end := 0
+			for end < len(path) && path[end] != ']' {
+				end++
+			}
+			if path[0] != '[' || end == len(path) {
+				return nil
+			}
+			i, err := strconv.Atoi(path[1:end])
+			if err != nil || i >= len(node) {
+				return nil
+			}
+			v = node[i]
+			path = path[end+1:]
+		default:
+			return nil
+		}
+		if len(path) > 0 && path[0] == '.' {
+			path = path[1:]
+		}
+	}
+	return v
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// SetPath copies the value at a dotted path from src into dst, removing it
+// from dst when src has none. List items cannot be addressed.
+func SetPath(dst, src map[string]interface{}, path []string) {
+	key := path[0]
+	if len(path) == 1 {
+		if v, ok := src[key]; ok {
+			dst[key] = v
+		} else {
+			delete(dst, key)
+		}
+		return
+	}
+	srcChild, _ := src[key].(map[string]interface{})
+	dstChild, ok := dst[key].(map[string]interface{})
+	if !ok {
+		if srcChild == nil {
+			return
+		}
+		dstChild = map[string]interface{}{}
+		dst[key] = dstChild
+	}
+	if srcChild == nil {
+		srcChild = map[string]interface{}{}
+	}
+	SetPath(dstChild, srcChild, path[1:])
+}
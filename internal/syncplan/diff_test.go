@@ -0,0 +1,58 @@
+package syncplan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeDropsZeroValues(t *testing.T) {
+	tree := Normalize(map[interface{}]interface{}{
+		"name":    "a",
+		"empty":   "",
+		"enabled": false,
+		"count":   0,
+		"ratio":   0.0,
+		"nested":  map[interface{}]interface{}{"off": false},
+		"list":    []interface{}{map[interface{}]interface{}{"name": "x", "zero": 0}, "", 2},
+	})
+	require.Equal(t, map[string]interface{}{
+		"name": "a",
+		"list": []interface{}{map[string]interface{}{"name": "x"}, "", 2},
+	}, tree)
+}
+
+func TestDiffMatchesListItems(t *testing.T) {
+	old := map[string]interface{}{"items": []interface{}{
+		map[string]interface{}{"name": "a", "value": 1.0},
+		map[string]interface{}{"name": "b", "value": 2.0},
+	}}
+	new := map[string]interface{}{"items": []interface{}{
+		map[string]interface{}{"name": "b", "value": 3.0},
+		map[string]interface{}{"name": "a", "value": 1.0},
+	}}
+	require.Equal(t, []FieldDiff{{Path: "items[b].value", Old: 2.0, New: 3.0}}, DiffByName(old, new))
+	require.Equal(t, []FieldDiff{
+		{Path: "items[0].name", Old: "a", New: "b"},
+		{Path: "items[0].value", Old: 1.0, New: 3.0},
+		{Path: "items[1].name", Old: "b", New: "a"},
+		{Path: "items[1].value", Old: 2.0, New: 1.0},
+	}, Diff(old, new))
+}
+
+func TestThreeWay(t *testing.T) {
+	base := map[string]interface{}{"name": "a", "tags": []interface{}{"x"}, "team": "t"}
+	remote := map[string]interface{}{"name": "b", "tags": []interface{}{"y"}, "team": "t"}
+	local := map[string]interface{}{"name": "a", "tags": []interface{}{"y"}, "team": "u"}
+	require.Equal(t, []Conflict{{Path: "name", Base: "a", Remote: "b", Local: "a"}}, ThreeWay(base, remote, local))
+	require.Equal(t, "y", Lookup(local, "tags[0]"))
+	require.Nil(t, Lookup(local, "tags[1]"))
+}
+
+func TestSetPath(t *testing.T) {
+	dst := map[string]interface{}{"a": map[string]interface{}{"b": 1, "c": 2}}
+	src := map[string]interface{}{"a": map[string]interface{}{"b": 3}}
+	SetPath(dst, src, []string{"a", "b"})
+	SetPath(dst, src, []string{"a", "c"})
+	require.Equal(t, map[string]interface{}{"a": map[string]interface{}{"b": 3}}, dst)
+}
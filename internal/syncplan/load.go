@@ -0,0 +1,61 @@
+package syncplan
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// LoadDir parses every file below dir with one of the given extensions, one
+// definition per file, and returns the definitions sorted by path. Extensions
+// are given in lower case, with the dot. parse receives the path relative to
+// dir, with forward slashes.
+func LoadDir[D any](dir string, exts []string, parse func(path string, data []byte) (D, error)) ([]D, error) {
+	type loaded struct {
+		path string
+		def  D
+	}
+	var files []loaded
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !slices.Contains(exts, strings.ToLower(filepath.Ext(path))) {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		def, err := parse(rel, data)
+		if err != nil {
+			return err
+		}
+		files = append(files, loaded{path: rel, def: def})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].path < files[j].path })
+	defs := make([]D, len(files))
+	for i, f := range files {
+		defs[i] = f.def
+	}
+	return defs, nil
+}
+
+// DefaultKey derives the key of a definition from its path, without its
+// extension.
+func DefaultKey(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path))
+}
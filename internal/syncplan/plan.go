@@ -0,0 +1,256 @@
+// Package syncplan holds what the monitorsync, syntheticsync and dashboardsync
+// packages share: loading definitions from a directory, the normalized trees
+// definitions are compared as, the differences between them, and the plans,
+// changes and results built from them. The sync packages embed Change in their own change types and keep
+// only the matching of definitions to resources and the API calls.
+package syncplan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Action is what a plan does to a single resource.
+type Action string
+
+// Plan actions.
+const (
+	ActionCreate   Action = "create"
+	ActionUpdate   Action = "update"
+	ActionDelete   Action = "delete"
+	ActionArchive  Action = "archive"
+	ActionConflict Action = "conflict"
+	ActionNoop     Action = "no-op"
+)
+
+// defaultSummary are the actions a plan made without NewPlan counts.
+var defaultSummary = []Action{ActionCreate, ActionUpdate, ActionDelete}
+
+// Change is the planned action for a single resource.
+type Change struct {
+	Action Action
+	// Key matches the local definition to the remote resource.
+	Key  string
+	Name string
+	// ID is the ID of the remote resource; it is empty for creates.
+	ID string
+	// Path is the file of the local definition; it is empty for deletes and
+	// archives.
+	Path  string
+	Diffs []FieldDiff
+	// Conflicts lists the changes made on the backend that applying the
+	// definition would overwrite.
+	Conflicts []Conflict
+}
+
+// String formats the change as a single line.
+func (c *Change) String() string {
+	return fmt.Sprintf("%s %s (%s)", c.Action, c.Key, c.Name)
+}
+
+func (c *Change) change() *Change {
+	return c
+}
+
+// changer is implemented by the change types that embed Change.
+type changer interface {
+	change() *Change
+}
+
+// Plan is the list of changes that brings a backend in line with a set of
+// definitions, sorted by key.
+type Plan[C changer] struct {
+	Changes []C
+
+	summary []Action
+}
+
+// NewPlan returns an empty plan whose String counts the given actions.
+func NewPlan[C changer](summary ...Action) *Plan[C] {
+	return &Plan[C]{summary: summary}
+}
+
+// HasChanges reports whether applying the plan would modify anything. A
+// plan with changes against an already applied set of definitions means the
+// backend has drifted.
+func (p *Plan[C]) HasChanges() bool {
+	for _, c := range p.Changes {
+		if c.change().Action != ActionNoop {
+			return true
+		}
+	}
+	return false
+}
+
+// Count returns how many changes of the given action the plan contains.
+func (p *Plan[C]) Count(action Action) int {
+	n := 0
+	for _, c := range p.Changes {
+		if c.change().Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// Sort orders the changes by key.
+func (p *Plan[C]) Sort() {
+	sort.Slice(p.Changes, func(i, j int) bool { return p.Changes[i].change().Key < p.Changes[j].change().Key })
+}
+
+// String renders the plan for review, one change per line followed by its
+// conflicts and field differences.
+func (p *Plan[C]) String() string {
+	var b strings.Builder
+	for _, pc := range p.Changes {
+		c := pc.change()
+		if c.Action == ActionNoop {
+			continue
+		}
+		b.WriteString(c.String())
+		b.WriteByte('\n')
+		for _, conflict := range c.Conflicts {
+			b.WriteString("    ")
+			b.WriteString(conflict.String())
+			b.WriteByte('\n')
+		}
+		for _, d := range c.Diffs {
+			b.WriteString("    ")
+			b.WriteString(d.String())
+			b.WriteByte('\n')
+		}
+	}
+	summary := p.summary
+	if summary == nil {
+		summary = defaultSummary
+	}
+	for _, action := range summary {
+		if action == ActionConflict {
+			fmt.Fprintf(&b, "%d in conflict, ", p.Count(action))
+		} else {
+			fmt.Fprintf(&b, "%d to %s, ", p.Count(action), action)
+		}
+	}
+	fmt.Fprintf(&b, "%d unchanged\n", p.Count(ActionNoop))
+	return b.String()
+}
+
+// Result reports what Apply did. In a dry run Applied lists the changes that
+// would have been made.
+type Result[C changer] struct {
+	DryRun  bool
+	Applied []C
+	Failed  []*ChangeError[C]
+}
+
+func (r *Result[C]) err() error {
+	errs := make([]error, len(r.Failed))
+	for i, f := range r.Failed {
+		errs[i] = f
+	}
+	return errors.Join(errs...)
+}
+
+// ChangeError is the failure to apply a single change.
+type ChangeError[C changer] struct {
+	Change C
+	Err    error
+
+	kind string
+}
+
+// Error implements the error interface.
+func (e *ChangeError[C]) Error() string {
+	c := e.Change.change()
+	action := c.Action
+	if action == ActionConflict {
+		action = ActionUpdate
+	}
+	return fmt.Sprintf("failed to %s %s %s: %v", action, e.kind, c.Key, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ChangeError[C]) Unwrap() error {
+	return e.Err
+}
+
+// Applier makes the changes of a plan.
+type Applier[C changer] struct {
+	// Kind names the resource in errors, for example "monitor".
+	Kind        string
+	Concurrency int
+	DryRun      bool
+	// Apply makes a single change.
+	Apply func(context.Context, C) error
+	// Applied, if set, is called for every change that was made, one at a
+	// time.
+	Applied func(C)
+	// ErrConflict is the error of the changes planned as conflicts, which
+	// are never made.
+	ErrConflict error
+}
+
+// Run makes the changes of a plan, up to Concurrency at a time. Failed
+// changes do not stop the others; they are reported in the result and
+// joined in the returned error.
+func (a *Applier[C]) Run(ctx context.Context, plan *Plan[C]) (*Result[C], error) {
+	result := &Result[C]{DryRun: a.DryRun}
+	var pending []C
+	for _, c := range plan.Changes {
+		switch c.change().Action {
+		case ActionNoop:
+		case ActionConflict:
+			result.Failed = append(result.Failed, &ChangeError[C]{Change: c, Err: a.ErrConflict, kind: a.Kind})
+		default:
+			pending = append(pending, c)
+		}
+	}
+	if a.DryRun {
+		result.Applied = pending
+		return result, result.err()
+	}
+
+	var mu sync.Mutex
+	attempted := make([]bool, len(pending))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(a.Concurrency)
+	for i, c := range pending {
+		g.Go(func() error {
+			if err := gctx.Err(); err != nil {
+				return err
+			}
+			err := a.Apply(gctx, c)
+			mu.Lock()
+			defer mu.Unlock()
+			attempted[i] = true
+			if err != nil {
+				result.Failed = append(result.Failed, &ChangeError[C]{Change: c, Err: err, kind: a.Kind})
+				return nil
+			}
+			result.Applied = append(result.Applied, c)
+			if a.Applied != nil {
+				a.Applied(c)
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		// The context was cancelled before every change was attempted.
+		for i, c := range pending {
+			if !attempted[i] {
+				result.Failed = append(result.Failed, &ChangeError[C]{Change: c, Err: err, kind: a.Kind})
+			}
+		}
+	}
+	sort.Slice(result.Applied, func(i, j int) bool { return result.Applied[i].change().Key < result.Applied[j].change().Key })
+	sort.Slice(result.Failed, func(i, j int) bool {
+		return result.Failed[i].Change.change().Key < result.Failed[j].Change.change().Key
+	})
+	return result, result.err()
+}
@@ -0,0 +1,47 @@
+// Package testutil holds the fixtures shared by the tests of the SDK packages.
+package testutil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/client"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/transport"
+	"github.com/stretchr/testify/require"
+)
+
+// NewAPI serves handler as a fake backend for the duration of the test and
+// returns a client for it.
+func NewAPI(t *testing.T, handler http.Handler) *client.GroundcoverAPI {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	api, err := transport.NewSDKClient("key", "backend", srv.URL)
+	require.NoError(t, err)
+	return api
+}
+
+// WriteFiles writes files, by slash-separated path relative to a new
+// temporary directory, and returns the directory.
+func WriteFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	return dir
+}
+
+// MustParse parses a definition with the ParseDefinition function of a sync
+// package and fails the test on error.
+func MustParse[T any](t *testing.T, parse func(path string, data []byte) (T, error), path, doc string) T {
+	t.Helper()
+	def, err := parse(path, []byte(doc))
+	require.NoError(t, err)
+	return def
+}
@@ -0,0 +1,46 @@
+// Package yamlutil converts YAML documents for the encoding/json based models
+// of the SDK.
+package yamlutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
+
+// JSONCompatible converts the maps YAML decodes into ones JSON can encode.
+func JSONCompatible(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, value := range v {
+			out[fmt.Sprint(k)] = JSONCompatible(value)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, value := range v {
+			out[i] = JSONCompatible(value)
+		}
+		return out
+	}
+	return v
+}
+
+// UnmarshalStrict decodes a YAML or JSON document into v through its JSON
+// form, so that the json tags of v apply. Unknown fields are rejected.
+func UnmarshalStrict(data []byte, v interface{}) error {
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	data, err := json.Marshal(JSONCompatible(doc))
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
@@ -0,0 +1,437 @@
+package dashboard
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/go-openapi/swag"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/timerange"
+)
+
+// GridColumns is the width of the dashboard grid, in grid units.
+const GridColumns = 12
+
+// Default size of a panel, in grid units.
+const (
+	DefaultPanelWidth  = 4
+	DefaultPanelHeight = 3
+)
+
+// Variable types.
+const (
+	VariableTypeQuery  = "query"
+	VariableTypeCustom = "custom"
+)
+
+var variableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Builder composes a dashboard. Panels are placed left to right in the
+// order they are added, wrapping to a new row when the grid is full, unless
+// they are given a position with At. Methods record problems rather than
+// failing immediately; Build reports all of them at once. A Builder should
+// not be reused after Build.
+type Builder struct {
+	name        string
+	description string
+	team        string
+	tags        []string
+	preset      *Preset
+	panels      []*Panel
+	errs        []error
+}
+
+// New starts a dashboard with the given name.
+func New(name string) *Builder {
+	return &Builder{
+		name:   name,
+		tags:   []string{},
+		preset: &Preset{SchemaVersion: SchemaVersion, Variables: map[string]*Variable{}},
+	}
+}
+
+func (b *Builder) addError(format string, args ...interface{}) {
+	b.errs = append(b.errs, fmt.Errorf(format, args...))
+}
+
+// Description sets the description of the dashboard.
+func (b *Builder) Description(description string) *Builder {
+	b.description = description
+	return b
+}
+
+// Team sets the team that owns the dashboard.
+func (b *Builder) Team(team string) *Builder {
+	b.team = team
+	return b
+}
+
+// Tags adds tags to the dashboard.
+func (b *Builder) Tags(tags ...string) *Builder {
+	b.tags = append(b.tags, tags...)
+	return b
+}
+
+// Duration sets the default time range, such as "Last 1 hour".
+func (b *Builder) Duration(duration string) *Builder {
+	b.preset.Duration = duration
+	return b
+}
+
+// Variable adds a variable that queries can refer to as $name.
+func (b *Builder) Variable(name string, v *Variable) *Builder {
+	switch {
+	case !variableName.MatchString(name):
+		b.addError("invalid variable name %q", name)
+	case v == nil:
+		b.addError("variable %s must not be nil", name)
+	case b.preset.Variables[name] != nil:
+		b.addError("variable %s is defined more than once", name)
+	default:
+		b.preset.Variables[name] = v
+	}
+	return b
+}
+
+// QueryVariable returns a variable whose values come from a query, such as
+// "label_values(groundcover_container_cpu_usage_rate_millis, cluster)".
+func QueryVariable(label, query string) *Variable {
+	return &Variable{Type: VariableTypeQuery, Label: label, Query: query}
+}
+
+// CustomVariable returns a variable with a fixed list of values. The first
+// is the default.
+func CustomVariable(label string, options ...string) *Variable {
+	v := &Variable{Type: VariableTypeCustom, Label: label, Options: options}
+	if len(options) > 0 {
+		v.Default = []string{options[0]}
+	}
+	return v
+}
+
+// Add adds panels to the dashboard.
+func (b *Builder) Add(panels ...*Panel) *Builder {
+	for _, p := range panels {
+		if p == nil {
+			b.addError("panel must not be nil")
+			continue
+		}
+		b.panels = append(b.panels, p)
+	}
+	return b
+}
+
+// Row starts a new row: the next panel placed automatically goes below all
+// panels added so far.
+func (b *Builder) Row() *Builder {
+	b.panels = append(b.panels, nil)
+	return b
+}
+
+// Preset checks the dashboard and returns its content.
+func (b *Builder) Preset() (*Preset, error) {
+	errs := append([]error(nil), b.errs...)
+	if b.name == "" {
+		errs = append(errs, fmt.Errorf("name is required"))
+	}
+
+	p := *b.preset
+	p.Widgets = nil
+	p.Layout = nil
+	var x, y, rowEnd, bottom int
+	for i, panel := range b.panels {
+		if panel == nil {
+			x, y = 0, bottom
+			rowEnd = bottom
+			continue
+		}
+		id := widgetID(len(p.Widgets))
+		w, err := panel.widget(id)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("panel %d (%s): %w", i+1, panel.title(), err))
+			continue
+		}
+		l := &LayoutItem{ID: id, W: panel.w, H: panel.h, MinH: panel.minH}
+		if panel.at != nil {
+			l.X, l.Y = panel.at[0], panel.at[1]
+		} else {
+			if x+l.W > GridColumns {
+				x, y = 0, rowEnd
+			}
+			l.X, l.Y = x, y
+			x += l.W
+			rowEnd = max(rowEnd, y+l.H)
+		}
+		bottom = max(bottom, l.Y+l.H)
+		if l.X+l.W > GridColumns {
+			errs = append(errs, fmt.Errorf("panel %d (%s) extends past column %d", i+1, panel.title(), GridColumns))
+		}
+		for _, other := range p.Layout {
+			if overlaps(l, other) {
+				errs = append(errs, fmt.Errorf("panel %d (%s) overlaps widget %s", i+1, panel.title(), other.ID))
+			}
+		}
+		p.Widgets = append(p.Widgets, w)
+		p.Layout = append(p.Layout, l)
+	}
+	if err := p.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("invalid dashboard %q: %w", b.name, err)
+	}
+	return &p, nil
+}
+
+// Build checks the dashboard and returns the request that creates it.
+func (b *Builder) Build() (*models.CreateDashboardRequest, error) {
+	p, err := b.Preset()
+	if err != nil {
+		return nil, err
+	}
+	preset, err := p.Encode()
+	if err != nil {
+		return nil, err
+	}
+	return &models.CreateDashboardRequest{
+		Name:        b.name,
+		Description: b.description,
+		Team:        b.team,
+		Tags:        b.tags,
+		Preset:      preset,
+	}, nil
+}
+
+// BuildUpdate is like Build but returns an update of the dashboard at the
+// given revision.
+func (b *Builder) BuildUpdate(currentRevision int32) (*models.UpdateDashboardRequest, error) {
+	req, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	return &models.UpdateDashboardRequest{
+		CurrentRevision: currentRevision,
+		Name:            req.Name,
+		Description:     req.Description,
+		Team:            req.Team,
+		Tags:            req.Tags,
+		Preset:          req.Preset,
+	}, nil
+}
+
+func overlaps(a, b *LayoutItem) bool {
+	return a.X < b.X+b.W && b.X < a.X+a.W && a.Y < b.Y+b.H && b.Y < a.Y+a.H
+}
+
+// widgetID returns the ID of the i-th widget: "A" to "Z", then "AA" and so
+// on, as the dashboard editor assigns them.
+func widgetID(i int) string {
+	id := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		id = string(rune('A'+(i-1)%26)) + id
+	}
+	return id
+}
+
+// Panel is a widget of a dashboard, with its size and position.
+type Panel struct {
+	kind    string
+	name    string
+	html    string
+	queries []*Query
+	options map[string]interface{}
+	w, h    int
+	minH    int
+	at      *[2]int
+	errs    []error
+}
+
+func newPanel(kind, name string) *Panel {
+	return &Panel{kind: kind, name: name, w: DefaultPanelWidth, h: DefaultPanelHeight}
+}
+
+// TimeSeries starts a panel that charts its queries over time.
+func TimeSeries(name string) *Panel {
+	return newPanel(VisualizationTimeSeries, name)
+}
+
+// Table starts a panel that shows its queries as a table.
+func Table(name string) *Panel {
+	return newPanel(VisualizationTable, name)
+}
+
+// Stat starts a panel that shows a single value.
+func Stat(name string) *Panel {
+	return newPanel(VisualizationStat, name)
+}
+
+// LogStream starts a panel that lists the logs its query matches.
+func LogStream(name string) *Panel {
+	return newPanel(VisualizationLogStream, name)
+}
+
+// Text starts a panel that shows HTML.
+func Text(html string) *Panel {
+	p := newPanel("", "")
+	p.html = html
+	return p
+}
+
+func (p *Panel) addError(format string, args ...interface{}) {
+	p.errs = append(p.errs, fmt.Errorf(format, args...))
+}
+
+func (p *Panel) title() string {
+	if p.kind == "" {
+		return "text"
+	}
+	return fmt.Sprintf("%s %q", p.kind, p.name)
+}
+
+// QueryOption configures a query of a panel.
+type QueryOption func(*Query)
+
+// WithStep sets the resolution of the query.
+func WithStep(step time.Duration) QueryOption {
+	return func(q *Query) {
+		q.Step = swag.String(timerange.FormatDuration(step))
+	}
+}
+
+// WithEditorMode sets how the dashboard editor shows the query.
+func WithEditorMode(mode string) QueryOption {
+	return func(q *Query) {
+		q.EditorMode = mode
+	}
+}
+
+// WithQueryID sets the ID of the query. Queries are named "A", "B" and so
+// on by default.
+func WithQueryID(id string) QueryOption {
+	return func(q *Query) {
+		q.ID = id
+	}
+}
+
+// Query adds a fully specified query.
+func (p *Panel) Query(q *Query, opts ...QueryOption) *Panel {
+	if q == nil {
+		p.addError("query must not be nil")
+		return p
+	}
+	for _, opt := range opts {
+		opt(q)
+	}
+	p.queries = append(p.queries, q)
+	return p
+}
+
+// PromQL adds a PromQL query over metrics.
+func (p *Panel) PromQL(expr string, opts ...QueryOption) *Panel {
+	if expr == "" {
+		p.addError("PromQL expression is required")
+	}
+	return p.Query(&Query{Expr: expr, DataType: DataTypeMetrics, EditorMode: EditorModeCode}, opts...)
+}
+
+// Logs adds a logs query running the given SQL pipeline.
+func (p *Panel) Logs(pipeline *models.SQLPipeline, opts ...QueryOption) *Panel {
+	return p.sqlQuery(DataTypeLogs, pipeline, opts)
+}
+
+// Traces adds a traces query running the given SQL pipeline.
+func (p *Panel) Traces(pipeline *models.SQLPipeline, opts ...QueryOption) *Panel {
+	return p.sqlQuery(DataTypeTraces, pipeline, opts)
+}
+
+// Events adds an events query running the given SQL pipeline.
+func (p *Panel) Events(pipeline *models.SQLPipeline, opts ...QueryOption) *Panel {
+	return p.sqlQuery(DataTypeEvents, pipeline, opts)
+}
+
+func (p *Panel) sqlQuery(dataType string, pipeline *models.SQLPipeline, opts []QueryOption) *Panel {
+	if pipeline == nil {
+		p.addError("%s SQL pipeline is required", dataType)
+	}
+	return p.Query(&Query{DataType: dataType, SQLPipeline: pipeline, EditorMode: EditorModeBuilder}, opts...)
+}
+
+// Option sets a visualization setting the model has no field for, such as
+// the unit of a panel.
+func (p *Panel) Option(key string, value interface{}) *Panel {
+	if p.options == nil {
+		p.options = map[string]interface{}{}
+	}
+	p.options[key] = value
+	return p
+}
+
+// Size sets the width and height of the panel, in grid units.
+func (p *Panel) Size(w, h int) *Panel {
+	if w <= 0 || h <= 0 || w > GridColumns {
+		p.addError("size must be between 1 and %d columns wide and positive, got %dx%d", GridColumns, w, h)
+		return p
+	}
+	p.w, p.h = w, h
+	return p
+}
+
+// MinHeight sets how far the panel can be shrunk in the dashboard editor.
+func (p *Panel) MinHeight(h int) *Panel {
+	p.minH = h
+	return p
+}
+
+// At places the panel at a fixed column and row instead of after the
+// previous panel.
+func (p *Panel) At(x, y int) *Panel {
+	if x < 0 || y < 0 {
+		p.addError("position must not be negative, got %d,%d", x, y)
+		return p
+	}
+	p.at = &[2]int{x, y}
+	return p
+}
+
+// widget converts the panel into the widget with the given ID.
+func (p *Panel) widget(id string) (*Widget, error) {
+	errs := append([]error(nil), p.errs...)
+	if p.kind == "" {
+		if len(p.queries) > 0 {
+			errs = append(errs, fmt.Errorf("text panels cannot have queries"))
+		}
+		return &Widget{ID: id, Type: WidgetTypeText, HTML: p.html}, errors.Join(errs...)
+	}
+
+	if len(p.queries) == 0 {
+		errs = append(errs, fmt.Errorf("at least one query is required"))
+	}
+	if p.kind == VisualizationLogStream {
+		for _, q := range p.queries {
+			if q.DataType != DataTypeLogs {
+				errs = append(errs, fmt.Errorf("log stream panels need logs queries, got %s", q.DataType))
+			}
+		}
+	}
+	w := &Widget{ID: id, Type: WidgetTypeWidget, Name: p.name, VisualizationConfig: &VisualizationConfig{Type: p.kind}}
+	ids := map[string]bool{}
+	for i, q := range p.queries {
+		q := *q
+		if q.ID == "" {
+			q.ID = widgetID(i)
+		}
+		if ids[q.ID] {
+			errs = append(errs, fmt.Errorf("query ID %s is used more than once", q.ID))
+		}
+		ids[q.ID] = true
+		w.Queries = append(w.Queries, &q)
+	}
+	for key, value := range p.options {
+		if err := w.VisualizationConfig.SetOption(key, value); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return w, errors.Join(errs...)
+}
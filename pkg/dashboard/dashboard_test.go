@@ -0,0 +1,172 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+// editorPreset is a preset as the dashboard editor writes it, with fields
+// the model does not know about at several levels.
+const editorPreset = `{
+  "duration": "Last 30 minutes",
+  "layout": [
+    {"id": "A", "x": 0, "y": 0, "w": 4, "h": 3, "minH": 2, "static": false},
+    {"id": "B", "x": 0, "y": 3, "w": 4, "h": 3, "minH": 1}
+  ],
+  "widgets": [
+    {
+      "id": "A",
+      "type": "widget",
+      "name": "Disk usage",
+      "description": "Used disk space per node",
+      "queries": [
+        {
+          "id": "A",
+          "expr": "avg(groundcover_node_rt_disk_space_used_percent{})",
+          "dataType": "metrics",
+          "step": null,
+          "editorMode": "builder",
+          "legendFormat": "{{node}}"
+        }
+      ],
+      "visualizationConfig": {"type": "time-series", "unit": "percent", "legend": {"show": true}}
+    },
+    {"id": "B", "type": "text", "html": "<p>SDK Test Widget</p>"}
+  ],
+  "variables": {"cluster": {"type": "query", "query": "label_values(cluster)", "hide": false}},
+  "schemaVersion": 3,
+  "timezone": "utc"
+}`
+
+func TestPresetRoundTripKeepsUnknownFields(t *testing.T) {
+	p, err := ParsePreset(editorPreset)
+	require.NoError(t, err)
+	require.NoError(t, p.Validate())
+	require.Equal(t, []string{
+		"layout[A].static",
+		"timezone",
+		"variables.cluster.hide",
+		"widgets[A].description",
+		"widgets[A].queries[A].legendFormat",
+		"widgets[A].visualizationConfig.legend",
+		"widgets[A].visualizationConfig.unit",
+	}, p.UnknownFields())
+
+	w := p.Widget("A")
+	require.Equal(t, VisualizationTimeSeries, w.VisualizationConfig.Type)
+	require.Nil(t, w.Queries[0].Step)
+	var unit string
+	ok, err := w.VisualizationConfig.Option("unit", &unit)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "percent", unit)
+
+	w.Name = "Disk usage per node"
+	p.LayoutOf("A").W = 6
+	encoded, err := p.Encode()
+	require.NoError(t, err)
+
+	var want, got map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(editorPreset), &want))
+	require.NoError(t, json.Unmarshal([]byte(encoded), &got))
+	want["widgets"].([]interface{})[0].(map[string]interface{})["name"] = "Disk usage per node"
+	want["layout"].([]interface{})[0].(map[string]interface{})["w"] = 6.0
+	require.Equal(t, want, got)
+}
+
+func TestPresetValidate(t *testing.T) {
+	p, err := ParsePreset(`{
+		"layout": [{"id": "A", "x": 0, "y": 0, "w": 4, "h": 3}, {"id": "C", "x": 0, "y": 0, "w": 0, "h": 3}],
+		"widgets": [{"id": "A", "type": "widget"}, {"id": "B", "type": "text"}]
+	}`)
+	require.NoError(t, err)
+	require.EqualError(t, p.Validate(), "widget A has no queries\n"+
+		"layout item C has no widget\n"+
+		"layout item C has an invalid position or size\n"+
+		"widget B has no layout item")
+}
+
+func TestBuilder(t *testing.T) {
+	errors := &models.SQLPipeline{Limit: 100}
+	req, err := New("Checkout").
+		Description("Checkout service health").
+		Team("payments").
+		Tags("checkout", "slo").
+		Duration("Last 1 hour").
+		Variable("cluster", QueryVariable("Cluster", "label_values(groundcover_workload_latency_seconds, cluster)")).
+		Variable("env", CustomVariable("Environment", "prod", "staging")).
+		Add(
+			Text("<h2>Checkout</h2>").Size(12, 1),
+			TimeSeries("Latency").
+				PromQL(`histogram_quantile(0.95, rate(groundcover_workload_latency_seconds_bucket{cluster="$cluster"}[5m]))`, WithStep(time.Minute)).
+				PromQL(`histogram_quantile(0.5, rate(groundcover_workload_latency_seconds_bucket{cluster="$cluster"}[5m]))`).
+				Option("unit", "s"),
+			Stat("Error rate").PromQL(`sum(rate(groundcover_workload_errors_total[5m]))`),
+			Table("Top errors").Logs(errors),
+		).
+		Row().
+		Add(LogStream("Errors").Logs(errors).Size(12, 4)).
+		Build()
+	require.NoError(t, err)
+	require.Equal(t, "Checkout", req.Name)
+	require.Equal(t, "payments", req.Team)
+	require.Equal(t, []string{"checkout", "slo"}, req.Tags)
+
+	p, err := ParsePreset(req.Preset)
+	require.NoError(t, err)
+	require.Equal(t, SchemaVersion, p.SchemaVersion)
+	require.Equal(t, "Last 1 hour", p.Duration)
+	require.Equal(t, []string{"prod"}, p.Variables["env"].Default)
+
+	type box struct{ x, y, w, h int }
+	var boxes []box
+	for _, l := range p.Layout {
+		boxes = append(boxes, box{l.X, l.Y, l.W, l.H})
+	}
+	require.Equal(t, []box{{0, 0, 12, 1}, {0, 1, 4, 3}, {4, 1, 4, 3}, {8, 1, 4, 3}, {0, 4, 12, 4}}, boxes)
+
+	latency := p.Widget("B")
+	require.Equal(t, "Latency", latency.Name)
+	require.Len(t, latency.Queries, 2)
+	require.Equal(t, "A", latency.Queries[0].ID)
+	require.Equal(t, "1m", *latency.Queries[0].Step)
+	require.Equal(t, "B", latency.Queries[1].ID)
+	require.Equal(t, []string{"widgets[B].visualizationConfig.unit"}, p.UnknownFields())
+	require.Equal(t, DataTypeLogs, p.Widget("E").Queries[0].DataType)
+	require.Equal(t, uint64(100), p.Widget("E").Queries[0].SQLPipeline.Limit)
+
+	update, err := New("Checkout").Add(Stat("Up").PromQL("up")).BuildUpdate(7)
+	require.NoError(t, err)
+	require.Equal(t, int32(7), update.CurrentRevision)
+}
+
+func TestBuilderReportsAllProblems(t *testing.T) {
+	_, err := New("").
+		Variable("bad name", CustomVariable("x")).
+		Add(
+			TimeSeries("Empty"),
+			LogStream("Metrics").PromQL("up"),
+			Stat("Wide").PromQL("up").Size(8, 2).At(6, 0),
+			Stat("Overlap").PromQL("up").At(7, 1),
+		).
+		Build()
+	require.EqualError(t, err, `invalid dashboard "": `+
+		`invalid variable name "bad name"`+"\n"+
+		`name is required`+"\n"+
+		`panel 1 (time-series "Empty"): at least one query is required`+"\n"+
+		`panel 2 (log-stream "Metrics"): log stream panels need logs queries, got metrics`+"\n"+
+		`panel 3 (stat "Wide") extends past column 12`+"\n"+
+		`panel 4 (stat "Overlap") overlaps widget A`)
+}
+
+func TestWidgetID(t *testing.T) {
+	require.Equal(t, "A", widgetID(0))
+	require.Equal(t, "Z", widgetID(25))
+	require.Equal(t, "AA", widgetID(26))
+	require.Equal(t, "AZ", widgetID(51))
+	require.Equal(t, "BA", widgetID(52))
+}
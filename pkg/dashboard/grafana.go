@@ -0,0 +1,811 @@
+package dashboard
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/client"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/client/dashboards"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+)
+
+// DefaultRateInterval replaces Grafana's $__rate_interval and $__interval in
+// imported queries, unless WithRateInterval sets another range.
+const DefaultRateInterval = "5m"
+
+// Grafana lays dashboards out on a 24-column grid, with panels 8 rows high
+// by default.
+const (
+	grafanaColumns     = 24
+	grafanaPanelHeight = 8
+)
+
+// GrafanaImport is a Grafana dashboard converted into a groundcover
+// dashboard.
+type GrafanaImport struct {
+	// Title is the title of the Grafana dashboard.
+	Title   string
+	Preset  *Preset
+	Request *models.CreateDashboardRequest
+	// Warnings point out what was converted with changes, such as a gauge
+	// shown as a stat panel.
+	Warnings []string
+	// Skipped lists the panels, queries and variables that were not
+	// converted.
+	Skipped []SkippedImport
+}
+
+// SkippedImport is a panel, query or variable that was not imported.
+type SkippedImport struct {
+	// Source identifies what was skipped, such as `panel "Latency" query B`.
+	Source string
+	Reason string
+}
+
+// String summarizes the import for review: the dashboard, its warnings and
+// what was skipped.
+func (im *GrafanaImport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "+ %s (%d panels)\n", im.Request.Name, len(im.Preset.Widgets))
+	for _, w := range im.Warnings {
+		fmt.Fprintf(&b, "    ! %s\n", w)
+	}
+	for _, s := range im.Skipped {
+		fmt.Fprintf(&b, "    - %s: %s\n", s.Source, s.Reason)
+	}
+	return b.String()
+}
+
+// GrafanaOption configures ImportGrafana and ImportGrafanaDir.
+type GrafanaOption func(*grafanaConfig)
+
+type grafanaConfig struct {
+	team         string
+	tags         []string
+	namePrefix   string
+	rateInterval string
+	dryRun       bool
+	out          io.Writer
+}
+
+func newGrafanaConfig(opts []GrafanaOption) *grafanaConfig {
+	cfg := &grafanaConfig{rateInterval: DefaultRateInterval, out: io.Discard}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithTeam sets the team that owns the imported dashboards.
+func WithTeam(team string) GrafanaOption {
+	return func(c *grafanaConfig) {
+		c.team = team
+	}
+}
+
+// WithTags adds tags to every imported dashboard, next to its Grafana tags.
+func WithTags(tags ...string) GrafanaOption {
+	return func(c *grafanaConfig) {
+		c.tags = append(c.tags, tags...)
+	}
+}
+
+// WithNamePrefix prefixes the names of the imported dashboards.
+func WithNamePrefix(prefix string) GrafanaOption {
+	return func(c *grafanaConfig) {
+		c.namePrefix = prefix
+	}
+}
+
+// WithRateInterval sets the range that replaces $__rate_interval and
+// $__interval in imported queries, such as "1m".
+func WithRateInterval(interval string) GrafanaOption {
+	return func(c *grafanaConfig) {
+		c.rateInterval = interval
+	}
+}
+
+// WithDryRun makes ImportGrafanaDir convert the dashboards without creating
+// them.
+func WithDryRun(dryRun bool) GrafanaOption {
+	return func(c *grafanaConfig) {
+		c.dryRun = dryRun
+	}
+}
+
+// WithOutput makes ImportGrafanaDir write a line per dashboard to w as it is
+// created, or every converted dashboard in a dry run.
+func WithOutput(w io.Writer) GrafanaOption {
+	return func(c *grafanaConfig) {
+		c.out = w
+	}
+}
+
+type grafanaDashboard struct {
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	Tags        []string        `json:"tags"`
+	Panels      []*grafanaPanel `json:"panels"`
+	Rows        json.RawMessage `json:"rows"`
+	Time        struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+	} `json:"time"`
+	Templating struct {
+		List []*grafanaVariable `json:"list"`
+	} `json:"templating"`
+}
+
+type grafanaPanel struct {
+	ID          int    `json:"id"`
+	Type        string `json:"type"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	GridPos     struct {
+		X int `json:"x"`
+		Y int `json:"y"`
+		W int `json:"w"`
+		H int `json:"h"`
+	} `json:"gridPos"`
+	Datasource   json.RawMessage  `json:"datasource"`
+	Targets      []*grafanaTarget `json:"targets"`
+	Repeat       string           `json:"repeat"`
+	LibraryPanel json.RawMessage  `json:"libraryPanel"`
+	FieldConfig  struct {
+		Defaults struct {
+			Unit       string             `json:"unit"`
+			Decimals   *int               `json:"decimals"`
+			Thresholds *grafanaThresholds `json:"thresholds"`
+		} `json:"defaults"`
+	} `json:"fieldConfig"`
+	Options struct {
+		Content string `json:"content"`
+		Mode    string `json:"mode"`
+	} `json:"options"`
+	// Content and Mode are where text panels kept their content before
+	// Grafana 7.
+	Content string `json:"content"`
+	Mode    string `json:"mode"`
+	// Panels are the panels of a collapsed row.
+	Panels []*grafanaPanel `json:"panels"`
+}
+
+type grafanaTarget struct {
+	RefID      string          `json:"refId"`
+	Expr       string          `json:"expr"`
+	Hide       bool            `json:"hide"`
+	Interval   string          `json:"interval"`
+	Datasource json.RawMessage `json:"datasource"`
+}
+
+type grafanaThresholds struct {
+	Mode  string `json:"mode"`
+	Steps []struct {
+		Color string   `json:"color"`
+		Value *float64 `json:"value"`
+	} `json:"steps"`
+}
+
+type grafanaVariable struct {
+	Name       string          `json:"name"`
+	Label      string          `json:"label"`
+	Type       string          `json:"type"`
+	Query      json.RawMessage `json:"query"`
+	Datasource json.RawMessage `json:"datasource"`
+	Multi      bool            `json:"multi"`
+	Current    struct {
+		Value json.RawMessage `json:"value"`
+	} `json:"current"`
+	Options []struct {
+		Value string `json:"value"`
+	} `json:"options"`
+}
+
+// ImportGrafana converts a Grafana dashboard, as exported to JSON or as the
+// Grafana API returns it, into a groundcover dashboard.
+//
+// Time series, stat, table and text panels are converted, along with their
+// Prometheus queries, units and thresholds; gauges become stat panels, and
+// rows become headings with their panels below them. Query and custom
+// variables are converted, and ${var} and [[var]] references are rewritten
+// to $var. Panel types, queries and variables that have no counterpart are
+// reported in Skipped rather than failing the import. The layout is scaled
+// to the 12-column grid of groundcover dashboards.
+func ImportGrafana(data []byte, opts ...GrafanaOption) (*GrafanaImport, error) {
+	var envelope struct {
+		Dashboard json.RawMessage `json:"dashboard"`
+	}
+	if err := json.Unmarshal(data, &envelope); err == nil && len(envelope.Dashboard) > 0 && envelope.Dashboard[0] == '{' {
+		data = envelope.Dashboard
+	}
+	var d grafanaDashboard
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil, fmt.Errorf("failed to parse Grafana dashboard: %w", err)
+	}
+	if d.Title == "" {
+		return nil, fmt.Errorf("failed to import Grafana dashboard: title is required")
+	}
+
+	c := &grafanaConverter{cfg: newGrafanaConfig(opts), result: &GrafanaImport{Title: d.Title}}
+	b := New(c.cfg.namePrefix + d.Title).
+		Description(d.Description).
+		Team(c.cfg.team).
+		Tags(d.Tags...).
+		Tags(c.cfg.tags...).
+		Duration(c.duration(d.Time.From, d.Time.To))
+	for _, v := range d.Templating.List {
+		if name, variable := c.variable(v); variable != nil {
+			b.Variable(name, variable)
+		}
+	}
+	if len(d.Rows) > 0 && string(d.Rows) != "null" && string(d.Rows) != "[]" {
+		c.skip("rows", "the row layout of dashboards before Grafana 5 is not supported; save the dashboard in a recent Grafana first")
+	}
+	b.Add(c.panels(d.Panels)...)
+
+	p, err := b.Preset()
+	if err != nil {
+		return nil, fmt.Errorf("failed to import Grafana dashboard: %w", err)
+	}
+	preset, err := p.Encode()
+	if err != nil {
+		return nil, err
+	}
+	c.result.Preset = p
+	c.result.Request = &models.CreateDashboardRequest{
+		Name:        b.name,
+		Description: b.description,
+		Team:        b.team,
+		Tags:        b.tags,
+		Preset:      preset,
+	}
+	return c.result, nil
+}
+
+// grafanaConverter collects the warnings and skipped entries of an import.
+type grafanaConverter struct {
+	cfg    *grafanaConfig
+	result *GrafanaImport
+}
+
+func (c *grafanaConverter) warn(format string, args ...interface{}) {
+	c.result.Warnings = append(c.result.Warnings, fmt.Sprintf(format, args...))
+}
+
+func (c *grafanaConverter) skip(source, format string, args ...interface{}) {
+	c.result.Skipped = append(c.result.Skipped, SkippedImport{Source: source, Reason: fmt.Sprintf(format, args...)})
+}
+
+var (
+	relativeTime = regexp.MustCompile(`^now-(\d+)([smhdwMy])$`)
+	timeUnits    = map[string]string{"s": "second", "m": "minute", "h": "hour", "d": "day", "w": "week", "M": "month", "y": "year"}
+)
+
+// duration converts a relative Grafana time range, such as now-6h to now,
+// into a duration such as "Last 6 hours".
+func (c *grafanaConverter) duration(from, to string) string {
+	if from == "" {
+		return ""
+	}
+	m := relativeTime.FindStringSubmatch(from)
+	if m == nil || (to != "" && to != "now") {
+		c.warn("time range %s to %s is not supported; the dashboard uses the default time range", from, to)
+		return ""
+	}
+	unit := timeUnits[m[2]]
+	if m[1] != "1" {
+		unit += "s"
+	}
+	return "Last " + m[1] + " " + unit
+}
+
+var (
+	bracedVariable  = regexp.MustCompile(`\$\{(\w+)(?::[\w-]+)?\}`)
+	bracketVariable = regexp.MustCompile(`\[\[(\w+)(?::[\w-]+)?\]\]`)
+	builtinVariable = regexp.MustCompile(`\$__\w+`)
+	// intervalVariable matches $__interval and $__rate_interval but not
+	// longer built-ins such as $__interval_ms.
+	intervalVariable = regexp.MustCompile(`\$__(rate_)?interval\b`)
+)
+
+// expression rewrites the variable references of a Grafana query into the
+// $name form, and returns why the query cannot be converted if it uses a
+// Grafana built-in variable other than the rate interval.
+func (c *grafanaConverter) expression(expr string) (string, string) {
+	expr = bracedVariable.ReplaceAllString(expr, "$$$1")
+	expr = bracketVariable.ReplaceAllString(expr, "$$$1")
+	expr = intervalVariable.ReplaceAllLiteralString(expr, c.cfg.rateInterval)
+	if v := builtinVariable.FindString(expr); v != "" {
+		return "", fmt.Sprintf("the Grafana variable %s is not supported", v)
+	}
+	return expr, ""
+}
+
+// datasourceType returns the type of a datasource reference. References by
+// name, used before Grafana 8, have no type.
+func datasourceType(raw json.RawMessage) string {
+	var ref struct {
+		Type string `json:"type"`
+		UID  string `json:"uid"`
+	}
+	if len(raw) == 0 || raw[0] != '{' || json.Unmarshal(raw, &ref) != nil {
+		return ""
+	}
+	if ref.UID == "-- Mixed --" {
+		return ""
+	}
+	return ref.Type
+}
+
+func (c *grafanaConverter) variable(v *grafanaVariable) (string, *Variable) {
+	source := fmt.Sprintf("variable %q", v.Name)
+	if !variableName.MatchString(v.Name) {
+		c.skip(source, "invalid variable name")
+		return "", nil
+	}
+	label := v.Label
+	if label == "" {
+		label = v.Name
+	}
+	var query string
+	if len(v.Query) > 0 && v.Query[0] == '{' {
+		var q struct {
+			Query string `json:"query"`
+		}
+		_ = json.Unmarshal(v.Query, &q)
+		query = q.Query
+	} else {
+		_ = json.Unmarshal(v.Query, &query)
+	}
+
+	var variable *Variable
+	switch v.Type {
+	case "query":
+		if t := datasourceType(v.Datasource); t != "" && t != "prometheus" {
+			c.skip(source, "%s variables are not supported", t)
+			return "", nil
+		}
+		expr, reason := c.expression(query)
+		if reason == "" && !strings.HasPrefix(strings.TrimSpace(expr), "label_values(") {
+			reason = "only label_values queries are supported"
+		}
+		if reason != "" {
+			c.skip(source, "%s", reason)
+			return "", nil
+		}
+		variable = QueryVariable(label, expr)
+	case "custom":
+		var options []string
+		for _, o := range strings.Split(query, ",") {
+			// Custom values can be given as "text : value".
+			if _, value, ok := strings.Cut(o, " : "); ok {
+				o = value
+			}
+			if o = strings.TrimSpace(o); o != "" {
+				options = append(options, o)
+			}
+		}
+		if len(options) == 0 {
+			for _, o := range v.Options {
+				options = append(options, o.Value)
+			}
+		}
+		variable = CustomVariable(label, options...)
+	case "constant":
+		variable = CustomVariable(label, query)
+	default:
+		c.skip(source, "%s variables are not supported", v.Type)
+		return "", nil
+	}
+	variable.Multi = v.Multi
+	if current := currentValues(v.Current.Value); len(current) > 0 {
+		variable.Default = current
+	}
+	return v.Name, variable
+}
+
+// currentValues decodes the selected value of a variable, which is a string
+// or a list of strings. Selecting all values yields none.
+func currentValues(raw json.RawMessage) []string {
+	var values []string
+	if err := json.Unmarshal(raw, &values); err != nil {
+		var value string
+		if json.Unmarshal(raw, &value) != nil || value == "" {
+			return nil
+		}
+		values = []string{value}
+	}
+	for _, v := range values {
+		if v == "$__all" {
+			return nil
+		}
+	}
+	return values
+}
+
+// placedPanel is a converted panel at its position on the groundcover grid.
+type placedPanel struct {
+	panel  *Panel
+	layout LayoutItem
+}
+
+// panels converts the panels of a dashboard, placing them at their scaled
+// Grafana positions.
+func (c *grafanaConverter) panels(panels []*grafanaPanel) []*Panel {
+	flat := flattenPanels(panels)
+	scale := newRowScale(flat)
+
+	var placed []*placedPanel
+	for _, gp := range flat {
+		panel := c.panel(gp)
+		if panel == nil {
+			continue
+		}
+		pos := gp.GridPos
+		l := LayoutItem{Y: scale[pos.Y], H: scale[pos.Y+pos.H] - scale[pos.Y]}
+		l.W = max(1, column(pos.X+pos.W)-column(pos.X))
+		l.X = min(column(pos.X), GridColumns-l.W)
+		placed = append(placed, &placedPanel{panel: panel, layout: l})
+	}
+	sort.SliceStable(placed, func(i, j int) bool {
+		a, b := placed[i].layout, placed[j].layout
+		if a.Y != b.Y {
+			return a.Y < b.Y
+		}
+		return a.X < b.X
+	})
+
+	// Rounding to half as many columns can make narrow neighbours overlap;
+	// such a panel goes below the others.
+	var out []*Panel
+	bottom := 0
+	for i, p := range placed {
+		for _, other := range placed[:i] {
+			if overlaps(&p.layout, &other.layout) {
+				c.warn("%s was moved down so that it does not overlap another panel", p.panel.title())
+				p.layout.Y = bottom
+				break
+			}
+		}
+		bottom = max(bottom, p.layout.Y+p.layout.H)
+		out = append(out, p.panel.Size(p.layout.W, p.layout.H).At(p.layout.X, p.layout.Y))
+	}
+	return out
+}
+
+// flattenPanels returns the panels of a dashboard sorted by position, with
+// the panels of collapsed rows placed below their row and the panels after
+// it moved down to make room.
+func flattenPanels(panels []*grafanaPanel) []*grafanaPanel {
+	var out []*grafanaPanel
+	shift := 0
+	for _, p := range sortPanels(panels) {
+		p.GridPos.Y += shift
+		out = append(out, p)
+		if p.Type != "row" || len(p.Panels) == 0 {
+			continue
+		}
+		children := sortPanels(p.Panels)
+		top, bottom := children[0].GridPos.Y, children[0].GridPos.Y
+		for _, child := range children {
+			bottom = max(bottom, child.GridPos.Y+child.GridPos.H)
+		}
+		for _, child := range children {
+			child.GridPos.Y = p.GridPos.Y + p.GridPos.H + child.GridPos.Y - top
+			out = append(out, child)
+		}
+		shift += bottom - top
+	}
+	return out
+}
+
+// sortPanels sorts panels by position, giving panels without one Grafana's
+// default size.
+func sortPanels(panels []*grafanaPanel) []*grafanaPanel {
+	var sorted []*grafanaPanel
+	for _, p := range panels {
+		if p == nil {
+			continue
+		}
+		if p.GridPos.W <= 0 {
+			p.GridPos.W = grafanaColumns / 2
+			if p.Type == "row" {
+				p.GridPos.W = grafanaColumns
+			}
+		}
+		if p.GridPos.H <= 0 {
+			p.GridPos.H = grafanaPanelHeight
+			if p.Type == "row" {
+				p.GridPos.H = 1
+			}
+		}
+		sorted = append(sorted, p)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i].GridPos, sorted[j].GridPos
+		if a.Y != b.Y {
+			return a.Y < b.Y
+		}
+		return a.X < b.X
+	})
+	return sorted
+}
+
+// newRowScale maps the top and bottom edges of Grafana panels to rows of the
+// groundcover grid. Every band between two edges keeps at least one row, so
+// panels that do not overlap in Grafana do not overlap after scaling.
+func newRowScale(panels []*grafanaPanel) map[int]int {
+	edges := map[int]bool{}
+	for _, p := range panels {
+		edges[p.GridPos.Y] = true
+		edges[p.GridPos.Y+p.GridPos.H] = true
+	}
+	sorted := make([]int, 0, len(edges))
+	for e := range edges {
+		sorted = append(sorted, e)
+	}
+	sort.Ints(sorted)
+
+	scale := map[int]int{}
+	row := 0
+	for i, e := range sorted {
+		if i > 0 {
+			band := float64(e-sorted[i-1]) * DefaultPanelHeight / grafanaPanelHeight
+			row += max(1, int(math.Round(band)))
+		}
+		scale[e] = row
+	}
+	return scale
+}
+
+// column maps a column of the Grafana grid to the groundcover grid.
+func column(x int) int {
+	return min(GridColumns, (x*GridColumns+grafanaColumns/2)/grafanaColumns)
+}
+
+func (p *grafanaPanel) source() string {
+	if p.Title == "" {
+		return fmt.Sprintf("panel %d", p.ID)
+	}
+	return fmt.Sprintf("panel %q", p.Title)
+}
+
+// panel converts a single panel, or returns nil if it is skipped.
+func (c *grafanaConverter) panel(gp *grafanaPanel) *Panel {
+	source := gp.source()
+	if len(gp.LibraryPanel) > 0 && string(gp.LibraryPanel) != "null" {
+		c.skip(source, "library panels are not supported")
+		return nil
+	}
+
+	var panel *Panel
+	switch gp.Type {
+	case "row":
+		if gp.Title == "" {
+			return nil
+		}
+		return Text("<h3>" + html.EscapeString(gp.Title) + "</h3>")
+	case "text":
+		return c.text(gp)
+	case "timeseries", "graph":
+		panel = TimeSeries(gp.Title)
+	case "stat", "singlestat":
+		panel = Stat(gp.Title)
+	case "gauge", "bargauge":
+		c.warn("%s: %s panel converted to a stat panel", source, gp.Type)
+		panel = Stat(gp.Title)
+	case "table", "table-old":
+		panel = Table(gp.Title)
+	default:
+		c.skip(source, "%s panels are not supported", gp.Type)
+		return nil
+	}
+	if gp.Repeat != "" {
+		c.warn("%s: repeating the panel for each value of $%s is not supported; it is shown once", source, gp.Repeat)
+	}
+
+	panelType := datasourceType(gp.Datasource)
+	ids := map[string]bool{}
+	queries := 0
+	for _, t := range gp.Targets {
+		if t == nil || t.Hide {
+			continue
+		}
+		querySource := source + " query " + t.RefID
+		dsType := datasourceType(t.Datasource)
+		if dsType == "" {
+			dsType = panelType
+		}
+		if dsType != "" && dsType != "prometheus" {
+			c.skip(querySource, "%s queries are not supported", dsType)
+			continue
+		}
+		if t.Expr == "" {
+			c.skip(querySource, "query has no PromQL expression")
+			continue
+		}
+		expr, reason := c.expression(t.Expr)
+		if reason != "" {
+			c.skip(querySource, "%s", reason)
+			continue
+		}
+		var opts []QueryOption
+		if t.RefID != "" && !ids[t.RefID] {
+			ids[t.RefID] = true
+			opts = append(opts, WithQueryID(t.RefID))
+		}
+		if step, err := time.ParseDuration(t.Interval); err == nil && step > 0 {
+			opts = append(opts, WithStep(step))
+		}
+		panel.PromQL(expr, opts...)
+		queries++
+	}
+	if queries == 0 {
+		c.skip(source, "the panel has no supported queries")
+		return nil
+	}
+
+	defaults := gp.FieldConfig.Defaults
+	if defaults.Unit != "" {
+		panel.Option("unit", defaults.Unit)
+	}
+	if defaults.Decimals != nil {
+		panel.Option("decimals", *defaults.Decimals)
+	}
+	if defaults.Thresholds != nil && len(defaults.Thresholds.Steps) > 0 {
+		panel.Option("thresholds", defaults.Thresholds)
+	}
+	return panel
+}
+
+var markdownHeading = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+
+// text converts a text panel. Markdown is converted to headings and
+// paragraphs; other formatting, including HTML, is escaped and kept as text,
+// so that imported dashboards cannot inject markup.
+func (c *grafanaConverter) text(gp *grafanaPanel) *Panel {
+	content, mode := gp.Options.Content, gp.Options.Mode
+	if content == "" && mode == "" {
+		content, mode = gp.Content, gp.Mode
+	}
+	if strings.TrimSpace(content) == "" {
+		c.skip(gp.source(), "the text panel is empty")
+		return nil
+	}
+	if mode != "" && mode != "markdown" {
+		c.warn("%s: %s content is shown as text", gp.source(), mode)
+	} else if strings.ContainsAny(content, "*_[`|") {
+		c.warn("%s: markdown formatting other than headings and paragraphs is shown as text", gp.source())
+	}
+
+	var b, paragraph strings.Builder
+	flush := func() {
+		if paragraph.Len() > 0 {
+			b.WriteString("<p>" + paragraph.String() + "</p>")
+			paragraph.Reset()
+		}
+	}
+	if gp.Title != "" {
+		b.WriteString("<h3>" + html.EscapeString(gp.Title) + "</h3>")
+	}
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if m := markdownHeading.FindStringSubmatch(line); m != nil {
+			flush()
+			level := len(m[1])
+			fmt.Fprintf(&b, "<h%d>%s</h%d>", level, html.EscapeString(m[2]), level)
+			continue
+		}
+		if line == "" {
+			flush()
+			continue
+		}
+		if paragraph.Len() > 0 {
+			paragraph.WriteByte(' ')
+		}
+		paragraph.WriteString(html.EscapeString(line))
+	}
+	flush()
+	return Text(b.String())
+}
+
+// GrafanaFile is the outcome of importing a single file of a directory.
+type GrafanaFile struct {
+	// Path is the file, relative to the imported directory.
+	Path   string
+	Import *GrafanaImport
+	// ID is the UUID of the created dashboard. It is empty in a dry run and
+	// when the file failed.
+	ID  string
+	Err error
+}
+
+// GrafanaDirResult is the outcome of ImportGrafanaDir.
+type GrafanaDirResult struct {
+	DryRun bool
+	Files  []*GrafanaFile
+}
+
+// Failed returns the files that could not be converted or created.
+func (r *GrafanaDirResult) Failed() []*GrafanaFile {
+	var failed []*GrafanaFile
+	for _, f := range r.Files {
+		if f.Err != nil {
+			failed = append(failed, f)
+		}
+	}
+	return failed
+}
+
+// ImportGrafanaDir converts every .json file below dir with ImportGrafana
+// and creates the dashboards, in path order. A file that fails does not stop
+// the others; the returned error joins every failure. With WithDryRun the
+// dashboards are only converted, and WithOutput reports progress for use in
+// command line tools.
+func ImportGrafanaDir(ctx context.Context, api *client.GroundcoverAPI, dir string, opts ...GrafanaOption) (*GrafanaDirResult, error) {
+	cfg := newGrafanaConfig(opts)
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.EqualFold(filepath.Ext(path), ".json") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Grafana dashboards in %s: %w", dir, err)
+	}
+	sort.Strings(paths)
+
+	result := &GrafanaDirResult{DryRun: cfg.dryRun}
+	var errs []error
+	for _, path := range paths {
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			rel = path
+		}
+		f := &GrafanaFile{Path: filepath.ToSlash(rel)}
+		result.Files = append(result.Files, f)
+		if f.Err = importGrafanaFile(ctx, api, path, f, opts, cfg); f.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", f.Path, f.Err))
+			fmt.Fprintf(cfg.out, "failed %s: %v\n", f.Path, f.Err)
+		}
+	}
+	return result, errors.Join(errs...)
+}
+
+func importGrafanaFile(ctx context.Context, api *client.GroundcoverAPI, path string, f *GrafanaFile, opts []GrafanaOption, cfg *grafanaConfig) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if f.Import, err = ImportGrafana(bytes.TrimSpace(data), opts...); err != nil {
+		return err
+	}
+	if cfg.dryRun {
+		fmt.Fprintf(cfg.out, "%s\n%s", f.Path, f.Import)
+		return nil
+	}
+	resp, err := api.Dashboards.CreateDashboard(dashboards.NewCreateDashboardParams().WithContext(ctx).WithBody(f.Import.Request), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create dashboard %q: %w", f.Import.Request.Name, err)
+	}
+	f.ID = resp.Payload.UUID
+	fmt.Fprintf(cfg.out, "created %s (%s) from %s, %d skipped\n", f.Import.Request.Name, f.ID, f.Path, len(f.Import.Skipped))
+	return nil
+}
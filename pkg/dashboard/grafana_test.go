@@ -0,0 +1,228 @@
+package dashboard
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/groundcover-com/groundcover-sdk-go/internal/testutil"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+// grafanaJSON is a Grafana dashboard as the export of Grafana 10 writes it.
+const grafanaJSON = `{
+  "title": "Service overview",
+  "description": "Golden signals",
+  "tags": ["services"],
+  "time": {"from": "now-6h", "to": "now"},
+  "templating": {"list": [
+    {"name": "cluster", "label": "Cluster", "type": "query",
+     "datasource": {"type": "prometheus", "uid": "prom"},
+     "query": {"query": "label_values(up, cluster)", "refId": "A"},
+     "current": {"value": "$__all"}},
+    {"name": "env", "type": "custom", "query": "prod,staging", "multi": true,
+     "current": {"value": ["staging"]}},
+    {"name": "ds", "type": "datasource", "query": "prometheus"}
+  ]},
+  "panels": [
+    {"id": 1, "type": "timeseries", "title": "Request rate",
+     "gridPos": {"x": 0, "y": 0, "w": 12, "h": 8},
+     "datasource": {"type": "prometheus", "uid": "prom"},
+     "fieldConfig": {"defaults": {"unit": "reqps"}},
+     "targets": [
+       {"refId": "A", "expr": "sum(rate(http_requests_total{cluster=~\"${cluster}\"}[$__rate_interval]))", "interval": "1m"},
+       {"refId": "B", "expr": "sum(rate(http_requests_total[$__range]))"},
+       {"refId": "C", "expr": "up", "hide": true}
+     ]},
+    {"id": 2, "type": "gauge", "title": "Error ratio",
+     "gridPos": {"x": 12, "y": 0, "w": 12, "h": 8},
+     "fieldConfig": {"defaults": {"unit": "percentunit", "decimals": 2,
+       "thresholds": {"mode": "absolute", "steps": [{"color": "green", "value": null}, {"color": "red", "value": 0.05}]}}},
+     "targets": [{"refId": "A", "expr": "sum(rate(errors_total{env=\"[[env]]\"}[5m]))"}]},
+    {"id": 3, "type": "row", "title": "Logs", "collapsed": false,
+     "gridPos": {"x": 0, "y": 8, "w": 24, "h": 1}},
+    {"id": 4, "type": "timeseries", "title": "Errors",
+     "gridPos": {"x": 0, "y": 9, "w": 24, "h": 8},
+     "datasource": {"type": "loki", "uid": "loki"},
+     "targets": [{"refId": "A", "expr": "sum(count_over_time({app=\"api\"} |= \"error\" [5m]))"}]},
+    {"id": 5, "type": "row", "title": "Details", "collapsed": true,
+     "gridPos": {"x": 0, "y": 17, "w": 24, "h": 1},
+     "panels": [
+       {"id": 6, "type": "table", "title": "Top endpoints",
+        "gridPos": {"x": 0, "y": 40, "w": 16, "h": 8},
+        "targets": [{"refId": "A", "expr": "topk(10, sum by (path) (rate(http_requests_total[5m])))"}]},
+       {"id": 7, "type": "text", "title": "Runbook",
+        "gridPos": {"x": 16, "y": 40, "w": 8, "h": 8},
+        "options": {"mode": "markdown", "content": "# On call\nPage the API team\nbefore 9am.\n\nSee the wiki."}}
+     ]},
+    {"id": 8, "type": "heatmap", "title": "Latency",
+     "gridPos": {"x": 0, "y": 18, "w": 24, "h": 8},
+     "targets": [{"refId": "A", "expr": "sum(rate(latency_bucket[5m])) by (le)"}]}
+  ]
+}`
+
+func TestImportGrafana(t *testing.T) {
+	im, err := ImportGrafana([]byte(grafanaJSON), WithTeam("platform"), WithTags("imported"), WithNamePrefix("[Grafana] "))
+	require.NoError(t, err)
+	require.Equal(t, "Service overview", im.Title)
+
+	req := im.Request
+	require.Equal(t, "[Grafana] Service overview", req.Name)
+	require.Equal(t, "Golden signals", req.Description)
+	require.Equal(t, "platform", req.Team)
+	require.Equal(t, []string{"services", "imported"}, req.Tags)
+
+	p, err := ParsePreset(req.Preset)
+	require.NoError(t, err)
+	require.NoError(t, p.Validate())
+	require.Equal(t, "Last 6 hours", p.Duration)
+	require.Equal(t, &Variable{Type: VariableTypeQuery, Label: "Cluster", Query: "label_values(up, cluster)"}, p.Variables["cluster"])
+	require.Equal(t, &Variable{Type: VariableTypeCustom, Label: "env", Options: []string{"prod", "staging"}, Default: []string{"staging"}, Multi: true}, p.Variables["env"])
+	require.NotContains(t, p.Variables, "ds")
+
+	type box struct {
+		name string
+		x, y int
+		w, h int
+	}
+	var boxes []box
+	for _, l := range p.Layout {
+		w := p.Widget(l.ID)
+		name := w.Name
+		if w.Type == WidgetTypeText {
+			name = w.HTML
+		}
+		boxes = append(boxes, box{name, l.X, l.Y, l.W, l.H})
+	}
+	require.Equal(t, []box{
+		{"Request rate", 0, 0, 6, 3},
+		{"Error ratio", 6, 0, 6, 3},
+		{"<h3>Logs</h3>", 0, 3, 12, 1},
+		{"<h3>Details</h3>", 0, 7, 12, 1},
+		{"Top endpoints", 0, 8, 8, 3},
+		{"<h3>Runbook</h3><h1>On call</h1><p>Page the API team before 9am.</p><p>See the wiki.</p>", 8, 8, 4, 3},
+	}, boxes)
+
+	rate := p.Widget("A")
+	require.Len(t, rate.Queries, 1)
+	require.Equal(t, `sum(rate(http_requests_total{cluster=~"$cluster"}[5m]))`, rate.Queries[0].Expr)
+	require.Equal(t, "1m", *rate.Queries[0].Step)
+	var unit string
+	ok, err := rate.VisualizationConfig.Option("unit", &unit)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "reqps", unit)
+
+	gauge := p.Widget("B")
+	require.Equal(t, VisualizationStat, gauge.VisualizationConfig.Type)
+	require.Equal(t, `sum(rate(errors_total{env="$env"}[5m]))`, gauge.Queries[0].Expr)
+	var thresholds map[string]interface{}
+	ok, err = gauge.VisualizationConfig.Option("thresholds", &thresholds)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "absolute", thresholds["mode"])
+	require.Len(t, thresholds["steps"], 2)
+
+	require.Equal(t, []SkippedImport{
+		{Source: `variable "ds"`, Reason: "datasource variables are not supported"},
+		{Source: `panel "Request rate" query B`, Reason: "the Grafana variable $__range is not supported"},
+		{Source: `panel "Errors" query A`, Reason: "loki queries are not supported"},
+		{Source: `panel "Errors"`, Reason: "the panel has no supported queries"},
+		{Source: `panel "Latency"`, Reason: "heatmap panels are not supported"},
+	}, im.Skipped)
+	require.Equal(t, []string{`panel "Error ratio": gauge panel converted to a stat panel`}, im.Warnings)
+	require.Contains(t, im.String(), "+ [Grafana] Service overview (6 panels)\n")
+}
+
+func TestImportGrafanaEnvelopeAndOverlaps(t *testing.T) {
+	// Narrow panels that share a column after scaling, as the Grafana API
+	// returns the dashboard.
+	im, err := ImportGrafana([]byte(`{"meta": {"slug": "narrow"}, "dashboard": {
+		"title": "Narrow",
+		"time": {"from": "now-15m", "to": "now-5m"},
+		"panels": [
+			{"id": 1, "type": "stat", "title": "One", "gridPos": {"x": 1, "y": 0, "w": 1, "h": 4}, "targets": [{"refId": "A", "expr": "up"}]},
+			{"id": 2, "type": "stat", "title": "Two", "gridPos": {"x": 2, "y": 0, "w": 1, "h": 4}, "targets": [{"refId": "A", "expr": "up"}]}
+		]}}`))
+	require.NoError(t, err)
+	require.Equal(t, "", im.Preset.Duration)
+	require.Equal(t, &LayoutItem{ID: "B", X: 1, Y: 2, W: 1, H: 2}, im.Preset.LayoutOf("B"))
+	require.Equal(t, []string{
+		"time range now-15m to now-5m is not supported; the dashboard uses the default time range",
+		`stat "Two" was moved down so that it does not overlap another panel`,
+	}, im.Warnings)
+
+	_, err = ImportGrafana([]byte(`{"panels": []}`))
+	require.EqualError(t, err, "failed to import Grafana dashboard: title is required")
+}
+
+func TestImportGrafanaIntervalVariables(t *testing.T) {
+	im, err := ImportGrafana([]byte(`{"title": "Intervals", "panels": [
+		{"id": 1, "type": "stat", "title": "Interval", "gridPos": {"x": 0, "y": 0, "w": 12, "h": 4},
+		 "targets": [{"refId": "A", "expr": "sum(rate(x[$__interval])) + sum(rate(y[$__rate_interval]))"}]},
+		{"id": 2, "type": "stat", "title": "Milliseconds", "gridPos": {"x": 12, "y": 0, "w": 12, "h": 4},
+		 "targets": [{"refId": "A", "expr": "sum(rate(x[$__interval_ms]))"}]}
+	]}`))
+	require.NoError(t, err)
+	require.Equal(t, "sum(rate(x[5m])) + sum(rate(y[5m]))", im.Preset.Widget("A").Queries[0].Expr)
+	require.Equal(t, []SkippedImport{
+		{Source: `panel "Milliseconds" query A`, Reason: "the Grafana variable $__interval_ms is not supported"},
+		{Source: `panel "Milliseconds"`, Reason: "the panel has no supported queries"},
+	}, im.Skipped)
+}
+
+func TestImportGrafanaEscapesHTMLText(t *testing.T) {
+	im, err := ImportGrafana([]byte(`{"title": "Notes", "panels": [
+		{"id": 1, "type": "text", "title": "Note", "gridPos": {"x": 0, "y": 0, "w": 24, "h": 4},
+		 "options": {"mode": "html", "content": "<b>Hi</b><script>alert(1)</script>"}}
+	]}`))
+	require.NoError(t, err)
+	require.Equal(t, "<h3>Note</h3><p>&lt;b&gt;Hi&lt;/b&gt;&lt;script&gt;alert(1)&lt;/script&gt;</p>", im.Preset.Widget("A").HTML)
+	require.Equal(t, []string{`panel "Note": html content is shown as text`}, im.Warnings)
+}
+
+func TestImportGrafanaDir(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		created []*models.CreateDashboardRequest
+	)
+	api := testutil.NewAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		var req models.CreateDashboardRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		created = append(created, &req)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(models.View{UUID: "dashboard-1", Name: req.Name})
+	}))
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "team"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "team", "services.json"), []byte(grafanaJSON), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.json"), []byte(`{"title": `), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a dashboard"), 0o644))
+
+	var out bytes.Buffer
+	result, err := ImportGrafanaDir(context.Background(), api, dir, WithDryRun(true), WithOutput(&out))
+	require.ErrorContains(t, err, "broken.json: failed to parse Grafana dashboard")
+	require.True(t, result.DryRun)
+	require.Len(t, result.Files, 2)
+	require.Len(t, result.Failed(), 1)
+	require.Empty(t, created)
+	require.Contains(t, out.String(), "team/services.json\n+ Service overview (6 panels)\n")
+
+	out.Reset()
+	result, err = ImportGrafanaDir(context.Background(), api, dir, WithOutput(&out))
+	require.Error(t, err)
+	require.Equal(t, "dashboard-1", result.Files[1].ID)
+	require.Len(t, created, 1)
+	require.Equal(t, "Service overview", created[0].Name)
+	require.Contains(t, out.String(), "created Service overview (dashboard-1) from team/services.json, 5 skipped\n")
+}
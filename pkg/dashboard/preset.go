@@ -0,0 +1,345 @@
+// Package dashboard provides a typed model and builders for groundcover
+// dashboards.
+//
+// The dashboards API carries the content of a dashboard, its layout, widgets
+// and variables, as an opaque JSON string in the preset field of
+// models.CreateDashboardRequest, models.UpdateDashboardRequest and
+// models.View. Preset models that document. ParsePreset decodes it, and
+// every type keeps the fields it does not model so that a parse, modify,
+// encode cycle does not drop settings the dashboard editor added. New
+// composes dashboards in Go, and ImportGrafana converts Grafana dashboards.
+package dashboard
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+)
+
+// SchemaVersion is the preset schema version written by this package.
+const SchemaVersion = 3
+
+// Widget types.
+const (
+	WidgetTypeWidget = "widget"
+	WidgetTypeText   = "text"
+)
+
+// Visualization types of widgets.
+const (
+	VisualizationTimeSeries = "time-series"
+	VisualizationTable      = "table"
+	VisualizationStat       = "stat"
+	VisualizationLogStream  = "log-stream"
+)
+
+// Data types of queries.
+const (
+	DataTypeMetrics = "metrics"
+	DataTypeLogs    = "logs"
+	DataTypeTraces  = "traces"
+	DataTypeEvents  = "events"
+)
+
+// Editor modes of queries: built with the query builder, or written as code.
+const (
+	EditorModeBuilder = "builder"
+	EditorModeCode    = "code"
+)
+
+// Preset is the content of a dashboard.
+type Preset struct {
+	// Duration is the default time range, such as "Last 30 minutes".
+	Duration      string               `json:"duration,omitempty"`
+	Layout        []*LayoutItem        `json:"layout"`
+	Widgets       []*Widget            `json:"widgets"`
+	Variables     map[string]*Variable `json:"variables"`
+	SchemaVersion int                  `json:"schemaVersion"`
+
+	unknown map[string]json.RawMessage
+}
+
+// LayoutItem places the widget with the same ID on the dashboard grid.
+// Positions and sizes are in grid units.
+type LayoutItem struct {
+	ID   string `json:"id"`
+	X    int    `json:"x"`
+	Y    int    `json:"y"`
+	W    int    `json:"w"`
+	H    int    `json:"h"`
+	MinH int    `json:"minH,omitempty"`
+
+	unknown map[string]json.RawMessage
+}
+
+// Widget is a panel of a dashboard. Widgets of type WidgetTypeWidget
+// visualize their queries; widgets of type WidgetTypeText show HTML.
+type Widget struct {
+	ID                  string               `json:"id"`
+	Type                string               `json:"type"`
+	Name                string               `json:"name,omitempty"`
+	Queries             []*Query             `json:"queries,omitempty"`
+	VisualizationConfig *VisualizationConfig `json:"visualizationConfig,omitempty"`
+	HTML                string               `json:"html,omitempty"`
+
+	unknown map[string]json.RawMessage
+}
+
+// Query is a query of a widget, either a PromQL expression over metrics or a
+// SQL pipeline over logs, traces or events.
+type Query struct {
+	ID       string `json:"id"`
+	Expr     string `json:"expr,omitempty"`
+	DataType string `json:"dataType,omitempty"`
+	// Step is the resolution of the query, such as "1m". Nil lets the
+	// dashboard choose.
+	Step        *string             `json:"step"`
+	EditorMode  string              `json:"editorMode,omitempty"`
+	SQLPipeline *models.SQLPipeline `json:"sqlPipeline,omitempty"`
+
+	unknown map[string]json.RawMessage
+}
+
+// VisualizationConfig is how a widget shows its data. Settings beyond the
+// type are available through Option and SetOption.
+type VisualizationConfig struct {
+	Type string `json:"type"`
+
+	unknown map[string]json.RawMessage
+}
+
+// Variable is a dashboard variable that queries can refer to as $name.
+type Variable struct {
+	Type  string `json:"type,omitempty"`
+	Label string `json:"label,omitempty"`
+	// Query lists the values of the variable, for example
+	// "label_values(groundcover_node_rt_disk_space_used_percent, cluster)".
+	Query   string   `json:"query,omitempty"`
+	Options []string `json:"options,omitempty"`
+	Default []string `json:"default,omitempty"`
+	Multi   bool     `json:"multi,omitempty"`
+
+	unknown map[string]json.RawMessage
+}
+
+// ParsePreset decodes the preset string of a dashboard.
+func ParsePreset(preset string) (*Preset, error) {
+	var p Preset
+	if err := json.Unmarshal([]byte(preset), &p); err != nil {
+		return nil, fmt.Errorf("failed to parse dashboard preset: %w", err)
+	}
+	return &p, nil
+}
+
+// Validate checks that widget IDs are unique, that every widget has exactly
+// one layout item and every layout item a widget, that sizes are positive,
+// and that widgets other than text have queries.
+func (p *Preset) Validate() error {
+	var errs []error
+	widgets := map[string]bool{}
+	for i, w := range p.Widgets {
+		switch {
+		case w == nil:
+			errs = append(errs, fmt.Errorf("widgets[%d] is empty", i))
+			continue
+		case w.ID == "":
+			errs = append(errs, fmt.Errorf("widgets[%d] has no ID", i))
+		case widgets[w.ID]:
+			errs = append(errs, fmt.Errorf("widget ID %s is used more than once", w.ID))
+		}
+		widgets[w.ID] = true
+		if w.Type != WidgetTypeText && len(w.Queries) == 0 {
+			errs = append(errs, fmt.Errorf("widget %s has no queries", w.ID))
+		}
+	}
+	placed := map[string]bool{}
+	for i, l := range p.Layout {
+		switch {
+		case l == nil:
+			errs = append(errs, fmt.Errorf("layout[%d] is empty", i))
+			continue
+		case !widgets[l.ID]:
+			errs = append(errs, fmt.Errorf("layout item %s has no widget", l.ID))
+		case placed[l.ID]:
+			errs = append(errs, fmt.Errorf("widget %s is placed more than once", l.ID))
+		}
+		placed[l.ID] = true
+		if l.W <= 0 || l.H <= 0 || l.X < 0 || l.Y < 0 {
+			errs = append(errs, fmt.Errorf("layout item %s has an invalid position or size", l.ID))
+		}
+	}
+	for _, w := range p.Widgets {
+		if w != nil && w.ID != "" && !placed[w.ID] {
+			errs = append(errs, fmt.Errorf("widget %s has no layout item", w.ID))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Encode encodes the preset into the string the dashboards API expects.
+func (p *Preset) Encode() (string, error) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode dashboard preset: %w", err)
+	}
+	return string(data), nil
+}
+
+// Widget returns the widget with the given ID, or nil.
+func (p *Preset) Widget(id string) *Widget {
+	for _, w := range p.Widgets {
+		if w.ID == id {
+			return w
+		}
+	}
+	return nil
+}
+
+// LayoutOf returns the layout item of the widget with the given ID, or nil.
+func (p *Preset) LayoutOf(id string) *LayoutItem {
+	for _, l := range p.Layout {
+		if l.ID == id {
+			return l
+		}
+	}
+	return nil
+}
+
+// UnknownFields returns the paths of the fields the model does not know
+// about, for example "widgets[A].visualizationConfig.legend".
+func (p *Preset) UnknownFields() []string {
+	paths := unknownPaths("", p.unknown)
+	for _, l := range p.Layout {
+		paths = append(paths, unknownPaths("layout["+l.ID+"]", l.unknown)...)
+	}
+	for _, w := range p.Widgets {
+		prefix := "widgets[" + w.ID + "]"
+		paths = append(paths, unknownPaths(prefix, w.unknown)...)
+		for _, q := range w.Queries {
+			paths = append(paths, unknownPaths(prefix+".queries["+q.ID+"]", q.unknown)...)
+		}
+		if w.VisualizationConfig != nil {
+			paths = append(paths, unknownPaths(prefix+".visualizationConfig", w.VisualizationConfig.unknown)...)
+		}
+	}
+	for name, v := range p.Variables {
+		if v != nil {
+			paths = append(paths, unknownPaths("variables."+name, v.unknown)...)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Option decodes the visualization setting key into v and reports whether
+// it is set.
+func (c *VisualizationConfig) Option(key string, v interface{}) (bool, error) {
+	raw, ok := c.unknown[key]
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(raw, v)
+}
+
+// SetOption sets a visualization setting the model has no field for, such
+// as the unit or legend of a panel.
+func (c *VisualizationConfig) SetOption(key string, v interface{}) error {
+	if key == "type" {
+		return fmt.Errorf("use the Type field to set the visualization type")
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode visualization option %s: %w", key, err)
+	}
+	if c.unknown == nil {
+		c.unknown = map[string]json.RawMessage{}
+	}
+	c.unknown[key] = raw
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, keeping unknown fields.
+func (p *Preset) UnmarshalJSON(data []byte) error {
+	type plain Preset
+	return unmarshalKeepingUnknown(data, (*plain)(p), &p.unknown)
+}
+
+// MarshalJSON implements json.Marshaler, writing unknown fields back.
+// Missing lists and variables are written as empty, as the dashboard editor
+// expects.
+func (p Preset) MarshalJSON() ([]byte, error) {
+	type plain Preset
+	if p.Layout == nil {
+		p.Layout = []*LayoutItem{}
+	}
+	if p.Widgets == nil {
+		p.Widgets = []*Widget{}
+	}
+	if p.Variables == nil {
+		p.Variables = map[string]*Variable{}
+	}
+	return marshalWithUnknown(plain(p), p.unknown)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, keeping unknown fields.
+func (l *LayoutItem) UnmarshalJSON(data []byte) error {
+	type plain LayoutItem
+	return unmarshalKeepingUnknown(data, (*plain)(l), &l.unknown)
+}
+
+// MarshalJSON implements json.Marshaler, writing unknown fields back.
+func (l LayoutItem) MarshalJSON() ([]byte, error) {
+	type plain LayoutItem
+	return marshalWithUnknown(plain(l), l.unknown)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, keeping unknown fields.
+func (w *Widget) UnmarshalJSON(data []byte) error {
+	type plain Widget
+	return unmarshalKeepingUnknown(data, (*plain)(w), &w.unknown)
+}
+
+// MarshalJSON implements json.Marshaler, writing unknown fields back.
+func (w Widget) MarshalJSON() ([]byte, error) {
+	type plain Widget
+	return marshalWithUnknown(plain(w), w.unknown)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, keeping unknown fields.
+func (q *Query) UnmarshalJSON(data []byte) error {
+	type plain Query
+	return unmarshalKeepingUnknown(data, (*plain)(q), &q.unknown)
+}
+
+// MarshalJSON implements json.Marshaler, writing unknown fields back.
+func (q Query) MarshalJSON() ([]byte, error) {
+	type plain Query
+	return marshalWithUnknown(plain(q), q.unknown)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, keeping unknown fields.
+func (c *VisualizationConfig) UnmarshalJSON(data []byte) error {
+	type plain VisualizationConfig
+	return unmarshalKeepingUnknown(data, (*plain)(c), &c.unknown)
+}
+
+// MarshalJSON implements json.Marshaler, writing unknown fields back.
+func (c VisualizationConfig) MarshalJSON() ([]byte, error) {
+	type plain VisualizationConfig
+	return marshalWithUnknown(plain(c), c.unknown)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, keeping unknown fields.
+func (v *Variable) UnmarshalJSON(data []byte) error {
+	type plain Variable
+	return unmarshalKeepingUnknown(data, (*plain)(v), &v.unknown)
+}
+
+// MarshalJSON implements json.Marshaler, writing unknown fields back.
+func (v Variable) MarshalJSON() ([]byte, error) {
+	type plain Variable
+	return marshalWithUnknown(plain(v), v.unknown)
+}
@@ -0,0 +1,107 @@
+package dashboard
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// unmarshalKeepingUnknown decodes data into v, a pointer to a struct, and
+// stores the fields that have no corresponding struct field in unknown.
+func unmarshalKeepingUnknown(data []byte, v interface{}, unknown *map[string]json.RawMessage) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return err
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	// encoding/json matches keys to fields case-insensitively, so a key
+	// that differs from a field only in case was decoded into it.
+	known := map[string]bool{}
+	for k := range jsonFields(reflect.TypeOf(v).Elem()) {
+		known[strings.ToLower(k)] = true
+	}
+	*unknown = nil
+	for k, value := range raw {
+		if known[strings.ToLower(k)] {
+			continue
+		}
+		if *unknown == nil {
+			*unknown = map[string]json.RawMessage{}
+		}
+		(*unknown)[k] = value
+	}
+	return nil
+}
+
+// marshalWithUnknown encodes v, a struct, and appends the unknown fields
+// that do not collide with its own, sorted by key.
+func marshalWithUnknown(v interface{}, unknown map[string]json.RawMessage) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || len(unknown) == 0 {
+		return data, err
+	}
+	known := jsonFields(reflect.TypeOf(v))
+	keys := make([]string, 0, len(unknown))
+	for k := range unknown {
+		if !known[k] {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return data, nil
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	buf.Write(data[:len(data)-1])
+	for i, k := range keys {
+		if i > 0 || len(data) > 2 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(unknown[k])
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// jsonFields returns the JSON keys of the exported fields of a struct type.
+func jsonFields(t reflect.Type) map[string]bool {
+	fields := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = true
+	}
+	return fields
+}
+
+func unknownPaths(prefix string, unknown map[string]json.RawMessage) []string {
+	var paths []string
+	for k := range unknown {
+		if prefix == "" {
+			paths = append(paths, k)
+		} else {
+			paths = append(paths, prefix+"."+k)
+		}
+	}
+	return paths
+}
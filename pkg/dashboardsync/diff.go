@@ -0,0 +1,49 @@
+package dashboardsync
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/groundcover-com/groundcover-sdk-go/internal/syncplan"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+)
+
+// FieldDiff is a single field that differs between the remote and the desired
+// dashboard. List items are identified by index, for example
+// "preset.widgets[0].name".
+type FieldDiff = syncplan.FieldDiff
+
+// Conflict is a field that was changed on the backend since the definitions
+// were last applied, to a value other than the desired one. Applying the
+// definition would overwrite the change.
+type Conflict = syncplan.Conflict
+
+// dashboardFields are the fields of a dashboard that plans compare. The
+// preset is compared as a JSON document rather than as a string, so that
+// formatting and key order do not matter, and tags are compared as a set.
+type dashboardFields struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Team        string      `json:"team"`
+	Tags        []string    `json:"tags"`
+	Preset      interface{} `json:"preset"`
+}
+
+// dashboardTree returns the normalized tree of the compared fields of a
+// dashboard.
+func dashboardTree(d *models.CreateDashboardRequest) (map[string]interface{}, error) {
+	fields := dashboardFields{
+		Name:        d.Name,
+		Description: d.Description,
+		Team:        d.Team,
+		Tags:        append([]string(nil), d.Tags...),
+	}
+	sort.Strings(fields.Tags)
+	if d.Preset != "" {
+		if err := json.Unmarshal([]byte(d.Preset), &fields.Preset); err != nil {
+			return nil, fmt.Errorf("failed to parse dashboard preset: %w", err)
+		}
+	}
+	return syncplan.Encode(fields)
+}
@@ -0,0 +1,86 @@
+package dashboardsync
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/groundcover-com/groundcover-sdk-go/internal/syncplan"
+	"github.com/groundcover-com/groundcover-sdk-go/internal/yamlutil"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/dashboard"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+)
+
+// Definition is a dashboard loaded from a local YAML or JSON file.
+type Definition struct {
+	// Path is the file the definition was loaded from, relative to the loaded
+	// directory.
+	Path      string
+	Dashboard *models.CreateDashboardRequest
+}
+
+// definitionFile is the format of a definition file. The preset is either an
+// object, as dashboard.Preset encodes it, or the preset string of the
+// dashboards API.
+type definitionFile struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Team        string          `json:"team"`
+	Tags        []string        `json:"tags"`
+	Preset      json.RawMessage `json:"preset"`
+}
+
+// LoadDir loads every .yaml, .yml and .json file below dir, one dashboard per
+// file. Files are returned sorted by path.
+func LoadDir(dir string) ([]*Definition, error) {
+	defs, err := syncplan.LoadDir(dir, []string{".yaml", ".yml", ".json"}, ParseDefinition)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load dashboards from %s: %w", dir, err)
+	}
+	return defs, nil
+}
+
+// ParseDefinition parses a single dashboard definition, in YAML or JSON, with
+// the fields name, description, team, tags and preset. Unknown fields are
+// rejected so that typos do not go unnoticed; the preset is checked with
+// dashboard.Preset.Validate. The path identifies the definition in errors
+// and plans, and derives its key when the definition does not carry one.
+func ParseDefinition(path string, data []byte) (*Definition, error) {
+	var file definitionFile
+	if err := yamlutil.UnmarshalStrict(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if file.Name == "" {
+		return nil, fmt.Errorf("%s: name is required", path)
+	}
+	if len(file.Preset) == 0 || string(file.Preset) == "null" {
+		return nil, fmt.Errorf("%s: preset is required", path)
+	}
+	raw := string(file.Preset)
+	if file.Preset[0] == '"' {
+		if err := json.Unmarshal(file.Preset, &raw); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	preset, err := dashboard.ParsePreset(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := preset.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: invalid preset: %w", path, err)
+	}
+	encoded, err := preset.Encode()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &Definition{
+		Path: path,
+		Dashboard: &models.CreateDashboardRequest{
+			Name:        file.Name,
+			Description: file.Description,
+			Team:        file.Team,
+			Tags:        file.Tags,
+			Preset:      encoded,
+		},
+	}, nil
+}
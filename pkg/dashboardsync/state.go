@@ -0,0 +1,89 @@
+package dashboardsync
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+)
+
+// State records, for every managed dashboard, the revision and content the
+// definitions were last applied as. It is the base of the three-way
+// comparison that tells changes made in the dashboard editor from changes to
+// the definitions, and is meant to be kept next to them.
+type State struct {
+	Dashboards map[string]*StateEntry `json:"dashboards"`
+}
+
+// StateEntry is the last applied revision of a dashboard.
+type StateEntry struct {
+	ID        string                         `json:"id"`
+	Revision  int32                          `json:"revision"`
+	Dashboard *models.CreateDashboardRequest `json:"dashboard"`
+}
+
+// NewState returns an empty state.
+func NewState() *State {
+	return &State{Dashboards: map[string]*StateEntry{}}
+}
+
+// LoadState reads a state file written by Save. A missing file yields an
+// empty state.
+func LoadState(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return NewState(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dashboard sync state: %w", err)
+	}
+	st := NewState()
+	if err := json.Unmarshal(data, st); err != nil {
+		return nil, fmt.Errorf("failed to parse dashboard sync state %s: %w", path, err)
+	}
+	if st.Dashboards == nil {
+		st.Dashboards = map[string]*StateEntry{}
+	}
+	return st, nil
+}
+
+// Save writes the state to path, replacing the file only once it is written
+// completely.
+func (st *State) Save(path string) error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode dashboard sync state: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".dashboard-state-*")
+	if err != nil {
+		return fmt.Errorf("failed to write dashboard sync state: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write dashboard sync state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write dashboard sync state: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write dashboard sync state: %w", err)
+	}
+	return nil
+}
+
+// base returns the entry of key if it belongs to the dashboard with the
+// given ID.
+func (st *State) base(key, id string) *StateEntry {
+	if st == nil {
+		return nil
+	}
+	if e := st.Dashboards[key]; e != nil && e.ID == id && e.Dashboard != nil {
+		return e
+	}
+	return nil
+}
@@ -0,0 +1,528 @@
+// Package dashboardsync manages groundcover dashboards as code: it loads
+// dashboard definitions from a directory of YAML or JSON files, matches them
+// to the dashboards of a backend, and computes and applies a plan that brings
+// the backend in line.
+//
+// Definitions are matched to dashboards by a key. By default the key is
+// carried in a tag of the form DefaultKeyTagPrefix+key, which Plan adds to
+// every definition that does not set it, derived from the file path.
+// Alternatively MatchByName uses the team and name of each dashboard.
+// Dashboards without a key are not managed and are never archived; a
+// definition only takes one over when it has the same team and name, see
+// WithAdoptByName.
+//
+// Dashboards are revisioned, and every update and archive names the revision
+// it was planned against, so that the backend rejects it when the dashboard
+// changed in the meantime. With a State, plans also detect dashboards edited
+// on the backend since the definitions were last applied: the fields such an
+// update would overwrite are reported as conflicts, in a three-way diff, and
+// the change is not applied unless WithOverride is set.
+package dashboardsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/groundcover-com/groundcover-sdk-go/internal/syncplan"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/client"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/client/dashboards"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+	"golang.org/x/sync/errgroup"
+)
+
+// DefaultKeyTagPrefix prefixes the tag that identifies managed dashboards.
+const DefaultKeyTagPrefix = "sync_id:"
+
+const (
+	defaultConcurrency = 4
+	statusArchived     = "archived"
+)
+
+// ErrConflict is returned for changes to dashboards that were changed on the
+// backend since the definitions were last applied or since the plan was made.
+var ErrConflict = errors.New("dashboard was changed on the backend")
+
+// Action is what a plan does to a single dashboard.
+type Action = syncplan.Action
+
+// Plan actions.
+const (
+	ActionCreate   = syncplan.ActionCreate
+	ActionUpdate   = syncplan.ActionUpdate
+	ActionArchive  = syncplan.ActionArchive
+	ActionConflict = syncplan.ActionConflict
+	ActionNoop     = syncplan.ActionNoop
+)
+
+// Change is the planned action for a single dashboard.
+type Change struct {
+	syncplan.Change
+	// Revision is the revision of the remote dashboard the change was planned
+	// against.
+	Revision int32
+
+	desired *models.CreateDashboardRequest
+	remote  map[string]interface{}
+	applied int32
+}
+
+// Plan is the list of changes that brings a backend in line with a set of
+// definitions, sorted by key.
+type Plan = syncplan.Plan[*Change]
+
+// Result reports what Apply did. In a dry run Applied lists the changes that
+// would have been made. Conflicts are always reported as failed.
+type Result = syncplan.Result[*Change]
+
+// ChangeError is the failure to apply a single change.
+type ChangeError = syncplan.ChangeError[*Change]
+
+// Syncer plans and applies dashboard changes against a backend.
+type Syncer struct {
+	api         *client.GroundcoverAPI
+	tagPrefix   string
+	byName      bool
+	prune       bool
+	dryRun      bool
+	override    bool
+	concurrency int
+	adopt       bool
+	state       *State
+}
+
+// Option configures a Syncer.
+type Option func(*Syncer)
+
+// WithKeyTagPrefix sets the prefix of the tag that identifies managed
+// dashboards.
+func WithKeyTagPrefix(prefix string) Option {
+	return func(s *Syncer) {
+		s.tagPrefix = prefix
+		s.byName = false
+	}
+}
+
+// MatchByName matches definitions to dashboards by team and name instead of
+// a tag. Every dashboard of the backend is then managed, so WithPrune
+// archives all dashboards without a definition.
+func MatchByName() Option {
+	return func(s *Syncer) {
+		s.byName = true
+	}
+}
+
+// WithPrune makes plans archive managed dashboards that have no local
+// definition.
+func WithPrune(prune bool) Option {
+	return func(s *Syncer) {
+		s.prune = prune
+	}
+}
+
+// WithAdoptByName sets whether a definition without a matching managed
+// dashboard takes over an unmanaged dashboard with the same team and name,
+// instead of creating a new one. Adoption is enabled by default; the adopting
+// update adds the key tag to the dashboard.
+func WithAdoptByName(adopt bool) Option {
+	return func(s *Syncer) {
+		s.adopt = adopt
+	}
+}
+
+// WithDryRun makes Apply report the changes it would make without making them.
+func WithDryRun(dryRun bool) Option {
+	return func(s *Syncer) {
+		s.dryRun = dryRun
+	}
+}
+
+// WithOverride resolves conflicts in favour of the definitions: conflicting
+// changes are planned as updates, and updates are sent with the override
+// flag so that the backend accepts them even when the dashboard changed
+// after planning.
+func WithOverride(override bool) Option {
+	return func(s *Syncer) {
+		s.override = override
+	}
+}
+
+// WithState sets the record of the last applied dashboards. Plans compare
+// against it to detect conflicts, and Apply updates it; the caller saves it.
+// Without a state, conflicts are only detected when a dashboard changes
+// between planning and applying.
+func WithState(st *State) Option {
+	return func(s *Syncer) {
+		s.state = st
+	}
+}
+
+// WithConcurrency sets how many dashboards are fetched or changed in parallel.
+func WithConcurrency(n int) Option {
+	return func(s *Syncer) {
+		s.concurrency = n
+	}
+}
+
+// New creates a Syncer backed by the given client.
+func New(api *client.GroundcoverAPI, opts ...Option) *Syncer {
+	s := &Syncer{
+		api:         api,
+		tagPrefix:   DefaultKeyTagPrefix,
+		concurrency: defaultConcurrency,
+		adopt:       true,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.concurrency <= 0 {
+		s.concurrency = 1
+	}
+	if s.state != nil && s.state.Dashboards == nil {
+		s.state.Dashboards = map[string]*StateEntry{}
+	}
+	return s
+}
+
+// Sync loads the definitions in dir, plans and applies the changes.
+func (s *Syncer) Sync(ctx context.Context, dir string) (*Plan, *Result, error) {
+	defs, err := LoadDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	plan, err := s.Plan(ctx, defs)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := s.Apply(ctx, plan)
+	return plan, result, err
+}
+
+// Plan compares the definitions with the dashboards of the backend.
+func (s *Syncer) Plan(ctx context.Context, defs []*Definition) (*Plan, error) {
+	desired := map[string]*Definition{}
+	for _, def := range defs {
+		key := s.definitionKey(def)
+		if prev, ok := desired[key]; ok {
+			return nil, fmt.Errorf("%s: key %q is already used by %s", def.Path, key, prev.Path)
+		}
+		desired[key] = def
+	}
+
+	managed, unmanaged, err := s.remoteDashboards(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(desired))
+	for key := range desired {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	matched := make([]*models.MemberView, len(keys))
+	for i, key := range keys {
+		d := desired[key].Dashboard
+		matched[i] = managed[key]
+		if matched[i] == nil && s.adopt {
+			name := nameKey(d.Team, d.Name)
+			if matched[i] = unmanaged[name]; matched[i] != nil {
+				delete(unmanaged, name)
+			}
+		}
+	}
+	views, err := s.fetch(ctx, matched)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := syncplan.NewPlan[*Change](ActionCreate, ActionUpdate, ActionArchive, ActionConflict)
+	for i, key := range keys {
+		change, err := s.planChange(key, desired[key], views[i])
+		if err != nil {
+			return nil, err
+		}
+		plan.Changes = append(plan.Changes, change)
+	}
+	if s.prune {
+		for key, d := range managed {
+			if _, ok := desired[key]; !ok {
+				plan.Changes = append(plan.Changes, &Change{
+					Change: syncplan.Change{
+						Action: ActionArchive,
+						Key:    key,
+						Name:   d.Name,
+						ID:     d.UUID,
+					},
+					Revision: d.RevisionNumber,
+				})
+			}
+		}
+	}
+	plan.Sort()
+	return plan, nil
+}
+
+// Apply makes the changes of a plan, up to the configured concurrency at a
+// time. Failed changes do not stop the others; they are reported in the
+// result and joined in the returned error. A change that fails because the
+// dashboard changed after planning gets its conflicts filled in and an error
+// wrapping ErrConflict.
+func (s *Syncer) Apply(ctx context.Context, plan *Plan) (*Result, error) {
+	if !s.dryRun {
+		for _, c := range plan.Changes {
+			if c.Action == ActionNoop {
+				s.record(c, c.Revision)
+			}
+		}
+	}
+	a := &syncplan.Applier[*Change]{
+		Kind:        "dashboard",
+		Concurrency: s.concurrency,
+		DryRun:      s.dryRun,
+		Apply:       s.apply,
+		Applied: func(c *Change) {
+			if c.Action == ActionArchive {
+				s.forget(c.Key)
+			} else {
+				s.record(c, c.applied)
+			}
+		},
+		ErrConflict: ErrConflict,
+	}
+	return a.Run(ctx, plan)
+}
+
+func (s *Syncer) apply(ctx context.Context, c *Change) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	switch c.Action {
+	case ActionCreate:
+		resp, err := s.api.Dashboards.CreateDashboard(dashboards.NewCreateDashboardParams().WithContext(ctx).WithBody(c.desired), nil)
+		if err != nil {
+			return err
+		}
+		c.ID = resp.Payload.UUID
+		c.applied = resp.Payload.RevisionNumber
+		return nil
+	case ActionUpdate:
+		d := c.desired
+		body := &models.UpdateDashboardRequest{
+			Name:            d.Name,
+			Description:     d.Description,
+			Team:            d.Team,
+			Tags:            d.Tags,
+			Preset:          d.Preset,
+			IsProvisioned:   d.IsProvisioned,
+			CurrentRevision: c.Revision,
+			Override:        s.override,
+		}
+		resp, err := s.api.Dashboards.UpdateDashboard(dashboards.NewUpdateDashboardParams().WithContext(ctx).WithID(c.ID).WithBody(body), nil)
+		var conflict *dashboards.UpdateDashboardConflict
+		if errors.As(err, &conflict) {
+			return s.conflict(ctx, c)
+		}
+		if err != nil {
+			return err
+		}
+		c.applied = resp.Payload.RevisionNumber
+		return nil
+	case ActionArchive:
+		_, err := s.api.Dashboards.ArchiveDashboard(dashboards.NewArchiveDashboardParams().WithContext(ctx).WithID(c.ID).WithCurrentRevision(c.Revision), nil)
+		var conflict *dashboards.ArchiveDashboardConflict
+		if errors.As(err, &conflict) {
+			return fmt.Errorf("%w since revision %d", ErrConflict, c.Revision)
+		}
+		return err
+	}
+	return nil
+}
+
+// conflict fills in the conflicts of an update the backend rejected because
+// the dashboard changed after planning, comparing it with the dashboard as
+// planned.
+func (s *Syncer) conflict(ctx context.Context, c *Change) error {
+	resp, err := s.api.Dashboards.GetDashboard(dashboards.NewGetDashboardParams().WithContext(ctx).WithID(c.ID), nil)
+	if err != nil {
+		return fmt.Errorf("%w since revision %d: failed to get dashboard: %v", ErrConflict, c.Revision, err)
+	}
+	remote, err := dashboardTree(viewRequest(resp.Payload))
+	if err != nil {
+		return fmt.Errorf("%w since revision %d: %v", ErrConflict, c.Revision, err)
+	}
+	local, err := dashboardTree(c.desired)
+	if err != nil {
+		return err
+	}
+	c.Conflicts = syncplan.ThreeWay(c.remote, remote, local)
+	return fmt.Errorf("%w since revision %d, now at revision %d", ErrConflict, c.Revision, resp.Payload.RevisionNumber)
+}
+
+// record stores the applied content of a change in the state.
+func (s *Syncer) record(c *Change, revision int32) {
+	if s.state == nil || c.desired == nil {
+		return
+	}
+	s.state.Dashboards[c.Key] = &StateEntry{ID: c.ID, Revision: revision, Dashboard: c.desired}
+}
+
+func (s *Syncer) forget(key string) {
+	if s.state != nil {
+		delete(s.state.Dashboards, key)
+	}
+}
+
+// definitionKey returns the key of a definition, adding the key tag to it
+// when matching by tag and the definition does not have one.
+func (s *Syncer) definitionKey(def *Definition) string {
+	d := def.Dashboard
+	if s.byName {
+		return nameKey(d.Team, d.Name)
+	}
+	if key := s.tagKey(d.Tags); key != "" {
+		return key
+	}
+	key := syncplan.DefaultKey(def.Path)
+	d.Tags = append(d.Tags, s.tagPrefix+key)
+	return key
+}
+
+func (s *Syncer) remoteKey(d *models.MemberView) string {
+	if s.byName {
+		return nameKey(d.Team, d.Name)
+	}
+	return s.tagKey(d.Tags)
+}
+
+func (s *Syncer) tagKey(tags []string) string {
+	for _, tag := range tags {
+		if key, ok := strings.CutPrefix(tag, s.tagPrefix); ok && key != "" {
+			return key
+		}
+	}
+	return ""
+}
+
+// nameKey identifies a dashboard by team and name.
+func nameKey(team, name string) string {
+	if team == "" {
+		return name
+	}
+	return team + "/" + name
+}
+
+func (s *Syncer) planChange(key string, def *Definition, remote *models.View) (*Change, error) {
+	change := &Change{
+		Change: syncplan.Change{
+			Key:  key,
+			Name: def.Dashboard.Name,
+			Path: def.Path,
+		},
+		desired: def.Dashboard,
+	}
+	want, err := dashboardTree(def.Dashboard)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", def.Path, err)
+	}
+	if remote == nil {
+		change.Action = ActionCreate
+		change.Diffs = syncplan.Diff(map[string]interface{}{}, want)
+		return change, nil
+	}
+
+	change.ID = remote.UUID
+	change.Revision = remote.RevisionNumber
+	if change.remote, err = dashboardTree(viewRequest(remote)); err != nil {
+		return nil, fmt.Errorf("dashboard %s: %w", remote.UUID, err)
+	}
+	change.Diffs = syncplan.Diff(change.remote, want)
+	if base := s.state.base(key, remote.UUID); base != nil && base.Revision != remote.RevisionNumber {
+		have, err := dashboardTree(base.Dashboard)
+		if err != nil {
+			return nil, fmt.Errorf("state of %s: %w", key, err)
+		}
+		change.Conflicts = syncplan.ThreeWay(have, change.remote, want)
+	}
+
+	switch {
+	case len(change.Conflicts) > 0 && !s.override:
+		change.Action = ActionConflict
+	case len(change.Diffs) > 0:
+		change.Action = ActionUpdate
+	default:
+		change.Action = ActionNoop
+	}
+	return change, nil
+}
+
+// viewRequest returns the compared fields of a dashboard as a create request.
+func viewRequest(v *models.View) *models.CreateDashboardRequest {
+	return &models.CreateDashboardRequest{
+		Name:          v.Name,
+		Description:   v.Description,
+		Team:          v.Team,
+		Tags:          v.Tags,
+		Preset:        v.Preset,
+		IsProvisioned: v.IsProvisioned,
+	}
+}
+
+// remoteDashboards lists the dashboards of the backend that are not archived
+// and returns the managed ones by key and the unmanaged ones by team and
+// name. Unmanaged dashboards that share a team and name are left out, since
+// a definition could not tell which one to adopt.
+func (s *Syncer) remoteDashboards(ctx context.Context) (map[string]*models.MemberView, map[string]*models.MemberView, error) {
+	list, err := s.api.Dashboards.GetDashboards(dashboards.NewGetDashboardsParams().WithContext(ctx), nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list dashboards: %w", err)
+	}
+	managed := map[string]*models.MemberView{}
+	unmanaged := map[string]*models.MemberView{}
+	ambiguous := map[string]bool{}
+	for _, d := range list.Payload {
+		if d == nil || d.Status == statusArchived {
+			continue
+		}
+		key := s.remoteKey(d)
+		if key == "" {
+			name := nameKey(d.Team, d.Name)
+			if _, ok := unmanaged[name]; ok {
+				ambiguous[name] = true
+			}
+			unmanaged[name] = d
+			continue
+		}
+		if prev, ok := managed[key]; ok {
+			return nil, nil, fmt.Errorf("dashboards %s and %s share the key %q", prev.UUID, d.UUID, key)
+		}
+		managed[key] = d
+	}
+	for name := range ambiguous {
+		delete(unmanaged, name)
+	}
+	return managed, unmanaged, nil
+}
+
+// fetch gets the full dashboards of the given list items; nil items stay nil.
+func (s *Syncer) fetch(ctx context.Context, items []*models.MemberView) ([]*models.View, error) {
+	views := make([]*models.View, len(items))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(s.concurrency)
+	for i, item := range items {
+		if item == nil {
+			continue
+		}
+		g.Go(func() error {
+			resp, err := s.api.Dashboards.GetDashboard(dashboards.NewGetDashboardParams().WithContext(gctx).WithID(item.UUID), nil)
+			if err != nil {
+				return fmt.Errorf("failed to get dashboard %s: %w", item.UUID, err)
+			}
+			views[i] = resp.Payload
+			return nil
+		})
+	}
+	return views, g.Wait()
+}
@@ -0,0 +1,322 @@
+package dashboardsync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/groundcover-com/groundcover-sdk-go/internal/testutil"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDashboards is an in-memory dashboards API. Like the backend, it bumps
+// the revision of a dashboard on every update and rejects updates and
+// archives that name another revision unless they override.
+type fakeDashboards struct {
+	mu         sync.Mutex
+	next       int
+	dashboards map[string]*models.View
+	updates    map[string]*models.UpdateDashboardRequest
+}
+
+func newFakeDashboards() *fakeDashboards {
+	return &fakeDashboards{
+		dashboards: map[string]*models.View{},
+		updates:    map[string]*models.UpdateDashboardRequest{},
+	}
+}
+
+func (f *fakeDashboards) add(v *models.View) string {
+	f.next++
+	v.UUID = fmt.Sprintf("00000000-0000-0000-0000-%012d", f.next)
+	v.Status = "active"
+	if v.RevisionNumber == 0 {
+		v.RevisionNumber = 1
+	}
+	f.dashboards[v.UUID] = v
+	return v.UUID
+}
+
+// edit changes a dashboard as the dashboard editor would.
+func (f *fakeDashboards) edit(id string, fn func(v *models.View)) {
+	fn(f.dashboards[id])
+	f.dashboards[id].RevisionNumber++
+}
+
+func (f *fakeDashboards) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+
+	rest := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/api/dashboards"), "/")
+	id, op, _ := strings.Cut(rest, "/")
+	switch {
+	case r.Method == http.MethodGet && id == "":
+		list := []*models.MemberView{}
+		for _, v := range f.dashboards {
+			list = append(list, &models.MemberView{
+				UUID:           v.UUID,
+				Name:           v.Name,
+				Team:           v.Team,
+				Tags:           v.Tags,
+				Status:         v.Status,
+				RevisionNumber: v.RevisionNumber,
+			})
+		}
+		_ = json.NewEncoder(w).Encode(list)
+	case r.Method == http.MethodPost && id == "":
+		var req models.CreateDashboardRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		v := &models.View{Name: req.Name, Description: req.Description, Team: req.Team, Tags: req.Tags, Preset: req.Preset}
+		f.add(v)
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(v)
+	case f.dashboards[id] == nil:
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message":"not found"}`))
+	case r.Method == http.MethodGet:
+		_ = json.NewEncoder(w).Encode(f.dashboards[id])
+	case r.Method == http.MethodPut:
+		var req models.UpdateDashboardRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		v := f.dashboards[id]
+		if req.CurrentRevision != v.RevisionNumber && !req.Override {
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"message":"revision mismatch"}`))
+			return
+		}
+		f.updates[id] = &req
+		v.Name, v.Description, v.Team, v.Tags, v.Preset = req.Name, req.Description, req.Team, req.Tags, req.Preset
+		v.RevisionNumber++
+		w.WriteHeader(http.StatusAccepted)
+		_ = json.NewEncoder(w).Encode(v)
+	case r.Method == http.MethodPost && op == "archive":
+		v := f.dashboards[id]
+		if r.URL.Query().Get("currentRevision") != strconv.Itoa(int(v.RevisionNumber)) {
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"message":"revision mismatch"}`))
+			return
+		}
+		v.Status = statusArchived
+		v.RevisionNumber++
+		w.WriteHeader(http.StatusAccepted)
+		_ = json.NewEncoder(w).Encode(v)
+	}
+}
+
+const overviewYAML = `
+name: Overview
+description: Cluster overview
+team: platform
+tags: [k8s]
+preset:
+  duration: Last 1 hour
+  layout:
+    - {id: A, x: 0, y: 0, w: 6, h: 3}
+  widgets:
+    - id: A
+      type: widget
+      name: Disk usage
+      queries:
+        - {id: A, expr: "avg(groundcover_node_rt_disk_space_used_percent{})", dataType: metrics}
+      visualizationConfig: {type: time-series}
+  schemaVersion: 3
+`
+
+const notesJSON = `{
+  "name": "Notes",
+  "preset": "{\"layout\":[{\"id\":\"A\",\"x\":0,\"y\":0,\"w\":12,\"h\":2}],\"widgets\":[{\"id\":\"A\",\"type\":\"text\",\"html\":\"<p>On call</p>\"}]}"
+}`
+
+func TestLoadDir(t *testing.T) {
+	dir := testutil.WriteFiles(t, map[string]string{
+		"k8s/overview.yaml": overviewYAML,
+		"notes.json":        notesJSON,
+		"README.md":         "not a definition",
+	})
+	defs, err := LoadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, defs, 2)
+	require.Equal(t, "k8s/overview.yaml", defs[0].Path)
+	require.Equal(t, "platform", defs[0].Dashboard.Team)
+	require.Contains(t, defs[0].Dashboard.Preset, `"duration":"Last 1 hour"`)
+	require.Equal(t, "notes.json", defs[1].Path)
+	require.Contains(t, defs[1].Dashboard.Preset, `"type":"text"`)
+
+	_, err = ParseDefinition("typo.yaml", []byte("name: x\ntaggs: [a]\n"))
+	require.ErrorContains(t, err, `unknown field "taggs"`)
+	_, err = ParseDefinition("empty.yaml", []byte("name: x\n"))
+	require.ErrorContains(t, err, "preset is required")
+	_, err = ParseDefinition("bad.yaml", []byte("name: x\npreset: {widgets: [{id: A, type: widget}]}\n"))
+	require.ErrorContains(t, err, "invalid preset: widget A has no queries")
+}
+
+func TestSyncCreatesUpdatesAndArchives(t *testing.T) {
+	fake := newFakeDashboards()
+	api := testutil.NewAPI(t, fake)
+	goneID := fake.add(&models.View{Name: "Gone", Tags: []string{DefaultKeyTagPrefix + "gone"}, Preset: "{}"})
+	handMadeID := fake.add(&models.View{Name: "Hand made", Preset: "{}"})
+	dir := testutil.WriteFiles(t, map[string]string{"k8s/overview.yaml": overviewYAML, "notes.json": notesJSON})
+	st := NewState()
+	syncer := New(api, WithPrune(true), WithState(st))
+
+	plan, result, err := syncer.Sync(context.Background(), dir)
+	require.NoError(t, err)
+	require.Equal(t, 2, plan.Count(ActionCreate))
+	require.Equal(t, 1, plan.Count(ActionArchive))
+	require.Len(t, result.Applied, 3)
+	require.Equal(t, statusArchived, fake.dashboards[goneID].Status)
+	require.Equal(t, "active", fake.dashboards[handMadeID].Status)
+
+	overviewID := result.Applied[1].ID
+	require.Equal(t, []string{"k8s", "sync_id:k8s/overview"}, fake.dashboards[overviewID].Tags)
+	require.Equal(t, &StateEntry{ID: overviewID, Revision: 1, Dashboard: result.Applied[1].desired}, st.Dashboards["k8s/overview"])
+	require.NotContains(t, st.Dashboards, "gone")
+
+	// Nothing changed: the plan is empty.
+	defs, err := LoadDir(dir)
+	require.NoError(t, err)
+	plan, err = syncer.Plan(context.Background(), defs)
+	require.NoError(t, err)
+	require.False(t, plan.HasChanges(), plan.String())
+
+	// The definition changes: the update names the current revision.
+	overview := strings.Replace(overviewYAML, "name: Disk usage", "name: Disk usage per node", 1)
+	defs[0] = testutil.MustParse(t, ParseDefinition, "k8s/overview.yaml", overview)
+	plan, err = syncer.Plan(context.Background(), defs)
+	require.NoError(t, err)
+	require.Equal(t, []FieldDiff{{Path: "preset.widgets[0].name", Old: "Disk usage", New: "Disk usage per node"}}, plan.Changes[0].Diffs)
+	_, err = syncer.Apply(context.Background(), plan)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), fake.updates[overviewID].CurrentRevision)
+	require.False(t, fake.updates[overviewID].Override)
+	require.Equal(t, int32(2), st.Dashboards["k8s/overview"].Revision)
+}
+
+func TestPlanReportsConflictsWithEditorChanges(t *testing.T) {
+	fake := newFakeDashboards()
+	api := testutil.NewAPI(t, fake)
+	st := NewState()
+	plan, _, err := New(api, WithState(st)).Sync(context.Background(), testutil.WriteFiles(t, map[string]string{"overview.yaml": overviewYAML}))
+	require.NoError(t, err)
+	id := plan.Changes[0].ID
+
+	// Someone renames the widget and the description in the editor, while
+	// the definition renames the widget differently.
+	fake.edit(id, func(v *models.View) {
+		v.Description = "Edited in the UI"
+		v.Preset = strings.Replace(v.Preset, `"name":"Disk usage"`, `"name":"Disk"`, 1)
+	})
+	overview := strings.Replace(overviewYAML, "name: Disk usage", "name: Disk usage per node", 1)
+	defs := []*Definition{testutil.MustParse(t, ParseDefinition, "overview.yaml", overview)}
+
+	plan, err = New(api, WithState(st)).Plan(context.Background(), defs)
+	require.NoError(t, err)
+	change := plan.Changes[0]
+	require.Equal(t, ActionConflict, change.Action)
+	require.Equal(t, []Conflict{
+		{Path: "description", Base: "Cluster overview", Remote: "Edited in the UI", Local: "Cluster overview"},
+		{Path: "preset.widgets[0].name", Base: "Disk usage", Remote: "Disk", Local: "Disk usage per node"},
+	}, change.Conflicts)
+	require.Contains(t, plan.String(), `! preset.widgets[0].name: base "Disk usage", remote "Disk", local "Disk usage per node"`)
+	require.Contains(t, plan.String(), "1 in conflict")
+
+	result, err := New(api, WithState(st)).Apply(context.Background(), plan)
+	require.ErrorIs(t, err, ErrConflict)
+	require.Len(t, result.Failed, 1)
+	require.Empty(t, fake.updates)
+
+	// Overriding applies the definition over the editor changes.
+	plan, err = New(api, WithState(st), WithOverride(true)).Plan(context.Background(), defs)
+	require.NoError(t, err)
+	require.Equal(t, ActionUpdate, plan.Changes[0].Action)
+	require.Len(t, plan.Changes[0].Conflicts, 2)
+	_, err = New(api, WithState(st), WithOverride(true)).Apply(context.Background(), plan)
+	require.NoError(t, err)
+	require.Equal(t, "Cluster overview", fake.dashboards[id].Description)
+	require.True(t, fake.updates[id].Override)
+}
+
+func TestApplyDetectsChangesAfterPlanning(t *testing.T) {
+	fake := newFakeDashboards()
+	api := testutil.NewAPI(t, fake)
+	plan, _, err := New(api).Sync(context.Background(), testutil.WriteFiles(t, map[string]string{"overview.yaml": overviewYAML, "notes.json": notesJSON}))
+	require.NoError(t, err)
+	overviewID, notesID := plan.Changes[1].ID, plan.Changes[0].ID
+
+	defs := []*Definition{testutil.MustParse(t, ParseDefinition, "overview.yaml", strings.Replace(overviewYAML, "Last 1 hour", "Last 6 hours", 1))}
+	syncer := New(api, WithPrune(true))
+	plan, err = syncer.Plan(context.Background(), defs)
+	require.NoError(t, err)
+	require.Equal(t, 1, plan.Count(ActionUpdate))
+	require.Equal(t, 1, plan.Count(ActionArchive))
+
+	fake.edit(overviewID, func(v *models.View) {
+		v.Preset = strings.Replace(v.Preset, "Last 1 hour", "Last 24 hours", 1)
+	})
+	fake.edit(notesID, func(v *models.View) { v.Description = "Still needed" })
+
+	result, err := syncer.Apply(context.Background(), plan)
+	require.ErrorIs(t, err, ErrConflict)
+	require.Len(t, result.Failed, 2)
+	require.EqualError(t, result.Failed[0], "failed to archive dashboard notes: dashboard was changed on the backend since revision 1")
+	require.EqualError(t, result.Failed[1], "failed to update dashboard overview: dashboard was changed on the backend since revision 1, now at revision 2")
+	require.Equal(t, []Conflict{
+		{Path: "preset.duration", Base: "Last 1 hour", Remote: "Last 24 hours", Local: "Last 6 hours"},
+	}, result.Failed[1].Change.Conflicts)
+	require.Equal(t, "active", fake.dashboards[notesID].Status)
+}
+
+func TestSyncAdoptsByNameAndDryRun(t *testing.T) {
+	fake := newFakeDashboards()
+	api := testutil.NewAPI(t, fake)
+	def := testutil.MustParse(t, ParseDefinition, "overview.yaml", overviewYAML)
+	id := fake.add(&models.View{
+		Name:        "Overview",
+		Description: "Cluster overview",
+		Team:        "platform",
+		Tags:        []string{"k8s"},
+		Preset:      def.Dashboard.Preset,
+	})
+	fake.add(&models.View{Name: "Overview", Preset: "{}"})
+
+	dry := New(api, WithDryRun(true))
+	plan, err := dry.Plan(context.Background(), []*Definition{def})
+	require.NoError(t, err)
+	require.Equal(t, id, plan.Changes[0].ID)
+	require.Equal(t, []FieldDiff{{Path: "tags[1]", New: "sync_id:overview"}}, plan.Changes[0].Diffs)
+	result, err := dry.Apply(context.Background(), plan)
+	require.NoError(t, err)
+	require.True(t, result.DryRun)
+	require.Len(t, result.Applied, 1)
+	require.Empty(t, fake.updates)
+
+	plan, err = New(api, MatchByName()).Plan(context.Background(), []*Definition{testutil.MustParse(t, ParseDefinition, "overview.yaml", overviewYAML)})
+	require.NoError(t, err)
+	require.Equal(t, "platform/Overview", plan.Changes[0].Key)
+	require.Equal(t, ActionNoop, plan.Changes[0].Action)
+
+	plan, err = New(api, WithAdoptByName(false)).Plan(context.Background(), []*Definition{testutil.MustParse(t, ParseDefinition, "overview.yaml", overviewYAML)})
+	require.NoError(t, err)
+	require.Equal(t, ActionCreate, plan.Changes[0].Action)
+}
+
+func TestStateRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	st, err := LoadState(path)
+	require.NoError(t, err)
+	require.Empty(t, st.Dashboards)
+
+	st.Dashboards["overview"] = &StateEntry{ID: "id", Revision: 3, Dashboard: &models.CreateDashboardRequest{Name: "Overview", Preset: "{}"}}
+	require.NoError(t, st.Save(path))
+	loaded, err := LoadState(path)
+	require.NoError(t, err)
+	require.Equal(t, st, loaded)
+}
@@ -0,0 +1,521 @@
+// Package discovery provides helpers for exploring the metrics, labels and
+// search keys available in a groundcover backend, for example to power
+// autocomplete or to validate user-provided queries before running them.
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/client"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/client/metrics"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/timerange"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	defaultDiscoveryWindow = time.Hour
+	defaultDiscoveryLimit  = 10000
+	defaultConcurrency     = 4
+)
+
+// MetricsCatalog lazily walks metric names, their label keys and label values
+// through the metrics discovery endpoints, caching every response. It is safe
+// for concurrent use.
+//
+// The cache is bound to a single time range: the fixed one set with
+// WithTimeRange, or else the rolling window as of the first query. Every
+// later query, including Snapshot, uses that same range until Invalidate
+// drops the cache, so results fetched over different windows are never
+// mixed.
+type MetricsCatalog struct {
+	api         *client.GroundcoverAPI
+	timeRange   timerange.Range
+	window      time.Duration
+	sources     []*models.Condition
+	limit       uint32
+	concurrency int
+
+	mu sync.Mutex
+	// cached is the time range of the cached responses, zero while the
+	// cache is empty.
+	cached     timerange.Range
+	names      []*models.MetricsNamesResultEnriched
+	namesFull  bool
+	namesReady bool
+	keys       map[string]*keysEntry
+	values     map[string]map[string]*valuesEntry
+}
+
+type keysEntry struct {
+	keys     []string
+	complete bool
+}
+
+type valuesEntry struct {
+	values   []string
+	complete bool
+}
+
+// CatalogOption configures a MetricsCatalog.
+type CatalogOption func(*MetricsCatalog)
+
+// WithTimeRange sets a fixed time range for discovery queries. By default each
+// query covers the hour preceding it.
+func WithTimeRange(r timerange.Range) CatalogOption {
+	return func(c *MetricsCatalog) {
+		c.timeRange = r
+	}
+}
+
+// WithWindow sets the length of the rolling window used for discovery queries
+// when no fixed time range is set.
+func WithWindow(window time.Duration) CatalogOption {
+	return func(c *MetricsCatalog) {
+		c.window = window
+	}
+}
+
+// WithSources restricts discovery to the given source conditions, for example
+// a single cluster or environment.
+func WithSources(sources []*models.Condition) CatalogOption {
+	return func(c *MetricsCatalog) {
+		c.sources = sources
+	}
+}
+
+// WithLimit sets the maximum number of results requested per discovery call.
+func WithLimit(limit uint32) CatalogOption {
+	return func(c *MetricsCatalog) {
+		c.limit = limit
+	}
+}
+
+// WithConcurrency sets how many discovery requests Snapshot issues in parallel.
+func WithConcurrency(n int) CatalogOption {
+	return func(c *MetricsCatalog) {
+		c.concurrency = n
+	}
+}
+
+// NewMetricsCatalog creates a MetricsCatalog backed by the given client.
+// Nothing is fetched until the catalog is first queried.
+func NewMetricsCatalog(api *client.GroundcoverAPI, opts ...CatalogOption) *MetricsCatalog {
+	c := &MetricsCatalog{
+		api:         api,
+		window:      defaultDiscoveryWindow,
+		limit:       defaultDiscoveryLimit,
+		concurrency: defaultConcurrency,
+		keys:        map[string]*keysEntry{},
+		values:      map[string]map[string]*valuesEntry{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.concurrency <= 0 {
+		c.concurrency = 1
+	}
+	return c
+}
+
+// Invalidate drops every cached response so that the next query refetches.
+func (c *MetricsCatalog) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cached = timerange.Range{}
+	c.names = nil
+	c.namesFull = false
+	c.namesReady = false
+	c.keys = map[string]*keysEntry{}
+	c.values = map[string]map[string]*valuesEntry{}
+}
+
+// cacheRange returns the time range of the cached responses, binding the
+// cache to the current range when it is empty.
+func (c *MetricsCatalog) cacheRange() timerange.Range {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cached.IsZero() {
+		c.cached = c.currentRange()
+	}
+	return c.cached
+}
+
+func (c *MetricsCatalog) currentRange() timerange.Range {
+	if !c.timeRange.IsZero() {
+		return c.timeRange
+	}
+	return timerange.Last(c.window)
+}
+
+// Metrics returns all metric names known to the backend, with their type, unit
+// and description.
+func (c *MetricsCatalog) Metrics(ctx context.Context) ([]*models.MetricsNamesResultEnriched, error) {
+	return c.metrics(ctx, c.cacheRange())
+}
+
+func (c *MetricsCatalog) metrics(ctx context.Context, r timerange.Range) ([]*models.MetricsNamesResultEnriched, error) {
+	c.mu.Lock()
+	if c.namesReady {
+		names := c.names
+		c.mu.Unlock()
+		return names, nil
+	}
+	c.mu.Unlock()
+
+	start, end := r.DateTimes()
+	params := metrics.NewGetMetricNamesParamsWithContext(ctx).WithBody(&models.MetricsNamesRequest{
+		Start:   start,
+		End:     end,
+		Limit:   c.limit,
+		Sources: c.sources,
+	})
+	resp, err := c.api.Metrics.GetMetricNames(params, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metric names: %w", err)
+	}
+
+	var names []*models.MetricsNamesResultEnriched
+	complete := true
+	if resp.Payload != nil {
+		names = resp.Payload.Metrics
+		complete = !resp.Payload.IsLimitReached
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i].Name < names[j].Name })
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cached != r {
+		// The cache was invalidated while the names were fetched.
+		return names, nil
+	}
+	c.names = names
+	c.namesFull = complete
+	c.namesReady = true
+	return names, nil
+}
+
+// Metric returns the metadata of a single metric, or nil if it is unknown.
+func (c *MetricsCatalog) Metric(ctx context.Context, name string) (*models.MetricsNamesResultEnriched, error) {
+	names, err := c.Metrics(ctx)
+	if err != nil {
+		return nil, err
+	}
+	i := sort.Search(len(names), func(i int) bool { return names[i].Name >= name })
+	if i < len(names) && names[i].Name == name {
+		return names[i], nil
+	}
+	return nil, nil
+}
+
+// SearchPrefix returns the metrics whose names start with prefix.
+func (c *MetricsCatalog) SearchPrefix(ctx context.Context, prefix string) ([]*models.MetricsNamesResultEnriched, error) {
+	return c.search(ctx, func(name string) bool { return strings.HasPrefix(name, prefix) })
+}
+
+// SearchRegexp returns the metrics whose names match re.
+func (c *MetricsCatalog) SearchRegexp(ctx context.Context, re *regexp.Regexp) ([]*models.MetricsNamesResultEnriched, error) {
+	return c.search(ctx, re.MatchString)
+}
+
+func (c *MetricsCatalog) search(ctx context.Context, match func(string) bool) ([]*models.MetricsNamesResultEnriched, error) {
+	names, err := c.Metrics(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var matched []*models.MetricsNamesResultEnriched
+	for _, m := range names {
+		if match(m.Name) {
+			matched = append(matched, m)
+		}
+	}
+	return matched, nil
+}
+
+// Keys returns the label keys of a metric.
+func (c *MetricsCatalog) Keys(ctx context.Context, metric string) ([]string, error) {
+	entry, err := c.keysEntry(ctx, c.cacheRange(), metric)
+	if err != nil {
+		return nil, err
+	}
+	return entry.keys, nil
+}
+
+func (c *MetricsCatalog) keysEntry(ctx context.Context, r timerange.Range, metric string) (*keysEntry, error) {
+	c.mu.Lock()
+	if entry, ok := c.keys[metric]; ok {
+		c.mu.Unlock()
+		return entry, nil
+	}
+	c.mu.Unlock()
+
+	start, end := r.DateTimes()
+	params := metrics.NewGetMetricKeysParamsWithContext(ctx).WithBody(&models.MetricsKeysRequest{
+		Name:    metric,
+		Start:   start,
+		End:     end,
+		Limit:   c.limit,
+		Sources: c.sources,
+	})
+	resp, err := c.api.Metrics.GetMetricKeys(params, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get keys of metric %s: %w", metric, err)
+	}
+
+	entry := &keysEntry{complete: true}
+	if resp.Payload != nil {
+		entry.keys = resp.Payload.Keys
+		entry.complete = !resp.Payload.IsLimitReached
+	}
+	sort.Strings(entry.keys)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cached != r {
+		return entry, nil
+	}
+	c.keys[metric] = entry
+	return entry, nil
+}
+
+// Values returns the values of a label key of a metric.
+func (c *MetricsCatalog) Values(ctx context.Context, metric, key string) ([]string, error) {
+	entry, err := c.valuesEntry(ctx, c.cacheRange(), metric, key)
+	if err != nil {
+		return nil, err
+	}
+	return entry.values, nil
+}
+
+func (c *MetricsCatalog) valuesEntry(ctx context.Context, r timerange.Range, metric, key string) (*valuesEntry, error) {
+	c.mu.Lock()
+	if entry, ok := c.values[metric][key]; ok {
+		c.mu.Unlock()
+		return entry, nil
+	}
+	c.mu.Unlock()
+
+	start, end := r.DateTimes()
+	params := metrics.NewGetMetricValuesParamsWithContext(ctx).WithBody(&models.MetricsValuesRequest{
+		Name:    metric,
+		Key:     key,
+		Start:   start,
+		End:     end,
+		Limit:   c.limit,
+		Sources: c.sources,
+	})
+	resp, err := c.api.Metrics.GetMetricValues(params, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get values of %s for metric %s: %w", key, metric, err)
+	}
+
+	entry := &valuesEntry{complete: true}
+	if resp.Payload != nil {
+		entry.values = resp.Payload.Values
+		entry.complete = !resp.Payload.IsLimitReached
+	}
+	sort.Strings(entry.values)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cached != r {
+		return entry, nil
+	}
+	if c.values[metric] == nil {
+		c.values[metric] = map[string]*valuesEntry{}
+	}
+	c.values[metric][key] = entry
+	return entry, nil
+}
+
+// LabelCardinality is the number of distinct values observed for a label.
+type LabelCardinality struct {
+	Key    string `json:"key"`
+	Values int    `json:"values"`
+	// Exact is false when the backend truncated the values at the request
+	// limit, in which case Values is a lower bound.
+	Exact bool `json:"exact"`
+}
+
+// MetricCardinality estimates the cardinality of a metric from the number of
+// distinct values of each of its labels.
+type MetricCardinality struct {
+	Metric string             `json:"metric"`
+	Labels []LabelCardinality `json:"labels"`
+	// EstimatedSeries is the product of the label cardinalities. Labels are
+	// rarely independent, so this is an upper bound on the number of series
+	// rather than a count.
+	EstimatedSeries uint64 `json:"estimatedSeries"`
+}
+
+// Cardinality fetches the values of every label of a metric and reports the
+// cardinality of each label along with an upper-bound series estimate.
+func (c *MetricsCatalog) Cardinality(ctx context.Context, metric string) (*MetricCardinality, error) {
+	return c.cardinality(ctx, c.cacheRange(), metric)
+}
+
+func (c *MetricsCatalog) cardinality(ctx context.Context, r timerange.Range, metric string) (*MetricCardinality, error) {
+	keysEntry, err := c.keysEntry(ctx, r, metric)
+	if err != nil {
+		return nil, err
+	}
+	keys := keysEntry.keys
+
+	result := &MetricCardinality{Metric: metric, EstimatedSeries: 1}
+	for _, key := range keys {
+		entry, err := c.valuesEntry(ctx, r, metric, key)
+		if err != nil {
+			return nil, err
+		}
+		n := len(entry.values)
+		result.Labels = append(result.Labels, LabelCardinality{Key: key, Values: n, Exact: entry.complete})
+		if n > 0 {
+			result.EstimatedSeries = saturatingMul(result.EstimatedSeries, uint64(n))
+		}
+	}
+	if len(keys) == 0 {
+		result.EstimatedSeries = 0
+	}
+	return result, nil
+}
+
+func saturatingMul(a, b uint64) uint64 {
+	if a != 0 && b > ^uint64(0)/a {
+		return ^uint64(0)
+	}
+	return a * b
+}
+
+// CatalogSnapshot is a serializable copy of the whole metrics catalog.
+type CatalogSnapshot struct {
+	GeneratedAt time.Time `json:"generatedAt"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	// Complete is false when any discovery call was truncated at the request
+	// limit.
+	Complete bool             `json:"complete"`
+	Metrics  []MetricSnapshot `json:"metrics"`
+}
+
+// MetricSnapshot describes a single metric and its labels.
+type MetricSnapshot struct {
+	Name            string          `json:"name"`
+	Type            string          `json:"type,omitempty"`
+	Unit            string          `json:"unit,omitempty"`
+	Description     string          `json:"description,omitempty"`
+	Labels          []LabelSnapshot `json:"labels"`
+	EstimatedSeries uint64          `json:"estimatedSeries"`
+}
+
+// LabelSnapshot describes a single label key and its values.
+type LabelSnapshot struct {
+	Key    string   `json:"key"`
+	Values []string `json:"values"`
+	Exact  bool     `json:"exact"`
+}
+
+// Snapshot walks every metric, label key and label value, filling the cache,
+// and returns the result as a CatalogSnapshot. The snapshot covers the time
+// range the cache is bound to, and its requests are issued with the
+// catalog's concurrency.
+func (c *MetricsCatalog) Snapshot(ctx context.Context) (*CatalogSnapshot, error) {
+	return c.snapshot(ctx, c.cacheRange())
+}
+
+func (c *MetricsCatalog) snapshot(ctx context.Context, r timerange.Range) (*CatalogSnapshot, error) {
+	names, err := c.metrics(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	snap := &CatalogSnapshot{
+		GeneratedAt: time.Now().UTC(),
+		Start:       r.Start.UTC(),
+		End:         r.End.UTC(),
+		Metrics:     make([]MetricSnapshot, len(names)),
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(c.concurrency)
+	for i, m := range names {
+		g.Go(func() error {
+			if err := gctx.Err(); err != nil {
+				return err
+			}
+			ms, err := c.metricSnapshot(gctx, r, m)
+			if err != nil {
+				return err
+			}
+			snap.Metrics[i] = ms
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	snap.Complete = c.namesFull
+	for _, entry := range c.keys {
+		snap.Complete = snap.Complete && entry.complete
+	}
+	for _, entries := range c.values {
+		for _, entry := range entries {
+			snap.Complete = snap.Complete && entry.complete
+		}
+	}
+	c.mu.Unlock()
+
+	return snap, nil
+}
+
+func (c *MetricsCatalog) metricSnapshot(ctx context.Context, r timerange.Range, m *models.MetricsNamesResultEnriched) (MetricSnapshot, error) {
+	ms := MetricSnapshot{
+		Name:        m.Name,
+		Type:        m.Type,
+		Unit:        m.Unit,
+		Description: m.Description,
+		Labels:      []LabelSnapshot{},
+	}
+
+	card, err := c.cardinality(ctx, r, m.Name)
+	if err != nil {
+		return ms, err
+	}
+	ms.EstimatedSeries = card.EstimatedSeries
+
+	for _, label := range card.Labels {
+		entry, err := c.valuesEntry(ctx, r, m.Name, label.Key)
+		if err != nil {
+			return ms, err
+		}
+		values := entry.values
+		if values == nil {
+			values = []string{}
+		}
+		ms.Labels = append(ms.Labels, LabelSnapshot{Key: label.Key, Values: values, Exact: label.Exact})
+	}
+	return ms, nil
+}
+
+// Dump writes a Snapshot of the catalog to w as indented JSON.
+func (c *MetricsCatalog) Dump(ctx context.Context, w io.Writer) error {
+	snap, err := c.Snapshot(ctx)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(snap); err != nil {
+		return fmt.Errorf("failed to encode metrics catalog: %w", err)
+	}
+	return nil
+}
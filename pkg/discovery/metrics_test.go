@@ -0,0 +1,212 @@
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/groundcover-com/groundcover-sdk-go/internal/testutil"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/timerange"
+	"github.com/stretchr/testify/require"
+)
+
+func writeJSON(t *testing.T, w http.ResponseWriter, v interface{}) {
+	t.Helper()
+	w.Header().Set("Content-Type", "application/json")
+	require.NoError(t, json.NewEncoder(w).Encode(v))
+}
+
+type fakeMetricsBackend struct {
+	t      *testing.T
+	calls  atomic.Int32
+	labels map[string]map[string][]string
+
+	mu     sync.Mutex
+	starts map[string]bool
+}
+
+// start records the start time of a request.
+func (f *fakeMetricsBackend) start(t strfmt.DateTime) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.starts == nil {
+		f.starts = map[string]bool{}
+	}
+	f.starts[t.String()] = true
+}
+
+func (f *fakeMetricsBackend) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.calls.Add(1)
+	switch r.URL.Path {
+	case "/api/metrics/names":
+		var req models.MetricsNamesRequest
+		require.NoError(f.t, json.NewDecoder(r.Body).Decode(&req))
+		f.start(req.Start)
+		resp := &models.MetricsNamesResponse{}
+		for name := range f.labels {
+			resp.Metrics = append(resp.Metrics, &models.MetricsNamesResultEnriched{Name: name, Type: "gauge"})
+		}
+		writeJSON(f.t, w, resp)
+	case "/api/metrics/keys":
+		var req models.MetricsKeysRequest
+		require.NoError(f.t, json.NewDecoder(r.Body).Decode(&req))
+		f.start(req.Start)
+		resp := &models.MetricsKeysResponse{Name: req.Name}
+		for key := range f.labels[req.Name] {
+			resp.Keys = append(resp.Keys, key)
+		}
+		writeJSON(f.t, w, resp)
+	case "/api/metrics/values":
+		var req models.MetricsValuesRequest
+		require.NoError(f.t, json.NewDecoder(r.Body).Decode(&req))
+		f.start(req.Start)
+		values := f.labels[req.Name][req.Key]
+		writeJSON(f.t, w, &models.MetricsValuesResponse{
+			Name:           req.Name,
+			Key:            req.Key,
+			Values:         values,
+			IsLimitReached: uint32(len(values)) >= req.Limit,
+		})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func newFakeMetricsBackend(t *testing.T) *fakeMetricsBackend {
+	return &fakeMetricsBackend{
+		t: t,
+		labels: map[string]map[string][]string{
+			"groundcover_container_cpu_usage": {
+				"namespace": {"default", "kube-system"},
+				"workload":  {"api", "web", "worker"},
+			},
+			"groundcover_container_memory_usage": {
+				"namespace": {"default"},
+			},
+			"http_requests_total": {
+				"status": {"200", "500"},
+			},
+		},
+	}
+}
+
+func TestMetricsCatalogCachesResponses(t *testing.T) {
+	backend := newFakeMetricsBackend(t)
+	catalog := NewMetricsCatalog(testutil.NewAPI(t, backend))
+	ctx := context.Background()
+
+	names, err := catalog.Metrics(ctx)
+	require.NoError(t, err)
+	require.Len(t, names, 3)
+	require.Equal(t, "groundcover_container_cpu_usage", names[0].Name)
+
+	keys, err := catalog.Keys(ctx, "groundcover_container_cpu_usage")
+	require.NoError(t, err)
+	require.Equal(t, []string{"namespace", "workload"}, keys)
+
+	values, err := catalog.Values(ctx, "groundcover_container_cpu_usage", "workload")
+	require.NoError(t, err)
+	require.Equal(t, []string{"api", "web", "worker"}, values)
+
+	calls := backend.calls.Load()
+	_, err = catalog.Metrics(ctx)
+	require.NoError(t, err)
+	_, err = catalog.Keys(ctx, "groundcover_container_cpu_usage")
+	require.NoError(t, err)
+	_, err = catalog.Values(ctx, "groundcover_container_cpu_usage", "workload")
+	require.NoError(t, err)
+	require.Equal(t, calls, backend.calls.Load())
+
+	catalog.Invalidate()
+	_, err = catalog.Metrics(ctx)
+	require.NoError(t, err)
+	require.Equal(t, calls+1, backend.calls.Load())
+}
+
+func TestMetricsCatalogBindsCacheToOneWindow(t *testing.T) {
+	backend := newFakeMetricsBackend(t)
+	catalog := NewMetricsCatalog(testutil.NewAPI(t, backend), WithWindow(time.Hour))
+	ctx := context.Background()
+
+	_, err := catalog.Keys(ctx, "groundcover_container_cpu_usage")
+	require.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+	snap, err := catalog.Snapshot(ctx)
+	require.NoError(t, err)
+	require.Len(t, backend.starts, 1)
+	require.True(t, backend.starts[strfmt.DateTime(snap.Start).String()])
+
+	time.Sleep(5 * time.Millisecond)
+	catalog.Invalidate()
+	_, err = catalog.Metrics(ctx)
+	require.NoError(t, err)
+	require.Len(t, backend.starts, 2)
+}
+
+func TestMetricsCatalogSearch(t *testing.T) {
+	catalog := NewMetricsCatalog(testutil.NewAPI(t, newFakeMetricsBackend(t)))
+	ctx := context.Background()
+
+	byPrefix, err := catalog.SearchPrefix(ctx, "groundcover_")
+	require.NoError(t, err)
+	require.Len(t, byPrefix, 2)
+
+	byRegexp, err := catalog.SearchRegexp(ctx, regexp.MustCompile(`_total$`))
+	require.NoError(t, err)
+	require.Len(t, byRegexp, 1)
+	require.Equal(t, "http_requests_total", byRegexp[0].Name)
+
+	metric, err := catalog.Metric(ctx, "http_requests_total")
+	require.NoError(t, err)
+	require.NotNil(t, metric)
+
+	missing, err := catalog.Metric(ctx, "does_not_exist")
+	require.NoError(t, err)
+	require.Nil(t, missing)
+}
+
+func TestMetricsCatalogCardinality(t *testing.T) {
+	catalog := NewMetricsCatalog(testutil.NewAPI(t, newFakeMetricsBackend(t)), WithLimit(3))
+
+	card, err := catalog.Cardinality(context.Background(), "groundcover_container_cpu_usage")
+	require.NoError(t, err)
+	require.Equal(t, uint64(6), card.EstimatedSeries)
+	require.Equal(t, []LabelCardinality{
+		{Key: "namespace", Values: 2, Exact: true},
+		{Key: "workload", Values: 3, Exact: false},
+	}, card.Labels)
+}
+
+func TestMetricsCatalogDump(t *testing.T) {
+	r := timerange.New(time.Date(2024, time.March, 13, 14, 0, 0, 0, time.UTC), time.Date(2024, time.March, 13, 15, 0, 0, 0, time.UTC))
+	catalog := NewMetricsCatalog(testutil.NewAPI(t, newFakeMetricsBackend(t)), WithTimeRange(r), WithConcurrency(2))
+
+	var buf bytes.Buffer
+	require.NoError(t, catalog.Dump(context.Background(), &buf))
+
+	var snap CatalogSnapshot
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &snap))
+	require.True(t, snap.Complete)
+	require.Equal(t, r.Start, snap.Start)
+	require.Len(t, snap.Metrics, 3)
+	require.Equal(t, "http_requests_total", snap.Metrics[2].Name)
+	require.Equal(t, []LabelSnapshot{{Key: "status", Values: []string{"200", "500"}, Exact: true}}, snap.Metrics[2].Labels)
+	require.Equal(t, uint64(2), snap.Metrics[2].EstimatedSeries)
+}
+
+func TestMetricsCatalogPropagatesErrors(t *testing.T) {
+	catalog := NewMetricsCatalog(testutil.NewAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"boom"}`, http.StatusBadRequest)
+	})))
+
+	_, err := catalog.Snapshot(context.Background())
+	require.Error(t, err)
+}
@@ -0,0 +1,397 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-openapi/swag"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/client"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/client/search"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/timerange"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/types"
+)
+
+// DataDomain is the data type searched by the search endpoints.
+type DataDomain string
+
+// Data domains supported by the search helpers.
+const (
+	DomainLogs   DataDomain = "logs"
+	DomainTraces DataDomain = "traces"
+	DomainEvents DataDomain = "events"
+	// DomainWorkloads searches Kubernetes entities such as workloads, which
+	// the search API exposes under the "entities" type.
+	DomainWorkloads DataDomain = "entities"
+)
+
+const (
+	defaultKeysLimit  = 1000
+	defaultPageSize   = 100
+	defaultMaxValues  = 10000
+	valuesGrowthRatio = 2
+)
+
+// KeyDescriptor describes a key that can be used in conditions for a data domain.
+type KeyDescriptor struct {
+	Key         string
+	Description string
+	// Types are the data types the key has been observed with, such as
+	// types.ConditionTypeString or types.ConditionTypeInt64.
+	Types []string
+	// Origin is the condition origin used to address the key.
+	Origin string
+	// MetricNames lists the metrics carrying the key, when applicable.
+	MetricNames []string
+}
+
+// Type returns the primary data type of the key, defaulting to string when
+// the backend did not report one.
+func (k *KeyDescriptor) Type() string {
+	if len(k.Types) == 0 {
+		return types.ConditionTypeString
+	}
+	return k.Types[0]
+}
+
+// HasType reports whether the key has been observed with the given data type.
+func (k *KeyDescriptor) HasType(condType string) bool {
+	if len(k.Types) == 0 {
+		return condType == types.ConditionTypeString
+	}
+	for _, t := range k.Types {
+		if t == condType {
+			return true
+		}
+	}
+	return false
+}
+
+// Condition builds a condition on the key using its primary type and origin.
+func (k *KeyDescriptor) Condition(op string, value interface{}) *models.Condition {
+	return &models.Condition{
+		Key:     k.Key,
+		Origin:  k.Origin,
+		Type:    k.Type(),
+		Filters: []*models.Filter{{Op: models.Op(op), Value: value}},
+	}
+}
+
+// SearchDiscovery discovers the keys and values available for filtering logs,
+// traces, events and workloads through the search endpoints. Keys are cached
+// per data domain; values are always fetched. It is safe for concurrent use.
+type SearchDiscovery struct {
+	api       *client.GroundcoverAPI
+	timeRange timerange.Range
+	window    time.Duration
+	sources   []*models.Condition
+	keysLimit uint32
+	pageSize  uint32
+	maxValues uint32
+
+	mu   sync.Mutex
+	keys map[DataDomain][]*KeyDescriptor
+}
+
+// SearchOption configures a SearchDiscovery.
+type SearchOption func(*SearchDiscovery)
+
+// WithSearchTimeRange sets a fixed time range for values queries. By default
+// each query covers the hour preceding it.
+func WithSearchTimeRange(r timerange.Range) SearchOption {
+	return func(d *SearchDiscovery) {
+		d.timeRange = r
+	}
+}
+
+// WithSearchWindow sets the length of the rolling window used for values
+// queries when no fixed time range is set.
+func WithSearchWindow(window time.Duration) SearchOption {
+	return func(d *SearchDiscovery) {
+		d.window = window
+	}
+}
+
+// WithSearchSources restricts discovery to the given source conditions.
+func WithSearchSources(sources []*models.Condition) SearchOption {
+	return func(d *SearchDiscovery) {
+		d.sources = sources
+	}
+}
+
+// WithKeysLimit sets the maximum number of keys requested per data domain.
+func WithKeysLimit(limit uint32) SearchOption {
+	return func(d *SearchDiscovery) {
+		d.keysLimit = limit
+	}
+}
+
+// WithPageSize sets the number of values requested by the first page of
+// ValuesPages.
+func WithPageSize(size uint32) SearchOption {
+	return func(d *SearchDiscovery) {
+		d.pageSize = size
+	}
+}
+
+// WithMaxValues caps the number of values ValuesPages fetches for a key.
+func WithMaxValues(max uint32) SearchOption {
+	return func(d *SearchDiscovery) {
+		d.maxValues = max
+	}
+}
+
+// NewSearchDiscovery creates a SearchDiscovery backed by the given client.
+func NewSearchDiscovery(api *client.GroundcoverAPI, opts ...SearchOption) *SearchDiscovery {
+	d := &SearchDiscovery{
+		api:       api,
+		window:    defaultDiscoveryWindow,
+		keysLimit: defaultKeysLimit,
+		pageSize:  defaultPageSize,
+		maxValues: defaultMaxValues,
+		keys:      map[DataDomain][]*KeyDescriptor{},
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	if d.pageSize == 0 {
+		d.pageSize = defaultPageSize
+	}
+	if d.maxValues < d.pageSize {
+		d.maxValues = d.pageSize
+	}
+	return d
+}
+
+func (d *SearchDiscovery) currentRange() timerange.Range {
+	if !d.timeRange.IsZero() {
+		return d.timeRange
+	}
+	return timerange.Last(d.window)
+}
+
+// Invalidate drops the cached keys so that the next query refetches them.
+func (d *SearchDiscovery) Invalidate() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.keys = map[DataDomain][]*KeyDescriptor{}
+}
+
+// LogsKeys returns the keys available for filtering logs.
+func (d *SearchDiscovery) LogsKeys(ctx context.Context) ([]*KeyDescriptor, error) {
+	return d.Keys(ctx, DomainLogs)
+}
+
+// TracesKeys returns the keys available for filtering traces.
+func (d *SearchDiscovery) TracesKeys(ctx context.Context) ([]*KeyDescriptor, error) {
+	return d.Keys(ctx, DomainTraces)
+}
+
+// EventsKeys returns the keys available for filtering events.
+func (d *SearchDiscovery) EventsKeys(ctx context.Context) ([]*KeyDescriptor, error) {
+	return d.Keys(ctx, DomainEvents)
+}
+
+// WorkloadsKeys returns the keys available for filtering workloads.
+func (d *SearchDiscovery) WorkloadsKeys(ctx context.Context) ([]*KeyDescriptor, error) {
+	return d.Keys(ctx, DomainWorkloads)
+}
+
+// Keys returns the keys available for filtering the given data domain, sorted
+// by key.
+func (d *SearchDiscovery) Keys(ctx context.Context, domain DataDomain) ([]*KeyDescriptor, error) {
+	d.mu.Lock()
+	if keys, ok := d.keys[domain]; ok {
+		d.mu.Unlock()
+		return keys, nil
+	}
+	d.mu.Unlock()
+
+	params := search.NewGetKeysParamsWithContext(ctx).WithBody(&models.KeysRequest{
+		Type:    models.StringOrStringSlice{string(domain)},
+		Limit:   swag.Uint32(d.keysLimit),
+		Sources: d.sources,
+	})
+	resp, err := d.api.Search.GetKeys(params, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s keys: %w", domain, err)
+	}
+
+	var keys []*KeyDescriptor
+	if resp.Payload != nil {
+		for _, item := range resp.Payload.Keys {
+			if item == nil || item.Key == "" {
+				continue
+			}
+			keys = append(keys, &KeyDescriptor{
+				Key:         item.Key,
+				Description: item.Description,
+				Types:       item.Types,
+				Origin:      types.ConditionOriginRoot,
+				MetricNames: item.MetricNames,
+			})
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Key < keys[j].Key })
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.keys[domain] = keys
+	return keys, nil
+}
+
+// Key returns the descriptor of a single key, or nil if it is unknown.
+func (d *SearchDiscovery) Key(ctx context.Context, domain DataDomain, key string) (*KeyDescriptor, error) {
+	keys, err := d.Keys(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	i := sort.Search(len(keys), func(i int) bool { return keys[i].Key >= key })
+	if i < len(keys) && keys[i].Key == key {
+		return keys[i], nil
+	}
+	return nil, nil
+}
+
+// ValuesFor returns the distinct values of key in the given data domain,
+// optionally narrowed by filterGroup, up to the configured maximum.
+func (d *SearchDiscovery) ValuesFor(ctx context.Context, domain DataDomain, key string, filterGroup *models.Group) ([]string, error) {
+	var values []string
+	err := d.ValuesPages(ctx, domain, key, filterGroup, func(page []string) error {
+		values = append(values, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// ValuesPages pages through the distinct values of key in the given data
+// domain, calling fn with each page of new values. The values endpoint has no
+// offset, so each page requests a larger limit than the previous one and only
+// the values not seen before are passed to fn. Paging stops when the backend
+// reports that the limit was not reached, the configured maximum is reached,
+// or fn returns an error.
+func (d *SearchDiscovery) ValuesPages(ctx context.Context, domain DataDomain, key string, filterGroup *models.Group, fn func(page []string) error) error {
+	start, end := d.currentRange().DateTimes()
+	seen := map[string]struct{}{}
+
+	for limit := d.pageSize; ; {
+		params := search.NewGetValuesParamsWithContext(ctx).WithBody(&models.ValuesRequest{
+			Type:        swag.String(string(domain)),
+			Key:         swag.String(key),
+			Limit:       swag.Uint32(limit),
+			Start:       start,
+			End:         end,
+			Sources:     d.sources,
+			FilterGroup: filterGroup,
+		})
+		resp, err := d.api.Search.GetValues(params, nil)
+		if err != nil {
+			return fmt.Errorf("failed to get %s values of %s: %w", domain, key, err)
+		}
+		if resp.Payload == nil {
+			return nil
+		}
+
+		var page []string
+		for _, result := range resp.Payload.Results {
+			if result == nil {
+				continue
+			}
+			if _, ok := seen[result.Value]; ok {
+				continue
+			}
+			seen[result.Value] = struct{}{}
+			page = append(page, result.Value)
+		}
+		if len(page) > 0 {
+			if err := fn(page); err != nil {
+				return err
+			}
+		}
+
+		if !resp.Payload.IsLimitReached || len(page) == 0 || limit >= d.maxValues {
+			return nil
+		}
+		limit *= valuesGrowthRatio
+		if limit > d.maxValues {
+			limit = d.maxValues
+		}
+	}
+}
+
+// Discover returns the key/value pairs the discovery endpoint suggests for the
+// given data domain, grouped by key.
+func (d *SearchDiscovery) Discover(ctx context.Context, domain DataDomain, filterGroup *models.Group) (map[string][]string, error) {
+	params := search.NewGetDiscoveryParamsWithContext(ctx).WithBody(&models.DiscoveryRequest{
+		Type:        swag.String(string(domain)),
+		Limit:       swag.Uint32(d.keysLimit),
+		Sources:     d.sources,
+		FilterGroup: filterGroup,
+	})
+	resp, err := d.api.Search.GetDiscovery(params, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s discovery: %w", domain, err)
+	}
+
+	discovered := map[string][]string{}
+	if resp.Payload != nil {
+		for _, result := range resp.Payload.Results {
+			if result != nil {
+				discovered[result.Key] = append(discovered[result.Key], result.Value)
+			}
+		}
+	}
+	return discovered, nil
+}
+
+// ValidateConditions checks that every condition references a key known to the
+// data domain and, when the condition sets a type, that the key has been
+// observed with that type. All problems are returned joined in a single error.
+func (d *SearchDiscovery) ValidateConditions(ctx context.Context, domain DataDomain, conditions []*models.Condition) error {
+	return d.validateConditions(ctx, domain, "conditions", conditions)
+}
+
+// ValidateGroup applies ValidateConditions to a filter group and all of its
+// nested groups.
+func (d *SearchDiscovery) ValidateGroup(ctx context.Context, domain DataDomain, group *models.Group) error {
+	return d.validateGroup(ctx, domain, "filterGroup", group)
+}
+
+func (d *SearchDiscovery) validateGroup(ctx context.Context, domain DataDomain, path string, group *models.Group) error {
+	if group == nil {
+		return nil
+	}
+	errs := []error{d.validateConditions(ctx, domain, path+".conditions", group.Conditions)}
+	for i, nested := range group.Groups {
+		errs = append(errs, d.validateGroup(ctx, domain, fmt.Sprintf("%s.groups[%d]", path, i), nested))
+	}
+	return errors.Join(errs...)
+}
+
+func (d *SearchDiscovery) validateConditions(ctx context.Context, domain DataDomain, path string, conditions []*models.Condition) error {
+	var errs []error
+	for i, cond := range conditions {
+		if cond == nil {
+			continue
+		}
+		desc, err := d.Key(ctx, domain, cond.Key)
+		if err != nil {
+			return err
+		}
+		if desc == nil {
+			errs = append(errs, fmt.Errorf("%s[%d]: unknown %s key %q", path, i, domain, cond.Key))
+			continue
+		}
+		if cond.Type != "" && !desc.HasType(cond.Type) {
+			errs = append(errs, fmt.Errorf("%s[%d]: key %q has type %s, not %s", path, i, cond.Key, desc.Type(), cond.Type))
+		}
+	}
+	return errors.Join(errs...)
+}
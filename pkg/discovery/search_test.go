@@ -0,0 +1,147 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/groundcover-com/groundcover-sdk-go/internal/testutil"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSearchBackend struct {
+	t           *testing.T
+	keyRequests []*models.KeysRequest
+	valueLimits []uint32
+	values      []string
+}
+
+func (f *fakeSearchBackend) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/api/search/keys":
+		var req models.KeysRequest
+		require.NoError(f.t, json.NewDecoder(r.Body).Decode(&req))
+		f.keyRequests = append(f.keyRequests, &req)
+		writeJSON(f.t, w, &models.KeysResponse{Keys: []*models.KeyItem{
+			{Key: "workload", Types: []string{types.ConditionTypeString}},
+			{Key: "duration", Types: []string{types.ConditionTypeFloat64}},
+			{Key: "level"},
+		}})
+	case "/api/search/values":
+		var req models.ValuesRequest
+		require.NoError(f.t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(f.t, "level", *req.Key)
+		f.valueLimits = append(f.valueLimits, *req.Limit)
+		resp := &models.ValuesResponse{}
+		for i := 0; i < len(f.values) && i < int(*req.Limit); i++ {
+			resp.Results = append(resp.Results, &models.ValuesResult{Value: f.values[i]})
+		}
+		resp.IsLimitReached = len(f.values) > int(*req.Limit)
+		writeJSON(f.t, w, resp)
+	case "/api/search/discovery":
+		writeJSON(f.t, w, &models.DiscoveryResponse{Results: []*models.DiscoveryResult{
+			{Key: "namespace", Value: "default"},
+			{Key: "namespace", Value: "prod"},
+			{Key: "workload", Value: "api"},
+		}})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestSearchDiscoveryKeys(t *testing.T) {
+	backend := &fakeSearchBackend{t: t}
+	d := NewSearchDiscovery(testutil.NewAPI(t, backend))
+	ctx := context.Background()
+
+	keys, err := d.LogsKeys(ctx)
+	require.NoError(t, err)
+	require.Len(t, keys, 3)
+	require.Equal(t, "duration", keys[0].Key)
+	require.Equal(t, types.ConditionTypeFloat64, keys[0].Type())
+	require.Equal(t, types.ConditionTypeString, keys[1].Type())
+	require.Equal(t, types.ConditionOriginRoot, keys[1].Origin)
+
+	_, err = d.LogsKeys(ctx)
+	require.NoError(t, err)
+	_, err = d.WorkloadsKeys(ctx)
+	require.NoError(t, err)
+	require.Len(t, backend.keyRequests, 2)
+	require.Equal(t, models.StringOrStringSlice{"logs"}, backend.keyRequests[0].Type)
+	require.Equal(t, models.StringOrStringSlice{"entities"}, backend.keyRequests[1].Type)
+
+	cond := keys[0].Condition(types.OperatorEqual, "1.5")
+	require.Equal(t, "duration", cond.Key)
+	require.Equal(t, types.ConditionTypeFloat64, cond.Type)
+	require.Equal(t, types.ConditionOriginRoot, cond.Origin)
+}
+
+func TestSearchDiscoveryValuesForPages(t *testing.T) {
+	backend := &fakeSearchBackend{t: t}
+	for i := 0; i < 7; i++ {
+		backend.values = append(backend.values, fmt.Sprintf("v%d", i))
+	}
+	d := NewSearchDiscovery(testutil.NewAPI(t, backend), WithPageSize(2), WithMaxValues(100))
+
+	var pages [][]string
+	err := d.ValuesPages(context.Background(), DomainLogs, "level", nil, func(page []string) error {
+		pages = append(pages, page)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"v0", "v1"}, {"v2", "v3"}, {"v4", "v5", "v6"}}, pages)
+	require.Equal(t, []uint32{2, 4, 8}, backend.valueLimits)
+
+	backend.valueLimits = nil
+	values, err := d.ValuesFor(context.Background(), DomainLogs, "level", &models.Group{})
+	require.NoError(t, err)
+	require.Len(t, values, 7)
+}
+
+func TestSearchDiscoveryValuesForRespectsMaximum(t *testing.T) {
+	backend := &fakeSearchBackend{t: t}
+	for i := 0; i < 20; i++ {
+		backend.values = append(backend.values, fmt.Sprintf("v%d", i))
+	}
+	d := NewSearchDiscovery(testutil.NewAPI(t, backend), WithPageSize(4), WithMaxValues(6))
+
+	values, err := d.ValuesFor(context.Background(), DomainLogs, "level", nil)
+	require.NoError(t, err)
+	require.Len(t, values, 6)
+	require.Equal(t, []uint32{4, 6}, backend.valueLimits)
+}
+
+func TestSearchDiscoveryDiscover(t *testing.T) {
+	d := NewSearchDiscovery(testutil.NewAPI(t, &fakeSearchBackend{t: t}))
+
+	discovered, err := d.Discover(context.Background(), DomainTraces, nil)
+	require.NoError(t, err)
+	require.Equal(t, map[string][]string{
+		"namespace": {"default", "prod"},
+		"workload":  {"api"},
+	}, discovered)
+}
+
+func TestSearchDiscoveryValidateGroup(t *testing.T) {
+	d := NewSearchDiscovery(testutil.NewAPI(t, &fakeSearchBackend{t: t}))
+	ctx := context.Background()
+
+	require.NoError(t, d.ValidateConditions(ctx, DomainLogs, []*models.Condition{
+		{Key: "workload", Type: types.ConditionTypeString},
+		{Key: "level"},
+	}))
+
+	err := d.ValidateGroup(ctx, DomainLogs, &models.Group{
+		Conditions: []*models.Condition{{Key: "unknown"}},
+		Groups: []*models.Group{{
+			Conditions: []*models.Condition{{Key: "duration", Type: types.ConditionTypeString}},
+		}},
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `filterGroup.conditions[0]: unknown logs key "unknown"`)
+	require.Contains(t, err.Error(), `filterGroup.groups[0].conditions[0]: key "duration" has type float64, not string`)
+}
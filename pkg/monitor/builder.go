@@ -0,0 +1,598 @@
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+)
+
+// Comparison is the condition a threshold checks: an operator and the values
+// it compares the reduced input against.
+type Comparison struct {
+	Operator string
+	Values   []float64
+	resolve  *Comparison
+}
+
+// GreaterThan fires when the input is greater than v.
+func GreaterThan(v float64) Comparison {
+	return Comparison{Operator: OpGreaterThan, Values: []float64{v}}
+}
+
+// GreaterOrEqual fires when the input is greater than or equal to v.
+func GreaterOrEqual(v float64) Comparison {
+	return Comparison{Operator: OpGreaterOrEqual, Values: []float64{v}}
+}
+
+// LessThan fires when the input is less than v.
+func LessThan(v float64) Comparison {
+	return Comparison{Operator: OpLessThan, Values: []float64{v}}
+}
+
+// LessOrEqual fires when the input is less than or equal to v.
+func LessOrEqual(v float64) Comparison {
+	return Comparison{Operator: OpLessOrEqual, Values: []float64{v}}
+}
+
+// Equal fires when the input equals v.
+func Equal(v float64) Comparison {
+	return Comparison{Operator: OpEqual, Values: []float64{v}}
+}
+
+// NotEqual fires when the input differs from v.
+func NotEqual(v float64) Comparison {
+	return Comparison{Operator: OpNotEqual, Values: []float64{v}}
+}
+
+// WithinRange fires when the input lies strictly between low and high.
+func WithinRange(low, high float64) Comparison {
+	return Comparison{Operator: OpWithinRange, Values: []float64{low, high}}
+}
+
+// OutsideRange fires when the input lies strictly outside low and high.
+func OutsideRange(low, high float64) Comparison {
+	return Comparison{Operator: OpOutsideRange, Values: []float64{low, high}}
+}
+
+// ResolveWhen sets a custom resolve threshold, so that a firing alert only
+// resolves once resolve holds rather than as soon as c stops holding. The
+// resolve operator must be the directional opposite of c's operator, for
+// example GreaterThan(90).ResolveWhen(LessThan(80)).
+func (c Comparison) ResolveWhen(resolve Comparison) Comparison {
+	c.resolve = &resolve
+	return c
+}
+
+// QueryOption customizes a query declared on a Builder.
+type QueryOption func(*models.BaseQuery)
+
+// WithConditions adds conditions to the query.
+func WithConditions(conditions ...*models.Condition) QueryOption {
+	return func(q *models.BaseQuery) {
+		q.Conditions = append(q.Conditions, conditions...)
+	}
+}
+
+// WithFilters sets the GCQL filters of the query.
+func WithFilters(filters string) QueryOption {
+	return func(q *models.BaseQuery) {
+		q.Filters = filters
+	}
+}
+
+// WithRollup sets the rollup function and window applied to the query.
+func WithRollup(function string, window time.Duration) QueryOption {
+	return func(q *models.BaseQuery) {
+		q.Rollup = &models.Rollup{Function: function, Time: models.Duration(window)}
+	}
+}
+
+// WithInstantRollup sets the instant rollup window of a logs or traces query.
+func WithInstantRollup(window string) QueryOption {
+	return func(q *models.BaseQuery) {
+		q.InstantRollup = window
+	}
+}
+
+// WithQueryType sets the query type, QueryTypeInstant or QueryTypeRange.
+func WithQueryType(queryType string) QueryOption {
+	return func(q *models.BaseQuery) {
+		q.QueryType = queryType
+	}
+}
+
+// WithRelativeTimerange sets the window the query is evaluated over, as
+// offsets from the evaluation time (for example -5*time.Minute and 0).
+func WithRelativeTimerange(from, to time.Duration) QueryOption {
+	return func(q *models.BaseQuery) {
+		q.RelativeTimerange = &models.RelativeTimerange{From: strfmt.Duration(from), To: strfmt.Duration(to)}
+	}
+}
+
+// WithEvaluationDelay shifts the evaluated window back by d, for sources that
+// backfill recent data.
+func WithEvaluationDelay(d time.Duration) QueryOption {
+	return func(q *models.BaseQuery) {
+		q.EvaluationDelay = swag.Int64(int64(d / time.Second))
+	}
+}
+
+// Builder builds a models.CreateMonitorRequest. Methods record problems rather
+// than failing immediately; Build reports all of them at once. A Builder should
+// not be reused after Build.
+type Builder struct {
+	req  *models.CreateMonitorRequest
+	errs []error
+}
+
+// New starts a monitor definition with the given title.
+func New(title string) *Builder {
+	return &Builder{
+		req: &models.CreateMonitorRequest{
+			Title:   swag.String(title),
+			Routing: []string{},
+			Model:   &models.Model{},
+		},
+	}
+}
+
+func (b *Builder) addError(format string, args ...interface{}) {
+	b.errs = append(b.errs, fmt.Errorf(format, args...))
+}
+
+// Query adds a fully specified query to the model.
+func (b *Builder) Query(q *models.BaseQuery, opts ...QueryOption) *Builder {
+	if q == nil {
+		b.addError("query must not be nil")
+		return b
+	}
+	for _, opt := range opts {
+		opt(q)
+	}
+	b.req.Model.Queries = append(b.req.Model.Queries, q)
+	return b
+}
+
+// PromQL adds an instant PromQL query named name.
+func (b *Builder) PromQL(name, expr string, opts ...QueryOption) *Builder {
+	if expr == "" {
+		b.addError("query %q: PromQL expression is required", name)
+	}
+	return b.Query(&models.BaseQuery{
+		Name:           name,
+		DataType:       DataTypeMetrics,
+		DatasourceType: DatasourcePrometheus,
+		QueryType:      QueryTypeInstant,
+		Expression:     expr,
+	}, opts...)
+}
+
+// Logs adds a logs query running the given SQL pipeline.
+func (b *Builder) Logs(name string, pipeline *models.SQLPipeline, opts ...QueryOption) *Builder {
+	return b.sqlQuery(name, DataTypeLogs, pipeline, opts)
+}
+
+// Traces adds a traces query running the given SQL pipeline.
+func (b *Builder) Traces(name string, pipeline *models.SQLPipeline, opts ...QueryOption) *Builder {
+	return b.sqlQuery(name, DataTypeTraces, pipeline, opts)
+}
+
+// Events adds an events query running the given SQL pipeline.
+func (b *Builder) Events(name string, pipeline *models.SQLPipeline, opts ...QueryOption) *Builder {
+	return b.sqlQuery(name, DataTypeEvents, pipeline, opts)
+}
+
+func (b *Builder) sqlQuery(name, dataType string, pipeline *models.SQLPipeline, opts []QueryOption) *Builder {
+	if pipeline == nil {
+		b.addError("query %q: %s SQL pipeline is required", name, dataType)
+	}
+	return b.Query(&models.BaseQuery{
+		Name:        name,
+		DataType:    dataType,
+		QueryType:   QueryTypeInstant,
+		SQLPipeline: pipeline,
+	}, opts...)
+}
+
+// Reduce adds a reducer of the given type that reduces input, a query or an
+// earlier reducer, into a single value named name.
+func (b *Builder) Reduce(name, reducerType, input string) *Builder {
+	b.req.Model.Reducers = append(b.req.Model.Reducers, &models.ReducerModel{
+		Name:      swag.String(name),
+		Type:      swag.String(reducerType),
+		InputName: input,
+	})
+	return b
+}
+
+// Last adds a reducer keeping the last value of input.
+func (b *Builder) Last(name, input string) *Builder {
+	return b.Reduce(name, ReducerLast, input)
+}
+
+// Mean adds a reducer averaging the values of input.
+func (b *Builder) Mean(name, input string) *Builder {
+	return b.Reduce(name, ReducerMean, input)
+}
+
+// Max adds a reducer keeping the maximum value of input.
+func (b *Builder) Max(name, input string) *Builder {
+	return b.Reduce(name, ReducerMax, input)
+}
+
+// Min adds a reducer keeping the minimum value of input.
+func (b *Builder) Min(name, input string) *Builder {
+	return b.Reduce(name, ReducerMin, input)
+}
+
+// Math adds a math reducer evaluating expression over other queries and
+// reducers, which the expression references as $name.
+func (b *Builder) Math(name, expression string) *Builder {
+	b.req.Model.Reducers = append(b.req.Model.Reducers, &models.ReducerModel{
+		Name:       swag.String(name),
+		Type:       swag.String(ReducerMath),
+		Expression: expression,
+	})
+	return b
+}
+
+// Threshold adds a threshold named name that fires when input, a query or a
+// reducer, satisfies the comparison.
+func (b *Builder) Threshold(name, input string, c Comparison) *Builder {
+	t := &models.Threshold{
+		Name:      swag.String(name),
+		InputName: swag.String(input),
+		Operator:  swag.String(c.Operator),
+		Values:    c.Values,
+	}
+	if c.resolve != nil {
+		t.CustomResolveThreshold = &models.CustomResolveThreshold{
+			Operator: swag.String(c.resolve.Operator),
+			Values:   c.resolve.Values,
+		}
+	}
+	b.req.Model.Thresholds = append(b.req.Model.Thresholds, t)
+	return b
+}
+
+// Severity sets the severity of the monitor.
+func (b *Builder) Severity(severity string) *Builder {
+	b.req.Severity = severity
+	return b
+}
+
+// Team sets the team owning the monitor.
+func (b *Builder) Team(team string) *Builder {
+	b.req.Team = team
+	return b
+}
+
+// Category sets the category of the monitor.
+func (b *Builder) Category(category string) *Builder {
+	b.req.Category = category
+	return b
+}
+
+// MeasurementType sets the measurement type, MeasurementState or MeasurementEvent.
+func (b *Builder) MeasurementType(measurementType string) *Builder {
+	b.req.MeasurementType = measurementType
+	return b
+}
+
+// Label sets a label attached to the monitor and its alerts.
+func (b *Builder) Label(key, value string) *Builder {
+	if b.req.Labels == nil {
+		b.req.Labels = map[string]string{}
+	}
+	b.req.Labels[key] = value
+	return b
+}
+
+// Labels sets several labels attached to the monitor and its alerts.
+func (b *Builder) Labels(labels map[string]string) *Builder {
+	for k, v := range labels {
+		b.Label(k, v)
+	}
+	return b
+}
+
+// Annotation sets an annotation attached to the alerts.
+func (b *Builder) Annotation(key, value string) *Builder {
+	if b.req.Annotations == nil {
+		b.req.Annotations = map[string]string{}
+	}
+	b.req.Annotations[key] = value
+	return b
+}
+
+// Routing appends routes the alerts are sent to.
+func (b *Builder) Routing(routes ...string) *Builder {
+	b.req.Routing = append(b.req.Routing, routes...)
+	return b
+}
+
+// EvaluationInterval sets how often the monitor is evaluated and how long a
+// threshold must hold before the alert fires.
+func (b *Builder) EvaluationInterval(interval, pendingFor time.Duration) *Builder {
+	pending := models.Duration(pendingFor)
+	b.req.EvaluationInterval = &models.EvaluationInterval{
+		Interval:   strfmt.Duration(interval),
+		PendingFor: &pending,
+	}
+	return b
+}
+
+// NoDataState sets the state entered when the queries return no data.
+func (b *Builder) NoDataState(state string) *Builder {
+	b.req.NoDataState = state
+	return b
+}
+
+// ExecutionErrorState sets the state entered when the queries fail.
+func (b *Builder) ExecutionErrorState(state string) *Builder {
+	b.req.ExecutionErrorState = state
+	return b
+}
+
+// AutoResolve sets whether alerts resolve automatically.
+func (b *Builder) AutoResolve(autoResolve bool) *Builder {
+	b.req.AutoResolve = autoResolve
+	return b
+}
+
+// Paused sets whether the monitor is created paused.
+func (b *Builder) Paused(paused bool) *Builder {
+	b.req.IsPaused = swag.Bool(paused)
+	return b
+}
+
+// Catalog sets the catalog metadata of the monitor.
+func (b *Builder) Catalog(catalog *models.CatalogModel) *Builder {
+	b.req.Catalog = catalog
+	return b
+}
+
+func (b *Builder) display() *models.DisplayModel {
+	if b.req.Display == nil {
+		b.req.Display = &models.DisplayModel{}
+	}
+	return b.req.Display
+}
+
+// Display sets the header and description templates shown for alerts.
+func (b *Builder) Display(header, description string) *Builder {
+	d := b.display()
+	d.Header = header
+	d.Description = description
+	return b
+}
+
+// TemplateLanguage sets the language of the display templates, TemplateGo or
+// TemplateJinja2.
+func (b *Builder) TemplateLanguage(language string) *Builder {
+	b.display().TemplateLanguage = language
+	return b
+}
+
+// ResourceHeaderLabels sets the labels shown in the resource header of alerts.
+func (b *Builder) ResourceHeaderLabels(labels ...string) *Builder {
+	b.display().ResourceHeaderLabels = labels
+	return b
+}
+
+// ContextHeaderLabels sets the labels shown in the context header of alerts.
+func (b *Builder) ContextHeaderLabels(labels ...string) *Builder {
+	b.display().ContextHeaderLabels = labels
+	return b
+}
+
+// NotificationSettings replaces the notification settings of the monitor.
+func (b *Builder) NotificationSettings(settings *models.NotificationSettings) *Builder {
+	b.req.NotificationSettings = settings
+	return b
+}
+
+func (b *Builder) notificationSettings() *models.NotificationSettings {
+	if b.req.NotificationSettings == nil {
+		b.req.NotificationSettings = &models.NotificationSettings{}
+	}
+	return b.req.NotificationSettings
+}
+
+// NotifyNotificationRoutes sends alerts through the notification routes
+// matching the monitor.
+func (b *Builder) NotifyNotificationRoutes() *Builder {
+	b.notificationSettings().Method = NotifyNotificationRoutes
+	return b
+}
+
+// NotifyConnectedApps sends alerts directly to the given connected apps.
+func (b *Builder) NotifyConnectedApps(appIDs ...string) *Builder {
+	ns := b.notificationSettings()
+	ns.Method = NotifyConnectedApps
+	ns.ConnectedApps = append(ns.ConnectedApps, appIDs...)
+	return b
+}
+
+// NoNotifications disables notifications for the monitor.
+func (b *Builder) NoNotifications() *Builder {
+	b.notificationSettings().Method = NotifyNone
+	return b
+}
+
+// Renotify sets how often a firing alert is re-sent. A zero interval disables
+// renotification.
+func (b *Builder) Renotify(interval time.Duration) *Builder {
+	ns := b.notificationSettings()
+	if interval <= 0 {
+		ns.DisableRenotification = true
+		ns.RenotificationInterval = nil
+		return b
+	}
+	ns.DisableRenotification = false
+	ns.RenotificationInterval = interval.String()
+	return b
+}
+
+// Build checks the definition and returns the monitor request. It reports
+// every problem found, including references between queries, reducers and
+// thresholds that do not resolve.
+func (b *Builder) Build() (*models.CreateMonitorRequest, error) {
+	errs := append([]error(nil), b.errs...)
+	if b.req.Title == nil || *b.req.Title == "" {
+		errs = append(errs, fmt.Errorf("title is required"))
+	}
+	for _, p := range modelProblems(b.req.Model) {
+		errs = append(errs, p)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("invalid monitor definition: %w", err)
+	}
+	return b.req, nil
+}
+
+// BuildUpdate is like Build but returns an update request.
+func (b *Builder) BuildUpdate() (*models.UpdateMonitorRequest, error) {
+	req, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	return ToUpdateRequest(req), nil
+}
+
+// modelProblems checks the structure of a monitor model: names are present and
+// unique, every input reference, including the $name references of math
+// expressions, resolves to a query or an earlier reducer, and
+// thresholds compare against the number of values their operator needs.
+func modelProblems(m *models.Model) []*Problem {
+	var problems []*Problem
+	add := func(path, format string, args ...interface{}) {
+		problems = append(problems, &Problem{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if m == nil {
+		add("model", "model is required")
+		return problems
+	}
+	if len(m.Queries) == 0 {
+		add("model.queries", "at least one query is required")
+	}
+	if len(m.Thresholds) == 0 {
+		add("model.thresholds", "at least one threshold is required")
+	}
+
+	known := map[string]string{}
+	declare := func(path, name, kind string) {
+		if name == "" {
+			add(path+".name", "name is required")
+			return
+		}
+		if prev, ok := known[name]; ok {
+			add(path+".name", "name %q is already used by a %s", name, prev)
+			return
+		}
+		known[name] = kind
+	}
+
+	for i, q := range m.Queries {
+		path := fmt.Sprintf("model.queries[%d]", i)
+		if q == nil {
+			add(path, "query must not be nil")
+			continue
+		}
+		declare(path, q.Name, "query")
+	}
+
+	for i, r := range m.Reducers {
+		path := fmt.Sprintf("model.reducers[%d]", i)
+		if r == nil {
+			add(path, "reducer must not be nil")
+			continue
+		}
+		reducerType := swag.StringValue(r.Type)
+		if reducerType == ReducerMath {
+			if r.Expression == "" {
+				add(path+".expression", "math reducer requires an expression")
+			} else if expr, err := parseMathExpr(r.Expression); err != nil {
+				add(path+".expression", "invalid expression: %v", err)
+			} else {
+				seen := map[string]bool{}
+				for _, name := range expr.vars {
+					if _, ok := known[name]; !ok && !seen[name] {
+						add(path+".expression", "$%s does not reference a query or an earlier reducer", name)
+					}
+					seen[name] = true
+				}
+			}
+		} else if r.InputName == "" {
+			add(path+".inputName", "input name is required")
+		} else if _, ok := known[r.InputName]; !ok {
+			add(path+".inputName", "input %q does not reference a query or an earlier reducer", r.InputName)
+		}
+		declare(path, swag.StringValue(r.Name), "reducer")
+	}
+
+	for i, t := range m.Thresholds {
+		path := fmt.Sprintf("model.thresholds[%d]", i)
+		if t == nil {
+			add(path, "threshold must not be nil")
+			continue
+		}
+		if swag.StringValue(t.Name) == "" {
+			add(path+".name", "name is required")
+		}
+		input := swag.StringValue(t.InputName)
+		if input == "" {
+			add(path+".inputName", "input name is required")
+		} else if _, ok := known[input]; !ok {
+			add(path+".inputName", "input %q does not reference a query or reducer", input)
+		}
+
+		op := swag.StringValue(t.Operator)
+		want := operatorValueCount(op)
+		if want == 0 {
+			add(path+".operator", "unknown operator %q", op)
+		} else if len(t.Values) != want {
+			add(path+".values", "operator %s requires %d value(s), got %d", op, want, len(t.Values))
+		}
+
+		if crt := t.CustomResolveThreshold; crt != nil {
+			resolveOp := swag.StringValue(crt.Operator)
+			if opposite, ok := resolveOpposites[op]; !ok {
+				add(path+".customResolveThreshold", "operator %s does not support a custom resolve threshold", op)
+			} else if resolveOp != opposite {
+				add(path+".customResolveThreshold.operator", "resolve operator must be %s for threshold operator %s, got %q", opposite, op, resolveOp)
+			} else if n := operatorValueCount(resolveOp); len(crt.Values) != n {
+				add(path+".customResolveThreshold.values", "operator %s requires %d value(s), got %d", resolveOp, n, len(crt.Values))
+			} else if !resolveDisjoint(op, t.Values, crt.Values) {
+				add(path+".customResolveThreshold.values", "resolve values %v overlap the firing values %v", crt.Values, t.Values)
+			}
+		}
+	}
+	return problems
+}
+
+// resolveDisjoint reports whether the resolve condition cannot hold while the
+// firing condition holds, which the server requires of custom resolve
+// thresholds.
+func resolveDisjoint(op string, fire, resolve []float64) bool {
+	switch op {
+	case OpGreaterThan, OpGreaterOrEqual:
+		return resolve[0] <= fire[0]
+	case OpLessThan, OpLessOrEqual:
+		return resolve[0] >= fire[0]
+	case OpWithinRange:
+		// Fires inside (lo, hi); resolves outside the resolve range, which must
+		// contain the firing range.
+		return resolve[0] <= fire[0] && resolve[1] >= fire[1]
+	case OpOutsideRange:
+		// Fires outside (lo, hi); resolves inside the resolve range, which must
+		// lie within the firing range.
+		return resolve[0] >= fire[0] && resolve[1] <= fire[1]
+	}
+	return true
+}
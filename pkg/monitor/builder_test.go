@@ -0,0 +1,148 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestBuilderBuildsWiredModel(t *testing.T) {
+	req, err := New("High CPU").
+		PromQL("cpu", `avg by (workload) (rate(container_cpu_usage_seconds_total[5m]))`, WithRollup("avg", 5*time.Minute)).
+		Mean("cpu_mean", "cpu").
+		Threshold("cpu_high", "cpu_mean", GreaterThan(0.9).ResolveWhen(LessThan(0.8))).
+		Severity(SeverityCritical).
+		Team("platform").
+		Label("team", "platform").
+		Annotation("summary", "CPU is high").
+		Routing("platform-oncall").
+		EvaluationInterval(time.Minute, 5*time.Minute).
+		NoDataState(StateOK).
+		ExecutionErrorState(StateError).
+		Display("High CPU on {{ .Labels.workload }}", "").
+		ResourceHeaderLabels("namespace", "workload").
+		NotifyConnectedApps("slack-app").
+		Renotify(time.Hour).
+		Build()
+	require.NoError(t, err)
+
+	require.Equal(t, "High CPU", *req.Title)
+	require.Len(t, req.Model.Queries, 1)
+	q := req.Model.Queries[0]
+	require.Equal(t, DataTypeMetrics, q.DataType)
+	require.Equal(t, DatasourcePrometheus, q.DatasourceType)
+	require.Equal(t, models.Duration(5*time.Minute), q.Rollup.Time)
+
+	require.Equal(t, "cpu", req.Model.Reducers[0].InputName)
+	th := req.Model.Thresholds[0]
+	require.Equal(t, "cpu_mean", *th.InputName)
+	require.Equal(t, OpGreaterThan, *th.Operator)
+	require.Equal(t, OpLessThan, *th.CustomResolveThreshold.Operator)
+	require.Equal(t, []float64{0.8}, th.CustomResolveThreshold.Values)
+
+	require.Equal(t, strfmt.Duration(time.Minute), req.EvaluationInterval.Interval)
+	require.Equal(t, models.Duration(5*time.Minute), *req.EvaluationInterval.PendingFor)
+	require.Equal(t, []string{"platform-oncall"}, req.Routing)
+	require.Equal(t, NotifyConnectedApps, req.NotificationSettings.Method)
+	require.Equal(t, "1h0m0s", req.NotificationSettings.RenotificationInterval)
+
+	require.NoError(t, req.Validate(strfmt.Default))
+
+	out, err := yaml.Marshal(req)
+	require.NoError(t, err)
+	var roundTripped models.CreateMonitorRequest
+	require.NoError(t, yaml.Unmarshal(out, &roundTripped))
+	require.Equal(t, "cpu_mean", *roundTripped.Model.Thresholds[0].InputName)
+}
+
+func TestBuilderLogsQuery(t *testing.T) {
+	pipeline := &models.SQLPipeline{Selectors: []*models.Selector{{Key: "count", Origin: "root"}}}
+	req, err := New("Log errors").
+		Logs("errors", pipeline, WithFilters("level:error"), WithInstantRollup("5 minutes")).
+		Threshold("too_many", "errors", GreaterOrEqual(100)).
+		Build()
+	require.NoError(t, err)
+	require.Equal(t, DataTypeLogs, req.Model.Queries[0].DataType)
+	require.Same(t, pipeline, req.Model.Queries[0].SQLPipeline)
+	require.Equal(t, "level:error", req.Model.Queries[0].Filters)
+	require.Equal(t, "5 minutes", req.Model.Queries[0].InstantRollup)
+}
+
+func TestBuilderReportsBrokenReferences(t *testing.T) {
+	_, err := New("Broken").
+		PromQL("cpu", "up").
+		PromQL("cpu", "up").
+		Last("cpu_last", "missing").
+		Threshold("high", "nowhere", WithinRange(1, 2)).
+		Threshold("resolve", "cpu", GreaterThan(10).ResolveWhen(GreaterThan(5))).
+		Threshold("overlap", "cpu", GreaterThan(10).ResolveWhen(LessThan(20))).
+		Threshold("values", "cpu", Comparison{Operator: OpGreaterThan, Values: []float64{1, 2}}).
+		Build()
+	require.Error(t, err)
+
+	msg := err.Error()
+	require.Contains(t, msg, `model.queries[1].name: name "cpu" is already used by a query`)
+	require.Contains(t, msg, `model.reducers[0].inputName: input "missing" does not reference a query or an earlier reducer`)
+	require.Contains(t, msg, `model.thresholds[0].inputName: input "nowhere" does not reference a query or reducer`)
+	require.Contains(t, msg, `model.thresholds[1].customResolveThreshold.operator: resolve operator must be lt`)
+	require.Contains(t, msg, `model.thresholds[2].customResolveThreshold.values: resolve values [20] overlap the firing values [10]`)
+	require.Contains(t, msg, `model.thresholds[3].values: operator gt requires 1 value(s), got 2`)
+}
+
+func TestBuilderRequiresQueriesAndThresholds(t *testing.T) {
+	_, err := New("").Build()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "title is required")
+	require.Contains(t, err.Error(), "model.queries: at least one query is required")
+	require.Contains(t, err.Error(), "model.thresholds: at least one threshold is required")
+}
+
+func TestBuilderReducersMayChain(t *testing.T) {
+	_, err := New("Chained").
+		PromQL("a", "up").
+		Max("a_max", "a").
+		Last("a_last", "a_max").
+		Threshold("t", "a_last", OutsideRange(0, 1).ResolveWhen(WithinRange(0.2, 0.8))).
+		Build()
+	require.NoError(t, err)
+}
+
+func TestBuilderChecksMathReferences(t *testing.T) {
+	_, err := New("Math").
+		PromQL("a", "up").
+		Math("ratio", "$a / ${later} + $missing").
+		Last("later", "a").
+		Math("broken", "$a +").
+		Threshold("t", "ratio", GreaterThan(1)).
+		Build()
+	require.Error(t, err)
+	msg := err.Error()
+	require.Contains(t, msg, `model.reducers[0].expression: $later does not reference a query or an earlier reducer`)
+	require.Contains(t, msg, `model.reducers[0].expression: $missing does not reference a query or an earlier reducer`)
+	require.Contains(t, msg, `model.reducers[2].expression: invalid expression`)
+	require.NotContains(t, msg, "$a does not")
+
+	_, err = New("Math").
+		PromQL("a", "up").
+		Last("a_last", "a").
+		Math("ratio", "$a_last / ${a}").
+		Threshold("t", "ratio", GreaterThan(1)).
+		Build()
+	require.NoError(t, err)
+}
+
+func TestBuildUpdateSharesDefinition(t *testing.T) {
+	b := New("Update").PromQL("a", "up").Threshold("t", "a", LessThan(1)).Paused(true)
+	req, err := b.BuildUpdate()
+	require.NoError(t, err)
+	require.Equal(t, "Update", *req.Title)
+	require.True(t, *req.IsPaused)
+	require.Len(t, req.Model.Queries, 1)
+
+	create := ToCreateRequest(req)
+	require.Equal(t, req.Model, create.Model)
+}
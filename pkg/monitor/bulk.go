@@ -0,0 +1,409 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-openapi/swag"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/client"
+)
+
+// BulkEdit is a change applied to every monitor selected by BulkUpdate. Zero
+// fields leave the monitor alone.
+type BulkEdit struct {
+	// Pause pauses or resumes the monitors when set.
+	Pause *bool
+
+	SetLabels         map[string]string
+	RemoveLabels      []string
+	SetAnnotations    map[string]string
+	RemoveAnnotations []string
+	AddRouting        []string
+	RemoveRouting     []string
+}
+
+// apply edits m and reports whether anything changed.
+func (e BulkEdit) apply(m *Monitor) bool {
+	before := captureState(m)
+	if e.Pause != nil {
+		m.IsPaused = swag.Bool(*e.Pause)
+	}
+	m.Labels = editMap(m.Labels, e.SetLabels, e.RemoveLabels)
+	m.Annotations = editMap(m.Annotations, e.SetAnnotations, e.RemoveAnnotations)
+	for _, route := range e.AddRouting {
+		if !contains(m.Routing, route) {
+			m.Routing = append(m.Routing, route)
+		}
+	}
+	if len(e.RemoveRouting) > 0 {
+		kept := []string{}
+		for _, route := range m.Routing {
+			if !contains(e.RemoveRouting, route) {
+				kept = append(kept, route)
+			}
+		}
+		m.Routing = kept
+	}
+	return !before.matches(m)
+}
+
+func editMap(m, set map[string]string, remove []string) map[string]string {
+	if len(set) == 0 && len(remove) == 0 {
+		return m
+	}
+	if m == nil {
+		m = map[string]string{}
+	}
+	for _, k := range remove {
+		delete(m, k)
+	}
+	for k, v := range set {
+		m[k] = v
+	}
+	return m
+}
+
+// MonitorState is the part of a monitor a bulk edit can change, as it was
+// before the edit.
+type MonitorState struct {
+	ID          string            `json:"id"`
+	Title       string            `json:"title"`
+	IsPaused    bool              `json:"isPaused"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Routing     []string          `json:"routing,omitempty"`
+}
+
+func captureState(m *Monitor) *MonitorState {
+	s := &MonitorState{
+		ID:       m.ID,
+		Title:    swag.StringValue(m.Title),
+		IsPaused: swag.BoolValue(m.IsPaused),
+		Routing:  append([]string(nil), m.Routing...),
+	}
+	if len(m.Labels) > 0 {
+		s.Labels = make(map[string]string, len(m.Labels))
+		for k, v := range m.Labels {
+			s.Labels[k] = v
+		}
+	}
+	if len(m.Annotations) > 0 {
+		s.Annotations = make(map[string]string, len(m.Annotations))
+		for k, v := range m.Annotations {
+			s.Annotations[k] = v
+		}
+	}
+	return s
+}
+
+// matches reports whether m is still in state s.
+func (s *MonitorState) matches(m *Monitor) bool {
+	other := captureState(m)
+	if s.IsPaused != other.IsPaused || len(s.Labels) != len(other.Labels) ||
+		len(s.Annotations) != len(other.Annotations) || len(s.Routing) != len(other.Routing) {
+		return false
+	}
+	for k, v := range s.Labels {
+		if w, ok := other.Labels[k]; !ok || w != v {
+			return false
+		}
+	}
+	for k, v := range s.Annotations {
+		if w, ok := other.Annotations[k]; !ok || w != v {
+			return false
+		}
+	}
+	for i := range s.Routing {
+		if s.Routing[i] != other.Routing[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// restore puts m back into state s.
+func (s *MonitorState) restore(m *Monitor) {
+	m.IsPaused = swag.Bool(s.IsPaused)
+	m.Labels = editMap(nil, s.Labels, nil)
+	m.Annotations = editMap(nil, s.Annotations, nil)
+	m.Routing = append([]string{}, s.Routing...)
+}
+
+// BulkState records the monitors a bulk edit changed, so that the edit can be
+// undone with RestoreMonitors.
+type BulkState struct {
+	CreatedAt time.Time       `json:"createdAt"`
+	Monitors  []*MonitorState `json:"monitors"`
+}
+
+// Save writes the state to a JSON file.
+func (s *BulkState) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode bulk state: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write bulk state: %w", err)
+	}
+	return nil
+}
+
+// LoadBulkState reads a state file written by BulkUpdate.
+func LoadBulkState(path string) (*BulkState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bulk state: %w", err)
+	}
+	var s BulkState
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to decode bulk state %s: %w", path, err)
+	}
+	return &s, nil
+}
+
+// BulkFailure is a monitor a bulk operation could not update.
+type BulkFailure struct {
+	ID    string
+	Title string
+	Err   error
+}
+
+// Error implements the error interface.
+func (f *BulkFailure) Error() string {
+	return fmt.Sprintf("monitor %s (%s): %v", f.ID, f.Title, f.Err)
+}
+
+// Unwrap returns the underlying error.
+func (f *BulkFailure) Unwrap() error {
+	return f.Err
+}
+
+// BulkResult reports the outcome of a bulk operation.
+type BulkResult struct {
+	// State holds the prior state of the monitors the operation changed, or
+	// would change in a dry run.
+	State *BulkState
+	// Updated and Unchanged hold monitor IDs. In a dry run, Updated lists
+	// the monitors that would be updated.
+	Updated   []string
+	Unchanged []string
+	Failed    []*BulkFailure
+	// RolledBack holds the IDs of updated monitors that were restored after
+	// a failure.
+	RolledBack []string
+	DryRun     bool
+}
+
+// Err returns the failures as one error, or nil if there are none.
+func (r *BulkResult) Err() error {
+	errs := make([]error, len(r.Failed))
+	for i, f := range r.Failed {
+		errs[i] = f
+	}
+	return errors.Join(errs...)
+}
+
+// BulkOption configures a bulk operation.
+type BulkOption func(*bulkConfig)
+
+type bulkConfig struct {
+	concurrency int
+	stateFile   string
+	dryRun      bool
+	rollback    bool
+}
+
+// WithBulkConcurrency sets how many monitors are updated in parallel.
+func WithBulkConcurrency(n int) BulkOption {
+	return func(c *bulkConfig) {
+		c.concurrency = n
+	}
+}
+
+// WithStateFile saves the prior state of the selected monitors to path before
+// any of them is updated. LoadBulkState and RestoreMonitors undo the edit
+// from it later, even from another process.
+func WithStateFile(path string) BulkOption {
+	return func(c *bulkConfig) {
+		c.stateFile = path
+	}
+}
+
+// WithBulkDryRun selects and edits the monitors without updating them.
+func WithBulkDryRun(dryRun bool) BulkOption {
+	return func(c *bulkConfig) {
+		c.dryRun = dryRun
+	}
+}
+
+// WithRollbackOnFailure restores the monitors that were updated when any
+// update fails, leaving the selection as it was.
+func WithRollbackOnFailure(rollback bool) BulkOption {
+	return func(c *bulkConfig) {
+		c.rollback = rollback
+	}
+}
+
+func newBulkConfig(opts []BulkOption) *bulkConfig {
+	cfg := &bulkConfig{concurrency: defaultConcurrency}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.concurrency <= 0 {
+		cfg.concurrency = defaultConcurrency
+	}
+	return cfg
+}
+
+// PauseMonitors pauses every monitor matching the filter.
+func PauseMonitors(ctx context.Context, api *client.GroundcoverAPI, filter ListFilter, opts ...BulkOption) (*BulkResult, error) {
+	return BulkUpdate(ctx, api, filter, BulkEdit{Pause: swag.Bool(true)}, opts...)
+}
+
+// ResumeMonitors resumes every monitor matching the filter.
+func ResumeMonitors(ctx context.Context, api *client.GroundcoverAPI, filter ListFilter, opts ...BulkOption) (*BulkResult, error) {
+	return BulkUpdate(ctx, api, filter, BulkEdit{Pause: swag.Bool(false)}, opts...)
+}
+
+// BulkUpdate applies an edit to every monitor matching the filter through
+// UpdateMonitor, a bounded number at a time. Monitors the edit does not
+// change are not updated. The prior state of the changed monitors is returned
+// in the result, and saved first when WithStateFile is given.
+//
+// Updates that fail do not stop the others; the returned error joins every
+// failure. With WithRollbackOnFailure, a failure restores the monitors that
+// were updated.
+func BulkUpdate(ctx context.Context, api *client.GroundcoverAPI, filter ListFilter, edit BulkEdit, opts ...BulkOption) (*BulkResult, error) {
+	cfg := newBulkConfig(opts)
+
+	filter.Hydrate = true
+	var changed []*Monitor
+	result := &BulkResult{State: &BulkState{CreatedAt: time.Now().UTC()}, DryRun: cfg.dryRun}
+	for listed, err := range ListAllMonitors(ctx, api, filter) {
+		if err != nil {
+			return nil, err
+		}
+		m := listed.Monitor
+		before := captureState(m)
+		if !edit.apply(m) {
+			result.Unchanged = append(result.Unchanged, m.ID)
+			continue
+		}
+		result.State.Monitors = append(result.State.Monitors, before)
+		changed = append(changed, m)
+	}
+
+	if cfg.stateFile != "" {
+		if err := result.State.Save(cfg.stateFile); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.dryRun {
+		for _, m := range changed {
+			result.Updated = append(result.Updated, m.ID)
+		}
+		return result, nil
+	}
+
+	result.Updated, result.Failed = updateEach(ctx, result.State.Monitors, cfg.concurrency, func(ctx context.Context, i int) error {
+		return UpdateMonitorTyped(ctx, api, changed[i])
+	})
+
+	if len(result.Failed) > 0 && cfg.rollback && len(result.Updated) > 0 {
+		updated := map[string]bool{}
+		for _, id := range result.Updated {
+			updated[id] = true
+		}
+		rollback := &BulkState{CreatedAt: result.State.CreatedAt}
+		for _, s := range result.State.Monitors {
+			if updated[s.ID] {
+				rollback.Monitors = append(rollback.Monitors, s)
+			}
+		}
+		restored, err := RestoreMonitors(ctx, api, rollback, WithBulkConcurrency(cfg.concurrency))
+		result.RolledBack = restored.Updated
+		if err != nil {
+			return result, fmt.Errorf("failed to roll back after %w: %w", result.Err(), err)
+		}
+	}
+	return result, result.Err()
+}
+
+// RestoreMonitors puts the monitors of a saved state back into it. Each
+// monitor is fetched first, so that changes to fields a bulk edit does not
+// touch are kept.
+func RestoreMonitors(ctx context.Context, api *client.GroundcoverAPI, state *BulkState, opts ...BulkOption) (*BulkResult, error) {
+	cfg := newBulkConfig(opts)
+	result := &BulkResult{State: state, DryRun: cfg.dryRun}
+	var unchanged sync.Map
+
+	result.Updated, result.Failed = updateEach(ctx, state.Monitors, cfg.concurrency, func(ctx context.Context, i int) error {
+		s := state.Monitors[i]
+		m, err := GetMonitorTyped(ctx, api, s.ID)
+		if err != nil {
+			return err
+		}
+		if s.matches(m) {
+			unchanged.Store(s.ID, true)
+			return nil
+		}
+		s.restore(m)
+		if cfg.dryRun {
+			return nil
+		}
+		return UpdateMonitorTyped(ctx, api, m)
+	})
+
+	updated := result.Updated[:0]
+	for _, id := range result.Updated {
+		if _, ok := unchanged.Load(id); ok {
+			result.Unchanged = append(result.Unchanged, id)
+		} else {
+			updated = append(updated, id)
+		}
+	}
+	result.Updated = updated
+	return result, result.Err()
+}
+
+// updateEach runs fn for every monitor with bounded concurrency, collecting
+// the IDs that succeeded and the failures, each sorted by ID. Unlike forEach,
+// a failure does not cancel the remaining calls; monitors not attempted
+// because ctx ended fail with its error.
+func updateEach(ctx context.Context, monitors []*MonitorState, concurrency int, fn func(ctx context.Context, i int) error) ([]string, []*BulkFailure) {
+	var (
+		mu       sync.Mutex
+		updated  []string
+		failures []*BulkFailure
+	)
+	attempted := make([]bool, len(monitors))
+	fail := func(s *MonitorState, err error) {
+		failures = append(failures, &BulkFailure{ID: s.ID, Title: s.Title, Err: err})
+	}
+	_ = forEach(ctx, len(monitors), concurrency, func(ctx context.Context, i int) error {
+		err := fn(ctx, i)
+		mu.Lock()
+		defer mu.Unlock()
+		attempted[i] = true
+		if err != nil {
+			fail(monitors[i], err)
+		} else {
+			updated = append(updated, monitors[i].ID)
+		}
+		return nil
+	})
+	for i, s := range monitors {
+		if !attempted[i] {
+			fail(s, ctx.Err())
+		}
+	}
+	sort.Strings(updated)
+	sort.Slice(failures, func(i, j int) bool { return failures[i].ID < failures[j].ID })
+	return updated, failures
+}
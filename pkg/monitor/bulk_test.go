@@ -0,0 +1,109 @@
+package monitor
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-openapi/swag"
+	"github.com/groundcover-com/groundcover-sdk-go/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func parseFake(t *testing.T, f *fakeList, i int) *Monitor {
+	t.Helper()
+	m, err := ParseMonitor([]byte(f.monitors[i]))
+	require.NoError(t, err)
+	return m
+}
+
+func TestPauseAndRestoreMonitors(t *testing.T) {
+	f := &fakeList{monitors: []string{
+		"title: a\nlabels:\n  env: prod\nrouting: [oncall]\n",
+		"title: b\nisPaused: true\nlabels:\n  env: prod\n",
+		"title: c\nlabels:\n  env: dev\n",
+		"title: d\nlabels:\n  env: prod\nfutureField: kept\n",
+	}}
+	api := testutil.NewAPI(t, f)
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	filter := ListFilter{Labels: map[string]string{"env": "prod"}}
+
+	dry, err := PauseMonitors(context.Background(), api, filter, WithBulkDryRun(true))
+	require.NoError(t, err)
+	require.Equal(t, []string{"0", "3"}, dry.Updated)
+	require.Zero(t, f.puts)
+
+	result, err := PauseMonitors(context.Background(), api, filter, WithStateFile(stateFile), WithBulkConcurrency(2))
+	require.NoError(t, err)
+	require.Equal(t, []string{"0", "3"}, result.Updated)
+	require.Equal(t, []string{"1"}, result.Unchanged)
+	require.True(t, *parseFake(t, f, 0).IsPaused)
+	require.Equal(t, []string{"oncall"}, parseFake(t, f, 0).Routing)
+	require.Equal(t, []string{"futureField"}, parseFake(t, f, 3).UnknownFields())
+	require.Nil(t, parseFake(t, f, 2).IsPaused)
+
+	state, err := LoadBulkState(stateFile)
+	require.NoError(t, err)
+	require.Len(t, state.Monitors, 2)
+	require.Equal(t, "a", state.Monitors[0].Title)
+	require.False(t, state.Monitors[0].IsPaused)
+
+	// Someone edits a field the bulk edit does not own; restoring keeps it.
+	f.monitors[0] = "title: a renamed\nisPaused: true\nlabels:\n  env: prod\nrouting: [oncall]\n"
+	restored, err := RestoreMonitors(context.Background(), api, state)
+	require.NoError(t, err)
+	require.Equal(t, []string{"0", "3"}, restored.Updated)
+	a := parseFake(t, f, 0)
+	require.False(t, *a.IsPaused)
+	require.Equal(t, "a renamed", *a.Title)
+	require.True(t, *parseFake(t, f, 1).IsPaused)
+
+	again, err := RestoreMonitors(context.Background(), api, state)
+	require.NoError(t, err)
+	require.Empty(t, again.Updated)
+	require.Equal(t, []string{"0", "3"}, again.Unchanged)
+}
+
+func TestBulkUpdateEditsLabelsAndRouting(t *testing.T) {
+	f := &fakeList{monitors: []string{
+		"title: a\nlabels:\n  env: prod\n  owner: old\nannotations:\n  runbook: x\nrouting: [oncall, legacy]\n",
+	}}
+	api := testutil.NewAPI(t, f)
+
+	edit := BulkEdit{
+		SetLabels:         map[string]string{"owner": "platform"},
+		RemoveAnnotations: []string{"runbook"},
+		AddRouting:        []string{"slack", "oncall"},
+		RemoveRouting:     []string{"legacy"},
+	}
+	_, err := BulkUpdate(context.Background(), api, ListFilter{}, edit)
+	require.NoError(t, err)
+
+	m := parseFake(t, f, 0)
+	require.Equal(t, map[string]string{"env": "prod", "owner": "platform"}, m.Labels)
+	require.Empty(t, m.Annotations)
+	require.Equal(t, []string{"oncall", "slack"}, m.Routing)
+
+	result, err := BulkUpdate(context.Background(), api, ListFilter{}, edit)
+	require.NoError(t, err)
+	require.Empty(t, result.Updated)
+	require.Equal(t, []string{"0"}, result.Unchanged)
+}
+
+func TestBulkUpdateRollsBackOnFailure(t *testing.T) {
+	f := &fakeList{
+		monitors: []string{"title: a\n", "title: b\n", "title: c\n"},
+		failPut:  map[int]bool{1: true},
+	}
+	api := testutil.NewAPI(t, f)
+
+	result, err := PauseMonitors(context.Background(), api, ListFilter{}, WithRollbackOnFailure(true))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "monitor 1 (b)")
+	require.Len(t, result.Failed, 1)
+	require.Equal(t, []string{"0", "2"}, result.Updated)
+	require.Equal(t, []string{"0", "2"}, result.RolledBack)
+	for i := range f.monitors {
+		require.False(t, swag.BoolValue(parseFake(t, f, i).IsPaused), i)
+	}
+}
@@ -0,0 +1,60 @@
+package monitor
+
+import "github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+
+// ToUpdateRequest converts a create request into the equivalent update request.
+// The two share every field except the read-only IsProvisioned flag. Nested
+// values are shared, not copied.
+func ToUpdateRequest(req *models.CreateMonitorRequest) *models.UpdateMonitorRequest {
+	if req == nil {
+		return nil
+	}
+	return &models.UpdateMonitorRequest{
+		Annotations:           req.Annotations,
+		AutoResolve:           req.AutoResolve,
+		Category:              req.Category,
+		ExecutionErrorState:   req.ExecutionErrorState,
+		HideSlackPreviewGraph: req.HideSlackPreviewGraph,
+		IsPaused:              req.IsPaused,
+		Labels:                req.Labels,
+		MeasurementType:       req.MeasurementType,
+		NoDataState:           req.NoDataState,
+		Routing:               req.Routing,
+		Severity:              req.Severity,
+		Team:                  req.Team,
+		Title:                 req.Title,
+		Catalog:               req.Catalog,
+		Display:               req.Display,
+		EvaluationInterval:    req.EvaluationInterval,
+		Model:                 req.Model,
+		NotificationSettings:  req.NotificationSettings,
+	}
+}
+
+// ToCreateRequest converts an update request into the equivalent create
+// request. Nested values are shared, not copied.
+func ToCreateRequest(req *models.UpdateMonitorRequest) *models.CreateMonitorRequest {
+	if req == nil {
+		return nil
+	}
+	return &models.CreateMonitorRequest{
+		Annotations:           req.Annotations,
+		AutoResolve:           req.AutoResolve,
+		Category:              req.Category,
+		ExecutionErrorState:   req.ExecutionErrorState,
+		HideSlackPreviewGraph: req.HideSlackPreviewGraph,
+		IsPaused:              req.IsPaused,
+		Labels:                req.Labels,
+		MeasurementType:       req.MeasurementType,
+		NoDataState:           req.NoDataState,
+		Routing:               req.Routing,
+		Severity:              req.Severity,
+		Team:                  req.Team,
+		Title:                 req.Title,
+		Catalog:               req.Catalog,
+		Display:               req.Display,
+		EvaluationInterval:    req.EvaluationInterval,
+		Model:                 req.Model,
+		NotificationSettings:  req.NotificationSettings,
+	}
+}
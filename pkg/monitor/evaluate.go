@@ -0,0 +1,305 @@
+package monitor
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/go-openapi/swag"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+)
+
+// frame is the output of a query or reducer at one evaluation, by labelsKey.
+// Queries yield every value in their window; reducers yield one value.
+type frame map[string]*frameSeries
+
+type frameSeries struct {
+	labels map[string]string
+	values []float64
+}
+
+func (s *frameSeries) last() float64 {
+	return s.values[len(s.values)-1]
+}
+
+// evaluateModel replays the model of a monitor over query results at the given
+// evaluation times.
+func evaluateModel(req *models.CreateMonitorRequest, data map[string]seriesSet, times []time.Time, step time.Duration) ([]*SeriesPreview, error) {
+	m := req.Model
+	maths := map[string]*mathExpr{}
+	for _, r := range m.Reducers {
+		if swag.StringValue(r.Type) != ReducerMath {
+			if _, err := reduce(swag.StringValue(r.Type), nil); err != nil {
+				return nil, fmt.Errorf("reducer %s: %w", swag.StringValue(r.Name), err)
+			}
+			continue
+		}
+		expr, err := parseMathExpr(r.Expression)
+		if err != nil {
+			return nil, fmt.Errorf("reducer %s: %w", swag.StringValue(r.Name), err)
+		}
+		maths[swag.StringValue(r.Name)] = expr
+	}
+
+	// inputs[i][threshold] holds the threshold input at times[i].
+	inputs := make([]map[string]frame, len(times))
+	for i, t := range times {
+		frames := map[string]frame{}
+		for _, q := range m.Queries {
+			from, to := queryWindow(q, step)
+			f := frame{}
+			for key, s := range data[q.Name] {
+				if values := s.window(t.Add(-from), t.Add(-to)); len(values) > 0 {
+					f[key] = &frameSeries{labels: s.labels, values: values}
+				}
+			}
+			frames[q.Name] = f
+		}
+		for _, r := range m.Reducers {
+			name := swag.StringValue(r.Name)
+			if expr, ok := maths[name]; ok {
+				frames[name] = evaluateMath(expr, frames)
+				continue
+			}
+			f := frame{}
+			for key, s := range frames[r.InputName] {
+				v, _ := reduce(swag.StringValue(r.Type), s.values)
+				f[key] = &frameSeries{labels: s.labels, values: []float64{v}}
+			}
+			frames[name] = f
+		}
+		inputs[i] = frames
+	}
+
+	var pendingFor time.Duration
+	if req.EvaluationInterval != nil && req.EvaluationInterval.PendingFor != nil {
+		pendingFor = time.Duration(*req.EvaluationInterval.PendingFor)
+	}
+
+	var result []*SeriesPreview
+	for _, th := range m.Thresholds {
+		input := swag.StringValue(th.InputName)
+		labels := map[string]map[string]string{}
+		for _, frames := range inputs {
+			for key, s := range frames[input] {
+				labels[key] = s.labels
+			}
+		}
+		if len(labels) == 0 {
+			// Without any data there is a single, unlabelled series.
+			labels[""] = map[string]string{}
+		}
+		keys := make([]string, 0, len(labels))
+		for key := range labels {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			values := make([]float64, len(times))
+			for i, frames := range inputs {
+				values[i] = math.NaN()
+				if s, ok := frames[input][key]; ok {
+					values[i] = s.last()
+				}
+			}
+			points, err := replayThreshold(th, req.NoDataState, pendingFor, times, values)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, &SeriesPreview{
+				Threshold: swag.StringValue(th.Name),
+				Labels:    labels[key],
+				Points:    points,
+			})
+		}
+	}
+	return result, nil
+}
+
+// queryWindow returns the part of a query's results an evaluation sees, as
+// durations before the evaluation time. Range queries see their relative time
+// range, whose offsets are negative ("-5m" to "-1m"); other queries see the
+// fallback window up to the evaluation time.
+func queryWindow(q *models.BaseQuery, fallback time.Duration) (from, to time.Duration) {
+	if q.QueryType == QueryTypeRange && q.RelativeTimerange != nil && q.RelativeTimerange.From < 0 {
+		return -time.Duration(q.RelativeTimerange.From), -time.Duration(q.RelativeTimerange.To)
+	}
+	return fallback, 0
+}
+
+// reduce applies a non-math reducer to the values of a series.
+func reduce(reducerType string, values []float64) (float64, error) {
+	if reducerType == ReducerCount {
+		return float64(len(values)), nil
+	}
+	var v float64
+	switch reducerType {
+	case ReducerLast:
+		if len(values) > 0 {
+			v = values[len(values)-1]
+		}
+	case ReducerMin:
+		v = math.Inf(1)
+		for _, x := range values {
+			v = math.Min(v, x)
+		}
+	case ReducerMax:
+		v = math.Inf(-1)
+		for _, x := range values {
+			v = math.Max(v, x)
+		}
+	case ReducerSum, ReducerMean:
+		for _, x := range values {
+			v += x
+		}
+		if reducerType == ReducerMean && len(values) > 0 {
+			v /= float64(len(values))
+		}
+	default:
+		return 0, fmt.Errorf("unsupported reducer type %q", reducerType)
+	}
+	return v, nil
+}
+
+// evaluateMath evaluates a math expression once per label set. Inputs with a
+// single unlabelled series act as scalars; other inputs are joined on equal
+// label sets, and label sets missing from an input are dropped.
+func evaluateMath(expr *mathExpr, frames map[string]frame) frame {
+	scalars := map[string]float64{}
+	var joined []string
+	for _, name := range expr.vars {
+		if s, ok := frames[name][""]; ok && len(frames[name]) == 1 {
+			scalars[name] = s.last()
+		} else {
+			joined = append(joined, name)
+		}
+	}
+
+	result := frame{}
+	if len(joined) == 0 {
+		if len(scalars) == len(expr.vars) {
+			result[""] = &frameSeries{labels: map[string]string{}, values: []float64{expr.eval(scalars)}}
+		}
+		return result
+	}
+	for key, s := range frames[joined[0]] {
+		vars := map[string]float64{}
+		for name, v := range scalars {
+			vars[name] = v
+		}
+		complete := true
+		for _, name := range joined {
+			other, ok := frames[name][key]
+			if !ok {
+				complete = false
+				break
+			}
+			vars[name] = other.last()
+		}
+		if complete {
+			result[key] = &frameSeries{labels: s.labels, values: []float64{expr.eval(vars)}}
+		}
+	}
+	return result
+}
+
+// replayThreshold runs the alert state machine of one label set. A condition
+// that holds moves it to pending, and to firing once it has held for
+// pendingFor. A firing series resolves when the condition stops holding or,
+// with a custom resolve threshold, only once the resolve condition holds.
+func replayThreshold(th *models.Threshold, noDataState string, pendingFor time.Duration, times []time.Time, values []float64) ([]PreviewPoint, error) {
+	op := swag.StringValue(th.Operator)
+	if _, err := compareThreshold(op, 0, th.Values); err != nil {
+		return nil, fmt.Errorf("threshold %s: %w", swag.StringValue(th.Name), err)
+	}
+	resolve := th.CustomResolveThreshold
+	if resolve != nil {
+		if _, err := compareThreshold(swag.StringValue(resolve.Operator), 0, resolve.Values); err != nil {
+			return nil, fmt.Errorf("threshold %s: resolve: %w", swag.StringValue(th.Name), err)
+		}
+	}
+
+	points := make([]PreviewPoint, len(times))
+	state := PreviewNormal
+	var pendingSince time.Time
+	for i, t := range times {
+		v := values[i]
+		var holds bool
+		if math.IsNaN(v) {
+			switch noDataState {
+			case StateOK:
+				holds = false
+			case StateAlerting:
+				holds = true
+			default:
+				state = PreviewNoData
+				points[i] = PreviewPoint{Time: t, Value: v, State: state}
+				continue
+			}
+		} else {
+			holds, _ = compareThreshold(op, v, th.Values)
+		}
+
+		switch state {
+		case PreviewFiring:
+			if resolve != nil {
+				if resolved, _ := compareThreshold(swag.StringValue(resolve.Operator), v, resolve.Values); resolved {
+					state = PreviewNormal
+				}
+			} else if !holds {
+				state = PreviewNormal
+			}
+		case PreviewPending:
+			switch {
+			case !holds:
+				state = PreviewNormal
+			case t.Sub(pendingSince) >= pendingFor:
+				state = PreviewFiring
+			}
+		default:
+			switch {
+			case !holds:
+				state = PreviewNormal
+			case pendingFor <= 0:
+				state = PreviewFiring
+			default:
+				state = PreviewPending
+				pendingSince = t
+			}
+		}
+		points[i] = PreviewPoint{Time: t, Value: v, State: state}
+	}
+	return points, nil
+}
+
+// compareThreshold reports whether v satisfies a threshold operator.
+func compareThreshold(op string, v float64, values []float64) (bool, error) {
+	if n := operatorValueCount(op); n == 0 {
+		return false, fmt.Errorf("unsupported operator %q", op)
+	} else if len(values) < n {
+		return false, fmt.Errorf("operator %s needs %d values, got %d", op, n, len(values))
+	}
+	switch op {
+	case OpGreaterThan:
+		return v > values[0], nil
+	case OpLessThan:
+		return v < values[0], nil
+	case OpGreaterOrEqual:
+		return v >= values[0], nil
+	case OpLessOrEqual:
+		return v <= values[0], nil
+	case OpEqual:
+		return v == values[0], nil
+	case OpNotEqual:
+		return v != values[0], nil
+	case OpWithinRange:
+		return v > values[0] && v < values[1], nil
+	case OpOutsideRange:
+		return v < values[0] || v > values[1], nil
+	case OpWithinRangeIncluded:
+		return v >= values[0] && v <= values[1], nil
+	}
+	return v <= values[0] || v >= values[1], nil
+}
@@ -0,0 +1,195 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"sort"
+	"strconv"
+
+	"github.com/go-openapi/swag"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/client"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/client/monitors"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/types"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	defaultListPageSize = 100
+	defaultConcurrency  = 4
+)
+
+// Condition keys of the monitors list endpoint.
+const (
+	ListKeySeverity    = "severity"
+	ListKeyTeam        = "team"
+	ListKeyCategory    = "category"
+	ListKeyIsPaused    = "isPaused"
+	ListKeyLabelPrefix = "labels."
+)
+
+// ListFilter selects monitors for ListAllMonitors.
+type ListFilter struct {
+	// Query is a GCQL filter on the fields the endpoint supports, such as
+	// monitor_name and type.
+	Query string
+	// Labels selects monitors that have every given label value.
+	Labels map[string]string
+	// Severities, Teams and Categories select monitors with any of the given
+	// values.
+	Severities []string
+	Teams      []string
+	Categories []string
+	// Paused selects paused or active monitors when set.
+	Paused *bool
+	// Sort is the field to sort by, "name" (the default) or "type".
+	Sort string
+
+	// PageSize is the number of monitors requested per page.
+	PageSize int64
+	// Hydrate fetches the full definition of every monitor. Hydrated
+	// monitors are also checked against the filter locally.
+	Hydrate bool
+	// Concurrency is the number of definitions fetched in parallel when
+	// hydrating.
+	Concurrency int
+}
+
+// ListedMonitor is a monitor returned by ListAllMonitors.
+type ListedMonitor struct {
+	*models.MonitorListItem
+	// Monitor is the full definition; it is nil unless the filter hydrates.
+	Monitor *Monitor
+}
+
+// Conditions returns the filter as conditions of the monitors list endpoint.
+func (f ListFilter) Conditions() []*models.Condition {
+	conditions := []*models.Condition{}
+	add := func(key, condType string, values ...string) {
+		if len(values) == 0 {
+			return
+		}
+		c := &models.Condition{Key: key, Origin: types.ConditionOriginRoot, Type: condType}
+		for _, v := range values {
+			c.Filters = append(c.Filters, &models.Filter{Op: types.OperatorEqual, Value: v})
+		}
+		conditions = append(conditions, c)
+	}
+
+	labels := make([]string, 0, len(f.Labels))
+	for k := range f.Labels {
+		labels = append(labels, k)
+	}
+	sort.Strings(labels)
+	for _, k := range labels {
+		add(ListKeyLabelPrefix+k, types.ConditionTypeString, f.Labels[k])
+	}
+	add(ListKeySeverity, types.ConditionTypeString, f.Severities...)
+	add(ListKeyTeam, types.ConditionTypeString, f.Teams...)
+	add(ListKeyCategory, types.ConditionTypeString, f.Categories...)
+	if f.Paused != nil {
+		add(ListKeyIsPaused, types.ConditionTypeBool, strconv.FormatBool(*f.Paused))
+	}
+	return conditions
+}
+
+// Matches reports whether a monitor definition satisfies the label,
+// severity, team, category and paused criteria of the filter. The GCQL
+// query is not evaluated.
+func (f ListFilter) Matches(m *models.CreateMonitorRequest) bool {
+	for k, v := range f.Labels {
+		if m.Labels[k] != v {
+			return false
+		}
+	}
+	if !matchesAny(f.Severities, m.Severity) || !matchesAny(f.Teams, m.Team) || !matchesAny(f.Categories, m.Category) {
+		return false
+	}
+	return f.Paused == nil || *f.Paused == swag.BoolValue(m.IsPaused)
+}
+
+func matchesAny(values []string, v string) bool {
+	return len(values) == 0 || contains(values, v)
+}
+
+// ListAllMonitors iterates over every monitor matching the filter, fetching
+// pages as needed. Iteration stops at the first error, which is yielded with
+// a nil monitor.
+func ListAllMonitors(ctx context.Context, api *client.GroundcoverAPI, filter ListFilter) iter.Seq2[*ListedMonitor, error] {
+	pageSize := filter.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultListPageSize
+	}
+	concurrency := filter.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+
+	return func(yield func(*ListedMonitor, error) bool) {
+		for skip := int64(0); ; {
+			params := monitors.NewListMonitorsParams().WithContext(ctx).WithBody(&models.MonitorListRequest{
+				Conditions: filter.Conditions(),
+				Limit:      pageSize,
+				Query:      filter.Query,
+				Skip:       skip,
+				Sort:       filter.Sort,
+			})
+			resp, err := api.Monitors.ListMonitors(params, nil)
+			if err != nil {
+				yield(nil, fmt.Errorf("failed to list monitors: %w", err))
+				return
+			}
+			page := resp.Payload.Monitors
+
+			listed := make([]*ListedMonitor, len(page))
+			for i, item := range page {
+				listed[i] = &ListedMonitor{MonitorListItem: item}
+			}
+			if filter.Hydrate {
+				err := forEach(ctx, len(listed), concurrency, func(ctx context.Context, i int) error {
+					m, err := GetMonitorTyped(ctx, api, string(listed[i].UUID))
+					if err != nil {
+						return err
+					}
+					listed[i].Monitor = m
+					return nil
+				})
+				if err != nil {
+					yield(nil, err)
+					return
+				}
+			}
+
+			for _, l := range listed {
+				if l.Monitor != nil && !filter.Matches(l.Monitor.CreateMonitorRequest) {
+					continue
+				}
+				if !yield(l, nil) {
+					return
+				}
+			}
+
+			skip += int64(len(page))
+			if resp.Payload.Done || int64(len(page)) < pageSize {
+				return
+			}
+		}
+	}
+}
+
+// forEach calls fn for 0..n-1, running up to concurrency calls at a time, and
+// returns the first error, cancelling the remaining calls.
+func forEach(ctx context.Context, n, concurrency int, fn func(ctx context.Context, i int) error) error {
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+	for i := 0; i < n; i++ {
+		g.Go(func() error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			return fn(ctx, i)
+		})
+	}
+	return g.Wait()
+}
@@ -0,0 +1,143 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/groundcover-com/groundcover-sdk-go/internal/testutil"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeList struct {
+	mu       sync.Mutex
+	monitors []string // YAML definitions; the UUID is the index
+	requests []models.MonitorListRequest
+	gets     int
+	puts     int
+	failPut  map[int]bool
+}
+
+func (f *fakeList) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.URL.Path == "/api/monitors/list" {
+		var req models.MonitorListRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		f.requests = append(f.requests, req)
+		resp := models.MonitorListResponse{Monitors: []*models.MonitorListItem{}}
+		for i := req.Skip; i < int64(len(f.monitors)) && i < req.Skip+req.Limit; i++ {
+			resp.Monitors = append(resp.Monitors, &models.MonitorListItem{
+				UUID:  strfmt.UUID(fmt.Sprint(i)),
+				Title: fmt.Sprintf("monitor %d", i),
+			})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+		return
+	}
+	var i int
+	_, _ = fmt.Sscan(strings.TrimPrefix(r.URL.Path, "/api/monitors/"), &i)
+	if r.Method == http.MethodPut {
+		if f.failPut[i] {
+			http.Error(w, `{"message":"invalid"}`, http.StatusBadRequest)
+			return
+		}
+		f.puts++
+		body, _ := io.ReadAll(r.Body)
+		f.monitors[i] = string(body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = io.WriteString(w, `{}`)
+		return
+	}
+	f.gets++
+	_, _ = io.WriteString(w, f.monitors[i])
+}
+
+func TestListAllMonitorsPages(t *testing.T) {
+	f := &fakeList{}
+	for i := 0; i < 5; i++ {
+		f.monitors = append(f.monitors, fmt.Sprintf("title: monitor %d\n", i))
+	}
+	api := testutil.NewAPI(t, f)
+
+	var titles []string
+	for m, err := range ListAllMonitors(context.Background(), api, ListFilter{PageSize: 2, Query: `monitor_name:"monitor*"`}) {
+		require.NoError(t, err)
+		require.Nil(t, m.Monitor)
+		titles = append(titles, m.Title)
+	}
+	require.Equal(t, []string{"monitor 0", "monitor 1", "monitor 2", "monitor 3", "monitor 4"}, titles)
+	require.Len(t, f.requests, 3)
+	require.Equal(t, int64(4), f.requests[2].Skip)
+	require.Equal(t, `monitor_name:"monitor*"`, f.requests[0].Query)
+	require.Zero(t, f.gets)
+
+	// Breaking out of the loop stops paging.
+	f.requests = nil
+	for range ListAllMonitors(context.Background(), api, ListFilter{PageSize: 2}) {
+		break
+	}
+	require.Len(t, f.requests, 1)
+}
+
+func TestListAllMonitorsFiltersAndHydrates(t *testing.T) {
+	f := &fakeList{monitors: []string{
+		"title: a\nseverity: critical\nteam: platform\nlabels:\n  env: prod\n",
+		"title: b\nseverity: info\nteam: platform\nlabels:\n  env: prod\n",
+		"title: c\nseverity: critical\nteam: platform\nisPaused: true\nlabels:\n  env: prod\n",
+		"title: d\nseverity: critical\nteam: platform\nlabels:\n  env: dev\n",
+	}}
+	api := testutil.NewAPI(t, f)
+
+	filter := ListFilter{
+		Labels:      map[string]string{"env": "prod"},
+		Severities:  []string{SeverityCritical, SeverityError},
+		Teams:       []string{"platform"},
+		Paused:      swag.Bool(false),
+		Hydrate:     true,
+		Concurrency: 2,
+	}
+	var titles []string
+	for m, err := range ListAllMonitors(context.Background(), api, filter) {
+		require.NoError(t, err)
+		require.NotNil(t, m.Monitor)
+		require.Equal(t, string(m.UUID), m.Monitor.ID)
+		titles = append(titles, *m.Monitor.Title)
+	}
+	require.Equal(t, []string{"a"}, titles)
+	require.Equal(t, 4, f.gets)
+
+	conditions := f.requests[0].Conditions
+	require.Len(t, conditions, 4)
+	require.Equal(t, "labels.env", conditions[0].Key)
+	require.Equal(t, ListKeySeverity, conditions[1].Key)
+	require.Len(t, conditions[1].Filters, 2)
+	require.Equal(t, "error", conditions[1].Filters[1].Value)
+	require.Equal(t, ListKeyIsPaused, conditions[3].Key)
+	require.Equal(t, "bool", conditions[3].Type)
+	require.Equal(t, "false", conditions[3].Filters[0].Value)
+}
+
+func TestListAllMonitorsYieldsErrors(t *testing.T) {
+	api := testutil.NewAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"bad"}`, http.StatusBadRequest)
+	}))
+
+	n := 0
+	for m, err := range ListAllMonitors(context.Background(), api, ListFilter{}) {
+		n++
+		require.Nil(t, m)
+		require.ErrorContains(t, err, "failed to list monitors")
+	}
+	require.Equal(t, 1, n)
+}
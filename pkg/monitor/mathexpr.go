@@ -0,0 +1,257 @@
+package monitor
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// mathExpr is a parsed math reducer expression.
+type mathExpr struct {
+	eval func(vars map[string]float64) float64
+	// vars are the names the expression references, in order of appearance.
+	vars []string
+}
+
+// mathFuncs are the functions math expressions may call.
+var mathFuncs = map[string]func(float64) float64{
+	"abs":   math.Abs,
+	"ceil":  math.Ceil,
+	"floor": math.Floor,
+	"round": math.Round,
+	"log":   math.Log,
+	"sqrt":  math.Sqrt,
+	"exp":   math.Exp,
+}
+
+// parseMathExpr parses the arithmetic a math reducer evaluates: numbers,
+// $name and ${name} references, + - * / %, comparisons and && || ! yielding
+// 1 or 0, parentheses and a few functions such as abs.
+func parseMathExpr(s string) (*mathExpr, error) {
+	p := &mathParser{src: s}
+	p.next()
+	eval, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok != "" {
+		return nil, fmt.Errorf("unexpected %q in %q", p.tok, s)
+	}
+	return &mathExpr{eval: eval, vars: p.vars}, nil
+}
+
+type mathParser struct {
+	src  string
+	pos  int
+	tok  string
+	vars []string
+	err  error
+}
+
+// next advances to the next token; tok is empty at the end of the input.
+func (p *mathParser) next() {
+	for p.pos < len(p.src) && unicode.IsSpace(rune(p.src[p.pos])) {
+		p.pos++
+	}
+	if p.pos >= len(p.src) {
+		p.tok = ""
+		return
+	}
+	start := p.pos
+	c := p.src[p.pos]
+	switch {
+	case c == '$':
+		p.pos++
+		if p.pos < len(p.src) && p.src[p.pos] == '{' {
+			end := strings.IndexByte(p.src[p.pos:], '}')
+			if end < 0 {
+				p.err = fmt.Errorf("unterminated ${ in %q", p.src)
+				p.pos = len(p.src)
+			} else {
+				p.pos += end + 1
+			}
+		} else {
+			for p.pos < len(p.src) && isIdentByte(p.src[p.pos]) {
+				p.pos++
+			}
+		}
+	case c >= '0' && c <= '9' || c == '.':
+		for p.pos < len(p.src) && (isIdentByte(p.src[p.pos]) || p.src[p.pos] == '.' ||
+			(p.src[p.pos] == '+' || p.src[p.pos] == '-') && (p.src[p.pos-1] == 'e' || p.src[p.pos-1] == 'E')) {
+			p.pos++
+		}
+	case isIdentByte(c):
+		for p.pos < len(p.src) && isIdentByte(p.src[p.pos]) {
+			p.pos++
+		}
+	default:
+		p.pos++
+		if p.pos < len(p.src) {
+			switch two := p.src[start : p.pos+1]; two {
+			case ">=", "<=", "==", "!=", "&&", "||":
+				p.pos++
+			}
+		}
+	}
+	p.tok = p.src[start:p.pos]
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+type mathNode = func(vars map[string]float64) float64
+
+func boolFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (p *mathParser) parseOr() (mathNode, error) {
+	return p.parseBinary(p.parseAnd, "||")
+}
+
+func (p *mathParser) parseAnd() (mathNode, error) {
+	return p.parseBinary(p.parseComparison, "&&")
+}
+
+func (p *mathParser) parseComparison() (mathNode, error) {
+	return p.parseBinary(p.parseSum, ">", "<", ">=", "<=", "==", "!=")
+}
+
+func (p *mathParser) parseSum() (mathNode, error) {
+	return p.parseBinary(p.parseProduct, "+", "-")
+}
+
+func (p *mathParser) parseProduct() (mathNode, error) {
+	return p.parseBinary(p.parseUnary, "*", "/", "%")
+}
+
+// parseBinary parses a left-associative chain of the given operators.
+func (p *mathParser) parseBinary(operand func() (mathNode, error), ops ...string) (mathNode, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for contains(ops, p.tok) {
+		op := p.tok
+		p.next()
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode(op, left, right)
+	}
+	return left, nil
+}
+
+func binaryNode(op string, l, r mathNode) mathNode {
+	return func(vars map[string]float64) float64 {
+		a, b := l(vars), r(vars)
+		switch op {
+		case "+":
+			return a + b
+		case "-":
+			return a - b
+		case "*":
+			return a * b
+		case "/":
+			return a / b
+		case "%":
+			return math.Mod(a, b)
+		case ">":
+			return boolFloat(a > b)
+		case "<":
+			return boolFloat(a < b)
+		case ">=":
+			return boolFloat(a >= b)
+		case "<=":
+			return boolFloat(a <= b)
+		case "==":
+			return boolFloat(a == b)
+		case "!=":
+			return boolFloat(a != b)
+		case "&&":
+			return boolFloat(a != 0 && b != 0)
+		}
+		return boolFloat(a != 0 || b != 0)
+	}
+}
+
+func (p *mathParser) parseUnary() (mathNode, error) {
+	switch p.tok {
+	case "-", "+", "!":
+		op := p.tok
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case "-":
+			return func(vars map[string]float64) float64 { return -operand(vars) }, nil
+		case "!":
+			return func(vars map[string]float64) float64 { return boolFloat(operand(vars) == 0) }, nil
+		}
+		return operand, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *mathParser) parsePrimary() (mathNode, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	tok := p.tok
+	switch {
+	case tok == "":
+		return nil, fmt.Errorf("unexpected end of %q", p.src)
+	case tok == "(":
+		p.next()
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok != ")" {
+			return nil, fmt.Errorf("missing ) in %q", p.src)
+		}
+		p.next()
+		return inner, nil
+	case tok[0] == '$':
+		name := strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(tok, "$"), "{"), "}")
+		if name == "" {
+			return nil, fmt.Errorf("empty reference in %q", p.src)
+		}
+		if !contains(p.vars, name) {
+			p.vars = append(p.vars, name)
+		}
+		p.next()
+		return func(vars map[string]float64) float64 { return vars[name] }, nil
+	case tok[0] >= '0' && tok[0] <= '9' || tok[0] == '.':
+		v, err := strconv.ParseFloat(tok, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q in %q", tok, p.src)
+		}
+		p.next()
+		return func(map[string]float64) float64 { return v }, nil
+	case isIdentByte(tok[0]):
+		fn, ok := mathFuncs[tok]
+		if !ok {
+			return nil, fmt.Errorf("unknown function %q in %q", tok, p.src)
+		}
+		p.next()
+		if p.tok != "(" {
+			return nil, fmt.Errorf("missing ( after %s in %q", tok, p.src)
+		}
+		arg, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		return func(vars map[string]float64) float64 { return fn(arg(vars)) }, nil
+	}
+	return nil, fmt.Errorf("unexpected %q in %q", tok, p.src)
+}
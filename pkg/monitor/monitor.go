@@ -0,0 +1,128 @@
+// Package monitor provides helpers for defining groundcover monitors.
+//
+// The API describes a monitor as a models.CreateMonitorRequest whose Model
+// wires queries, reducers and thresholds together by name. Builder declares
+// those parts fluently and checks the references between them before the
+// request is sent, so that mistakes surface locally instead of as server errors.
+// ValidateMonitor applies the same checks, and more, to definitions that were
+// written by hand or fetched from the API, and PreviewMonitor replays one over
+// historical data to show when it would have fired.
+package monitor
+
+// Data types of monitor queries.
+const (
+	DataTypeMetrics = "metrics"
+	DataTypeLogs    = "logs"
+	DataTypeTraces  = "traces"
+	DataTypeEvents  = "events"
+)
+
+// Query types of monitor queries.
+const (
+	QueryTypeInstant = "instant"
+	QueryTypeRange   = "range"
+)
+
+// DatasourcePrometheus is the datasource type of PromQL queries.
+const DatasourcePrometheus = "prometheus"
+
+// Reducer types.
+const (
+	ReducerLast  = "last"
+	ReducerMin   = "min"
+	ReducerMax   = "max"
+	ReducerMean  = "mean"
+	ReducerSum   = "sum"
+	ReducerCount = "count"
+	ReducerMath  = "math"
+)
+
+// Threshold operators.
+const (
+	OpGreaterThan          = "gt"
+	OpLessThan             = "lt"
+	OpGreaterOrEqual       = "gte"
+	OpLessOrEqual          = "lte"
+	OpEqual                = "eq"
+	OpNotEqual             = "neq"
+	OpWithinRange          = "within_range"
+	OpOutsideRange         = "outside_range"
+	OpWithinRangeIncluded  = "within_range_included"
+	OpOutsideRangeIncluded = "outside_range_included"
+)
+
+// Severities commonly used by groundcover monitors. Severity is free-form on the
+// server; ValidateMonitor accepts only these unless WithSeverities is given.
+const (
+	SeverityCritical = "critical"
+	SeverityError    = "error"
+	SeverityWarning  = "warning"
+	SeverityInfo     = "info"
+)
+
+// States a monitor can enter when a query returns no data or fails.
+const (
+	StateOK       = "OK"
+	StateNoData   = "NoData"
+	StateAlerting = "Alerting"
+	StateError    = "Error"
+)
+
+// Measurement types.
+const (
+	MeasurementState = "state"
+	MeasurementEvent = "event"
+)
+
+// Notification methods.
+const (
+	NotifyNotificationRoutes = "notificationRoutes"
+	NotifyConnectedApps      = "connectedApps"
+	NotifyNone               = "noNotifications"
+)
+
+// Display template languages. An empty language selects Go templates.
+const (
+	TemplateGo     = ""
+	TemplateJinja2 = "jinja2"
+)
+
+// rangeOperators take two threshold values; all other operators take one.
+var rangeOperators = map[string]bool{
+	OpWithinRange:          true,
+	OpOutsideRange:         true,
+	OpWithinRangeIncluded:  true,
+	OpOutsideRangeIncluded: true,
+}
+
+var singleValueOperators = map[string]bool{
+	OpGreaterThan:    true,
+	OpLessThan:       true,
+	OpGreaterOrEqual: true,
+	OpLessOrEqual:    true,
+	OpEqual:          true,
+	OpNotEqual:       true,
+}
+
+// resolveOpposites maps each threshold operator that supports a custom resolve
+// threshold to the resolve operator it must be paired with.
+var resolveOpposites = map[string]string{
+	OpGreaterThan:    OpLessThan,
+	OpGreaterOrEqual: OpLessThan,
+	OpLessThan:       OpGreaterThan,
+	OpLessOrEqual:    OpGreaterThan,
+	OpWithinRange:    OpOutsideRange,
+	OpOutsideRange:   OpWithinRange,
+}
+
+// operatorValueCount returns how many values an operator compares against, or
+// zero if the operator is unknown.
+func operatorValueCount(op string) int {
+	switch {
+	case singleValueOperators[op]:
+		return 1
+	case rangeOperators[op]:
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,535 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/client"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/client/logs"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/client/metrics"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/timerange"
+)
+
+const (
+	defaultPreviewStep           = time.Minute
+	defaultPreviewMaxEvaluations = 1000
+)
+
+// PreviewState is the state of one label set of a monitor at one evaluation.
+type PreviewState string
+
+// Preview states.
+const (
+	PreviewNormal  PreviewState = "normal"
+	PreviewPending PreviewState = "pending"
+	PreviewFiring  PreviewState = "firing"
+	PreviewNoData  PreviewState = "nodata"
+)
+
+// PreviewPoint is the outcome of one evaluation of a threshold for a label set.
+type PreviewPoint struct {
+	Time time.Time
+	// Value is the threshold input, or NaN if the label set had no data.
+	Value float64
+	State PreviewState
+}
+
+// PreviewTransition is a change of state of a label set, such as a monitor
+// starting to fire or resolving.
+type PreviewTransition struct {
+	Time  time.Time
+	From  PreviewState
+	To    PreviewState
+	Value float64
+}
+
+// SeriesPreview is the timeline of one threshold for one label set.
+type SeriesPreview struct {
+	Threshold string
+	Labels    map[string]string
+	Points    []PreviewPoint
+}
+
+// Transitions returns the points at which the state changed. The first
+// evaluation counts as a transition from normal.
+func (s *SeriesPreview) Transitions() []PreviewTransition {
+	var transitions []PreviewTransition
+	prev := PreviewNormal
+	for _, p := range s.Points {
+		if p.State != prev {
+			transitions = append(transitions, PreviewTransition{Time: p.Time, From: prev, To: p.State, Value: p.Value})
+			prev = p.State
+		}
+	}
+	return transitions
+}
+
+// Fired reports whether the label set fired at any evaluation.
+func (s *SeriesPreview) Fired() bool {
+	for _, p := range s.Points {
+		if p.State == PreviewFiring {
+			return true
+		}
+	}
+	return false
+}
+
+// String formats the series as threshold{key="value", ...}.
+func (s *SeriesPreview) String() string {
+	return s.Threshold + formatLabels(s.Labels)
+}
+
+// Preview is the would-be behaviour of a monitor over a historical window.
+type Preview struct {
+	Range timerange.Range
+	Step  time.Duration
+	// Series holds one timeline per threshold and label set, ordered by
+	// threshold and then by labels.
+	Series []*SeriesPreview
+}
+
+// Fired returns the timelines that fired at least once.
+func (p *Preview) Fired() []*SeriesPreview {
+	var fired []*SeriesPreview
+	for _, s := range p.Series {
+		if s.Fired() {
+			fired = append(fired, s)
+		}
+	}
+	return fired
+}
+
+// PreviewOption configures PreviewMonitor.
+type PreviewOption func(*previewConfig)
+
+type previewConfig struct {
+	step           time.Duration
+	maxEvaluations int
+	concurrency    int
+}
+
+// WithPreviewStep sets the time between evaluations. It defaults to the
+// evaluation interval of the monitor, or one minute.
+func WithPreviewStep(step time.Duration) PreviewOption {
+	return func(c *previewConfig) {
+		c.step = step
+	}
+}
+
+// WithMaxEvaluations bounds the number of evaluations of a preview; longer
+// ranges need a larger step. The default is 1000.
+func WithMaxEvaluations(n int) PreviewOption {
+	return func(c *previewConfig) {
+		c.maxEvaluations = n
+	}
+}
+
+// WithPreviewConcurrency sets how many searches run in parallel for queries
+// that are evaluated one step at a time.
+func WithPreviewConcurrency(n int) PreviewOption {
+	return func(c *previewConfig) {
+		c.concurrency = n
+	}
+}
+
+// PreviewMonitor shows what a monitor would have fired on over a historical
+// window. Every query is run against the backend: metrics queries as a single
+// range query evaluated at each step, logs queries as one search per step.
+// Reducers, thresholds, custom resolve thresholds and the pending period are
+// then applied locally, yielding a timeline of states per threshold and label
+// set.
+//
+// The preview approximates the server: queries of other data types are not
+// supported, a query that returns no data for a label set puts it in the state
+// selected by noDataState, and PromQL range queries are sampled at the preview
+// step rather than the resolution the server uses.
+func PreviewMonitor(ctx context.Context, api *client.GroundcoverAPI, req *models.CreateMonitorRequest, r timerange.Range, opts ...PreviewOption) (*Preview, error) {
+	if req == nil {
+		return nil, fmt.Errorf("monitor must not be nil")
+	}
+	if err := Problems(modelProblems(req.Model)).Err(); err != nil {
+		return nil, fmt.Errorf("invalid monitor model: %w", err)
+	}
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	cfg := &previewConfig{maxEvaluations: defaultPreviewMaxEvaluations, concurrency: defaultConcurrency}
+	if req.EvaluationInterval != nil && req.EvaluationInterval.Interval > 0 {
+		cfg.step = time.Duration(req.EvaluationInterval.Interval)
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.step <= 0 {
+		cfg.step = defaultPreviewStep
+	}
+	if cfg.concurrency <= 0 {
+		cfg.concurrency = defaultConcurrency
+	}
+
+	times := evaluationTimes(r, cfg.step)
+	if len(times) == 0 {
+		return nil, fmt.Errorf("range %s is shorter than the step %s", r, cfg.step)
+	}
+	if cfg.maxEvaluations > 0 && len(times) > cfg.maxEvaluations {
+		return nil, fmt.Errorf("range %s at step %s needs %d evaluations, more than the limit of %d", r, cfg.step, len(times), cfg.maxEvaluations)
+	}
+
+	data := map[string]seriesSet{}
+	for _, q := range req.Model.Queries {
+		var (
+			set seriesSet
+			err error
+		)
+		switch queryDataType(q) {
+		case DataTypeMetrics:
+			set, err = previewMetrics(ctx, api, q, times, cfg.step)
+		case DataTypeLogs:
+			set, err = previewLogs(ctx, api, q, times, cfg)
+		default:
+			err = fmt.Errorf("preview of %s queries is not supported", q.DataType)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to run query %s: %w", q.Name, err)
+		}
+		data[q.Name] = set
+	}
+
+	series, err := evaluateModel(req, data, times, cfg.step)
+	if err != nil {
+		return nil, err
+	}
+	return &Preview{Range: r, Step: cfg.step, Series: series}, nil
+}
+
+// evaluationTimes returns the times within r that fall on multiples of step,
+// aligned to the Unix epoch like timerange.Align.
+func evaluationTimes(r timerange.Range, step time.Duration) []time.Time {
+	var times []time.Time
+	for t := r.Align(step).Start; !t.After(r.End); t = t.Add(step) {
+		if !t.Before(r.Start) {
+			times = append(times, t)
+		}
+	}
+	return times
+}
+
+func queryDataType(q *models.BaseQuery) string {
+	if q.DataType == "" && q.DatasourceType == DatasourcePrometheus {
+		return DataTypeMetrics
+	}
+	return q.DataType
+}
+
+func evaluationDelay(q *models.BaseQuery) time.Duration {
+	if q.EvaluationDelay == nil {
+		return 0
+	}
+	return time.Duration(*q.EvaluationDelay) * time.Second
+}
+
+// previewMetrics runs a metrics query over the whole preview window at once.
+// Samples are shifted forward by the evaluation delay of the query, so that
+// they line up with the evaluations that would have seen them.
+func previewMetrics(ctx context.Context, api *client.GroundcoverAPI, q *models.BaseQuery, times []time.Time, step time.Duration) (seriesSet, error) {
+	delay := evaluationDelay(q)
+	from, _ := queryWindow(q, step)
+	start := times[0].Add(-delay - from)
+	end := times[len(times)-1].Add(-delay)
+
+	body := &models.QueryRequest{
+		Conditions: q.Conditions,
+		Filters:    q.Filters,
+		Pipeline:   q.Pipeline,
+		Promql:     q.Expression,
+		QueryType:  QueryTypeRange,
+		Step:       timerange.FormatDuration(step),
+	}
+	timerange.New(start, end).ApplyToQuery(body)
+	resp, err := api.Metrics.MetricsQuery(metrics.NewMetricsQueryParamsWithContext(ctx).WithBody(body), nil)
+	if err != nil {
+		return nil, err
+	}
+	set, err := parsePrometheusResult(resp.Payload)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range set {
+		for i := range s.samples {
+			s.samples[i].t = s.samples[i].t.Add(delay)
+		}
+	}
+	return set, nil
+}
+
+// previewLogs runs a logs query once per evaluation, over the window the
+// query covers at that time.
+func previewLogs(ctx context.Context, api *client.GroundcoverAPI, q *models.BaseQuery, times []time.Time, cfg *previewConfig) (seriesSet, error) {
+	delay := evaluationDelay(q)
+	// Instant logs queries cover their rollup window, or one step.
+	window := cfg.step
+	if d, err := parseRollupWindow(q.InstantRollup); err == nil && d > 0 {
+		window = d
+	}
+	from, to := queryWindow(q, window)
+	rows := make([][]map[string]interface{}, len(times))
+
+	err := forEach(ctx, len(times), cfg.concurrency, func(ctx context.Context, i int) error {
+		end := times[i].Add(-delay - to)
+		r := timerange.New(times[i].Add(-delay-from), end)
+		body := &models.LogsSearchRequest{
+			Filters:  q.Filters,
+			Query:    q.Expression,
+			Pipeline: q.SQLPipeline,
+			Sources:  q.Conditions,
+		}
+		r.ApplyToLogs(body)
+		resp, err := api.Logs.SearchLogs(logs.NewSearchLogsParamsWithContext(ctx).WithBody(body), nil)
+		if err != nil {
+			return err
+		}
+		rows[i], err = decodeRows(resp.Payload)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	set := seriesSet{}
+	for i, t := range times {
+		for _, row := range rows[i] {
+			labels, v, ok := splitRow(row)
+			if ok {
+				set.add(labels, t, v)
+			}
+		}
+	}
+	return set, nil
+}
+
+// parseRollupWindow parses instant rollup windows, which are written either as
+// durations ("5m") or in words ("5 minutes").
+func parseRollupWindow(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if d, err := timerange.ParseDuration(s); err == nil {
+		return d, nil
+	}
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return 0, fmt.Errorf("invalid rollup window %q", s)
+	}
+	n, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0, fmt.Errorf("invalid rollup window %q", s)
+	}
+	units := map[string]time.Duration{
+		"second": time.Second,
+		"minute": time.Minute,
+		"hour":   time.Hour,
+		"day":    24 * time.Hour,
+		"week":   7 * 24 * time.Hour,
+	}
+	unit, ok := units[strings.TrimSuffix(strings.ToLower(fields[1]), "s")]
+	if !ok {
+		return 0, fmt.Errorf("invalid rollup window %q", s)
+	}
+	return time.Duration(n) * unit, nil
+}
+
+// sample is one value of a series.
+type sample struct {
+	t time.Time
+	v float64
+}
+
+// series is the samples of one label set, in time order.
+type series struct {
+	labels  map[string]string
+	samples []sample
+}
+
+// seriesSet holds series by labelsKey.
+type seriesSet map[string]*series
+
+func (s seriesSet) add(labels map[string]string, t time.Time, v float64) {
+	key := labelsKey(labels)
+	if s[key] == nil {
+		s[key] = &series{labels: labels}
+	}
+	s[key].samples = append(s[key].samples, sample{t: t, v: v})
+}
+
+// window returns the values of samples in (start, end].
+func (s *series) window(start, end time.Time) []float64 {
+	var values []float64
+	for _, smp := range s.samples {
+		if smp.t.After(start) && !smp.t.After(end) {
+			values = append(values, smp.v)
+		}
+	}
+	return values
+}
+
+// prometheusResult is the data of a Prometheus query response.
+type prometheusResult struct {
+	ResultType string `json:"resultType"`
+	Result     []struct {
+		Metric map[string]string `json:"metric"`
+		Values [][2]interface{}  `json:"values"`
+		Value  [2]interface{}    `json:"value"`
+	} `json:"result"`
+}
+
+// parsePrometheusResult reads matrix and vector results, either wrapped in a
+// {"status", "data"} envelope or bare.
+func parsePrometheusResult(payload interface{}) (seriesSet, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metrics response: %w", err)
+	}
+	var envelope struct {
+		Status string           `json:"status"`
+		Error  string           `json:"error"`
+		Data   prometheusResult `json:"data"`
+		prometheusResult
+	}
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		return nil, fmt.Errorf("failed to read metrics response: %w", err)
+	}
+	if envelope.Status == "error" {
+		return nil, fmt.Errorf("metrics query failed: %s", envelope.Error)
+	}
+	result := envelope.Data
+	if result.ResultType == "" {
+		result = envelope.prometheusResult
+	}
+
+	set := seriesSet{}
+	for _, r := range result.Result {
+		labels := map[string]string{}
+		for k, v := range r.Metric {
+			if k != "__name__" {
+				labels[k] = v
+			}
+		}
+		values := r.Values
+		if result.ResultType == "vector" {
+			values = [][2]interface{}{r.Value}
+		}
+		for _, pair := range values {
+			t, v, err := parsePrometheusSample(pair)
+			if err != nil {
+				return nil, err
+			}
+			if !math.IsNaN(v) {
+				set.add(labels, t, v)
+			}
+		}
+	}
+	return set, nil
+}
+
+func parsePrometheusSample(pair [2]interface{}) (time.Time, float64, error) {
+	ts, ok := pair[0].(float64)
+	if !ok {
+		return time.Time{}, 0, fmt.Errorf("invalid sample timestamp %v", pair[0])
+	}
+	s, ok := pair[1].(string)
+	if !ok {
+		return time.Time{}, 0, fmt.Errorf("invalid sample value %v", pair[1])
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf("invalid sample value %q", s)
+	}
+	sec, frac := math.Modf(ts)
+	return time.Unix(int64(sec), int64(frac*1e9)).UTC(), v, nil
+}
+
+func decodeRows(payload interface{}) ([]map[string]interface{}, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read logs response: %w", err)
+	}
+	var rows []map[string]interface{}
+	if err := json.Unmarshal(raw, &rows); err != nil {
+		return nil, fmt.Errorf("failed to read logs response: %w", err)
+	}
+	return rows, nil
+}
+
+// splitRow takes the value of a search result row from its numeric column and
+// its labels from the others. When several columns are numeric, one named
+// "value" or "count" wins, and then the first by name; the rest are dropped.
+func splitRow(row map[string]interface{}) (map[string]string, float64, bool) {
+	var numeric []string
+	for k, v := range row {
+		if _, ok := v.(float64); ok {
+			numeric = append(numeric, k)
+		}
+	}
+	sort.Strings(numeric)
+
+	valueKey := ""
+	for _, preferred := range []string{"value", "count"} {
+		if _, ok := row[preferred]; ok && (contains(numeric, preferred) || len(numeric) == 0) {
+			valueKey = preferred
+			break
+		}
+	}
+	if valueKey == "" && len(numeric) > 0 {
+		valueKey = numeric[0]
+	}
+	if valueKey == "" {
+		return nil, 0, false
+	}
+
+	var v float64
+	switch x := row[valueKey].(type) {
+	case float64:
+		v = x
+	case string:
+		f, err := strconv.ParseFloat(x, 64)
+		if err != nil {
+			return nil, 0, false
+		}
+		v = f
+	default:
+		return nil, 0, false
+	}
+
+	labels := map[string]string{}
+	for k, val := range row {
+		if k == valueKey || contains(numeric, k) || val == nil {
+			continue
+		}
+		labels[k] = fmt.Sprint(val)
+	}
+	return labels, v, true
+}
+
+func labelsKey(labels map[string]string) string {
+	keys := sortedKeys(labels)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + "=" + labels[k]
+	}
+	return strings.Join(parts, "\xff")
+}
+
+func formatLabels(labels map[string]string) string {
+	keys := sortedKeys(labels)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%s=%q", k, labels[k])
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
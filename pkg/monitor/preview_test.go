@@ -0,0 +1,249 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-openapi/swag"
+	"github.com/groundcover-com/groundcover-sdk-go/internal/testutil"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/timerange"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviewMonitorMetrics(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	podA := []float64{0.1, 0.95, 0.95, 0.95, 0.7, 0.6, 0.4, 0.95, 0.2, 0.2}
+
+	var got models.QueryRequest
+	api := testutil.NewAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/metrics/query", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		var a, b [][2]interface{}
+		for i, v := range podA {
+			ts := float64(start.Add(time.Duration(i) * time.Minute).Unix())
+			a = append(a, [2]interface{}{ts, fmt.Sprint(v)})
+			if i < len(podA)-1 {
+				b = append(b, [2]interface{}{ts, "0.1"})
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"status": "success",
+			"data": map[string]interface{}{
+				"resultType": "matrix",
+				"result": []interface{}{
+					map[string]interface{}{"metric": map[string]string{"__name__": "cpu", "pod": "a"}, "values": a},
+					map[string]interface{}{"metric": map[string]string{"pod": "b"}, "values": b},
+				},
+			},
+		})
+	}))
+
+	req, err := New("High CPU").
+		PromQL("cpu", "avg by (pod) (rate(cpu[5m]))").
+		Last("last_cpu", "cpu").
+		Threshold("high", "last_cpu", GreaterThan(0.9).ResolveWhen(LessThan(0.5))).
+		EvaluationInterval(time.Minute, 2*time.Minute).
+		Build()
+	require.NoError(t, err)
+
+	preview, err := PreviewMonitor(context.Background(), api, req, timerange.New(start, start.Add(9*time.Minute)))
+	require.NoError(t, err)
+	require.Equal(t, time.Minute, preview.Step)
+	require.Equal(t, "1m", got.Step)
+	require.Equal(t, QueryTypeRange, got.QueryType)
+	require.Equal(t, "avg by (pod) (rate(cpu[5m]))", got.Promql)
+
+	require.Len(t, preview.Series, 2)
+	a, b := preview.Series[0], preview.Series[1]
+	require.Equal(t, map[string]string{"pod": "a"}, a.Labels)
+	require.Equal(t, `high{pod="a"}`, a.String())
+
+	at := func(i int) time.Time { return start.Add(time.Duration(i) * time.Minute) }
+	require.Equal(t, []PreviewTransition{
+		{Time: at(1), From: PreviewNormal, To: PreviewPending, Value: 0.95},
+		{Time: at(3), From: PreviewPending, To: PreviewFiring, Value: 0.95},
+		{Time: at(6), From: PreviewFiring, To: PreviewNormal, Value: 0.4},
+		{Time: at(7), From: PreviewNormal, To: PreviewPending, Value: 0.95},
+		{Time: at(8), From: PreviewPending, To: PreviewNormal, Value: 0.2},
+	}, a.Transitions())
+
+	require.False(t, b.Fired())
+	require.Equal(t, PreviewNoData, b.Points[9].State)
+	require.True(t, math.IsNaN(b.Points[9].Value))
+	require.Equal(t, []*SeriesPreview{a}, preview.Fired())
+}
+
+func TestPreviewMonitorLogs(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	errorsAt := map[time.Time]float64{
+		start:                       5,
+		start.Add(5 * time.Minute):  40,
+		start.Add(10 * time.Minute): 12,
+	}
+
+	var (
+		mu      sync.Mutex
+		windows []time.Duration
+	)
+	api := testutil.NewAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/logs/v2/search", r.URL.Path)
+		var req models.LogsSearchRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, "level:error", req.Filters)
+		end := time.Time(*req.End).UTC()
+		mu.Lock()
+		windows = append(windows, end.Sub(time.Time(*req.Start)))
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode([]map[string]interface{}{
+			{"workload": "api", "count": errorsAt[end]},
+			{"workload": "web", "count": "3"},
+		})
+	}))
+
+	req, err := New("Error spike").
+		Logs("errors", &models.SQLPipeline{}, WithFilters("level:error"), WithInstantRollup("5 minutes")).
+		Math("per_minute", "${errors} / 5").
+		Threshold("spike", "per_minute", GreaterOrEqual(2)).
+		Build()
+	require.NoError(t, err)
+
+	preview, err := PreviewMonitor(context.Background(), api, req,
+		timerange.New(start, start.Add(10*time.Minute)), WithPreviewStep(5*time.Minute), WithPreviewConcurrency(2))
+	require.NoError(t, err)
+	require.Equal(t, []time.Duration{5 * time.Minute, 5 * time.Minute, 5 * time.Minute}, windows)
+
+	require.Len(t, preview.Series, 2)
+	api0 := preview.Series[0]
+	require.Equal(t, map[string]string{"workload": "api"}, api0.Labels)
+	require.Equal(t, []PreviewState{PreviewNormal, PreviewFiring, PreviewFiring}, states(api0))
+	require.Equal(t, 8.0, api0.Points[1].Value)
+	require.Equal(t, []PreviewState{PreviewNormal, PreviewNormal, PreviewNormal}, states(preview.Series[1]))
+}
+
+func TestPreviewMonitorLogsRelativeTimerange(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	var (
+		mu      sync.Mutex
+		windows []timerange.Range
+	)
+	api := testutil.NewAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req models.LogsSearchRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		mu.Lock()
+		windows = append(windows, timerange.New(time.Time(*req.Start).UTC(), time.Time(*req.End).UTC()))
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode([]map[string]interface{}{})
+	}))
+
+	req, err := New("Late errors").
+		Logs("errors", &models.SQLPipeline{}, WithQueryType(QueryTypeRange), WithRelativeTimerange(-10*time.Minute, -2*time.Minute)).
+		Threshold("some", "errors", GreaterThan(0)).
+		Build()
+	require.NoError(t, err)
+
+	_, err = PreviewMonitor(context.Background(), api, req, timerange.New(start, start.Add(time.Minute)), WithPreviewStep(time.Minute))
+	require.NoError(t, err)
+	require.ElementsMatch(t, []timerange.Range{
+		timerange.New(start.Add(-10*time.Minute), start.Add(-2*time.Minute)),
+		timerange.New(start.Add(-9*time.Minute), start.Add(-time.Minute)),
+	}, windows)
+}
+
+func TestPreviewMonitorLimits(t *testing.T) {
+	req, err := New("x").PromQL("q", "up").Threshold("t", "q", LessThan(1)).Build()
+	require.NoError(t, err)
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	_, err = PreviewMonitor(context.Background(), nil, req, timerange.New(start, start.Add(24*time.Hour)))
+	require.ErrorContains(t, err, "needs 1441 evaluations, more than the limit of 1000")
+
+	req.Model.Queries[0].DataType = DataTypeTraces
+	_, err = PreviewMonitor(context.Background(), nil, req, timerange.New(start, start.Add(time.Hour)))
+	require.ErrorContains(t, err, "failed to run query q: preview of traces queries is not supported")
+}
+
+func TestEvaluationTimesAlignToUnixEpoch(t *testing.T) {
+	step := 7 * time.Minute
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	times := evaluationTimes(timerange.New(start, start.Add(time.Hour)), step)
+
+	require.Len(t, times, 8)
+	require.True(t, times[0].Sub(start) < step)
+	for _, tm := range times {
+		require.Zero(t, tm.Unix()%int64(step/time.Second), tm)
+		require.False(t, tm.Before(start), tm)
+	}
+}
+
+func TestReplayThresholdNoData(t *testing.T) {
+	th := &models.Threshold{Name: swag.String("t"), InputName: swag.String("q"), Operator: swag.String(OpGreaterThan), Values: []float64{1}}
+	times := []time.Time{time.Unix(0, 0), time.Unix(60, 0), time.Unix(120, 0)}
+	values := []float64{2, math.NaN(), 0}
+
+	for noData, want := range map[string][]PreviewState{
+		"":            {PreviewFiring, PreviewNoData, PreviewNormal},
+		StateOK:       {PreviewFiring, PreviewNormal, PreviewNormal},
+		StateAlerting: {PreviewFiring, PreviewFiring, PreviewNormal},
+	} {
+		points, err := replayThreshold(th, noData, 0, times, values)
+		require.NoError(t, err)
+		got := make([]PreviewState, len(points))
+		for i, p := range points {
+			got[i] = p.State
+		}
+		require.Equal(t, want, got, noData)
+	}
+}
+
+func TestParseMathExpr(t *testing.T) {
+	for expr, want := range map[string]float64{
+		"$a + $b * 2":         8,
+		"($a + $b) * 2":       10,
+		"-$a + ${b} / 2":      -0.5,
+		"$a > 1 && !($b < 1)": 1,
+		"abs(-$b) % 2":        1,
+		"1e1 - 2.5":           7.5,
+	} {
+		e, err := parseMathExpr(expr)
+		require.NoError(t, err, expr)
+		require.Equal(t, want, e.eval(map[string]float64{"a": 2, "b": 3}), expr)
+	}
+
+	e, err := parseMathExpr("$x / $y + $x")
+	require.NoError(t, err)
+	require.Equal(t, []string{"x", "y"}, e.vars)
+
+	for _, bad := range []string{"", "$a +", "($a", "pow($a)", "${a", "$a $b"} {
+		_, err := parseMathExpr(bad)
+		require.Error(t, err, bad)
+	}
+}
+
+func TestSplitRow(t *testing.T) {
+	labels, v, ok := splitRow(map[string]interface{}{"workload": "api", "status": "500", "count": 3.0, "bytes": 10.0})
+	require.True(t, ok)
+	require.Equal(t, 3.0, v)
+	require.Equal(t, map[string]string{"workload": "api", "status": "500"}, labels)
+
+	_, _, ok = splitRow(map[string]interface{}{"workload": "api"})
+	require.False(t, ok)
+}
+
+func states(s *SeriesPreview) []PreviewState {
+	out := make([]PreviewState, len(s.Points))
+	for i, p := range s.Points {
+		out[i] = p.State
+	}
+	return out
+}
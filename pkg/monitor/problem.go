@@ -0,0 +1,35 @@
+package monitor
+
+import "strings"
+
+// Problem is a single issue found in a monitor definition.
+type Problem struct {
+	// Path locates the offending field, for example "model.thresholds[0].inputName".
+	Path    string
+	Message string
+}
+
+// Error implements the error interface.
+func (p *Problem) Error() string {
+	return p.Path + ": " + p.Message
+}
+
+// Problems is a list of issues found in a monitor definition.
+type Problems []*Problem
+
+// Error implements the error interface, listing one problem per line.
+func (ps Problems) Error() string {
+	lines := make([]string, len(ps))
+	for i, p := range ps {
+		lines[i] = p.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Err returns the problems as an error, or nil if there are none.
+func (ps Problems) Err() error {
+	if len(ps) == 0 {
+		return nil
+	}
+	return ps
+}
@@ -0,0 +1,355 @@
+package monitor
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+)
+
+type promTokenKind int
+
+const (
+	promIdent promTokenKind = iota
+	promNumber
+	promString
+	promDuration
+	promOperator
+	promMatchOp
+	promOpen
+	promClose
+	promComma
+	promColon
+	promAt
+)
+
+type promToken struct {
+	kind promTokenKind
+	text string
+	pos  int
+}
+
+// promBinaryOperators are the binary operators and keywords after which an
+// operand must follow.
+var promBinaryOperators = map[string]bool{
+	"+": true, "-": true, "*": true, "/": true, "%": true, "^": true,
+	"==": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true,
+	"and": true, "or": true, "unless": true, "atan2": true,
+}
+
+// CheckPromQL checks the syntax of a PromQL expression locally: it verifies
+// that the expression lexes, that brackets are balanced and correctly nested,
+// that label matchers are well formed with valid regular expressions, and that
+// range and subquery durations parse. It does not type-check the expression
+// or verify that functions and metrics exist.
+func CheckPromQL(expr string) error {
+	if strings.TrimSpace(expr) == "" {
+		return fmt.Errorf("expression is empty")
+	}
+	tokens, err := lexPromQL(expr)
+	if err != nil {
+		return err
+	}
+
+	var stack []promToken
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		switch tok.kind {
+		case promOpen:
+			stack = append(stack, tok)
+			switch tok.text {
+			case "{":
+				end, err := checkPromMatchers(tokens, i+1)
+				if err != nil {
+					return err
+				}
+				i = end - 1
+			case "[":
+				end, err := checkPromRange(tokens, i+1)
+				if err != nil {
+					return err
+				}
+				i = end - 1
+			}
+		case promClose:
+			if len(stack) == 0 {
+				return fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+			}
+			open := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if closing[open.text] != tok.text {
+				return fmt.Errorf("unexpected %q at position %d: %q opened at position %d is not closed", tok.text, tok.pos, open.text, open.pos)
+			}
+			if tok.text == ")" && i > 0 && tokens[i-1].kind == promOperator {
+				return fmt.Errorf("missing operand after %q at position %d", tokens[i-1].text, tokens[i-1].pos)
+			}
+		case promOperator:
+			if i+1 < len(tokens) && tokens[i+1].kind == promOperator && !isUnary(tokens[i+1].text) {
+				return fmt.Errorf("unexpected operator %q at position %d", tokens[i+1].text, tokens[i+1].pos)
+			}
+		case promDuration:
+			if !followsOffset(tokens, i) {
+				return fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+			}
+		case promMatchOp, promColon:
+			return fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+		}
+	}
+	if len(stack) > 0 {
+		open := stack[len(stack)-1]
+		return fmt.Errorf("%q opened at position %d is not closed", open.text, open.pos)
+	}
+	if last := tokens[len(tokens)-1]; last.kind == promOperator || (last.kind == promIdent && promBinaryOperators[last.text]) {
+		return fmt.Errorf("missing operand after %q at position %d", last.text, last.pos)
+	}
+	return nil
+}
+
+// followsOffset reports whether tokens[i] is the duration of an offset
+// modifier, which may be negative.
+func followsOffset(tokens []promToken, i int) bool {
+	if i > 0 && tokens[i-1].kind == promOperator && isUnary(tokens[i-1].text) {
+		i--
+	}
+	return i > 0 && tokens[i-1].kind == promIdent && tokens[i-1].text == "offset"
+}
+
+var closing = map[string]string{"(": ")", "{": "}", "[": "]"}
+
+func isUnary(op string) bool {
+	return op == "+" || op == "-"
+}
+
+// checkPromMatchers checks the label matchers following a "{" at tokens[start]
+// and returns the index of the closing "}".
+func checkPromMatchers(tokens []promToken, start int) (int, error) {
+	i := start
+	for {
+		if i >= len(tokens) {
+			return 0, fmt.Errorf("unterminated label matchers")
+		}
+		if tokens[i].kind == promClose && tokens[i].text == "}" {
+			return i, nil
+		}
+
+		name := tokens[i]
+		if name.kind != promIdent && name.kind != promString {
+			return 0, fmt.Errorf("expected label name at position %d, got %q", name.pos, name.text)
+		}
+		if i+1 >= len(tokens) {
+			return 0, fmt.Errorf("unterminated label matcher for %q", name.text)
+		}
+		if op := tokens[i+1]; op.kind != promMatchOp {
+			return 0, fmt.Errorf("expected label matching operator after %q at position %d, got %q", name.text, op.pos, op.text)
+		}
+		if i+2 >= len(tokens) {
+			return 0, fmt.Errorf("unterminated label matcher for %q", name.text)
+		}
+		op, value := tokens[i+1], tokens[i+2]
+		if value.kind != promString {
+			return 0, fmt.Errorf("expected string value for label %q at position %d, got %q", name.text, value.pos, value.text)
+		}
+		if op.text == "=~" || op.text == "!~" {
+			pattern, err := unquotePromString(value.text)
+			if err != nil {
+				return 0, fmt.Errorf("invalid string at position %d: %w", value.pos, err)
+			}
+			if _, err := regexp.Compile("^(?:" + pattern + ")$"); err != nil {
+				return 0, fmt.Errorf("invalid regular expression for label %q: %w", name.text, err)
+			}
+		}
+
+		i += 3
+		if i < len(tokens) && tokens[i].kind == promComma {
+			i++
+		} else if i < len(tokens) && !(tokens[i].kind == promClose && tokens[i].text == "}") {
+			return 0, fmt.Errorf("expected \",\" or \"}\" at position %d, got %q", tokens[i].pos, tokens[i].text)
+		}
+	}
+}
+
+// checkPromRange checks a range or subquery duration following a "[" at
+// tokens[start] and returns the index of the closing "]".
+func checkPromRange(tokens []promToken, start int) (int, error) {
+	i := start
+	expectDuration := true
+	sawColon := false
+	for ; i < len(tokens); i++ {
+		tok := tokens[i]
+		switch {
+		case tok.kind == promClose && tok.text == "]":
+			if i == start {
+				return 0, fmt.Errorf("missing range duration at position %d", tok.pos)
+			}
+			if expectDuration && !sawColon {
+				return 0, fmt.Errorf("missing range duration at position %d", tok.pos)
+			}
+			return i, nil
+		case tok.kind == promDuration || tok.kind == promNumber:
+			if !expectDuration {
+				return 0, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+			}
+			if err := checkPromDuration(tok.text); err != nil {
+				return 0, fmt.Errorf("invalid duration %q at position %d: %w", tok.text, tok.pos, err)
+			}
+			expectDuration = false
+		case tok.kind == promColon && !sawColon:
+			sawColon = true
+			expectDuration = true
+		default:
+			return 0, fmt.Errorf("unexpected %q in range at position %d", tok.text, tok.pos)
+		}
+	}
+	return 0, fmt.Errorf("unterminated range")
+}
+
+func checkPromDuration(s string) error {
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		// Plain numbers are seconds.
+		return nil
+	}
+	var d models.Duration
+	return d.UnmarshalText([]byte(s))
+}
+
+func unquotePromString(s string) (string, error) {
+	if strings.HasPrefix(s, "`") {
+		return strings.Trim(s, "`"), nil
+	}
+	if strings.HasPrefix(s, "'") {
+		// strconv.Unquote only accepts single-character single-quoted strings.
+		inner := s[1 : len(s)-1]
+		inner = strings.ReplaceAll(inner, `\'`, `'`)
+		inner = strings.ReplaceAll(inner, `"`, `\"`)
+		s = `"` + inner + `"`
+	}
+	return strconv.Unquote(s)
+}
+
+func lexPromQL(expr string) ([]promToken, error) {
+	var tokens []promToken
+	afterOpenRange := false
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '#':
+			for i < len(expr) && expr[i] != '\n' {
+				i++
+			}
+		case c == '"' || c == '\'' || c == '`':
+			j := i + 1
+			for ; j < len(expr) && expr[j] != c; j++ {
+				if expr[j] == '\\' && c != '`' {
+					j++
+				}
+			}
+			if j >= len(expr) {
+				return nil, fmt.Errorf("unterminated string starting at position %d", i)
+			}
+			tokens = append(tokens, promToken{kind: promString, text: expr[i : j+1], pos: i})
+			i = j + 1
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(expr) && expr[i+1] >= '0' && expr[i+1] <= '9':
+			j := i
+			for j < len(expr) && (isPromIdentChar(expr[j]) || expr[j] == '.' ||
+				(expr[j] == '+' || expr[j] == '-') && (expr[j-1] == 'e' || expr[j-1] == 'E') && !afterOpenRange) {
+				j++
+			}
+			text := expr[i:j]
+			kind := promNumber
+			if _, err := strconv.ParseFloat(text, 64); err != nil {
+				if _, err := strconv.ParseInt(text, 0, 64); err != nil {
+					kind = promDuration
+				}
+			}
+			tokens = append(tokens, promToken{kind: kind, text: text, pos: i})
+			i = j
+		case isPromIdentStart(c):
+			j := i
+			for j < len(expr) && (isPromIdentChar(expr[j]) || expr[j] == ':' && !afterOpenRange) {
+				j++
+			}
+			tokens = append(tokens, promToken{kind: promIdent, text: expr[i:j], pos: i})
+			i = j
+		case c == '(' || c == '{' || c == '[':
+			tokens = append(tokens, promToken{kind: promOpen, text: string(c), pos: i})
+			i++
+		case c == ')' || c == '}' || c == ']':
+			tokens = append(tokens, promToken{kind: promClose, text: string(c), pos: i})
+			i++
+		case c == ',':
+			tokens = append(tokens, promToken{kind: promComma, text: ",", pos: i})
+			i++
+		case c == ':':
+			tokens = append(tokens, promToken{kind: promColon, text: ":", pos: i})
+			i++
+		case c == '@':
+			tokens = append(tokens, promToken{kind: promAt, text: "@", pos: i})
+			i++
+		default:
+			op := lexPromOperator(expr[i:])
+			if op == "" {
+				r := []rune(expr[i:])[0]
+				if unicode.IsPrint(r) {
+					return nil, fmt.Errorf("unexpected character %q at position %d", r, i)
+				}
+				return nil, fmt.Errorf("unexpected character %U at position %d", r, i)
+			}
+			kind := promOperator
+			if op == "=" || op == "=~" || op == "!~" || (op == "!=" && insideBraces(tokens)) {
+				kind = promMatchOp
+			}
+			tokens = append(tokens, promToken{kind: kind, text: op, pos: i})
+			i += len(op)
+		}
+		afterOpenRange = insideBrackets(tokens)
+	}
+	return tokens, nil
+}
+
+func lexPromOperator(s string) string {
+	for _, op := range []string{"==", "!=", "<=", ">=", "=~", "!~", "+", "-", "*", "/", "%", "^", "<", ">", "="} {
+		if strings.HasPrefix(s, op) {
+			return op
+		}
+	}
+	return ""
+}
+
+// insideBraces reports whether the last unclosed bracket is "{".
+func insideBraces(tokens []promToken) bool {
+	return lastOpen(tokens) == "{"
+}
+
+// insideBrackets reports whether the last unclosed bracket is "[".
+func insideBrackets(tokens []promToken) bool {
+	return lastOpen(tokens) == "["
+}
+
+func lastOpen(tokens []promToken) string {
+	depth := 0
+	for i := len(tokens) - 1; i >= 0; i-- {
+		switch tokens[i].kind {
+		case promClose:
+			depth++
+		case promOpen:
+			if depth == 0 {
+				return tokens[i].text
+			}
+			depth--
+		}
+	}
+	return ""
+}
+
+func isPromIdentStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isPromIdentChar(c byte) bool {
+	return isPromIdentStart(c) || c >= '0' && c <= '9'
+}
@@ -0,0 +1,402 @@
+// Package templates provides ready-made monitors for common Kubernetes alerts.
+//
+// Every template returns a complete models.CreateMonitorRequest built with
+// monitor.Builder. Options narrow it to a cluster, namespace or workload, and
+// set its severity, routing, labels and timing; the template's own arguments
+// set its thresholds. Anything else can be changed with Customize before the
+// request is built.
+package templates
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/monitor"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/types"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/utils"
+)
+
+// Metrics the templates query.
+const (
+	MetricWorkloadRequests     = "groundcover_workload_total_counter"
+	MetricWorkloadLatency      = "groundcover_workload_latency_seconds"
+	MetricContainerWaiting     = "groundcover_kube_pod_container_status_waiting_reason"
+	MetricNodeCondition        = "groundcover_kube_node_status_condition"
+	MetricVolumeUsedBytes      = "groundcover_kubelet_volume_stats_used_bytes"
+	MetricVolumeCapacityBytes  = "groundcover_kubelet_volume_stats_capacity_bytes"
+	labelWorkload              = "workload_name"
+	labelStatusCode            = "status_code"
+	labelQuantile              = "quantile"
+	labelPersistentVolumeClaim = "persistentvolumeclaim"
+)
+
+// Option configures a template.
+type Option func(*config)
+
+type config struct {
+	title      string
+	cluster    string
+	namespace  string
+	workload   string
+	severity   string
+	team       string
+	labels     map[string]string
+	routing    []string
+	interval   time.Duration
+	pendingFor time.Duration
+	window     time.Duration
+	customize  []func(*monitor.Builder)
+}
+
+// WithTitle overrides the title of the monitor.
+func WithTitle(title string) Option {
+	return func(c *config) {
+		c.title = title
+	}
+}
+
+// WithCluster limits the monitor to one cluster.
+func WithCluster(cluster string) Option {
+	return func(c *config) {
+		c.cluster = cluster
+	}
+}
+
+// WithNamespace limits the monitor to one namespace.
+func WithNamespace(namespace string) Option {
+	return func(c *config) {
+		c.namespace = namespace
+	}
+}
+
+// WithWorkload limits the monitor to one workload. Templates about nodes and
+// volumes ignore it.
+func WithWorkload(workload string) Option {
+	return func(c *config) {
+		c.workload = workload
+	}
+}
+
+// WithSeverity sets the severity of the monitor.
+func WithSeverity(severity string) Option {
+	return func(c *config) {
+		c.severity = severity
+	}
+}
+
+// WithTeam sets the team owning the monitor.
+func WithTeam(team string) Option {
+	return func(c *config) {
+		c.team = team
+	}
+}
+
+// WithLabels adds labels to the monitor and its alerts.
+func WithLabels(labels map[string]string) Option {
+	return func(c *config) {
+		if c.labels == nil {
+			c.labels = map[string]string{}
+		}
+		for k, v := range labels {
+			c.labels[k] = v
+		}
+	}
+}
+
+// WithRouting sends the alerts through the given notification routes.
+func WithRouting(routes ...string) Option {
+	return func(c *config) {
+		c.routing = append(c.routing, routes...)
+	}
+}
+
+// WithEvaluationInterval sets how often the monitor is evaluated and how long
+// its threshold must hold before the alert fires.
+func WithEvaluationInterval(interval, pendingFor time.Duration) Option {
+	return func(c *config) {
+		c.interval = interval
+		c.pendingFor = pendingFor
+	}
+}
+
+// WithWindow sets the time window rates, counts and averages are computed
+// over.
+func WithWindow(window time.Duration) Option {
+	return func(c *config) {
+		c.window = window
+	}
+}
+
+// Customize runs fn on the builder after the template has declared the
+// monitor, for changes the other options do not cover.
+func Customize(fn func(*monitor.Builder)) Option {
+	return func(c *config) {
+		c.customize = append(c.customize, fn)
+	}
+}
+
+// newConfig applies opts over the defaults of a template.
+func newConfig(title string, severity string, pendingFor, window time.Duration, opts []Option) *config {
+	c := &config{
+		title:      title,
+		severity:   severity,
+		interval:   time.Minute,
+		pendingFor: pendingFor,
+		window:     window,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// scopeTitle appends the non-empty scope parts to a default title, as in
+// "High 5xx rate (prod/api)".
+func (c *config) scopeTitle(scope ...string) string {
+	var parts []string
+	for _, p := range scope {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	if len(parts) == 0 {
+		return c.title
+	}
+	return c.title + " (" + strings.Join(parts, "/") + ")"
+}
+
+// build declares the settings shared by every template on b and builds it.
+func (c *config) build(b *monitor.Builder) (*models.CreateMonitorRequest, error) {
+	b.Severity(c.severity).
+		Labels(c.labels).
+		EvaluationInterval(c.interval, c.pendingFor)
+	if c.team != "" {
+		b.Team(c.team)
+	}
+	if len(c.routing) > 0 {
+		b.Routing(c.routing...).NotifyNotificationRoutes()
+	}
+	for _, fn := range c.customize {
+		fn(b)
+	}
+	return b.Build()
+}
+
+// promMatchers returns label matchers for the cluster and namespace of the
+// scope, and for the workload when withWorkload is set.
+func (c *config) promMatchers(withWorkload bool) []string {
+	var matchers []string
+	if c.cluster != "" {
+		matchers = append(matchers, matcher(types.ConditionKeyCluster, types.MatchEqual, c.cluster))
+	}
+	if c.namespace != "" {
+		matchers = append(matchers, matcher(types.ConditionKeyNamespace, types.MatchEqual, c.namespace))
+	}
+	if withWorkload && c.workload != "" {
+		matchers = append(matchers, matcher(labelWorkload, types.MatchEqual, c.workload))
+	}
+	return matchers
+}
+
+func matcher(label string, match types.MatchType, value string) string {
+	return label + match.String() + strconv.Quote(value)
+}
+
+// conditions returns the scope as search conditions.
+func (c *config) conditions(set *utils.ConditionSet) *utils.ConditionSet {
+	for _, kv := range [][2]string{
+		{types.ConditionKeyCluster, c.cluster},
+		{types.ConditionKeyNamespace, c.namespace},
+		{types.ConditionKeyWorkload, c.workload},
+	} {
+		if kv[1] != "" {
+			set.Add(kv[0], kv[1])
+		}
+	}
+	return set
+}
+
+// selector formats a PromQL series selector.
+func selector(metric string, matchers ...string) string {
+	if len(matchers) == 0 {
+		return metric
+	}
+	return metric + "{" + strings.Join(matchers, ", ") + "}"
+}
+
+// promDuration formats a duration for PromQL, as in "5m".
+func promDuration(d time.Duration) string {
+	switch {
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	}
+	return fmt.Sprintf("%ds", d/time.Second)
+}
+
+// rollupWindow formats a duration as an instant rollup window, as in
+// "5 minutes".
+func rollupWindow(d time.Duration) string {
+	if d%time.Minute == 0 {
+		return fmt.Sprintf("%d minutes", d/time.Minute)
+	}
+	return fmt.Sprintf("%d seconds", d/time.Second)
+}
+
+// countPipeline counts rows grouped by the given keys.
+func countPipeline(groupBy ...string) *models.SQLPipeline {
+	p := &models.SQLPipeline{
+		Selectors: []*models.Selector{{
+			Alias:      "count",
+			Processors: []*models.Processor{{Op: "count"}},
+		}},
+	}
+	for _, key := range groupBy {
+		s := &models.Selector{Key: key, Origin: types.ConditionOriginRoot, Type: types.ConditionTypeString}
+		p.Selectors = append(p.Selectors, s)
+		p.GroupBy = append(p.GroupBy, s)
+	}
+	return p
+}
+
+func groupBy(labels ...string) string {
+	return "by (" + strings.Join(labels, ", ") + ")"
+}
+
+// workloadLabels are the labels per-workload metrics are grouped by.
+var workloadLabels = []string{types.ConditionKeyCluster, types.ConditionKeyNamespace, labelWorkload}
+
+// OOMKilled fires when containers are killed for running out of memory. It
+// counts OOM events per workload over the window, five minutes by default.
+func OOMKilled(opts ...Option) (*models.CreateMonitorRequest, error) {
+	c := newConfig("Container OOMKilled", monitor.SeverityError, 0, 5*time.Minute, opts)
+	conditions := c.conditions(utils.NewConditionSet().AddOOMEventConditions()).Build()
+	b := monitor.New(c.scopeTitle(c.cluster, c.namespace, c.workload)).
+		Events("oom_events", countPipeline(types.ConditionKeyNamespace, types.ConditionKeyWorkload),
+			monitor.WithConditions(conditions...), monitor.WithInstantRollup(rollupWindow(c.window))).
+		Threshold("oom_killed", "oom_events", monitor.GreaterThan(0)).
+		MeasurementType(monitor.MeasurementEvent).
+		Display("{{ $labels.workload }} was OOMKilled", "{{ $value }} containers of {{ $labels.namespace }}/{{ $labels.workload }} ran out of memory.").
+		ResourceHeaderLabels(types.ConditionKeyNamespace, types.ConditionKeyWorkload)
+	return c.build(b)
+}
+
+// CrashLoopBackOff fires when a container has been waiting in
+// CrashLoopBackOff for the pending period, five minutes by default.
+func CrashLoopBackOff(opts ...Option) (*models.CreateMonitorRequest, error) {
+	c := newConfig("Container in CrashLoopBackOff", monitor.SeverityError, 5*time.Minute, 5*time.Minute, opts)
+	matchers := append(c.promMatchers(false), matcher(types.ConditionKeyReason, types.MatchEqual, types.ConditionValueCrashLoopBackOff))
+	if c.workload != "" {
+		// The metric has no workload label; pods are named after their workload.
+		matchers = append(matchers, matcher("pod", types.MatchRegexp, regexp.QuoteMeta(c.workload)+"-.*"))
+	}
+	expr := fmt.Sprintf("max %s (%s)", groupBy(types.ConditionKeyCluster, types.ConditionKeyNamespace, "pod", "container"), selector(MetricContainerWaiting, matchers...))
+	b := monitor.New(c.scopeTitle(c.cluster, c.namespace, c.workload)).
+		PromQL("waiting", expr).
+		Threshold("crash_looping", "waiting", monitor.GreaterThan(0)).
+		Display("{{ $labels.pod }} is in CrashLoopBackOff", "Container {{ $labels.container }} of {{ $labels.namespace }}/{{ $labels.pod }} keeps crashing.").
+		ResourceHeaderLabels(types.ConditionKeyNamespace, "pod", "container")
+	return c.build(b)
+}
+
+// HighErrorRate fires when the share of requests a workload answers with a 5xx
+// status exceeds ratio, for example 0.05 for 5%, over the window.
+func HighErrorRate(ratio float64, opts ...Option) (*models.CreateMonitorRequest, error) {
+	c := newConfig("High 5xx rate", monitor.SeverityError, 5*time.Minute, 5*time.Minute, opts)
+	window := promDuration(c.window)
+	all := c.promMatchers(true)
+	errs := append(append([]string(nil), all...), matcher(labelStatusCode, types.MatchRegexp, "5.."))
+	expr := fmt.Sprintf("sum %[1]s (rate(%[2]s[%[4]s])) / sum %[1]s (rate(%[3]s[%[4]s]))",
+		groupBy(workloadLabels...), selector(MetricWorkloadRequests, errs...), selector(MetricWorkloadRequests, all...), window)
+	b := monitor.New(c.scopeTitle(c.cluster, c.namespace, c.workload)).
+		PromQL("error_ratio", expr).
+		Threshold("high_error_rate", "error_ratio", monitor.GreaterThan(ratio)).
+		Display("High 5xx rate on {{ $labels.workload_name }}", "{{ humanizePercentage $value }} of the requests to {{ $labels.namespace }}/{{ $labels.workload_name }} failed.").
+		ResourceHeaderLabels(types.ConditionKeyNamespace, labelWorkload)
+	return c.build(b)
+}
+
+// LatencyRegression fires when the p99 latency of a workload, averaged over
+// the window, is more than factor times what it was a day earlier. Use
+// Customize to compare against a different baseline.
+func LatencyRegression(factor float64, opts ...Option) (*models.CreateMonitorRequest, error) {
+	c := newConfig("p99 latency regression", monitor.SeverityWarning, 10*time.Minute, 10*time.Minute, opts)
+	matchers := append(c.promMatchers(true), matcher(labelQuantile, types.MatchEqual, "0.99"))
+	p99 := fmt.Sprintf("max %s (avg_over_time(%s[%s]%%s))", groupBy(workloadLabels...), selector(MetricWorkloadLatency, matchers...), promDuration(c.window))
+	b := monitor.New(c.scopeTitle(c.cluster, c.namespace, c.workload)).
+		PromQL("p99", fmt.Sprintf(p99, "")).
+		PromQL("p99_baseline", fmt.Sprintf(p99, " offset 1d")).
+		Math("p99_ratio", "$p99 / $p99_baseline").
+		Threshold("latency_regression", "p99_ratio", monitor.GreaterThan(factor)).
+		Display("p99 latency of {{ $labels.workload_name }} regressed", "The p99 latency of {{ $labels.namespace }}/{{ $labels.workload_name }} is {{ $value }} times yesterday's.").
+		ResourceHeaderLabels(types.ConditionKeyNamespace, labelWorkload)
+	return c.build(b)
+}
+
+// LogErrorSpike fires when a workload writes at least count error logs within
+// the window, five minutes by default.
+func LogErrorSpike(count float64, opts ...Option) (*models.CreateMonitorRequest, error) {
+	c := newConfig("Error log spike", monitor.SeverityWarning, 0, 5*time.Minute, opts)
+	conditions := c.conditions(utils.NewConditionSet().Add(types.ConditionKeyLevel, types.ConditionValueLevelError)).Build()
+	b := monitor.New(c.scopeTitle(c.cluster, c.namespace, c.workload)).
+		Logs("error_logs", countPipeline(types.ConditionKeyNamespace, types.ConditionKeyWorkload),
+			monitor.WithConditions(conditions...), monitor.WithInstantRollup(rollupWindow(c.window))).
+		Threshold("error_spike", "error_logs", monitor.GreaterOrEqual(count)).
+		Display("Error logs spiking in {{ $labels.workload }}", "{{ $labels.namespace }}/{{ $labels.workload }} wrote {{ $value }} error logs.").
+		ResourceHeaderLabels(types.ConditionKeyNamespace, types.ConditionKeyWorkload)
+	return c.build(b)
+}
+
+// Node pressure conditions reported by the kubelet.
+const (
+	NodeMemoryPressure = "MemoryPressure"
+	NodeDiskPressure   = "DiskPressure"
+	NodePIDPressure    = "PIDPressure"
+)
+
+// NodePressure fires when a node reports one of the given pressure
+// conditions, all of them by default, for the pending period.
+func NodePressure(conditions []string, opts ...Option) (*models.CreateMonitorRequest, error) {
+	c := newConfig("Node under pressure", monitor.SeverityWarning, 5*time.Minute, 5*time.Minute, opts)
+	if len(conditions) == 0 {
+		conditions = []string{NodeMemoryPressure, NodeDiskPressure, NodePIDPressure}
+	}
+	conditions = append([]string(nil), conditions...)
+	sort.Strings(conditions)
+	// Nodes belong to no namespace or workload, so only the cluster applies.
+	var matchers []string
+	if c.cluster != "" {
+		matchers = append(matchers, matcher(types.ConditionKeyCluster, types.MatchEqual, c.cluster))
+	}
+	matchers = append(matchers,
+		matcher("condition", types.MatchRegexp, strings.Join(conditions, "|")),
+		matcher("status", types.MatchEqual, "true"))
+	expr := fmt.Sprintf("max %s (%s)", groupBy(types.ConditionKeyCluster, "node", "condition"), selector(MetricNodeCondition, matchers...))
+	b := monitor.New(c.scopeTitle(c.cluster)).
+		PromQL("node_condition", expr).
+		Threshold("under_pressure", "node_condition", monitor.GreaterThan(0)).
+		Display("{{ $labels.node }} reports {{ $labels.condition }}", "Node {{ $labels.node }} has reported {{ $labels.condition }}.").
+		ResourceHeaderLabels("node", "condition")
+	return c.build(b)
+}
+
+// PVCNearFull fires when a persistent volume claim is fuller than ratio, for
+// example 0.9 for 90%, for the pending period.
+func PVCNearFull(ratio float64, opts ...Option) (*models.CreateMonitorRequest, error) {
+	c := newConfig("PVC almost full", monitor.SeverityWarning, 10*time.Minute, 5*time.Minute, opts)
+	matchers := c.promMatchers(false)
+	by := groupBy(types.ConditionKeyCluster, types.ConditionKeyNamespace, labelPersistentVolumeClaim)
+	expr := fmt.Sprintf("max %[1]s (%[2]s) / max %[1]s (%[3]s)", by, selector(MetricVolumeUsedBytes, matchers...), selector(MetricVolumeCapacityBytes, matchers...))
+	b := monitor.New(c.scopeTitle(c.cluster, c.namespace)).
+		PromQL("pvc_usage", expr).
+		Threshold("almost_full", "pvc_usage", monitor.GreaterThan(ratio)).
+		Display("PVC {{ $labels.persistentvolumeclaim }} is almost full", "{{ $labels.namespace }}/{{ $labels.persistentvolumeclaim }} is {{ humanizePercentage $value }} full.").
+		ResourceHeaderLabels(types.ConditionKeyNamespace, labelPersistentVolumeClaim)
+	return c.build(b)
+}
@@ -0,0 +1,89 @@
+package templates
+
+import (
+	"testing"
+	"time"
+
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/monitor"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplatesValidate(t *testing.T) {
+	scope := []Option{WithCluster("prod"), WithNamespace("shop"), WithWorkload("checkout"), WithRouting("oncall")}
+	builders := map[string]func(opts ...Option) (*models.CreateMonitorRequest, error){
+		"oom":     OOMKilled,
+		"crash":   CrashLoopBackOff,
+		"errors":  func(opts ...Option) (*models.CreateMonitorRequest, error) { return HighErrorRate(0.05, opts...) },
+		"latency": func(opts ...Option) (*models.CreateMonitorRequest, error) { return LatencyRegression(1.5, opts...) },
+		"logs":    func(opts ...Option) (*models.CreateMonitorRequest, error) { return LogErrorSpike(100, opts...) },
+		"nodes":   func(opts ...Option) (*models.CreateMonitorRequest, error) { return NodePressure(nil, opts...) },
+		"pvc":     func(opts ...Option) (*models.CreateMonitorRequest, error) { return PVCNearFull(0.9, opts...) },
+	}
+	for name, build := range builders {
+		for _, opts := range [][]Option{nil, scope} {
+			req, err := build(opts...)
+			require.NoError(t, err, name)
+			require.Empty(t, monitor.ValidateMonitor(req), name)
+			if opts != nil {
+				require.Equal(t, []string{"oncall"}, req.Routing, name)
+				require.Equal(t, monitor.NotifyNotificationRoutes, req.NotificationSettings.Method, name)
+			}
+		}
+	}
+}
+
+func TestHighErrorRateScope(t *testing.T) {
+	req, err := HighErrorRate(0.05, WithNamespace("shop"), WithWorkload("checkout"), WithWindow(10*time.Minute),
+		WithSeverity(monitor.SeverityCritical), WithLabels(map[string]string{"team": "payments"}))
+	require.NoError(t, err)
+	require.Equal(t, "High 5xx rate (shop/checkout)", *req.Title)
+	require.Equal(t, monitor.SeverityCritical, req.Severity)
+	require.Equal(t, map[string]string{"team": "payments"}, req.Labels)
+	require.Equal(t,
+		`sum by (cluster, namespace, workload_name) (rate(groundcover_workload_total_counter{namespace="shop", workload_name="checkout", status_code=~"5.."}[10m])) / `+
+			`sum by (cluster, namespace, workload_name) (rate(groundcover_workload_total_counter{namespace="shop", workload_name="checkout"}[10m]))`,
+		req.Model.Queries[0].Expression)
+	require.Equal(t, []float64{0.05}, req.Model.Thresholds[0].Values)
+}
+
+func TestOOMKilledUsesEventConditions(t *testing.T) {
+	req, err := OOMKilled(WithNamespace("shop"), WithTitle("OOM"))
+	require.NoError(t, err)
+	require.Equal(t, "OOM (shop)", *req.Title)
+
+	q := req.Model.Queries[0]
+	require.Equal(t, monitor.DataTypeEvents, q.DataType)
+	require.Equal(t, "5 minutes", q.InstantRollup)
+	keys := map[string]interface{}{}
+	for _, c := range q.Conditions {
+		keys[c.Key] = c.Filters[0].Value
+	}
+	require.Equal(t, map[string]interface{}{
+		types.ConditionKeyReason:    types.ConditionValueOOMKilled,
+		types.ConditionKeyType:      types.ConditionValueTypeContainerCrash,
+		types.ConditionKeyNamespace: "shop",
+	}, keys)
+}
+
+func TestLatencyRegressionComparesWithBaseline(t *testing.T) {
+	req, err := LatencyRegression(2, WithWorkload("api"), Customize(func(b *monitor.Builder) {
+		b.Annotation("runbook", "https://runbooks/latency")
+	}))
+	require.NoError(t, err)
+	require.Len(t, req.Model.Queries, 2)
+	require.Contains(t, req.Model.Queries[1].Expression, `[10m] offset 1d))`)
+	require.Equal(t, "$p99 / $p99_baseline", req.Model.Reducers[0].Expression)
+	require.Equal(t, "https://runbooks/latency", req.Annotations["runbook"])
+	require.Equal(t, 10*time.Minute, time.Duration(*req.EvaluationInterval.PendingFor))
+}
+
+func TestNodePressureIgnoresNamespace(t *testing.T) {
+	req, err := NodePressure([]string{NodePIDPressure, NodeDiskPressure}, WithCluster("prod"), WithNamespace("shop"))
+	require.NoError(t, err)
+	require.Equal(t, "Node under pressure (prod)", *req.Title)
+	require.Equal(t,
+		`max by (cluster, node, condition) (groundcover_kube_node_status_condition{cluster="prod", condition=~"DiskPressure|PIDPressure", status="true"})`,
+		req.Model.Queries[0].Expression)
+}
@@ -0,0 +1,323 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/strfmt"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/client"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/client/monitors"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+	"gopkg.in/yaml.v2"
+)
+
+// Monitor is a monitor definition decoded from the YAML the API returns for
+// GET /api/monitors/{id}.
+//
+// The embedded CreateMonitorRequest carries every field the SDK models know
+// about and can be modified in place. Fields the models do not know about are
+// kept aside and written back by MarshalYAML and UpdateMonitorTyped, so a
+// read-modify-write cycle does not drop settings added to the API after this
+// SDK was generated.
+type Monitor struct {
+	*models.CreateMonitorRequest
+
+	// ID is the monitor UUID. It is set by GetMonitorTyped.
+	ID string
+
+	unknown yaml.MapSlice
+}
+
+// wholeValue marks an unknown field whose entire value must be preserved, as
+// opposed to a known field that only contains unknown nested fields.
+type wholeValue struct {
+	value interface{}
+}
+
+// itemFields holds the unknown fields of a list item, identified by its name
+// when it has one and by its position otherwise.
+type itemFields struct {
+	id     string
+	fields interface{}
+}
+
+// ParseMonitor decodes a monitor definition from YAML.
+func ParseMonitor(data []byte) (*Monitor, error) {
+	req := &models.CreateMonitorRequest{}
+	if err := yaml.Unmarshal(data, req); err != nil {
+		return nil, fmt.Errorf("failed to decode monitor: %w", err)
+	}
+
+	var raw yaml.MapSlice
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to decode monitor: %w", err)
+	}
+
+	m := &Monitor{CreateMonitorRequest: req}
+	if unknown, ok := unknownFields(raw, reflect.TypeOf(req)).(yaml.MapSlice); ok {
+		m.unknown = unknown
+	}
+	return m, nil
+}
+
+// GetMonitorTyped fetches a monitor and decodes it into a Monitor.
+func GetMonitorTyped(ctx context.Context, api *client.GroundcoverAPI, id string) (*Monitor, error) {
+	resp, err := api.Monitors.GetMonitor(monitors.NewGetMonitorParams().WithContext(ctx).WithID(id), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get monitor %s: %w", id, err)
+	}
+	m, err := ParseMonitor(resp.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse monitor %s: %w", id, err)
+	}
+	m.ID = id
+	return m, nil
+}
+
+// CreateMonitorTyped creates a monitor from a Monitor, including the fields
+// the SDK models do not know about, and sets its ID.
+func CreateMonitorTyped(ctx context.Context, api *client.GroundcoverAPI, m *Monitor) error {
+	body, err := yaml.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("failed to encode monitor: %w", err)
+	}
+	params := monitors.NewCreateMonitorParams().WithContext(ctx).WithBody(m.CreateMonitorRequest)
+	resp, err := api.Monitors.CreateMonitor(params, nil, withRawBody(body), monitors.WithContentTypeApplicationxYaml, monitors.WithAcceptApplicationJSON)
+	if err != nil {
+		return fmt.Errorf("failed to create monitor: %w", err)
+	}
+	m.ID = resp.Payload.MonitorID
+	return nil
+}
+
+// UpdateMonitorTyped writes a Monitor back, including the fields the SDK
+// models do not know about.
+func UpdateMonitorTyped(ctx context.Context, api *client.GroundcoverAPI, m *Monitor) error {
+	if m.ID == "" {
+		return fmt.Errorf("monitor has no ID")
+	}
+	body, err := yaml.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("failed to encode monitor %s: %w", m.ID, err)
+	}
+	params := monitors.NewUpdateMonitorParams().WithContext(ctx).WithID(m.ID).WithBody(m.UpdateRequest())
+	if _, err := api.Monitors.UpdateMonitor(params, nil, withRawBody(body), monitors.WithContentTypeApplicationxYaml, monitors.WithAcceptApplicationJSON); err != nil {
+		return fmt.Errorf("failed to update monitor %s: %w", m.ID, err)
+	}
+	return nil
+}
+
+// UpdateRequest returns the monitor as an update request. Fields the SDK
+// models do not know about are not part of the result; use MarshalYAML or
+// UpdateMonitorTyped to keep them.
+func (m *Monitor) UpdateRequest() *models.UpdateMonitorRequest {
+	return ToUpdateRequest(m.CreateMonitorRequest)
+}
+
+// UnknownFields returns the paths of the fields the SDK models do not know
+// about, for example "model.queries[cpu].newSetting".
+func (m *Monitor) UnknownFields() []string {
+	var paths []string
+	collectPaths("", m.unknown, &paths)
+	sort.Strings(paths)
+	return paths
+}
+
+// MarshalYAML implements yaml.Marshaler. It encodes the monitor as an update
+// request merged with the fields the SDK models do not know about.
+func (m *Monitor) MarshalYAML() (interface{}, error) {
+	known, err := toMapSlice(m.UpdateRequest())
+	if err != nil {
+		return nil, err
+	}
+	return mergeUnknown(known, m.unknown), nil
+}
+
+// withRawBody replaces the request body with a pre-encoded document.
+func withRawBody(body []byte) monitors.ClientOption {
+	return func(op *runtime.ClientOperation) {
+		params := op.Params
+		op.Params = runtime.ClientRequestWriterFunc(func(r runtime.ClientRequest, reg strfmt.Registry) error {
+			if err := params.WriteToRequest(r, reg); err != nil {
+				return err
+			}
+			return r.SetBodyParam(bytes.NewReader(body))
+		})
+	}
+}
+
+func toMapSlice(v interface{}) (yaml.MapSlice, error) {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode monitor: %w", err)
+	}
+	var out yaml.MapSlice
+	if err := yaml.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("failed to encode monitor: %w", err)
+	}
+	return out, nil
+}
+
+// unknownFields returns the parts of raw that have no corresponding field in
+// the model type t, or nil if there are none.
+func unknownFields(raw interface{}, t reflect.Type) interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch raw := raw.(type) {
+	case yaml.MapSlice:
+		if t.Kind() != reflect.Struct {
+			return nil
+		}
+		fields := yamlFields(t)
+		var out yaml.MapSlice
+		for _, item := range raw {
+			key, _ := item.Key.(string)
+			fieldType, found := fields[key]
+			if !found {
+				out = append(out, yaml.MapItem{Key: item.Key, Value: wholeValue{item.Value}})
+				continue
+			}
+			if nested := unknownFields(item.Value, fieldType); nested != nil {
+				out = append(out, yaml.MapItem{Key: item.Key, Value: nested})
+			}
+		}
+		if len(out) == 0 {
+			return nil
+		}
+		return out
+	case []interface{}:
+		if t.Kind() != reflect.Slice {
+			return nil
+		}
+		var out []itemFields
+		for i, item := range raw {
+			if nested := unknownFields(item, t.Elem()); nested != nil {
+				out = append(out, itemFields{id: itemID(item, i), fields: nested})
+			}
+		}
+		if len(out) == 0 {
+			return nil
+		}
+		return out
+	}
+	return nil
+}
+
+// yamlFields maps the YAML keys of a struct type to their field types,
+// following the naming rules of gopkg.in/yaml.v2.
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if strings.Contains(opts, "inline") {
+			for k, v := range yamlFields(f.Type) {
+				fields[k] = v
+			}
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+// mergeUnknown adds unknown fields back into an encoded model. Nested unknown
+// fields are only restored while their parent field is still set.
+func mergeUnknown(known interface{}, unknown interface{}) interface{} {
+	switch unknown := unknown.(type) {
+	case yaml.MapSlice:
+		knownMap, ok := known.(yaml.MapSlice)
+		if !ok {
+			return known
+		}
+		out := append(yaml.MapSlice(nil), knownMap...)
+		for _, item := range unknown {
+			if whole, ok := item.Value.(wholeValue); ok {
+				if _, found := lookup(out, item.Key); !found {
+					out = append(out, yaml.MapItem{Key: item.Key, Value: whole.value})
+				}
+				continue
+			}
+			for i := range out {
+				if out[i].Key == item.Key {
+					out[i].Value = mergeUnknown(out[i].Value, item.Value)
+				}
+			}
+		}
+		return out
+	case []itemFields:
+		knownList, ok := known.([]interface{})
+		if !ok {
+			return known
+		}
+		out := append([]interface{}(nil), knownList...)
+		for i, item := range out {
+			id := itemID(item, i)
+			for _, fields := range unknown {
+				if fields.id == id {
+					out[i] = mergeUnknown(item, fields.fields)
+				}
+			}
+		}
+		return out
+	}
+	return known
+}
+
+func collectPaths(prefix string, unknown interface{}, paths *[]string) {
+	switch unknown := unknown.(type) {
+	case yaml.MapSlice:
+		for _, item := range unknown {
+			path := fmt.Sprint(item.Key)
+			if prefix != "" {
+				path = prefix + "." + path
+			}
+			if _, ok := item.Value.(wholeValue); ok {
+				*paths = append(*paths, path)
+				continue
+			}
+			collectPaths(path, item.Value, paths)
+		}
+	case []itemFields:
+		for _, item := range unknown {
+			collectPaths(prefix+"["+item.id+"]", item.fields, paths)
+		}
+	}
+}
+
+// itemID identifies a list item by its name, falling back to its position.
+func itemID(item interface{}, index int) string {
+	if m, ok := item.(yaml.MapSlice); ok {
+		if name, found := lookup(m, "name"); found {
+			if s, ok := name.(string); ok && s != "" {
+				return s
+			}
+		}
+	}
+	return strconv.Itoa(index)
+}
+
+func lookup(m yaml.MapSlice, key interface{}) (interface{}, bool) {
+	for _, item := range m {
+		if item.Key == key {
+			return item.Value, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,126 @@
+package monitor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/groundcover-com/groundcover-sdk-go/internal/testutil"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+const fetchedMonitor = `
+title: Pods not running
+severity: critical
+futureTopLevel:
+  enabled: true
+display:
+  header: Pod {{ .Labels.pod }}
+  resourceHeaderLabels: [namespace, pod]
+  contextHeaderLabels: [cluster]
+  futureDisplayOption: compact
+model:
+  queries:
+    - name: pods
+      dataType: metrics
+      expression: up
+      futureQueryOption: 3
+  thresholds:
+    - name: stuck
+      inputName: pods
+      operator: gt
+      values: [0]
+evaluationInterval:
+  interval: 1m
+  pendingFor: 1d
+autoResolve: false
+`
+
+func TestParseMonitorKeepsUnknownFields(t *testing.T) {
+	m, err := ParseMonitor([]byte(fetchedMonitor))
+	require.NoError(t, err)
+
+	require.Equal(t, "Pods not running", *m.Title)
+	require.Equal(t, "up", m.Model.Queries[0].Expression)
+	require.Equal(t, 24*time.Hour, time.Duration(*m.EvaluationInterval.PendingFor))
+	require.Equal(t, []string{
+		"display.futureDisplayOption",
+		"futureTopLevel",
+		"model.queries[pods].futureQueryOption",
+	}, m.UnknownFields())
+
+	m.Severity = SeverityWarning
+	m.Model.Queries[0].Expression = "up == 0"
+	out, err := yaml.Marshal(m)
+	require.NoError(t, err)
+
+	var doc map[string]interface{}
+	require.NoError(t, yaml.Unmarshal(out, &doc))
+	require.Equal(t, "warning", doc["severity"])
+	require.Equal(t, map[interface{}]interface{}{"enabled": true}, doc["futureTopLevel"])
+	display := doc["display"].(map[interface{}]interface{})
+	require.Equal(t, "compact", display["futureDisplayOption"])
+	query := doc["model"].(map[interface{}]interface{})["queries"].([]interface{})[0].(map[interface{}]interface{})
+	require.Equal(t, "up == 0", query["expression"])
+	require.Equal(t, 3, query["futureQueryOption"])
+
+	reparsed, err := ParseMonitor(out)
+	require.NoError(t, err)
+	require.Equal(t, m.UnknownFields(), reparsed.UnknownFields())
+	want, err := yaml.Marshal(m.UpdateRequest())
+	require.NoError(t, err)
+	got, err := yaml.Marshal(reparsed.UpdateRequest())
+	require.NoError(t, err)
+	require.Equal(t, string(want), string(got))
+}
+
+func TestMonitorDropsUnknownFieldsOfClearedParents(t *testing.T) {
+	m, err := ParseMonitor([]byte(fetchedMonitor))
+	require.NoError(t, err)
+	m.Display = nil
+
+	out, err := yaml.Marshal(m)
+	require.NoError(t, err)
+	require.NotContains(t, string(out), "futureDisplayOption")
+	require.Contains(t, string(out), "futureTopLevel")
+}
+
+func TestGetAndUpdateMonitorTyped(t *testing.T) {
+	var updated []byte
+	var contentType string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/monitors/m-1", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			_, _ = io.WriteString(w, fetchedMonitor)
+		case http.MethodPut:
+			contentType = r.Header.Get("Content-Type")
+			updated, _ = io.ReadAll(r.Body)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusAccepted)
+			_, _ = io.WriteString(w, `{}`)
+		}
+	})
+	api := testutil.NewAPI(t, mux)
+
+	ctx := context.Background()
+	m, err := GetMonitorTyped(ctx, api, "m-1")
+	require.NoError(t, err)
+	require.Equal(t, "m-1", m.ID)
+	require.Equal(t, SeverityCritical, m.Severity)
+
+	m.Severity = SeverityInfo
+	require.NoError(t, UpdateMonitorTyped(ctx, api, m))
+	require.Equal(t, "application/x-yaml", contentType)
+
+	sent, err := ParseMonitor(updated)
+	require.NoError(t, err)
+	require.Equal(t, SeverityInfo, sent.Severity)
+	require.Equal(t, m.UnknownFields(), sent.UnknownFields())
+
+	m.ID = ""
+	require.Error(t, UpdateMonitorTyped(ctx, api, m))
+}
@@ -0,0 +1,321 @@
+package monitor
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+)
+
+var (
+	defaultSeverities = []string{SeverityCritical, SeverityError, SeverityWarning, SeverityInfo}
+
+	noDataStates         = []string{StateOK, StateNoData, StateAlerting}
+	executionErrorStates = []string{StateOK, StateError, StateAlerting}
+	measurementTypes     = []string{MeasurementState, MeasurementEvent}
+	notificationMethods  = []string{NotifyNotificationRoutes, NotifyConnectedApps, NotifyNone}
+	dataTypes            = []string{DataTypeMetrics, DataTypeLogs, DataTypeTraces, DataTypeEvents}
+	queryTypes           = []string{QueryTypeInstant, QueryTypeRange}
+)
+
+// ValidateOption configures ValidateMonitor.
+type ValidateOption func(*validateConfig)
+
+type validateConfig struct {
+	severities   []string
+	sampleLabels map[string]string
+}
+
+// WithSeverities replaces the severities ValidateMonitor accepts. The default
+// set is SeverityCritical, SeverityError, SeverityWarning and SeverityInfo.
+func WithSeverities(severities ...string) ValidateOption {
+	return func(c *validateConfig) {
+		c.severities = severities
+	}
+}
+
+// WithSampleLabels sets the labels display and annotation templates are
+// rendered with. By default every label the monitor names in its display
+// header labels and labels is given a placeholder value.
+func WithSampleLabels(labels map[string]string) ValidateOption {
+	return func(c *validateConfig) {
+		c.sampleLabels = labels
+	}
+}
+
+// ValidateMonitor checks a monitor definition locally, beyond the schema
+// checks of the generated Validate method. On top of the reference checks
+// Builder.Build performs, it verifies that:
+//
+//   - noDataState, executionErrorState, severity, measurementType and the
+//     notification method are in their allowed sets;
+//   - rollup windows cover at least one evaluation interval and the pending
+//     period is not negative;
+//   - PromQL expressions pass CheckPromQL;
+//   - display and annotation templates parse and render with sample labels.
+//
+// All problems are returned, each with the path of the offending field. The
+// result is empty when the definition is valid.
+func ValidateMonitor(req *models.CreateMonitorRequest, opts ...ValidateOption) Problems {
+	cfg := &validateConfig{severities: defaultSeverities}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	var problems Problems
+	add := func(path, format string, args ...interface{}) {
+		problems = append(problems, &Problem{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if req == nil {
+		add("", "monitor must not be nil")
+		return problems
+	}
+	if req.Title == nil || *req.Title == "" {
+		add("title", "title is required")
+	}
+
+	checkEnum := func(path, value string, allowed []string) {
+		if value != "" && !contains(allowed, value) {
+			add(path, "%q is not one of %s", value, strings.Join(allowed, ", "))
+		}
+	}
+	checkEnum("noDataState", req.NoDataState, noDataStates)
+	checkEnum("executionErrorState", req.ExecutionErrorState, executionErrorStates)
+	checkEnum("severity", req.Severity, cfg.severities)
+	checkEnum("measurementType", req.MeasurementType, measurementTypes)
+	if ns := req.NotificationSettings; ns != nil {
+		checkEnum("notificationSettings.method", ns.Method, notificationMethods)
+		if interval, ok := ns.RenotificationInterval.(string); ok && interval != "" {
+			var d models.Duration
+			if err := d.UnmarshalText([]byte(interval)); err != nil {
+				add("notificationSettings.renotificationInterval", "invalid duration %q", interval)
+			}
+		}
+	}
+
+	problems = append(problems, modelProblems(req.Model)...)
+
+	var interval time.Duration
+	if ei := req.EvaluationInterval; ei != nil {
+		interval = time.Duration(ei.Interval)
+		if interval < 0 {
+			add("evaluationInterval.interval", "interval must not be negative")
+		}
+		if ei.PendingFor != nil && time.Duration(*ei.PendingFor) < 0 {
+			add("evaluationInterval.pendingFor", "pending period must not be negative")
+		}
+	}
+
+	if req.Model != nil {
+		for i, q := range req.Model.Queries {
+			if q == nil {
+				continue
+			}
+			path := fmt.Sprintf("model.queries[%d]", i)
+			checkEnum(path+".dataType", q.DataType, dataTypes)
+			checkEnum(path+".queryType", q.QueryType, queryTypes)
+			if q.Expression == "" && q.Pipeline == nil && q.SQLPipeline == nil {
+				add(path, "query requires an expression, pipeline or sqlPipeline")
+			}
+			if q.Expression != "" && (q.DataType == DataTypeMetrics || q.DatasourceType == DatasourcePrometheus) {
+				if err := CheckPromQL(q.Expression); err != nil {
+					add(path+".expression", "invalid PromQL: %v", err)
+				}
+			}
+			if q.Rollup != nil {
+				window := time.Duration(q.Rollup.Time)
+				switch {
+				case window <= 0:
+					add(path+".rollup.time", "rollup window must be positive")
+				case interval > 0 && window < interval:
+					add(path+".rollup.time", "rollup window %s is shorter than the evaluation interval %s, so data between evaluations is never checked", window, interval)
+				}
+			}
+			if rt := q.RelativeTimerange; rt != nil && (rt.From != 0 || rt.To != 0) && rt.From >= rt.To {
+				add(path+".relativeTimerange", "from %s must be further in the past than to %s", time.Duration(rt.From), time.Duration(rt.To))
+			}
+		}
+	}
+
+	data := templateData(req, cfg.sampleLabels)
+	language := ""
+	if d := req.Display; d != nil {
+		language = d.TemplateLanguage
+		if language != TemplateGo && language != TemplateJinja2 {
+			add("display.templateLanguage", "%q is not one of go (empty), %s", language, TemplateJinja2)
+		} else {
+			if err := checkTemplate(language, d.Header, data); err != nil {
+				add("display.header", "%v", err)
+			}
+			if err := checkTemplate(language, d.Description, data); err != nil {
+				add("display.description", "%v", err)
+			}
+		}
+	}
+	if language == TemplateGo || language == TemplateJinja2 {
+		for _, key := range sortedKeys(req.Annotations) {
+			if err := checkTemplate(language, req.Annotations[key], data); err != nil {
+				add("annotations."+key, "%v", err)
+			}
+		}
+	}
+	return problems
+}
+
+// ValidateUpdateMonitor is like ValidateMonitor for update requests.
+func ValidateUpdateMonitor(req *models.UpdateMonitorRequest, opts ...ValidateOption) Problems {
+	if req == nil {
+		return Problems{{Message: "monitor must not be nil"}}
+	}
+	return ValidateMonitor(ToCreateRequest(req), opts...)
+}
+
+// templateData returns the data display templates are rendered with. Labels
+// named by the monitor get placeholder values unless sample labels are given.
+func templateData(req *models.CreateMonitorRequest, sample map[string]string) map[string]interface{} {
+	labels := map[string]string{}
+	if sample == nil {
+		for k := range req.Labels {
+			labels[k] = "sample-" + k
+		}
+		if d := req.Display; d != nil {
+			for _, k := range append(append([]string(nil), d.ResourceHeaderLabels...), d.ContextHeaderLabels...) {
+				labels[k] = "sample-" + k
+			}
+		}
+	} else {
+		for k, v := range sample {
+			labels[k] = v
+		}
+	}
+
+	values := map[string]float64{}
+	if req.Model != nil {
+		for _, q := range req.Model.Queries {
+			if q != nil && q.Name != "" {
+				values[q.Name] = 1
+			}
+		}
+		for _, r := range req.Model.Reducers {
+			if r != nil && r.Name != nil {
+				values[*r.Name] = 1
+			}
+		}
+	}
+	return map[string]interface{}{
+		"Labels": labels,
+		"Values": values,
+		"Value":  1.0,
+	}
+}
+
+// templateFuncs stubs the helper functions available to monitor templates so
+// that templates using them parse.
+var templateFuncs = template.FuncMap{
+	"title":              stubString,
+	"toUpper":            strings.ToUpper,
+	"toLower":            strings.ToLower,
+	"trimSpace":          strings.TrimSpace,
+	"match":              regexp.MatchString,
+	"reReplaceAll":       func(pattern, repl, text string) string { return text },
+	"humanize":           stubValue,
+	"humanize1024":       stubValue,
+	"humanizeDuration":   stubValue,
+	"humanizePercentage": stubValue,
+}
+
+func stubString(s string) string { return s }
+
+func stubValue(v interface{}) string { return fmt.Sprint(v) }
+
+// checkTemplate parses and renders a display or annotation template. Jinja2
+// templates can only be checked for balanced delimiters and block tags.
+func checkTemplate(language, text string, data map[string]interface{}) error {
+	if text == "" {
+		return nil
+	}
+	if language == TemplateJinja2 {
+		return checkJinja2(text)
+	}
+	// Alertmanager-style $labels and $value references are accepted as well.
+	tmpl, err := template.New("").Funcs(templateFuncs).Parse(`{{ $labels := .Labels }}{{ $value := .Value }}{{ $values := .Values }}` + text)
+	if err != nil {
+		return fmt.Errorf("invalid template: %w", err)
+	}
+	if err := tmpl.Execute(io.Discard, data); err != nil {
+		return fmt.Errorf("template does not render: %w", err)
+	}
+	return nil
+}
+
+var jinja2Tag = regexp.MustCompile(`\{%-?\s*(\w+)`)
+
+// jinja2Blocks maps jinja2 block tags to the tag closing them.
+var jinja2Blocks = map[string]string{
+	"if":     "endif",
+	"for":    "endfor",
+	"macro":  "endmacro",
+	"block":  "endblock",
+	"with":   "endwith",
+	"filter": "endfilter",
+	"raw":    "endraw",
+	"set":    "",
+}
+
+func checkJinja2(text string) error {
+	for _, delim := range [][2]string{{"{{", "}}"}, {"{%", "%}"}, {"{#", "#}"}} {
+		if strings.Count(text, delim[0]) != strings.Count(text, delim[1]) {
+			return fmt.Errorf("unbalanced %s %s delimiters", delim[0], delim[1])
+		}
+	}
+
+	var open []string
+	for _, m := range jinja2Tag.FindAllStringSubmatch(text, -1) {
+		tag := m[1]
+		if end, ok := jinja2Blocks[tag]; ok {
+			// Block-form set is only used with an explicit endset; ignore it.
+			if end != "" {
+				open = append(open, end)
+			}
+			continue
+		}
+		if !strings.HasPrefix(tag, "end") {
+			continue
+		}
+		if tag == "endset" {
+			continue
+		}
+		if len(open) == 0 || open[len(open)-1] != tag {
+			return fmt.Errorf("unexpected {%% %s %%}", tag)
+		}
+		open = open[:len(open)-1]
+	}
+	if len(open) > 0 {
+		return fmt.Errorf("missing {%% %s %%}", open[len(open)-1])
+	}
+	return nil
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
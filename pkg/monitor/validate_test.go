@@ -0,0 +1,160 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func validMonitor(t *testing.T) *models.CreateMonitorRequest {
+	t.Helper()
+	req, err := New("Pods not running").
+		PromQL("pods", `sum by (namespace, pod) (kube_pod_status_phase{phase!~"Running|Succeeded"})`, WithRollup("avg", 5*time.Minute)).
+		Last("pods_last", "pods").
+		Threshold("stuck", "pods_last", GreaterThan(0)).
+		Severity(SeverityWarning).
+		EvaluationInterval(time.Minute, 15*time.Minute).
+		NoDataState(StateOK).
+		ExecutionErrorState(StateAlerting).
+		Display("Pod {{ .Labels.namespace }}/{{ .Labels.pod }} is not running", "value is {{ humanize $value }}").
+		ResourceHeaderLabels("namespace", "pod").
+		Annotation("summary", "{{ $labels.pod }} stuck").
+		Build()
+	require.NoError(t, err)
+	return req
+}
+
+func TestValidateMonitorAcceptsValidDefinition(t *testing.T) {
+	problems := ValidateMonitor(validMonitor(t))
+	require.Empty(t, problems)
+	require.NoError(t, problems.Err())
+}
+
+func TestValidateMonitorReportsAllProblems(t *testing.T) {
+	req := validMonitor(t)
+	req.NoDataState = "Pending"
+	req.ExecutionErrorState = "NoData"
+	req.Severity = "S1"
+	req.MeasurementType = "gauge"
+	req.EvaluationInterval.Interval = strfmt.Duration(10 * time.Minute)
+	req.Model.Queries[0].Expression = `sum(rate(http_requests_total{status=~"5.."[5m])`
+	req.Model.Thresholds[0].InputName = swag.String("missing")
+	req.Display.Header = "{{ .Labels.pod "
+	req.Annotations["runbook"] = "{{ nosuchfunc .Labels.pod }}"
+
+	problems := ValidateMonitor(req)
+	paths := map[string]string{}
+	for _, p := range problems {
+		paths[p.Path] = p.Message
+	}
+	require.Contains(t, paths, "noDataState")
+	require.Contains(t, paths, "executionErrorState")
+	require.Contains(t, paths, "severity")
+	require.Contains(t, paths, "measurementType")
+	require.Contains(t, paths, "model.queries[0].expression")
+	require.Contains(t, paths, "model.thresholds[0].inputName")
+	require.Contains(t, paths, "display.header")
+	require.Contains(t, paths, "annotations.runbook")
+	require.Equal(t, "rollup window 5m0s is shorter than the evaluation interval 10m0s, so data between evaluations is never checked", paths["model.queries[0].rollup.time"])
+	require.NotContains(t, paths, "annotations.summary")
+
+	require.Error(t, problems.Err())
+	require.Contains(t, problems.Error(), "severity: \"S1\" is not one of critical, error, warning, info")
+}
+
+func TestValidateMonitorRelativeTimerange(t *testing.T) {
+	build := func(from, to time.Duration) *models.CreateMonitorRequest {
+		req, err := New("Relative window").
+			PromQL("q", "up", WithQueryType(QueryTypeRange), WithRelativeTimerange(from, to)).
+			Last("q_last", "q").
+			Threshold("down", "q_last", LessThan(1)).
+			Build()
+		require.NoError(t, err)
+		return req
+	}
+
+	require.Empty(t, ValidateMonitor(build(-5*time.Minute, -1*time.Minute)))
+	require.Empty(t, ValidateMonitor(build(-5*time.Minute, 0)))
+
+	problems := ValidateMonitor(build(-1*time.Minute, -5*time.Minute))
+	require.Len(t, problems, 1)
+	require.Equal(t, "model.queries[0].relativeTimerange: from -1m0s must be further in the past than to -5m0s", problems[0].Error())
+	require.Len(t, ValidateMonitor(build(0, -time.Minute)), 1)
+}
+
+func TestValidateMonitorOptions(t *testing.T) {
+	req := validMonitor(t)
+	req.Severity = "S1"
+	req.Display.Header = `{{ if eq .Labels.tier "gold" }}VIP{{ end }} {{ .Labels.pod }}`
+
+	require.Empty(t, ValidateMonitor(req, WithSeverities("S1", "S2"), WithSampleLabels(map[string]string{"tier": "gold"})))
+}
+
+func TestValidateMonitorJinja2Templates(t *testing.T) {
+	req := validMonitor(t)
+	req.Display.TemplateLanguage = TemplateJinja2
+	req.Display.Header = "{% if labels.pod %}{{ labels.pod }}{% endif %}"
+	req.Display.Description = "{% for k in labels %}{{ k }}"
+	req.Annotations = map[string]string{"summary": "{{ labels.pod }"}
+
+	problems := ValidateMonitor(req)
+	require.Len(t, problems, 2)
+	require.Equal(t, "display.description: missing {% endfor %}", problems[0].Error())
+	require.Equal(t, "annotations.summary: unbalanced {{ }} delimiters", problems[1].Error())
+}
+
+func TestValidateUpdateMonitor(t *testing.T) {
+	update := ToUpdateRequest(validMonitor(t))
+	require.Empty(t, ValidateUpdateMonitor(update))
+
+	update.NoDataState = "Bogus"
+	require.Len(t, ValidateUpdateMonitor(update), 1)
+}
+
+func TestCheckPromQL(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr string
+	}{
+		{expr: `up`},
+		{expr: `up{job="api", instance!="a",}`},
+		{expr: `sum by (job) (rate(http_requests_total{code=~"5.."}[5m])) / ignoring(code) group_left sum(rate(http_requests_total[5m]))`},
+		{expr: `max_over_time(deriv(rate(x[1m])[5m:1m])[1h:]) > 0.5`},
+		{expr: `histogram_quantile(0.99, sum by (le) (rate(latency_bucket[1d]))) * -1`},
+		{expr: `{__name__=~"node_.*", job='node'} offset 5m`},
+		{expr: `rate(x[5m] @ 1609746000)`},
+		{expr: `1e-3 + 0x1f`},
+		{expr: `foo:bar:rate5m`},
+		{expr: ``, wantErr: "expression is empty"},
+		{expr: `sum(rate(x[5m])`, wantErr: `"(" opened at position 3 is not closed`},
+		{expr: `sum(x))`, wantErr: `unexpected ")"`},
+		{expr: `sum(x]`, wantErr: `unexpected "]"`},
+		{expr: `x{job="a"`, wantErr: "unterminated label matchers"},
+		{expr: `x{job=a}`, wantErr: `expected string value for label "job"`},
+		{expr: `x{job}`, wantErr: `expected label matching operator after "job"`},
+		{expr: `x{job=~"("}`, wantErr: `invalid regular expression for label "job"`},
+		{expr: `x{a="1" b="2"}`, wantErr: `expected "," or "}"`},
+		{expr: `rate(x[5q])`, wantErr: `invalid duration "5q"`},
+		{expr: `rate(x[])`, wantErr: "missing range duration"},
+		{expr: `x{job="a}`, wantErr: "unterminated string"},
+		{expr: `x + `, wantErr: `missing operand after "+"`},
+		{expr: `x * / y`, wantErr: `unexpected operator "/"`},
+		{expr: `x and`, wantErr: `missing operand after "and"`},
+		{expr: `x ; y`, wantErr: `unexpected character ';'`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			err := CheckPromQL(tt.expr)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package monitorsync
+
+import (
+	"github.com/groundcover-com/groundcover-sdk-go/internal/syncplan"
+	"gopkg.in/yaml.v2"
+)
+
+// FieldDiff is a single field that differs between the remote and the desired
+// definition of a monitor. List items are identified by name when they have
+// one, for example "model.thresholds[high].values".
+type FieldDiff = syncplan.FieldDiff
+
+// unorderedLists are the paths of monitor lists whose order has no meaning.
+var unorderedLists = [][]string{
+	{"routing"},
+	{"catalog", "tags"},
+	{"notificationSettings", "connectedApps"},
+	{"notificationSettings", "statusFilters"},
+}
+
+// encode converts a value into a normalized tree using its YAML form, with
+// the unordered lists sorted.
+func encode(v interface{}) (interface{}, error) {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var tree interface{}
+	if err := yaml.Unmarshal(data, &tree); err != nil {
+		return nil, err
+	}
+	tree = syncplan.Normalize(tree)
+	if root, ok := tree.(map[string]interface{}); ok {
+		syncplan.SortLists(root, unorderedLists...)
+	}
+	return tree, nil
+}
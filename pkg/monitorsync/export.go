@@ -0,0 +1,125 @@
+package monitorsync
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/go-openapi/swag"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/monitor"
+	"gopkg.in/yaml.v2"
+)
+
+// ExportedFile is a monitor written by Export.
+type ExportedFile struct {
+	// Path is the file name, relative to the export directory.
+	Path  string
+	ID    string
+	Title string
+	Key   string
+}
+
+// Export fetches every monitor of the backend and writes it to dir as one
+// YAML file named after its title. Monitors without a key get one derived
+// from the file name, so that the directory can be synced back with Plan:
+// the first plan adopts the existing monitors by title and adds their keys.
+//
+// Exported definitions only contain the fields the SDK models know about, with
+// zero values dropped, map keys sorted and unordered lists such as routing
+// sorted, so that exporting an unchanged backend again produces identical
+// files. Existing files in dir with the same names are overwritten; other
+// files are left alone.
+func (s *Syncer) Export(ctx context.Context, dir string) ([]*ExportedFile, error) {
+	fetched, err := s.fetchMonitors(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(fetched, func(i, j int) bool {
+		ti, tj := swag.StringValue(fetched[i].Title), swag.StringValue(fetched[j].Title)
+		if ti != tj {
+			return ti < tj
+		}
+		return fetched[i].ID < fetched[j].ID
+	})
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	used := map[string]bool{}
+	var files []*ExportedFile
+	for _, m := range fetched {
+		name := uniqueName(slugify(swag.StringValue(m.Title)), used)
+		file := &ExportedFile{
+			Path:  name + ".yaml",
+			ID:    m.ID,
+			Title: swag.StringValue(m.Title),
+			Key:   s.remoteKey(m),
+		}
+		if file.Key == "" && !s.byCatalogID {
+			file.Key = name
+			if m.Labels == nil {
+				m.Labels = map[string]string{}
+			}
+			m.Labels[s.labelKey] = name
+		}
+
+		data, err := ExportMonitor(m)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export monitor %s: %w", m.ID, err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, file.Path), data, 0o644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", file.Path, err)
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// ExportMonitor encodes a monitor as a normalized YAML definition. Like
+// MarshalYAML it leaves out the fields of the response that are not part of
+// an update and keeps the fields the SDK models do not know about, so that
+// syncing the definition back does not reset them.
+func ExportMonitor(m *monitor.Monitor) ([]byte, error) {
+	tree, err := encode(m)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(tree)
+}
+
+// slugify turns a title into a file name: lower case letters and digits
+// separated by single dashes.
+func slugify(title string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	if b.Len() == 0 {
+		return "monitor"
+	}
+	return b.String()
+}
+
+func uniqueName(name string, used map[string]bool) string {
+	candidate := name
+	for i := 2; used[candidate]; i++ {
+		candidate = name + "-" + strconv.Itoa(i)
+	}
+	used[candidate] = true
+	return candidate
+}
@@ -0,0 +1,128 @@
+package monitorsync
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/groundcover-com/groundcover-sdk-go/internal/testutil"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/monitor"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportWritesStableFiles(t *testing.T) {
+	fake := newFakeMonitors()
+	fake.add(cpuMonitor + "routing: [b-route, a-route]\ncreatedBy: someone\nisPaused: false\n")
+	fake.add("title: high-cpu\nlabels:\n  sync_id: custom/key\n")
+	fake.add("title: '!!!'\n")
+	api := testutil.NewAPI(t, fake)
+	syncer := New(api)
+
+	dir := t.TempDir()
+	files, err := syncer.Export(context.Background(), dir)
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+
+	require.Equal(t, "monitor.yaml", files[0].Path)
+	require.Equal(t, "high-cpu.yaml", files[1].Path)
+	require.Equal(t, "High CPU", files[1].Title)
+	require.Equal(t, "high-cpu", files[1].Key)
+	require.Equal(t, "high-cpu-2.yaml", files[2].Path)
+	require.Equal(t, "custom/key", files[2].Key)
+
+	data, err := os.ReadFile(filepath.Join(dir, "high-cpu.yaml"))
+	require.NoError(t, err)
+	require.Equal(t, `createdBy: someone
+labels:
+  sync_id: high-cpu
+model:
+  queries:
+  - dataType: metrics
+    expression: avg(rate(cpu[5m]))
+    name: cpu
+  thresholds:
+  - inputName: cpu
+    name: high
+    operator: gt
+    values:
+    - 0.9
+routing:
+- a-route
+- b-route
+severity: critical
+title: High CPU
+`, string(data))
+
+	_, err = syncer.Export(context.Background(), dir)
+	require.NoError(t, err)
+	again, err := os.ReadFile(filepath.Join(dir, "high-cpu.yaml"))
+	require.NoError(t, err)
+	require.Equal(t, string(data), string(again))
+
+	// Syncing the export back adopts the monitors by title and only adds
+	// their keys; afterwards nothing is left to do.
+	defs, err := LoadDir(dir)
+	require.NoError(t, err)
+	plan, err := syncer.Plan(context.Background(), defs)
+	require.NoError(t, err)
+	require.Equal(t, 0, plan.Count(ActionCreate))
+	require.Equal(t, 2, plan.Count(ActionUpdate))
+	for _, c := range plan.Changes {
+		if c.Action == ActionUpdate {
+			require.Equal(t, []FieldDiff{{Path: "labels", New: map[string]interface{}{"sync_id": c.Key}}}, c.Diffs)
+		}
+	}
+	_, err = syncer.Apply(context.Background(), plan)
+	require.NoError(t, err)
+
+	plan, err = syncer.Plan(context.Background(), defs)
+	require.NoError(t, err)
+	require.False(t, plan.HasChanges(), plan.String())
+}
+
+func TestExportKeepsUnknownFields(t *testing.T) {
+	m, err := monitor.ParseMonitor([]byte(cpuMonitor + "newSetting: {mode: strict}\n"))
+	require.NoError(t, err)
+	data, err := ExportMonitor(m)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "newSetting:\n  mode: strict\n")
+
+	def, err := ParseDefinition("cpu.yaml", data)
+	require.NoError(t, err)
+	require.Equal(t, []string{"newSetting"}, def.Monitor.UnknownFields())
+	again, err := ExportMonitor(def.Monitor)
+	require.NoError(t, err)
+	require.Equal(t, string(data), string(again))
+}
+
+func TestPlanWithoutAdoptionCreates(t *testing.T) {
+	fake := newFakeMonitors()
+	fake.add(cpuMonitor)
+	api := testutil.NewAPI(t, fake)
+
+	def, err := ParseDefinition("cpu.yaml", []byte(cpuMonitor))
+	require.NoError(t, err)
+	plan, err := New(api, WithAdoptByTitle(false)).Plan(context.Background(), []*Definition{def})
+	require.NoError(t, err)
+	require.Equal(t, ActionCreate, plan.Changes[0].Action)
+}
+
+func TestPlanDoesNotAdoptAmbiguousTitles(t *testing.T) {
+	fake := newFakeMonitors()
+	fake.add(cpuMonitor)
+	fake.add(cpuMonitor)
+	api := testutil.NewAPI(t, fake)
+
+	def, err := ParseDefinition("cpu.yaml", []byte(cpuMonitor))
+	require.NoError(t, err)
+	plan, err := New(api).Plan(context.Background(), []*Definition{def})
+	require.NoError(t, err)
+	require.Equal(t, ActionCreate, plan.Changes[0].Action)
+}
+
+func TestSlugify(t *testing.T) {
+	require.Equal(t, "high-5xx-rate-on-api", slugify("High 5xx rate on API!"))
+	require.Equal(t, "über-latenz", slugify("  Über  Latenz "))
+	require.Equal(t, "monitor", slugify("***"))
+}
@@ -0,0 +1,40 @@
+package monitorsync
+
+import (
+	"fmt"
+
+	"github.com/groundcover-com/groundcover-sdk-go/internal/syncplan"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/monitor"
+)
+
+// Definition is a monitor loaded from a local YAML file.
+type Definition struct {
+	// Path is the file the definition was loaded from, relative to the loaded
+	// directory.
+	Path    string
+	Monitor *monitor.Monitor
+}
+
+// LoadDir loads every .yaml and .yml file below dir, one monitor per file.
+// Files are returned sorted by path.
+func LoadDir(dir string) ([]*Definition, error) {
+	defs, err := syncplan.LoadDir(dir, []string{".yaml", ".yml"}, ParseDefinition)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load monitors from %s: %w", dir, err)
+	}
+	return defs, nil
+}
+
+// ParseDefinition parses a single monitor definition. The path identifies the
+// definition in errors and plans, and derives its key when the definition
+// does not carry one.
+func ParseDefinition(path string, data []byte) (*Definition, error) {
+	m, err := monitor.ParseMonitor(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if m.Title == nil || *m.Title == "" {
+		return nil, fmt.Errorf("%s: title is required", path)
+	}
+	return &Definition{Path: path, Monitor: m}, nil
+}
@@ -0,0 +1,400 @@
+// Package monitorsync manages groundcover monitors as code: it loads monitor
+// definitions from a directory of YAML files, matches them to the monitors of
+// a backend, and computes and applies a plan that brings the backend in line.
+//
+// Definitions are matched to monitors by a key. By default the key is the
+// value of the DefaultLabelKey label, which Plan adds to every definition that
+// does not set it, derived from the file path. Alternatively MatchByCatalogID
+// uses the catalog ID of each definition. Monitors without a key are not
+// managed and are never deleted; a definition only takes one over when it has
+// the same title, see WithAdoptByTitle.
+package monitorsync
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-openapi/swag"
+	"github.com/groundcover-com/groundcover-sdk-go/internal/syncplan"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/client"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/client/monitors"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/monitor"
+	"gopkg.in/yaml.v2"
+)
+
+// DefaultLabelKey is the monitor label that identifies managed monitors.
+const DefaultLabelKey = "sync_id"
+
+const defaultConcurrency = 4
+
+// Action is what a plan does to a single monitor.
+type Action = syncplan.Action
+
+// Plan actions.
+const (
+	ActionCreate = syncplan.ActionCreate
+	ActionUpdate = syncplan.ActionUpdate
+	ActionDelete = syncplan.ActionDelete
+	ActionNoop   = syncplan.ActionNoop
+)
+
+// Change is the planned action for a single monitor. Its Name is the title
+// of the monitor.
+type Change struct {
+	syncplan.Change
+
+	desired *monitor.Monitor
+}
+
+// Plan is the list of changes that brings a backend in line with a set of
+// definitions, sorted by key.
+type Plan = syncplan.Plan[*Change]
+
+// Result reports what Apply did. In a dry run Applied lists the changes that
+// would have been made.
+type Result = syncplan.Result[*Change]
+
+// ChangeError is the failure to apply a single change.
+type ChangeError = syncplan.ChangeError[*Change]
+
+// Syncer plans and applies monitor changes against a backend.
+type Syncer struct {
+	api          *client.GroundcoverAPI
+	labelKey     string
+	byCatalogID  bool
+	prune        bool
+	dryRun       bool
+	concurrency  int
+	adopt        bool
+	ignoreFields [][]string
+}
+
+// Option configures a Syncer.
+type Option func(*Syncer)
+
+// WithLabelKey sets the label that identifies managed monitors.
+func WithLabelKey(key string) Option {
+	return func(s *Syncer) {
+		s.labelKey = key
+		s.byCatalogID = false
+	}
+}
+
+// MatchByCatalogID matches definitions to monitors by catalog ID instead of a
+// label. Every definition must then set catalog.id.
+func MatchByCatalogID() Option {
+	return func(s *Syncer) {
+		s.byCatalogID = true
+	}
+}
+
+// WithPrune makes plans delete managed monitors that have no local definition.
+func WithPrune(prune bool) Option {
+	return func(s *Syncer) {
+		s.prune = prune
+	}
+}
+
+// WithAdoptByTitle sets whether a definition without a matching managed
+// monitor takes over an unmanaged monitor with the same title, instead of
+// creating a new one. Adoption is enabled by default; the adopting update adds
+// the key to the monitor. A title shared by several unmanaged monitors is
+// never adopted, since the one taken over would depend on list order.
+func WithAdoptByTitle(adopt bool) Option {
+	return func(s *Syncer) {
+		s.adopt = adopt
+	}
+}
+
+// WithDryRun makes Apply report the changes it would make without making them.
+func WithDryRun(dryRun bool) Option {
+	return func(s *Syncer) {
+		s.dryRun = dryRun
+	}
+}
+
+// WithConcurrency sets how many monitors are fetched or changed in parallel.
+func WithConcurrency(n int) Option {
+	return func(s *Syncer) {
+		s.concurrency = n
+	}
+}
+
+// WithIgnoreFields keeps the remote value of the given dotted field paths, for
+// example "isPaused" or "display.description", so that they neither show up
+// in plans nor are overwritten by updates.
+func WithIgnoreFields(paths ...string) Option {
+	return func(s *Syncer) {
+		for _, p := range paths {
+			s.ignoreFields = append(s.ignoreFields, strings.Split(p, "."))
+		}
+	}
+}
+
+// New creates a Syncer backed by the given client.
+func New(api *client.GroundcoverAPI, opts ...Option) *Syncer {
+	s := &Syncer{
+		api:         api,
+		labelKey:    DefaultLabelKey,
+		concurrency: defaultConcurrency,
+		adopt:       true,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.concurrency <= 0 {
+		s.concurrency = 1
+	}
+	return s
+}
+
+// Sync loads the definitions in dir, plans and applies the changes.
+func (s *Syncer) Sync(ctx context.Context, dir string) (*Plan, *Result, error) {
+	defs, err := LoadDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	plan, err := s.Plan(ctx, defs)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := s.Apply(ctx, plan)
+	return plan, result, err
+}
+
+// Plan compares the definitions with the monitors of the backend.
+func (s *Syncer) Plan(ctx context.Context, defs []*Definition) (*Plan, error) {
+	desired := map[string]*Definition{}
+	for _, def := range defs {
+		key, err := s.definitionKey(def)
+		if err != nil {
+			return nil, err
+		}
+		if prev, ok := desired[key]; ok {
+			return nil, fmt.Errorf("%s: key %q is already used by %s", def.Path, key, prev.Path)
+		}
+		desired[key] = def
+	}
+
+	remote, unmanaged, err := s.remoteMonitors(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(desired))
+	for key := range desired {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	plan := syncplan.NewPlan[*Change](ActionCreate, ActionUpdate, ActionDelete)
+	for _, key := range keys {
+		def := desired[key]
+		current := remote[key]
+		if current == nil && s.adopt {
+			title := swag.StringValue(def.Monitor.Title)
+			if current = unmanaged[title]; current != nil {
+				delete(unmanaged, title)
+			}
+		}
+		change, err := s.planChange(key, def, current)
+		if err != nil {
+			return nil, err
+		}
+		plan.Changes = append(plan.Changes, change)
+	}
+	if s.prune {
+		for key, m := range remote {
+			if _, ok := desired[key]; !ok {
+				plan.Changes = append(plan.Changes, &Change{Change: syncplan.Change{
+					Action: ActionDelete,
+					Key:    key,
+					Name:   swag.StringValue(m.Title),
+					ID:     m.ID,
+				}})
+			}
+		}
+	}
+	plan.Sort()
+	return plan, nil
+}
+
+// Apply makes the changes of a plan, up to the configured concurrency at a
+// time. Failed changes do not stop the others; they are reported in the
+// result and joined in the returned error.
+func (s *Syncer) Apply(ctx context.Context, plan *Plan) (*Result, error) {
+	a := &syncplan.Applier[*Change]{
+		Kind:        "monitor",
+		Concurrency: s.concurrency,
+		DryRun:      s.dryRun,
+		Apply:       s.apply,
+	}
+	return a.Run(ctx, plan)
+}
+
+func (s *Syncer) apply(ctx context.Context, c *Change) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	switch c.Action {
+	case ActionCreate:
+		if err := monitor.CreateMonitorTyped(ctx, s.api, c.desired); err != nil {
+			return err
+		}
+		c.ID = c.desired.ID
+		return nil
+	case ActionUpdate:
+		c.desired.ID = c.ID
+		return monitor.UpdateMonitorTyped(ctx, s.api, c.desired)
+	case ActionDelete:
+		_, err := s.api.Monitors.DeleteMonitor(monitors.NewDeleteMonitorParams().WithContext(ctx).WithID(c.ID), nil)
+		return err
+	}
+	return nil
+}
+
+// definitionKey returns the key of a definition, adding the key label to it
+// when matching by label and the definition does not set one.
+func (s *Syncer) definitionKey(def *Definition) (string, error) {
+	m := def.Monitor
+	if s.byCatalogID {
+		if m.Catalog == nil || m.Catalog.CatalogID == "" {
+			return "", fmt.Errorf("%s: catalog.id is required to match by catalog ID", def.Path)
+		}
+		return m.Catalog.CatalogID, nil
+	}
+	if key := m.Labels[s.labelKey]; key != "" {
+		return key, nil
+	}
+	key := syncplan.DefaultKey(def.Path)
+	if m.Labels == nil {
+		m.Labels = map[string]string{}
+	}
+	m.Labels[s.labelKey] = key
+	return key, nil
+}
+
+func (s *Syncer) remoteKey(m *monitor.Monitor) string {
+	if s.byCatalogID {
+		if m.Catalog == nil {
+			return ""
+		}
+		return m.Catalog.CatalogID
+	}
+	return m.Labels[s.labelKey]
+}
+
+func (s *Syncer) planChange(key string, def *Definition, remote *monitor.Monitor) (*Change, error) {
+	change := &Change{
+		Change: syncplan.Change{
+			Key:  key,
+			Name: swag.StringValue(def.Monitor.Title),
+			Path: def.Path,
+		},
+		desired: def.Monitor,
+	}
+
+	want, err := encode(def.Monitor)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to encode monitor: %w", def.Path, err)
+	}
+	if remote == nil {
+		change.Action = ActionCreate
+		change.Diffs = syncplan.DiffByName(map[string]interface{}{}, want)
+		return change, nil
+	}
+
+	change.ID = remote.ID
+	have, err := encode(remote)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode monitor %s: %w", remote.ID, err)
+	}
+
+	if len(s.ignoreFields) > 0 {
+		wantMap, _ := want.(map[string]interface{})
+		haveMap, _ := have.(map[string]interface{})
+		if wantMap != nil && haveMap != nil {
+			for _, path := range s.ignoreFields {
+				syncplan.SetPath(wantMap, haveMap, path)
+			}
+			if change.desired, err = reparse(wantMap); err != nil {
+				return nil, fmt.Errorf("%s: %w", def.Path, err)
+			}
+		}
+	}
+
+	// Fields the SDK models do not know about and the definition does not
+	// set are server-managed; they are not part of the plan.
+	serverManaged := map[string]bool{}
+	for _, path := range remote.UnknownFields() {
+		serverManaged[path] = true
+	}
+	for _, d := range syncplan.DiffByName(have, want) {
+		if d.New == nil && serverManaged[d.Path] {
+			continue
+		}
+		change.Diffs = append(change.Diffs, d)
+	}
+
+	change.Action = ActionNoop
+	if len(change.Diffs) > 0 {
+		change.Action = ActionUpdate
+	}
+	return change, nil
+}
+
+func reparse(tree map[string]interface{}) (*monitor.Monitor, error) {
+	data, err := yaml.Marshal(tree)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode monitor: %w", err)
+	}
+	return monitor.ParseMonitor(data)
+}
+
+// remoteMonitors fetches every monitor of the backend and returns the managed
+// ones by key and the unmanaged ones by title. Unmanaged monitors that share a
+// title are left out, since a definition could not tell which one to adopt.
+func (s *Syncer) remoteMonitors(ctx context.Context) (map[string]*monitor.Monitor, map[string]*monitor.Monitor, error) {
+	fetched, err := s.fetchMonitors(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	managed := map[string]*monitor.Monitor{}
+	unmanaged := map[string]*monitor.Monitor{}
+	ambiguous := map[string]bool{}
+	for _, m := range fetched {
+		key := s.remoteKey(m)
+		if key == "" {
+			title := swag.StringValue(m.Title)
+			if _, ok := unmanaged[title]; ok {
+				ambiguous[title] = true
+			}
+			unmanaged[title] = m
+			continue
+		}
+		if prev, ok := managed[key]; ok {
+			return nil, nil, fmt.Errorf("monitors %s and %s share the key %q", prev.ID, m.ID, key)
+		}
+		managed[key] = m
+	}
+	for title := range ambiguous {
+		delete(unmanaged, title)
+	}
+	return managed, unmanaged, nil
+}
+
+// fetchMonitors lists and fetches every monitor of the backend.
+func (s *Syncer) fetchMonitors(ctx context.Context) ([]*monitor.Monitor, error) {
+	var fetched []*monitor.Monitor
+	filter := monitor.ListFilter{Hydrate: true, Concurrency: s.concurrency}
+	for listed, err := range monitor.ListAllMonitors(ctx, s.api, filter) {
+		if err != nil {
+			return nil, err
+		}
+		fetched = append(fetched, listed.Monitor)
+	}
+	return fetched, nil
+}
@@ -0,0 +1,282 @@
+package monitorsync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/groundcover-com/groundcover-sdk-go/internal/testutil"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/monitor"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMonitors is an in-memory monitors API that stores definitions as YAML.
+type fakeMonitors struct {
+	mu       sync.Mutex
+	next     int
+	monitors map[string]string
+	failPut  map[string]bool
+	calls    []string
+}
+
+func newFakeMonitors() *fakeMonitors {
+	return &fakeMonitors{monitors: map[string]string{}, failPut: map[string]bool{}}
+}
+
+func (f *fakeMonitors) add(yamlDoc string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.next++
+	id := fmt.Sprintf("00000000-0000-0000-0000-%012d", f.next)
+	f.monitors[id] = yamlDoc
+	return id
+}
+
+func (f *fakeMonitors) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.calls = append(f.calls, r.Method+" "+r.URL.Path)
+	f.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/api/monitors/list":
+		var req models.MonitorListRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		f.mu.Lock()
+		ids := make([]string, 0, len(f.monitors))
+		for id := range f.monitors {
+			ids = append(ids, id)
+		}
+		f.mu.Unlock()
+		sort.Strings(ids)
+		resp := models.MonitorListResponse{Monitors: []*models.MonitorListItem{}}
+		for i := int(req.Skip); i < len(ids) && i < int(req.Skip+req.Limit); i++ {
+			resp.Monitors = append(resp.Monitors, &models.MonitorListItem{UUID: strfmt.UUID(ids[i])})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	case r.Method == http.MethodPost && r.URL.Path == "/api/monitors":
+		body, _ := io.ReadAll(r.Body)
+		id := f.add(string(body))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"monitorId":%q}`, id)
+	case strings.HasPrefix(r.URL.Path, "/api/monitors/"):
+		id := strings.TrimPrefix(r.URL.Path, "/api/monitors/")
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		doc, ok := f.monitors[id]
+		if !ok {
+			http.Error(w, `{"message":"not found"}`, http.StatusNotFound)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			_, _ = io.WriteString(w, doc)
+		case http.MethodPut:
+			if f.failPut[id] {
+				http.Error(w, `{"message":"invalid"}`, http.StatusBadRequest)
+				return
+			}
+			body, _ := io.ReadAll(r.Body)
+			f.monitors[id] = string(body)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusAccepted)
+			_, _ = io.WriteString(w, `{}`)
+		case http.MethodDelete:
+			delete(f.monitors, id)
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = io.WriteString(w, `{}`)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeMonitors) callCount(prefix string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, c := range f.calls {
+		if strings.HasPrefix(c, prefix) {
+			n++
+		}
+	}
+	return n
+}
+
+const cpuMonitor = `
+title: High CPU
+severity: critical
+model:
+  queries:
+    - name: cpu
+      dataType: metrics
+      expression: avg(rate(cpu[5m]))
+  thresholds:
+    - name: high
+      inputName: cpu
+      operator: gt
+      values: [0.9]
+`
+
+func TestPlanAndApply(t *testing.T) {
+	fake := newFakeMonitors()
+	unmanagedID := fake.add("title: Hand made\n")
+	staleID := fake.add("title: Stale\nlabels:\n  sync_id: team/stale\n")
+	cpuID := fake.add(strings.Replace(cpuMonitor, "severity: critical", "severity: warning\nlabels:\n  sync_id: team/cpu\ncreatedBy: someone", 1))
+	api := testutil.NewAPI(t, fake)
+
+	dir := testutil.WriteFiles(t, map[string]string{
+		"team/cpu.yaml":     cpuMonitor,
+		"team/memory.yml":   strings.Replace(cpuMonitor, "High CPU", "High memory", 1),
+		"README.md":         "not a monitor",
+		"team/nested/.keep": "",
+	})
+	defs, err := LoadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, defs, 2)
+	require.Equal(t, "team/cpu.yaml", defs[0].Path)
+
+	syncer := New(api, WithPrune(true), WithConcurrency(2))
+	plan, err := syncer.Plan(context.Background(), defs)
+	require.NoError(t, err)
+	require.True(t, plan.HasChanges())
+
+	require.Len(t, plan.Changes, 3)
+	cpu, memory, stale := plan.Changes[0], plan.Changes[1], plan.Changes[2]
+
+	require.Equal(t, ActionUpdate, cpu.Action)
+	require.Equal(t, cpuID, cpu.ID)
+	require.Equal(t, []FieldDiff{{Path: "severity", Old: "warning", New: "critical"}}, cpu.Diffs)
+	require.Equal(t, `~ severity: "warning" -> "critical"`, cpu.Diffs[0].String())
+
+	require.Equal(t, ActionCreate, memory.Action)
+	require.Equal(t, "team/memory", memory.Key)
+	require.Contains(t, memory.Diffs, FieldDiff{Path: "title", New: "High memory"})
+
+	require.Equal(t, ActionDelete, stale.Action)
+	require.Equal(t, staleID, stale.ID)
+
+	require.Contains(t, plan.String(), "1 to create, 1 to update, 1 to delete, 0 unchanged")
+
+	dry, err := New(api, WithPrune(true), WithDryRun(true)).Apply(context.Background(), plan)
+	require.NoError(t, err)
+	require.True(t, dry.DryRun)
+	require.Len(t, dry.Applied, 3)
+	require.Zero(t, fake.callCount("PUT"))
+
+	result, err := syncer.Apply(context.Background(), plan)
+	require.NoError(t, err)
+	require.Len(t, result.Applied, 3)
+	require.NotEmpty(t, memory.ID)
+
+	fake.mu.Lock()
+	require.Contains(t, fake.monitors, unmanagedID)
+	require.NotContains(t, fake.monitors, staleID)
+	require.Contains(t, fake.monitors[cpuID], "severity: critical")
+	require.Contains(t, fake.monitors[memory.ID], "sync_id: team/memory")
+	fake.mu.Unlock()
+
+	// A second plan is empty: server-managed fields such as createdBy are not
+	// reported as removals.
+	defs, err = LoadDir(dir)
+	require.NoError(t, err)
+	plan, err = syncer.Plan(context.Background(), defs)
+	require.NoError(t, err)
+	require.False(t, plan.HasChanges(), plan.String())
+	require.Equal(t, 2, plan.Count(ActionNoop))
+}
+
+func TestPlanWithoutPruneKeepsRemoteMonitors(t *testing.T) {
+	fake := newFakeMonitors()
+	fake.add("title: Stale\nlabels:\n  sync_id: stale\n")
+	api := testutil.NewAPI(t, fake)
+
+	plan, err := New(api).Plan(context.Background(), nil)
+	require.NoError(t, err)
+	require.Empty(t, plan.Changes)
+}
+
+func TestPlanIgnoreFields(t *testing.T) {
+	fake := newFakeMonitors()
+	id := fake.add(cpuMonitor + "isPaused: true\nlabels:\n  sync_id: cpu\n")
+	api := testutil.NewAPI(t, fake)
+
+	def, err := ParseDefinition("cpu.yaml", []byte(strings.Replace(cpuMonitor, "0.9", "0.8", 1)))
+	require.NoError(t, err)
+
+	syncer := New(api, WithIgnoreFields("isPaused"))
+	plan, err := syncer.Plan(context.Background(), []*Definition{def})
+	require.NoError(t, err)
+	require.Len(t, plan.Changes, 1)
+	require.Equal(t, []FieldDiff{{Path: "model.thresholds[high].values", Old: []interface{}{0.9}, New: []interface{}{0.8}}}, plan.Changes[0].Diffs)
+
+	_, err = syncer.Apply(context.Background(), plan)
+	require.NoError(t, err)
+	updated, err := monitor.ParseMonitor([]byte(fake.monitors[id]))
+	require.NoError(t, err)
+	require.True(t, *updated.IsPaused)
+	require.Equal(t, []float64{0.8}, updated.Model.Thresholds[0].Values)
+}
+
+func TestMatchByCatalogID(t *testing.T) {
+	fake := newFakeMonitors()
+	fake.add(cpuMonitor + "catalog:\n  id: cpu-v1\n")
+	api := testutil.NewAPI(t, fake)
+
+	def, err := ParseDefinition("cpu.yaml", []byte(cpuMonitor+"catalog:\n  id: cpu-v1\n"))
+	require.NoError(t, err)
+	plan, err := New(api, MatchByCatalogID()).Plan(context.Background(), []*Definition{def})
+	require.NoError(t, err)
+	require.Equal(t, ActionNoop, plan.Changes[0].Action)
+
+	missing, err := ParseDefinition("other.yaml", []byte(cpuMonitor))
+	require.NoError(t, err)
+	_, err = New(api, MatchByCatalogID()).Plan(context.Background(), []*Definition{missing})
+	require.ErrorContains(t, err, "other.yaml: catalog.id is required")
+}
+
+func TestPlanRejectsDuplicateKeys(t *testing.T) {
+	api := testutil.NewAPI(t, newFakeMonitors())
+	a, err := ParseDefinition("a.yaml", []byte(cpuMonitor+"labels:\n  sync_id: same\n"))
+	require.NoError(t, err)
+	b, err := ParseDefinition("b.yaml", []byte(cpuMonitor+"labels:\n  sync_id: same\n"))
+	require.NoError(t, err)
+
+	_, err = New(api).Plan(context.Background(), []*Definition{a, b})
+	require.ErrorContains(t, err, `b.yaml: key "same" is already used by a.yaml`)
+}
+
+func TestApplyReportsFailures(t *testing.T) {
+	fake := newFakeMonitors()
+	id := fake.add("title: Old\nlabels:\n  sync_id: cpu\n")
+	fake.failPut[id] = true
+	api := testutil.NewAPI(t, fake)
+
+	def, err := ParseDefinition("cpu.yaml", []byte(cpuMonitor))
+	require.NoError(t, err)
+	other, err := ParseDefinition("other.yaml", []byte(strings.Replace(cpuMonitor, "High CPU", "Other", 1)))
+	require.NoError(t, err)
+
+	syncer := New(api)
+	plan, err := syncer.Plan(context.Background(), []*Definition{def, other})
+	require.NoError(t, err)
+	result, err := syncer.Apply(context.Background(), plan)
+	require.Error(t, err)
+	require.Len(t, result.Applied, 1)
+	require.Len(t, result.Failed, 1)
+	require.Equal(t, "cpu", result.Failed[0].Change.Key)
+	require.Contains(t, err.Error(), "failed to update monitor cpu")
+}
+
+func TestParseDefinitionRequiresTitle(t *testing.T) {
+	_, err := ParseDefinition("empty.yaml", []byte("severity: info\n"))
+	require.ErrorContains(t, err, "empty.yaml: title is required")
+}
@@ -0,0 +1,254 @@
+package rum
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/client"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+	"golang.org/x/sync/errgroup"
+)
+
+// DefaultMaxFileSize is the largest source map UploadDir uploads unless
+// WithMaxFileSize says otherwise.
+const DefaultMaxFileSize = 50 << 20
+
+const defaultUploadConcurrency = 4
+
+// FileResult is the outcome of uploading one source map.
+type FileResult struct {
+	// Path is the file relative to the uploaded directory.
+	Path string
+	Size int64
+	// Response is the server's answer, nil if the upload failed or was
+	// skipped.
+	Response *models.SourceMapUploadResponse
+	Err      error
+}
+
+// BatchResult is the outcome of UploadDir, one entry per source map found,
+// in path order.
+type BatchResult struct {
+	Files []FileResult
+}
+
+// Uploaded returns the files that were uploaded.
+func (r *BatchResult) Uploaded() []FileResult {
+	var out []FileResult
+	for _, f := range r.Files {
+		if f.Err == nil {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// Failed returns the files that were not uploaded.
+func (r *BatchResult) Failed() []FileResult {
+	var out []FileResult
+	for _, f := range r.Files {
+		if f.Err != nil {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// Err joins the errors of the files that were not uploaded, or returns nil
+// if all were.
+func (r *BatchResult) Err() error {
+	var errs []error
+	for _, f := range r.Failed() {
+		errs = append(errs, fmt.Errorf("%s: %w", f.Path, f.Err))
+	}
+	return errors.Join(errs...)
+}
+
+// Progress reports a file that finished uploading, successfully or not.
+type Progress struct {
+	File FileResult
+	// Done counts the files finished so far, including this one, out of
+	// Total.
+	Done  int
+	Total int
+}
+
+// BatchOption configures UploadDir.
+type BatchOption func(*batchConfig)
+
+type batchConfig struct {
+	concurrency int
+	maxSize     int64
+	progress    func(Progress)
+	pattern     string
+}
+
+// WithConcurrency sets how many files are uploaded at once. It defaults
+// to 4.
+func WithConcurrency(n int) BatchOption {
+	return func(c *batchConfig) {
+		c.concurrency = n
+	}
+}
+
+// WithMaxFileSize sets the size above which files are not uploaded. It
+// defaults to DefaultMaxFileSize.
+func WithMaxFileSize(bytes int64) BatchOption {
+	return func(c *batchConfig) {
+		c.maxSize = bytes
+	}
+}
+
+// WithProgress calls fn after each file, one call at a time.
+func WithProgress(fn func(Progress)) BatchOption {
+	return func(c *batchConfig) {
+		c.progress = fn
+	}
+}
+
+// WithPattern sets the file name pattern, in path.Match syntax, of the
+// files to upload. It defaults to "*.js.map".
+func WithPattern(pattern string) BatchOption {
+	return func(c *batchConfig) {
+		c.pattern = pattern
+	}
+}
+
+// UploadDir finds the source maps below dir, typically the output directory
+// of a build, and uploads them for the given app and release.
+//
+// Files are uploaded under their base name. Empty files, files larger than
+// the size limit and files whose base name another map already uses are not
+// uploaded. A failed file does not stop the others: the error is recorded in
+// its FileResult, and UploadDir only returns an error if dir cannot be
+// walked or ctx is canceled.
+func UploadDir(ctx context.Context, api *client.GroundcoverAPI, dir, appID, releaseID string, opts ...BatchOption) (*BatchResult, error) {
+	if appID == "" {
+		return nil, fmt.Errorf("app ID is required")
+	}
+	if releaseID == "" {
+		return nil, fmt.Errorf("release ID is required")
+	}
+	cfg := &batchConfig{concurrency: defaultUploadConcurrency, maxSize: DefaultMaxFileSize, pattern: "*.js.map"}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.concurrency <= 0 {
+		cfg.concurrency = defaultUploadConcurrency
+	}
+	if _, err := path.Match(cfg.pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", cfg.pattern, err)
+	}
+
+	files, err := findFiles(dir, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		mu   sync.Mutex
+		done int
+	)
+	finish := func(f *FileResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		done++
+		if cfg.progress != nil {
+			cfg.progress(Progress{File: *f, Done: done, Total: len(files)})
+		}
+	}
+	var g errgroup.Group
+	g.SetLimit(cfg.concurrency)
+	for i := range files {
+		f := &files[i]
+		if f.Err != nil {
+			finish(f)
+			continue
+		}
+		g.Go(func() error {
+			if err := ctx.Err(); err != nil {
+				f.Err = err
+			} else {
+				f.Response, f.Err = uploadFile(ctx, api, filepath.Join(dir, filepath.FromSlash(f.Path)), appID, releaseID)
+			}
+			finish(f)
+			return nil
+		})
+	}
+	// The uploads record their errors in the results and never fail the group.
+	_ = g.Wait()
+	if err := ctx.Err(); err != nil {
+		return &BatchResult{Files: files}, err
+	}
+	return &BatchResult{Files: files}, nil
+}
+
+// findFiles lists the files matching the pattern, in path order, with the
+// ones that must not be uploaded already failed.
+func findFiles(dir string, cfg *batchConfig) ([]FileResult, error) {
+	var files []FileResult
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if ok, _ := path.Match(cfg.pattern, d.Name()); !ok {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		f := FileResult{Path: filepath.ToSlash(rel), Size: info.Size()}
+		switch {
+		case f.Size == 0:
+			f.Err = fmt.Errorf("file is empty")
+		case cfg.maxSize > 0 && f.Size > cfg.maxSize:
+			f.Err = fmt.Errorf("file is %d bytes, more than the limit of %d", f.Size, cfg.maxSize)
+		}
+		files = append(files, f)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find source maps in %s: %w", dir, err)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+
+	seen := map[string]string{}
+	for i := range files {
+		base := path.Base(files[i].Path)
+		if first, ok := seen[strings.ToLower(base)]; ok {
+			if files[i].Err == nil {
+				files[i].Err = fmt.Errorf("file name %s is already used by %s", base, first)
+			}
+			continue
+		}
+		seen[strings.ToLower(base)] = files[i].Path
+	}
+	return files, nil
+}
+
+func uploadFile(ctx context.Context, api *client.GroundcoverAPI, name, appID, releaseID string) (*models.SourceMapUploadResponse, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	// The runtime closes the file once the request body is written, but not
+	// if the request fails before that.
+	defer file.Close()
+	return UploadSourceMap(ctx, api, &SourceMap{AppID: appID, ReleaseID: releaseID, Name: filepath.Base(name), Content: file})
+}
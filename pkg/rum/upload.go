@@ -0,0 +1,114 @@
+// Package rum uploads source maps for groundcover Real User Monitoring.
+//
+// The generated rum client documents the app_id, release_id and file fields
+// of the upload endpoint but has no parameters for them. WithSourceMap adds
+// them to a call of the generated client, UploadSourceMap wraps such a call,
+// and UploadDir uploads every source map of a build output directory.
+package rum
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/strfmt"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/client"
+	rumclient "github.com/groundcover-com/groundcover-sdk-go/pkg/client/rum"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+)
+
+// Multipart fields of the upload endpoint.
+const (
+	FieldAppID     = "app_id"
+	FieldReleaseID = "release_id"
+	FieldFile      = "file"
+)
+
+// SourceMap is a source map to upload.
+type SourceMap struct {
+	// AppID is the RUM application the map belongs to.
+	AppID string
+	// ReleaseID is the release of the application the map was built for.
+	ReleaseID string
+	// Name is the file name the map is uploaded as, such as
+	// "main.3f2a1c.js.map". Only its base name is sent.
+	Name string
+	// Content is streamed into the request as it is sent. It is closed after
+	// the upload if it implements io.Closer.
+	Content io.Reader
+}
+
+func (m *SourceMap) validate() error {
+	switch {
+	case m.AppID == "":
+		return fmt.Errorf("app ID is required")
+	case m.ReleaseID == "":
+		return fmt.Errorf("release ID is required")
+	case m.Name == "":
+		return fmt.Errorf("file name is required")
+	case m.Content == nil:
+		return fmt.Errorf("content of %s is required", m.Name)
+	}
+	return nil
+}
+
+// WithSourceMap adds the multipart fields of m to a call of the generated
+// UploadSourceMap operation:
+//
+//	api.Rum.UploadSourceMap(rumclient.NewUploadSourceMapParamsWithContext(ctx), nil, rum.WithSourceMap(m))
+func WithSourceMap(m *SourceMap) rumclient.ClientOption {
+	return func(op *runtime.ClientOperation) {
+		op.ConsumesMediaTypes = []string{runtime.MultipartFormMime}
+		params := op.Params
+		op.Params = runtime.ClientRequestWriterFunc(func(r runtime.ClientRequest, reg strfmt.Registry) error {
+			if err := params.WriteToRequest(r, reg); err != nil {
+				return err
+			}
+			if err := r.SetFormParam(FieldAppID, m.AppID); err != nil {
+				return err
+			}
+			if err := r.SetFormParam(FieldReleaseID, m.ReleaseID); err != nil {
+				return err
+			}
+			return r.SetFileParam(FieldFile, namedReader(m))
+		})
+	}
+}
+
+// UploadSourceMap uploads a single source map.
+func UploadSourceMap(ctx context.Context, api *client.GroundcoverAPI, m *SourceMap) (*models.SourceMapUploadResponse, error) {
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	resp, err := api.Rum.UploadSourceMap(rumclient.NewUploadSourceMapParamsWithContext(ctx), nil, WithSourceMap(m))
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload source map %s: %w", m.Name, err)
+	}
+	return resp.Payload, nil
+}
+
+// namedReader adapts the content of a map to the file parameter of the
+// runtime. Readers that are not closers are not closed.
+func namedReader(m *SourceMap) runtime.NamedReadCloser {
+	rc, ok := m.Content.(io.ReadCloser)
+	if !ok {
+		rc = io.NopCloser(m.Content)
+	}
+	return &sourceMapFile{ReadCloser: rc, name: m.Name}
+}
+
+// sourceMapFile names the file part and sets its content type, which the
+// runtime would otherwise sniff from the first bytes.
+type sourceMapFile struct {
+	io.ReadCloser
+	name string
+}
+
+func (f *sourceMapFile) Name() string {
+	return f.name
+}
+
+func (f *sourceMapFile) ContentType() string {
+	return runtime.JSONMime
+}
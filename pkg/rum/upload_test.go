@@ -0,0 +1,161 @@
+package rum
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/groundcover-com/groundcover-sdk-go/internal/testutil"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/client"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+type upload struct {
+	AppID, ReleaseID, Filename, ContentType, Content string
+}
+
+// fakeRUM accepts source map uploads, rejecting files whose content is
+// "reject".
+func fakeRUM(t *testing.T) (*client.GroundcoverAPI, func() []upload) {
+	var (
+		mu      sync.Mutex
+		uploads []upload
+	)
+	api := testutil.NewAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "/api/rum/sourcemaps", r.URL.Path)
+		file, header, err := r.FormFile(FieldFile)
+		require.NoError(t, err)
+		content, err := io.ReadAll(file)
+		require.NoError(t, err)
+		u := upload{
+			AppID:       r.FormValue(FieldAppID),
+			ReleaseID:   r.FormValue(FieldReleaseID),
+			Filename:    header.Filename,
+			ContentType: header.Header.Get("Content-Type"),
+			Content:     string(content),
+		}
+		mu.Lock()
+		uploads = append(uploads, u)
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		if u.Content == "reject" {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"message": "invalid source map"})
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(models.SourceMapUploadResponse{
+			AppID: u.AppID, ReleaseID: u.ReleaseID, Filename: u.Filename, SizeBytes: int64(len(content)), Status: "uploaded",
+		})
+	}))
+	return api, func() []upload {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]upload(nil), uploads...)
+	}
+}
+
+func TestUploadSourceMap(t *testing.T) {
+	api, uploads := fakeRUM(t)
+
+	resp, err := UploadSourceMap(context.Background(), api, &SourceMap{
+		AppID:     "shop",
+		ReleaseID: "1.2.3",
+		Name:      "dist/main.js.map",
+		Content:   strings.NewReader(`{"version":3}`),
+	})
+	require.NoError(t, err)
+	require.Equal(t, &models.SourceMapUploadResponse{
+		AppID: "shop", ReleaseID: "1.2.3", Filename: "main.js.map", SizeBytes: 13, Status: "uploaded",
+	}, resp)
+	require.Equal(t, []upload{{
+		AppID: "shop", ReleaseID: "1.2.3", Filename: "main.js.map", ContentType: "application/json", Content: `{"version":3}`,
+	}}, uploads())
+
+	_, err = UploadSourceMap(context.Background(), api, &SourceMap{AppID: "shop", Name: "main.js.map", Content: strings.NewReader("{}")})
+	require.EqualError(t, err, "release ID is required")
+}
+
+func TestUploadDir(t *testing.T) {
+	api, uploads := fakeRUM(t)
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.js.map":            `{"version":3,"file":"main.js"}`,
+		"main.js":                "console.log(1)",
+		"chunks/a.js.map":        `{"version":3,"file":"a.js"}`,
+		"chunks/big.js.map":      strings.Repeat("x", 100),
+		"chunks/empty.js.map":    "",
+		"chunks/bad.js.map":      "reject",
+		"legacy/main.js.map":     `{"version":3}`,
+		"styles/site.css.map":    `{"version":3}`,
+		"chunks/nested/b.js.map": `{"version":3,"file":"b.js"}`,
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0o644))
+	}
+
+	var progress []Progress
+	result, err := UploadDir(context.Background(), api, dir, "shop", "1.2.3",
+		WithMaxFileSize(64),
+		WithConcurrency(2),
+		WithProgress(func(p Progress) { progress = append(progress, p) }),
+	)
+	require.NoError(t, err)
+
+	var paths []string
+	for _, f := range result.Files {
+		paths = append(paths, f.Path)
+	}
+	require.Equal(t, []string{
+		"chunks/a.js.map", "chunks/bad.js.map", "chunks/big.js.map", "chunks/empty.js.map",
+		"chunks/nested/b.js.map", "legacy/main.js.map", "main.js.map",
+	}, paths)
+
+	var uploaded []string
+	for _, f := range result.Uploaded() {
+		uploaded = append(uploaded, f.Path)
+		require.Equal(t, "uploaded", f.Response.Status)
+		require.Equal(t, f.Size, f.Response.SizeBytes)
+	}
+	require.Equal(t, []string{"chunks/a.js.map", "chunks/nested/b.js.map", "legacy/main.js.map"}, uploaded)
+
+	failed := map[string]string{}
+	for _, f := range result.Failed() {
+		failed[f.Path] = f.Err.Error()
+	}
+	require.Len(t, failed, 4)
+	require.Contains(t, failed["chunks/bad.js.map"], "failed to upload source map bad.js.map")
+	require.Equal(t, "file is 100 bytes, more than the limit of 64", failed["chunks/big.js.map"])
+	require.Equal(t, "file is empty", failed["chunks/empty.js.map"])
+	require.Equal(t, "file name main.js.map is already used by legacy/main.js.map", failed["main.js.map"])
+	require.ErrorContains(t, result.Err(), "chunks/empty.js.map: file is empty")
+
+	require.Len(t, uploads(), 4)
+	for _, u := range uploads() {
+		require.Equal(t, "shop", u.AppID)
+		require.Equal(t, "1.2.3", u.ReleaseID)
+	}
+
+	require.Len(t, progress, 7)
+	for i, p := range progress {
+		require.Equal(t, i+1, p.Done)
+		require.Equal(t, 7, p.Total)
+	}
+}
+
+func TestUploadDirRequiresIDs(t *testing.T) {
+	_, err := UploadDir(context.Background(), nil, t.TempDir(), "", "1.2.3")
+	require.EqualError(t, err, "app ID is required")
+}
@@ -0,0 +1,194 @@
+package silence
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/client"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/client/monitors"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+)
+
+// CreateOption configures Create.
+type CreateOption func(*createConfig)
+
+type createConfig struct {
+	legacy bool
+}
+
+// WithLegacyEndpoints sends the silence to the v1 one-off or recurring
+// silence endpoint instead of the v2 endpoint.
+func WithLegacyEndpoints(legacy bool) CreateOption {
+	return func(c *createConfig) {
+		c.legacy = legacy
+	}
+}
+
+// Create builds the silence and creates it. The result is returned in the v2
+// shape whichever endpoint was used.
+func (b *Builder) Create(ctx context.Context, api *client.GroundcoverAPI, opts ...CreateOption) (*models.V2SilenceResponse, error) {
+	var cfg createConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if !cfg.legacy {
+		req, err := b.Build()
+		if err != nil {
+			return nil, err
+		}
+		resp, err := api.Monitors.V2CreateSilence(monitors.NewV2CreateSilenceParams().WithContext(ctx).WithBody(req), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create silence: %w", err)
+		}
+		return resp.Payload, nil
+	}
+
+	if b.Recurring() {
+		req, err := b.BuildRecurring()
+		if err != nil {
+			return nil, err
+		}
+		resp, err := api.Monitors.CreateRecurringSilence(monitors.NewCreateRecurringSilenceParams().WithContext(ctx).WithBody(req), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create recurring silence: %w", err)
+		}
+		return fromRecurring(resp.Payload), nil
+	}
+	req, err := b.BuildOneTime()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := api.Monitors.CreateSilence(monitors.NewCreateSilenceParams().WithContext(ctx).WithBody(req), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create silence: %w", err)
+	}
+	return fromOneTime(resp.Payload), nil
+}
+
+// SilenceFor silences alerts matching matchers from now for d.
+func SilenceFor(ctx context.Context, api *client.GroundcoverAPI, matchers models.Matchers, d time.Duration, comment string) (*models.V2SilenceResponse, error) {
+	return New(matchers...).Comment(comment).For(d).Create(ctx, api)
+}
+
+// ExtendSilence pushes the end of a one-off silence back by d. A silence that
+// already ended is extended from now.
+func ExtendSilence(ctx context.Context, api *client.GroundcoverAPI, id string, d time.Duration) (*models.V2SilenceResponse, error) {
+	if d <= 0 {
+		return nil, fmt.Errorf("extension must be positive, got %s", d)
+	}
+	s, err := getSilence(ctx, api, id)
+	if err != nil {
+		return nil, err
+	}
+	if s.Type == TypeRecurring {
+		return nil, fmt.Errorf("silence %s is recurring and has no end to extend", id)
+	}
+	end := time.Time(s.EndsAt)
+	if now := time.Now(); end.Before(now) {
+		end = now
+	}
+	req := toUpdate(s)
+	req.EndsAt = strfmt.DateTime(end.Add(d))
+	return updateSilence(ctx, api, id, req)
+}
+
+// ExpireSilence ends a silence now. One-off silences that are running get
+// their end set to now, so they stay in the history; those that have not
+// started yet are deleted. Recurring silences are disabled.
+func ExpireSilence(ctx context.Context, api *client.GroundcoverAPI, id string) error {
+	s, err := getSilence(ctx, api, id)
+	if err != nil {
+		return err
+	}
+	req := toUpdate(s)
+	now := time.Now()
+	switch {
+	case s.Type == TypeRecurring:
+		req.Enabled = swag.Bool(false)
+	case time.Time(s.StartsAt).After(now):
+		if _, err := api.Monitors.V2DeleteSilence(monitors.NewV2DeleteSilenceParams().WithContext(ctx).WithID(id), nil); err != nil {
+			return fmt.Errorf("failed to delete silence %s: %w", id, err)
+		}
+		return nil
+	case !time.Time(s.EndsAt).After(now):
+		return nil
+	default:
+		req.EndsAt = strfmt.DateTime(now)
+	}
+	_, err = updateSilence(ctx, api, id, req)
+	return err
+}
+
+func getSilence(ctx context.Context, api *client.GroundcoverAPI, id string) (*models.V2SilenceResponse, error) {
+	resp, err := api.Monitors.V2GetSilence(monitors.NewV2GetSilenceParams().WithContext(ctx).WithID(id), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get silence %s: %w", id, err)
+	}
+	return resp.Payload, nil
+}
+
+func updateSilence(ctx context.Context, api *client.GroundcoverAPI, id string, req *models.V2UpdateSilenceRequest) (*models.V2SilenceResponse, error) {
+	resp, err := api.Monitors.V2UpdateSilence(monitors.NewV2UpdateSilenceParams().WithContext(ctx).WithID(id).WithBody(req), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update silence %s: %w", id, err)
+	}
+	return resp.Payload, nil
+}
+
+// toUpdate copies a silence into an update request. The update replaces the
+// whole silence, so every field has to be carried over.
+func toUpdate(s *models.V2SilenceResponse) *models.V2UpdateSilenceRequest {
+	return &models.V2UpdateSilenceRequest{
+		Comment:        s.Comment,
+		Enabled:        swag.Bool(s.Enabled),
+		EndsAt:         s.EndsAt,
+		StartsAt:       s.StartsAt,
+		Matchers:       s.Matchers,
+		RecurrenceType: s.RecurrenceType,
+		Timeframes:     s.Timeframes,
+		Timezone:       s.Timezone,
+		Type:           s.Type,
+	}
+}
+
+func fromOneTime(s *models.Silence) *models.V2SilenceResponse {
+	if s == nil {
+		return nil
+	}
+	return &models.V2SilenceResponse{
+		UUID:               s.UUID,
+		Type:               TypeOneTime,
+		Active:             s.Active,
+		Enabled:            true,
+		Comment:            s.Comment,
+		CreatedBy:          s.CreatedBy,
+		CreatedByEmail:     s.CreatedByEmail,
+		StartsAt:           s.StartsAt,
+		EndsAt:             s.EndsAt,
+		RecurringSilenceID: s.RecurringSilenceID,
+		Matchers:           s.Matchers,
+	}
+}
+
+func fromRecurring(s *models.RecurringSilenceResponse) *models.V2SilenceResponse {
+	if s == nil {
+		return nil
+	}
+	return &models.V2SilenceResponse{
+		UUID:           s.UUID,
+		Type:           TypeRecurring,
+		Enabled:        s.Enabled,
+		Comment:        s.Comment,
+		CreatedAt:      s.CreatedAt,
+		CreatedBy:      s.CreatedBy,
+		CreatedByEmail: s.CreatedByEmail,
+		UpdatedAt:      s.UpdatedAt,
+		RecurrenceType: s.RecurrenceType,
+		Timeframes:     s.Timeframes,
+		Timezone:       s.Timezone,
+		Matchers:       s.Matchers,
+	}
+}
@@ -0,0 +1,317 @@
+package silence
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+)
+
+const (
+	icalDateTime    = "20060102T150405"
+	icalDateTimeUTC = "20060102T150405Z"
+	icalLineLimit   = 75
+)
+
+// Event is a silence shown in a calendar.
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Schedule    *Schedule
+}
+
+// Calendar renders silence schedules as an iCalendar (RFC 5545) document, so
+// maintenance windows show up next to on-call rotations. One-off silences
+// become single events; every timeframe of a recurring silence becomes a
+// repeating event in the silence timezone.
+type Calendar struct {
+	// Name is shown by calendar clients that support X-WR-CALNAME.
+	Name string
+	// Stamp is the DTSTAMP of every event, and the date recurring events
+	// start from when the silence has no start of its own. It defaults to now.
+	Stamp  time.Time
+	Events []Event
+}
+
+// CalendarOf returns a calendar of the given silences. Disabled recurring
+// silences are left out.
+func CalendarOf(name string, silences []*models.V2SilenceResponse) (*Calendar, error) {
+	c := &Calendar{Name: name}
+	for _, s := range silences {
+		if s.Type == TypeRecurring && !s.Enabled {
+			continue
+		}
+		schedule, err := ScheduleOf(s)
+		if err != nil {
+			return nil, err
+		}
+		matchers := make([]string, 0, len(s.Matchers))
+		for _, m := range s.Matchers {
+			matchers = append(matchers, FormatMatcher(m))
+		}
+		summary := s.Comment
+		if summary == "" {
+			summary = "Silence"
+		}
+		c.Events = append(c.Events, Event{
+			UID:         string(s.UUID) + "@groundcover",
+			Summary:     summary,
+			Description: "Matchers: " + strings.Join(matchers, ", "),
+			Schedule:    schedule,
+		})
+	}
+	return c, nil
+}
+
+// WriteTo writes the calendar to w.
+func (c *Calendar) WriteTo(w io.Writer) (int64, error) {
+	n, err := io.WriteString(w, c.String())
+	return int64(n), err
+}
+
+func (c *Calendar) String() string {
+	stamp := c.Stamp
+	if stamp.IsZero() {
+		stamp = time.Now()
+	}
+	var buf bytes.Buffer
+	line := func(s string) {
+		writeFolded(&buf, s)
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//groundcover//groundcover-sdk-go//EN")
+	line("CALSCALE:GREGORIAN")
+	if c.Name != "" {
+		line("X-WR-CALNAME:" + escapeText(c.Name))
+	}
+
+	zones := map[string]bool{}
+	var events [][]string
+	for _, e := range c.Events {
+		lines, loc, first := e.lines(stamp)
+		if lines == nil {
+			continue
+		}
+		if loc != time.UTC && !zones[loc.String()] {
+			zones[loc.String()] = true
+			for _, l := range vtimezone(loc, first.Year()) {
+				line(l)
+			}
+		}
+		events = append(events, lines)
+	}
+	for _, lines := range events {
+		for _, l := range lines {
+			line(l)
+		}
+	}
+	line("END:VCALENDAR")
+	return buf.String()
+}
+
+// lines renders the event. It returns nil when the schedule never fires,
+// together with the timezone the event uses and its first start.
+func (e Event) lines(stamp time.Time) ([]string, *time.Location, time.Time) {
+	s := e.Schedule
+	if !s.Recurring() {
+		header := e.header(stamp)
+		header = append(header, "DTSTART:"+s.start.UTC().Format(icalDateTimeUTC))
+		if !s.end.IsZero() {
+			header = append(header, "DTEND:"+s.end.UTC().Format(icalDateTimeUTC))
+		}
+		return append(header, "END:VEVENT"), time.UTC, s.start
+	}
+
+	from := s.start
+	if from.IsZero() {
+		local := stamp.In(s.loc)
+		from = time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, s.loc)
+	}
+	var lines []string
+	var first time.Time
+	for i, f := range s.frames {
+		w, ok := s.firstWindow(f, from)
+		if !ok || (!s.end.IsZero() && !w.Start.Before(s.end)) {
+			continue
+		}
+		if first.IsZero() || w.Start.Before(first) {
+			first = w.Start
+		}
+		ev := e
+		if len(s.frames) > 1 {
+			ev.UID = strconv.Itoa(i) + "-" + e.UID
+		}
+		lines = append(lines, ev.header(stamp)...)
+		lines = append(lines,
+			"DTSTART"+icalTime(w.Start, s.loc),
+			"DTEND"+icalTime(w.End, s.loc),
+			"RRULE:"+s.rrule(f),
+			"END:VEVENT")
+	}
+	return lines, s.loc, first
+}
+
+func (e Event) header(stamp time.Time) []string {
+	lines := []string{
+		"BEGIN:VEVENT",
+		"UID:" + escapeText(e.UID),
+		"DTSTAMP:" + stamp.UTC().Format(icalDateTimeUTC),
+		"SUMMARY:" + escapeText(e.Summary),
+	}
+	if e.Description != "" {
+		lines = append(lines, "DESCRIPTION:"+escapeText(e.Description))
+	}
+	return append(lines, "TRANSP:TRANSPARENT")
+}
+
+// firstWindow returns the first window of the timeframe that starts at or
+// after from.
+func (s *Schedule) firstWindow(f frame, from time.Time) (Window, bool) {
+	local := from.In(s.loc)
+	for i := 0; i < 4*366; i++ {
+		day := time.Date(local.Year(), local.Month(), local.Day()+i, 0, 0, 0, 0, s.loc)
+		if s.keyOf(day) != f.Key {
+			continue
+		}
+		if w := f.window(day); !w.Start.Before(from) {
+			return w, true
+		}
+	}
+	return Window{}, false
+}
+
+func (s *Schedule) rrule(f frame) string {
+	var rule string
+	switch s.recurrence {
+	case RecurrenceDaily:
+		rule = "FREQ=DAILY"
+	case RecurrenceWeekly:
+		rule = "FREQ=WEEKLY;BYDAY=" + strings.ToUpper(f.Key[:2])
+	default:
+		rule = "FREQ=MONTHLY;BYMONTHDAY=" + f.Key
+	}
+	if !s.end.IsZero() {
+		rule += ";UNTIL=" + s.end.UTC().Format(icalDateTimeUTC)
+	}
+	return rule
+}
+
+// icalTime formats t as the value of a DTSTART or DTEND property, including
+// the separator and TZID parameter.
+func icalTime(t time.Time, loc *time.Location) string {
+	if loc == time.UTC {
+		return ":" + t.UTC().Format(icalDateTimeUTC)
+	}
+	return ";TZID=" + loc.String() + ":" + t.In(loc).Format(icalDateTime)
+}
+
+// vtimezone describes loc as a VTIMEZONE component. Daylight saving rules are
+// derived from the transitions loc makes in the given year and expressed as
+// yearly recurrences on the nth (or last) weekday of the month.
+func vtimezone(loc *time.Location, year int) []string {
+	lines := []string{"BEGIN:VTIMEZONE", "TZID:" + loc.String()}
+	transitions := zoneTransitions(loc, year)
+	if len(transitions) == 0 {
+		name, offset := time.Date(year, time.January, 1, 0, 0, 0, 0, loc).Zone()
+		lines = append(lines,
+			"BEGIN:STANDARD",
+			"DTSTART:19700101T000000",
+			"TZOFFSETFROM:"+icalOffset(offset),
+			"TZOFFSETTO:"+icalOffset(offset),
+			"TZNAME:"+name,
+			"END:STANDARD")
+		return append(lines, "END:VTIMEZONE")
+	}
+	for _, t := range transitions {
+		_, fromOffset := t.Add(-time.Minute).In(loc).Zone()
+		name, toOffset := t.In(loc).Zone()
+		kind := "STANDARD"
+		if toOffset > fromOffset {
+			kind = "DAYLIGHT"
+		}
+		wall := t.Add(time.Duration(fromOffset) * time.Second).UTC()
+		nth := (wall.Day()-1)/7 + 1
+		if wall.Day()+7 > daysIn(wall.Month(), wall.Year()) {
+			nth = -1
+		}
+		lines = append(lines,
+			"BEGIN:"+kind,
+			"DTSTART:"+wall.Format(icalDateTime),
+			fmt.Sprintf("RRULE:FREQ=YEARLY;BYMONTH=%d;BYDAY=%d%s", int(wall.Month()), nth, strings.ToUpper(wall.Weekday().String()[:2])),
+			"TZOFFSETFROM:"+icalOffset(fromOffset),
+			"TZOFFSETTO:"+icalOffset(toOffset),
+			"TZNAME:"+name,
+			"END:"+kind)
+	}
+	return append(lines, "END:VTIMEZONE")
+}
+
+// zoneTransitions returns the instants in year at which loc changes its UTC
+// offset, to the minute.
+func zoneTransitions(loc *time.Location, year int) []time.Time {
+	var transitions []time.Time
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(1, 0, 0)
+	for day := start; day.Before(end); day = day.Add(24 * time.Hour) {
+		next := day.Add(24 * time.Hour)
+		_, before := day.In(loc).Zone()
+		if _, after := next.In(loc).Zone(); after == before {
+			continue
+		}
+		lo, hi := day, next
+		for hi.Sub(lo) > time.Minute {
+			mid := lo.Add(hi.Sub(lo) / 2).Truncate(time.Minute)
+			if _, off := mid.In(loc).Zone(); off == before {
+				lo = mid
+			} else {
+				hi = mid
+			}
+		}
+		transitions = append(transitions, hi)
+	}
+	return transitions
+}
+
+func daysIn(m time.Month, year int) int {
+	return time.Date(year, m+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func icalOffset(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign, seconds = "-", -seconds
+	}
+	return fmt.Sprintf("%s%02d%02d", sign, seconds/3600, seconds%3600/60)
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// writeFolded writes a content line terminated by CRLF, folding it so that no
+// line exceeds 75 octets without splitting a UTF-8 sequence.
+func writeFolded(buf *bytes.Buffer, line string) {
+	limit := icalLineLimit
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		buf.WriteString(line[:cut])
+		buf.WriteString("\r\n ")
+		line = line[cut:]
+		limit = icalLineLimit - 1
+	}
+	buf.WriteString(line)
+	buf.WriteString("\r\n")
+}
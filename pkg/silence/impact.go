@@ -0,0 +1,192 @@
+package silence
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+
+	"github.com/go-openapi/swag"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/client"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/monitor"
+)
+
+// MatcherImpact is how many of the scanned monitors a single matcher matches.
+type MatcherImpact struct {
+	Matcher string
+	Matched int
+}
+
+// ImpactReport lists the monitors a silence would mute.
+type ImpactReport struct {
+	// Monitors are the monitors whose labels satisfy every matcher.
+	Monitors []*monitor.ListedMonitor
+	// Scanned is the number of monitors the matchers were evaluated against.
+	Scanned int
+	// Matchers breaks the result down per matcher.
+	Matchers []MatcherImpact
+	// Warnings flag matchers that would mute everything or nothing.
+	Warnings []string
+}
+
+func (r *ImpactReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "silence mutes %d of %d monitors\n", len(r.Monitors), r.Scanned)
+	for _, m := range r.Monitors {
+		fmt.Fprintf(&b, "  %s (%s)\n", m.Title, m.UUID)
+	}
+	for _, w := range r.Warnings {
+		fmt.Fprintf(&b, "warning: %s\n", w)
+	}
+	return b.String()
+}
+
+// AnalyzeImpact lists the monitors selected by filter and evaluates the
+// matchers against their labels, as alerts from those monitors would be.
+// Alert labels that come from query results rather than the monitor
+// definition are not known in advance, so a monitor is reported only when
+// its own labels satisfy every matcher; a label the monitor does not set
+// counts as empty.
+func AnalyzeImpact(ctx context.Context, api *client.GroundcoverAPI, matchers models.Matchers, filter monitor.ListFilter) (*ImpactReport, error) {
+	compiled, err := compileMatchers(matchers)
+	if err != nil {
+		return nil, err
+	}
+	filter.Hydrate = true
+	var listed []*monitor.ListedMonitor
+	for m, err := range monitor.ListAllMonitors(ctx, api, filter) {
+		if err != nil {
+			return nil, err
+		}
+		listed = append(listed, m)
+	}
+	return impactOf(compiled, listed), nil
+}
+
+// MatchLabels reports whether a label set satisfies every matcher. Missing
+// labels count as empty, so name="" matches a label set without name.
+func MatchLabels(matchers models.Matchers, labels map[string]string) (bool, error) {
+	compiled, err := compileMatchers(matchers)
+	if err != nil {
+		return false, err
+	}
+	for _, c := range compiled {
+		if !c.matches(labels) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func impactOf(compiled []compiledMatcher, listed []*monitor.ListedMonitor) *ImpactReport {
+	r := &ImpactReport{Scanned: len(listed)}
+	counts := make([]int, len(compiled))
+	for _, m := range listed {
+		var labels map[string]string
+		if m.Monitor != nil {
+			labels = m.Monitor.Labels
+		}
+		all := true
+		for i, c := range compiled {
+			if c.matches(labels) {
+				counts[i]++
+			} else {
+				all = false
+			}
+		}
+		if all {
+			r.Monitors = append(r.Monitors, m)
+		}
+	}
+
+	if len(compiled) == 0 {
+		r.Warnings = append(r.Warnings, "silence has no matchers and would mute every alert")
+	}
+	for i, c := range compiled {
+		name := FormatMatcher(c.m)
+		r.Matchers = append(r.Matchers, MatcherImpact{Matcher: name, Matched: counts[i]})
+		switch {
+		case c.universal():
+			r.Warnings = append(r.Warnings, fmt.Sprintf("matcher %s matches any value, including a missing label", name))
+		case r.Scanned > 0 && counts[i] == r.Scanned:
+			r.Warnings = append(r.Warnings, fmt.Sprintf("matcher %s matches every monitor", name))
+		case r.Scanned > 0 && counts[i] == 0:
+			r.Warnings = append(r.Warnings, fmt.Sprintf("matcher %s matches no monitor", name))
+		}
+	}
+	if r.Scanned > 0 && len(compiled) > 0 {
+		switch len(r.Monitors) {
+		case r.Scanned:
+			r.Warnings = append(r.Warnings, fmt.Sprintf("silence would mute all %d monitors", r.Scanned))
+		case 0:
+			r.Warnings = append(r.Warnings, "silence would not mute any monitor")
+		}
+	}
+	return r
+}
+
+type compiledMatcher struct {
+	m  *models.SilenceMatcher
+	re *regexp.Regexp
+}
+
+func compileMatchers(matchers models.Matchers) ([]compiledMatcher, error) {
+	compiled := make([]compiledMatcher, 0, len(matchers))
+	for _, m := range matchers {
+		if err := ValidateMatcher(m); err != nil {
+			return nil, err
+		}
+		c := compiledMatcher{m: m}
+		if swag.BoolValue(m.IsRegex) {
+			c.re, _ = CompileMatcher(m)
+		}
+		compiled = append(compiled, c)
+	}
+	return compiled, nil
+}
+
+func (c compiledMatcher) matches(labels map[string]string) bool {
+	value := labels[c.m.Name]
+	var ok bool
+	if c.re != nil {
+		ok = c.re.MatchString(value)
+	} else {
+		ok = value == c.m.Value
+	}
+	if c.m.IsEqual == nil || *c.m.IsEqual {
+		return ok
+	}
+	return !ok
+}
+
+// universal reports whether the matcher accepts any value, like =~".*".
+func (c compiledMatcher) universal() bool {
+	if c.re == nil || (c.m.IsEqual != nil && !*c.m.IsEqual) {
+		return false
+	}
+	re, err := syntax.Parse(c.m.Value, syntax.Perl)
+	if err != nil {
+		return false
+	}
+	return matchesAnything(re.Simplify())
+}
+
+// matchesAnything reports whether a parsed regex accepts every string: ".*",
+// possibly grouped or as one branch of an alternation.
+func matchesAnything(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpStar:
+		return re.Sub[0].Op == syntax.OpAnyChar || re.Sub[0].Op == syntax.OpAnyCharNotNL
+	case syntax.OpCapture:
+		return matchesAnything(re.Sub[0])
+	case syntax.OpAlternate:
+		for _, sub := range re.Sub {
+			if matchesAnything(sub) {
+				return true
+			}
+		}
+	}
+	return false
+}
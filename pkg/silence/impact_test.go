@@ -0,0 +1,123 @@
+package silence
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/groundcover-com/groundcover-sdk-go/internal/testutil"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/client"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/monitor"
+	"github.com/stretchr/testify/require"
+)
+
+// newMonitorsAPI serves the given YAML monitor definitions; the UUID of each
+// is its index.
+func newMonitorsAPI(t *testing.T, monitors ...string) *client.GroundcoverAPI {
+	t.Helper()
+	api := testutil.NewAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/monitors/list" {
+			var req models.MonitorListRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			resp := models.MonitorListResponse{Monitors: []*models.MonitorListItem{}}
+			for i := req.Skip; i < int64(len(monitors)) && i < req.Skip+req.Limit; i++ {
+				resp.Monitors = append(resp.Monitors, &models.MonitorListItem{UUID: strfmt.UUID(fmt.Sprint(i)), Title: fmt.Sprint("monitor ", i)})
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(resp)
+			return
+		}
+		var i int
+		_, _ = fmt.Sscan(strings.TrimPrefix(r.URL.Path, "/api/monitors/"), &i)
+		_, _ = io.WriteString(w, monitors[i])
+	}))
+	return api
+}
+
+func TestAnalyzeImpact(t *testing.T) {
+	api := newMonitorsAPI(t,
+		"title: a\nlabels:\n  env: prod\n  team: payments\n",
+		"title: b\nlabels:\n  env: prod\n  team: search\n",
+		"title: c\nlabels:\n  env: staging\n  team: payments\n",
+		"title: d\n",
+	)
+	ctx := context.Background()
+
+	matchers, err := ParseMatchers(`env=~"prod|staging"`, `team!="search"`)
+	require.NoError(t, err)
+	report, err := AnalyzeImpact(ctx, api, matchers, monitor.ListFilter{})
+	require.NoError(t, err)
+	require.Equal(t, 4, report.Scanned)
+	var ids []string
+	for _, m := range report.Monitors {
+		ids = append(ids, string(m.UUID))
+	}
+	require.Equal(t, []string{"0", "2"}, ids)
+	require.Equal(t, []MatcherImpact{{`env=~"prod|staging"`, 3}, {`team!="search"`, 3}}, report.Matchers)
+	require.Empty(t, report.Warnings)
+	require.Contains(t, report.String(), "silence mutes 2 of 4 monitors")
+
+	report, err = AnalyzeImpact(ctx, api, models.Matchers{Regexp("env", ".*"), Equal("team", "billing")}, monitor.ListFilter{})
+	require.NoError(t, err)
+	require.Empty(t, report.Monitors)
+	require.Equal(t, []string{
+		`matcher env=~".*" matches any value, including a missing label`,
+		`matcher team="billing" matches no monitor`,
+		"silence would not mute any monitor",
+	}, report.Warnings)
+
+	report, err = AnalyzeImpact(ctx, api, nil, monitor.ListFilter{})
+	require.NoError(t, err)
+	require.Len(t, report.Monitors, 4)
+	require.Contains(t, report.Warnings, "silence has no matchers and would mute every alert")
+
+	_, err = AnalyzeImpact(ctx, api, models.Matchers{Regexp("env", "(")}, monitor.ListFilter{})
+	require.ErrorContains(t, err, "invalid regex")
+}
+
+func TestMatchLabels(t *testing.T) {
+	labels := map[string]string{"env": "prod", "workload": "api-7"}
+	for _, tc := range []struct {
+		matcher *models.SilenceMatcher
+		want    bool
+	}{
+		{Equal("env", "prod"), true},
+		{Equal("region", ""), true},
+		{NotEqual("env", "prod"), false},
+		{Regexp("workload", "api-[0-9]+"), true},
+		{Regexp("workload", "api"), false},
+		{NotRegexp("workload", "web-.*"), true},
+		{&models.SilenceMatcher{Name: "env", Value: "prod"}, true},
+	} {
+		ok, err := MatchLabels(models.Matchers{tc.matcher}, labels)
+		require.NoError(t, err)
+		require.Equal(t, tc.want, ok, FormatMatcher(tc.matcher))
+	}
+}
+
+func TestMatcherUniversal(t *testing.T) {
+	for value, want := range map[string]bool{
+		".*":           true,
+		"(.*)":         true,
+		"prod|.*":      true,
+		"(?s).*":       true,
+		".+":           false,
+		"":             false,
+		"x|prod|.*_.*": false,
+		"prod.*":       false,
+	} {
+		compiled, err := compileMatchers(models.Matchers{Regexp("env", value)})
+		require.NoError(t, err)
+		require.Equal(t, want, compiled[0].universal(), value)
+	}
+
+	compiled, err := compileMatchers(models.Matchers{NotRegexp("env", ".*")})
+	require.NoError(t, err)
+	require.False(t, compiled[0].universal())
+}
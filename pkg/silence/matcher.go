@@ -0,0 +1,159 @@
+package silence
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-openapi/swag"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/types"
+)
+
+// NewMatcher returns a matcher on a label using one of the four match types.
+func NewMatcher(name string, matchType types.MatchType, value string) *models.SilenceMatcher {
+	return &models.SilenceMatcher{
+		Name:    name,
+		Value:   value,
+		IsEqual: swag.Bool(matchType == types.MatchEqual || matchType == types.MatchRegexp),
+		IsRegex: swag.Bool(matchType == types.MatchRegexp || matchType == types.MatchNotRegexp),
+	}
+}
+
+// Equal matches alerts whose label equals value (name="value").
+func Equal(name, value string) *models.SilenceMatcher {
+	return NewMatcher(name, types.MatchEqual, value)
+}
+
+// NotEqual matches alerts whose label differs from value (name!="value").
+func NotEqual(name, value string) *models.SilenceMatcher {
+	return NewMatcher(name, types.MatchNotEqual, value)
+}
+
+// Regexp matches alerts whose label fully matches the regular expression
+// (name=~"value").
+func Regexp(name, value string) *models.SilenceMatcher {
+	return NewMatcher(name, types.MatchRegexp, value)
+}
+
+// NotRegexp matches alerts whose label does not fully match the regular
+// expression (name!~"value").
+func NotRegexp(name, value string) *models.SilenceMatcher {
+	return NewMatcher(name, types.MatchNotRegexp, value)
+}
+
+// MatchTypeOf returns the match type of m. A nil IsEqual counts as equal and
+// a nil IsRegex as a literal match, as the API treats them.
+func MatchTypeOf(m *models.SilenceMatcher) types.MatchType {
+	equal := m.IsEqual == nil || *m.IsEqual
+	regex := swag.BoolValue(m.IsRegex)
+	switch {
+	case equal && regex:
+		return types.MatchRegexp
+	case regex:
+		return types.MatchNotRegexp
+	case equal:
+		return types.MatchEqual
+	default:
+		return types.MatchNotEqual
+	}
+}
+
+// FormatMatcher renders m the way ParseMatcher reads it, e.g. env=~"prod|staging".
+func FormatMatcher(m *models.SilenceMatcher) string {
+	return m.Name + MatchTypeOf(m).String() + strconv.Quote(m.Value)
+}
+
+// ParseMatcher parses a matcher written as name=value, name!=value,
+// name=~regex or name!~regex. The value may be double-quoted.
+func ParseMatcher(s string) (*models.SilenceMatcher, error) {
+	i := strings.IndexAny(s, "=!")
+	if i <= 0 {
+		return nil, fmt.Errorf("invalid matcher %q: expected name, operator and value", s)
+	}
+	name, rest := strings.TrimSpace(s[:i]), s[i:]
+
+	var matchType types.MatchType
+	switch {
+	case strings.HasPrefix(rest, "=~"):
+		matchType, rest = types.MatchRegexp, rest[2:]
+	case strings.HasPrefix(rest, "!~"):
+		matchType, rest = types.MatchNotRegexp, rest[2:]
+	case strings.HasPrefix(rest, "!="):
+		matchType, rest = types.MatchNotEqual, rest[2:]
+	case strings.HasPrefix(rest, "="):
+		matchType, rest = types.MatchEqual, rest[1:]
+	default:
+		return nil, fmt.Errorf("invalid matcher %q: unknown operator", s)
+	}
+
+	value := strings.TrimSpace(rest)
+	if strings.HasPrefix(value, `"`) {
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid matcher %q: %w", s, err)
+		}
+		value = unquoted
+	}
+	m := NewMatcher(name, matchType, value)
+	if err := ValidateMatcher(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ParseMatchers parses each matcher with ParseMatcher.
+func ParseMatchers(ss ...string) (models.Matchers, error) {
+	matchers := make(models.Matchers, 0, len(ss))
+	for _, s := range ss {
+		m, err := ParseMatcher(s)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, m)
+	}
+	return matchers, nil
+}
+
+// CompileMatcher compiles the regular expression of a regex matcher. Like
+// the API, the expression is anchored at both ends.
+func CompileMatcher(m *models.SilenceMatcher) (*regexp.Regexp, error) {
+	re, err := regexp.Compile("^(?:" + m.Value + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex in matcher %s: %w", FormatMatcher(m), err)
+	}
+	return re, nil
+}
+
+// ValidateMatcher checks that m has a label name and, for regex matchers,
+// that its value compiles.
+func ValidateMatcher(m *models.SilenceMatcher) error {
+	if m == nil {
+		return fmt.Errorf("matcher is nil")
+	}
+	if m.Name == "" {
+		return fmt.Errorf("matcher %s has no label name", FormatMatcher(m))
+	}
+	if swag.BoolValue(m.IsRegex) {
+		if _, err := CompileMatcher(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateMatchers validates every matcher and requires at least one.
+func ValidateMatchers(matchers models.Matchers) error {
+	if len(matchers) == 0 {
+		return fmt.Errorf("silence needs at least one matcher")
+	}
+	var errs []error
+	for _, m := range matchers {
+		if err := ValidateMatcher(m); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,396 @@
+package silence
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+)
+
+// maxScan bounds how far ahead Next looks for windows.
+const maxScan = 4 * 366 * 24 * time.Hour
+
+// scanChunk is the stretch of time Next evaluates at once.
+const scanChunk = 31 * 24 * time.Hour
+
+// Window is a stretch of time in which a silence is active, from Start up to
+// but not including End. A zero End means the window does not end.
+type Window struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Contains reports whether t falls inside the window.
+func (w Window) Contains(t time.Time) bool {
+	return !t.Before(w.Start) && (w.End.IsZero() || t.Before(w.End))
+}
+
+func (w Window) String() string {
+	if w.End.IsZero() {
+		return w.Start.Format(time.RFC3339) + " onwards"
+	}
+	return w.Start.Format(time.RFC3339) + " - " + w.End.Format(time.RFC3339)
+}
+
+// Timeframe is one entry of a recurring schedule, such as the "22:00" to
+// "24:00" range under the "saturday" key.
+type Timeframe struct {
+	Key   string
+	Start string
+	End   string
+}
+
+func (f Timeframe) String() string {
+	return f.Key + " " + f.Start + "-" + f.End
+}
+
+// Overlap is a pair of timeframes of one schedule that are active at the same
+// time.
+type Overlap struct {
+	A, B Timeframe
+}
+
+func (o Overlap) String() string {
+	return o.A.String() + " overlaps " + o.B.String()
+}
+
+// Schedule tells when a silence is active. A recurring schedule repeats its
+// timeframes daily, weekly or monthly on the wall clock of its timezone, so
+// windows keep their local times across daylight saving changes. A one-off
+// schedule is a single window.
+type Schedule struct {
+	recurrence string
+	frames     []frame
+	loc        *time.Location
+	start, end time.Time
+}
+
+type frame struct {
+	Timeframe
+	from, to time.Duration
+}
+
+// NewSchedule returns the schedule of a recurring silence. The recurrence
+// type is one of the Recurrence constants, timeframes are keyed as for the
+// API, and an empty timezone means UTC.
+func NewSchedule(recurrence string, timeframes map[string][]models.TimeRange, timezone string) (*Schedule, error) {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", timezone, err)
+	}
+	s := &Schedule{recurrence: recurrence, loc: loc}
+	for _, key := range sortedKeys(timeframes) {
+		norm, err := normalizeKey(recurrence, key)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range timeframes[key] {
+			f := frame{Timeframe: Timeframe{Key: norm}}
+			if r.StartTime == nil || r.EndTime == nil {
+				return nil, fmt.Errorf("timeframe %s needs a start and an end time", key)
+			}
+			f.Start, f.End = *r.StartTime, *r.EndTime
+			if f.from, err = ParseClock(f.Start); err != nil {
+				return nil, err
+			}
+			if f.to, err = ParseClock(f.End); err != nil {
+				return nil, err
+			}
+			s.frames = append(s.frames, f)
+		}
+	}
+	if len(s.frames) == 0 {
+		return nil, fmt.Errorf("recurring schedule has no timeframes")
+	}
+	return s, nil
+}
+
+// OneTimeSchedule returns the schedule of a silence active from start to end.
+func OneTimeSchedule(start, end time.Time) *Schedule {
+	return &Schedule{loc: time.UTC, start: start, end: end}
+}
+
+// ScheduleOf returns the schedule of a silence fetched from the v2 API. The
+// start and end of a recurring silence, when set, bound its windows.
+func ScheduleOf(s *models.V2SilenceResponse) (*Schedule, error) {
+	start, end := time.Time(s.StartsAt), time.Time(s.EndsAt)
+	if s.Type != TypeRecurring {
+		return OneTimeSchedule(start, end), nil
+	}
+	schedule, err := NewSchedule(s.RecurrenceType, s.Timeframes, s.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("silence %s: %w", s.UUID, err)
+	}
+	return schedule.bounded(start, end), nil
+}
+
+// Schedule returns the schedule of the silence being built.
+func (b *Builder) Schedule() (*Schedule, error) {
+	if !b.Recurring() {
+		return OneTimeSchedule(b.startsAt, b.endsAt), nil
+	}
+	s, err := NewSchedule(b.recurrence, b.timeframes, b.timezone)
+	if err != nil {
+		return nil, err
+	}
+	return s.bounded(b.startsAt, b.endsAt), nil
+}
+
+func (s *Schedule) bounded(start, end time.Time) *Schedule {
+	if start.Year() > 1 {
+		s.start = start
+	}
+	if end.Year() > 1 {
+		s.end = end
+	}
+	return s
+}
+
+// Recurring reports whether the schedule repeats.
+func (s *Schedule) Recurring() bool {
+	return s.recurrence != ""
+}
+
+// Location returns the timezone the timeframes are read in.
+func (s *Schedule) Location() *time.Location {
+	return s.loc
+}
+
+// ActiveAt reports whether the silence is active at t.
+func (s *Schedule) ActiveAt(t time.Time) bool {
+	for _, w := range s.occurrences(t, t.Add(time.Nanosecond)) {
+		if w.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Next returns up to n windows that end after t, in order. A window that is
+// active at t is included with its actual start. Timeframes that touch or
+// overlap are merged, so a Saturday 22:00-24:00 and Sunday 00:00-06:00 pair
+// is one window. A schedule without gaps yields a single window with no end.
+func (s *Schedule) Next(t time.Time, n int) []Window {
+	if n <= 0 {
+		return nil
+	}
+	if s.Recurring() && s.Gapless() {
+		start := t
+		if s.start.After(start) {
+			start = s.start
+		}
+		if !s.end.IsZero() && !s.end.After(t) {
+			return nil
+		}
+		return []Window{{Start: start, End: s.end}}
+	}
+
+	var windows []Window
+	for from := t; len(windows) <= n && from.Before(t.Add(maxScan)); from = from.Add(scanChunk) {
+		windows = mergeWindows(windows, s.occurrences(from, from.Add(scanChunk)))
+		if !s.Recurring() || (!s.end.IsZero() && !from.Add(scanChunk).Before(s.end)) {
+			break
+		}
+	}
+	if len(windows) > n {
+		windows = windows[:n]
+	}
+	return windows
+}
+
+// Gapless reports whether the timeframes of a recurring schedule cover every
+// moment of the recurrence cycle, which makes the silence permanent.
+func (s *Schedule) Gapless() bool {
+	if !s.Recurring() {
+		return false
+	}
+	from, to := cycle(s.recurrence)
+	windows := mergeWindows(nil, s.wallOccurrences(from, to))
+	return len(windows) > 0 && !windows[0].Start.After(from) && !windows[0].End.Before(to)
+}
+
+// Overlaps returns the pairs of timeframes that are active at the same time
+// somewhere in the recurrence cycle. Touching timeframes do not overlap.
+func (s *Schedule) Overlaps() []Overlap {
+	if !s.Recurring() {
+		return nil
+	}
+	from, to := cycle(s.recurrence)
+	occs := s.wallFrames(from.Add(-24*time.Hour), to)
+	seen := map[Overlap]bool{}
+	var overlaps []Overlap
+	for i, a := range occs {
+		for _, b := range occs[i+1:] {
+			if !b.Start.Before(a.End) {
+				break
+			}
+			o := Overlap{A: a.frame.Timeframe, B: b.frame.Timeframe}
+			if !seen[o] {
+				seen[o] = true
+				overlaps = append(overlaps, o)
+			}
+		}
+	}
+	return overlaps
+}
+
+type occurrence struct {
+	Window
+	frame frame
+}
+
+// cycle returns a stretch of wall-clock time that contains every combination
+// of days a schedule of the recurrence type can produce, including months of
+// every length and a leap February.
+func cycle(recurrence string) (time.Time, time.Time) {
+	from := time.Date(2001, time.January, 1, 0, 0, 0, 0, time.UTC) // a Monday
+	switch recurrence {
+	case RecurrenceDaily:
+		return from, from.AddDate(0, 0, 1)
+	case RecurrenceWeekly:
+		return from, from.AddDate(0, 0, 7)
+	default:
+		return from, from.AddDate(4, 0, 0)
+	}
+}
+
+// occurrences returns the windows of the schedule that intersect [from, to),
+// clipped to its bounds and sorted by start.
+func (s *Schedule) occurrences(from, to time.Time) []Window {
+	if !s.Recurring() {
+		w := Window{Start: s.start, End: s.end}
+		if w.End.IsZero() || (w.End.After(from) && w.Start.Before(to)) {
+			return []Window{w}
+		}
+		return nil
+	}
+	var windows []Window
+	for _, o := range s.framesIn(s.loc, from, to) {
+		w := o.Window
+		if !s.start.IsZero() && w.Start.Before(s.start) {
+			w.Start = s.start
+		}
+		if !s.end.IsZero() && w.End.After(s.end) {
+			w.End = s.end
+		}
+		if w.Start.Before(w.End) {
+			windows = append(windows, w)
+		}
+	}
+	return windows
+}
+
+// wallOccurrences is like occurrences but reads the timeframes in UTC and
+// ignores the bounds, to reason about the schedule itself.
+func (s *Schedule) wallOccurrences(from, to time.Time) []Window {
+	var windows []Window
+	for _, o := range s.wallFrames(from, to) {
+		windows = append(windows, o.Window)
+	}
+	return windows
+}
+
+func (s *Schedule) wallFrames(from, to time.Time) []occurrence {
+	return s.framesIn(time.UTC, from, to)
+}
+
+// framesIn expands the timeframes in loc for every local date whose windows
+// may intersect [from, to).
+func (s *Schedule) framesIn(loc *time.Location, from, to time.Time) []occurrence {
+	var occs []occurrence
+	local := from.In(loc)
+	day := time.Date(local.Year(), local.Month(), local.Day()-1, 0, 0, 0, 0, loc)
+	for ; day.Before(to); day = time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, loc) {
+		key := s.keyOf(day)
+		for _, f := range s.frames {
+			if f.Key != key {
+				continue
+			}
+			w := f.window(day)
+			if w.End.After(from) && w.Start.Before(to) {
+				occs = append(occs, occurrence{Window: w, frame: f})
+			}
+		}
+	}
+	sort.SliceStable(occs, func(i, j int) bool {
+		return occs[i].Start.Before(occs[j].Start)
+	})
+	return occs
+}
+
+// window places the timeframe on the local date of day. An end at or before
+// the start falls on the next day.
+func (f frame) window(day time.Time) Window {
+	y, m, d := day.Date()
+	endDay := d
+	if f.to <= f.from {
+		endDay++
+	}
+	return Window{
+		Start: time.Date(y, m, d, int(f.from/time.Hour), int(f.from%time.Hour/time.Minute), 0, 0, day.Location()),
+		End:   time.Date(y, m, endDay, int(f.to/time.Hour), int(f.to%time.Hour/time.Minute), 0, 0, day.Location()),
+	}
+}
+
+func (s *Schedule) keyOf(day time.Time) string {
+	switch s.recurrence {
+	case RecurrenceDaily:
+		return EveryDay
+	case RecurrenceWeekly:
+		return strings.ToLower(day.Weekday().String())
+	default:
+		return strconv.Itoa(day.Day())
+	}
+}
+
+// mergeWindows appends the sorted windows to merged, joining windows that
+// touch or overlap.
+func mergeWindows(merged []Window, windows []Window) []Window {
+	for _, w := range windows {
+		if n := len(merged); n > 0 && !w.Start.After(merged[n-1].End) {
+			if w.End.After(merged[n-1].End) {
+				merged[n-1].End = w.End
+			}
+			continue
+		}
+		merged = append(merged, w)
+	}
+	return merged
+}
+
+var weekdays = map[string]bool{
+	"sunday": true, "monday": true, "tuesday": true, "wednesday": true,
+	"thursday": true, "friday": true, "saturday": true,
+}
+
+func normalizeKey(recurrence, key string) (string, error) {
+	switch recurrence {
+	case RecurrenceDaily:
+		if key == EveryDay {
+			return key, nil
+		}
+	case RecurrenceWeekly:
+		if lower := strings.ToLower(key); weekdays[lower] {
+			return lower, nil
+		}
+	case RecurrenceMonthly:
+		if day, err := strconv.Atoi(key); err == nil && day >= 1 && day <= 31 {
+			return strconv.Itoa(day), nil
+		}
+	default:
+		return "", fmt.Errorf("unknown recurrence type %q", recurrence)
+	}
+	return "", fmt.Errorf("invalid %s timeframe key %q", recurrence, key)
+}
+
+func sortedKeys(m map[string][]models.TimeRange) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
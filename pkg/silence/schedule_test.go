@@ -0,0 +1,175 @@
+package silence
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func timeframes(kv ...string) map[string][]models.TimeRange {
+	frames := map[string][]models.TimeRange{}
+	for i := 0; i < len(kv); i += 3 {
+		frames[kv[i]] = append(frames[kv[i]], models.TimeRange{StartTime: swag.String(kv[i+1]), EndTime: swag.String(kv[i+2])})
+	}
+	return frames
+}
+
+func TestScheduleFollowsLocalTimeAcrossDST(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	s, err := NewSchedule(RecurrenceDaily, timeframes(EveryDay, "01:00", "05:00"), "Europe/Berlin")
+	require.NoError(t, err)
+
+	windows := s.Next(time.Date(2024, 3, 30, 12, 0, 0, 0, berlin), 3)
+	require.Len(t, windows, 3)
+	for i, w := range windows {
+		require.Equal(t, 1, w.Start.In(berlin).Hour(), i)
+		require.Equal(t, 5, w.End.In(berlin).Hour(), i)
+	}
+	// Clocks go forward on March 31st, so that night's window is an hour short.
+	require.Equal(t, 3*time.Hour, windows[0].End.Sub(windows[0].Start))
+	require.Equal(t, 4*time.Hour, windows[1].End.Sub(windows[1].Start))
+
+	require.True(t, s.ActiveAt(time.Date(2024, 10, 27, 2, 30, 0, 0, berlin)))
+	require.False(t, s.ActiveAt(time.Date(2024, 10, 27, 5, 0, 0, 0, berlin)))
+	require.False(t, s.Gapless())
+	require.Empty(t, s.Overlaps())
+}
+
+func TestScheduleMergesAcrossMidnight(t *testing.T) {
+	s, err := NewSchedule(RecurrenceWeekly, timeframes(
+		"Saturday", "22:00", "24:00",
+		"sunday", "00:00", "06:00",
+		"wednesday", "23:00", "01:00",
+	), "")
+	require.NoError(t, err)
+
+	// Wednesday, January 3rd 2024.
+	windows := s.Next(time.Date(2024, 1, 3, 23, 30, 0, 0, time.UTC), 3)
+	require.Equal(t, []Window{
+		{Start: time.Date(2024, 1, 3, 23, 0, 0, 0, time.UTC), End: time.Date(2024, 1, 4, 1, 0, 0, 0, time.UTC)},
+		{Start: time.Date(2024, 1, 6, 22, 0, 0, 0, time.UTC), End: time.Date(2024, 1, 7, 6, 0, 0, 0, time.UTC)},
+		{Start: time.Date(2024, 1, 10, 23, 0, 0, 0, time.UTC), End: time.Date(2024, 1, 11, 1, 0, 0, 0, time.UTC)},
+	}, windows)
+	require.True(t, s.ActiveAt(time.Date(2024, 1, 4, 0, 30, 0, 0, time.UTC)))
+	require.Empty(t, s.Overlaps())
+}
+
+func TestScheduleDetectsOverlapsAndGaps(t *testing.T) {
+	s, err := NewSchedule(RecurrenceMonthly, timeframes(
+		"31", "20:00", "08:00",
+		"1", "06:00", "10:00",
+		"15", "09:00", "12:00",
+		"15", "11:00", "13:00",
+	), "UTC")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []Overlap{
+		{A: Timeframe{"31", "20:00", "08:00"}, B: Timeframe{"1", "06:00", "10:00"}},
+		{A: Timeframe{"15", "09:00", "12:00"}, B: Timeframe{"15", "11:00", "13:00"}},
+	}, s.Overlaps())
+	require.False(t, s.Gapless())
+
+	// Day 31 only exists in some months.
+	windows := s.Next(time.Date(2024, 4, 20, 0, 0, 0, 0, time.UTC), 3)
+	require.Equal(t, time.Date(2024, 5, 1, 6, 0, 0, 0, time.UTC), windows[0].Start)
+	require.Equal(t, time.Date(2024, 5, 15, 9, 0, 0, 0, time.UTC), windows[1].Start)
+	require.Equal(t, time.Date(2024, 5, 15, 13, 0, 0, 0, time.UTC), windows[1].End)
+	require.Equal(t, time.Date(2024, 5, 31, 20, 0, 0, 0, time.UTC), windows[2].Start)
+	require.Equal(t, time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC), windows[2].End)
+
+	always, err := NewSchedule(RecurrenceWeekly, timeframes(
+		"monday", "00:00", "00:00", "tuesday", "00:00", "00:00", "wednesday", "00:00", "00:00",
+		"thursday", "00:00", "00:00", "friday", "00:00", "12:00", "friday", "12:00", "24:00",
+		"saturday", "00:00", "00:00", "sunday", "00:00", "00:00",
+	), "America/New_York")
+	require.NoError(t, err)
+	require.True(t, always.Gapless())
+	now := time.Now()
+	require.Equal(t, []Window{{Start: now}}, always.Next(now, 5))
+}
+
+func TestScheduleBoundsAndErrors(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s, err := ScheduleOf(&models.V2SilenceResponse{
+		Type:           TypeRecurring,
+		RecurrenceType: RecurrenceDaily,
+		Timeframes:     timeframes(EveryDay, "10:00", "11:00"),
+		StartsAt:       strfmt.DateTime(start.Add(10*time.Hour + 30*time.Minute)),
+		EndsAt:         strfmt.DateTime(start.AddDate(0, 0, 2)),
+	})
+	require.NoError(t, err)
+	require.Equal(t, []Window{
+		{Start: start.Add(10*time.Hour + 30*time.Minute), End: start.Add(11 * time.Hour)},
+		{Start: start.Add(34 * time.Hour), End: start.Add(35 * time.Hour)},
+	}, s.Next(start, 5))
+
+	oneOff := OneTimeSchedule(start, start.Add(time.Hour))
+	require.True(t, oneOff.ActiveAt(start))
+	require.False(t, oneOff.ActiveAt(start.Add(time.Hour)))
+	require.Len(t, oneOff.Next(start.Add(-time.Hour), 3), 1)
+	require.Empty(t, oneOff.Next(start.Add(time.Hour), 3))
+
+	for _, tc := range []struct {
+		recurrence string
+		frames     map[string][]models.TimeRange
+	}{
+		{RecurrenceDaily, timeframes("monday", "01:00", "02:00")},
+		{RecurrenceWeekly, timeframes("someday", "01:00", "02:00")},
+		{RecurrenceMonthly, timeframes("32", "01:00", "02:00")},
+		{RecurrenceDaily, timeframes(EveryDay, "1:00", "02:00")},
+		{"yearly", timeframes(EveryDay, "01:00", "02:00")},
+	} {
+		_, err := NewSchedule(tc.recurrence, tc.frames, "")
+		require.Error(t, err, tc)
+	}
+}
+
+func TestCalendarExport(t *testing.T) {
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	cal, err := CalendarOf("Maintenance", []*models.V2SilenceResponse{
+		{
+			UUID:           "weekly",
+			Type:           TypeRecurring,
+			Enabled:        true,
+			Comment:        "DB maintenance; nightly, mostly",
+			RecurrenceType: RecurrenceWeekly,
+			Timeframes:     timeframes("sunday", "22:00", "02:00"),
+			Timezone:       "Europe/Berlin",
+			EndsAt:         strfmt.DateTime(start.AddDate(0, 6, 0)),
+			Matchers:       models.Matchers{Equal("env", "prod"), Regexp("workload", "db-.*")},
+		},
+		{UUID: "once", Type: TypeOneTime, StartsAt: strfmt.DateTime(start), EndsAt: strfmt.DateTime(start.Add(time.Hour))},
+		{UUID: "off", Type: TypeRecurring, RecurrenceType: RecurrenceDaily, Timeframes: timeframes(EveryDay, "01:00", "02:00")},
+	})
+	require.NoError(t, err)
+	require.Len(t, cal.Events, 2)
+	cal.Stamp = start
+
+	out := cal.String()
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		require.LessOrEqual(t, len(line), 75, line)
+	}
+	unfolded := strings.ReplaceAll(out, "\r\n ", "")
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\nVERSION:2.0\r\n",
+		"X-WR-CALNAME:Maintenance\r\n",
+		"BEGIN:VTIMEZONE\r\nTZID:Europe/Berlin\r\n",
+		"BEGIN:DAYLIGHT\r\nDTSTART:20240331T020000\r\nRRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=-1SU\r\nTZOFFSETFROM:+0100\r\nTZOFFSETTO:+0200\r\nTZNAME:CEST\r\n",
+		"BEGIN:STANDARD\r\nDTSTART:20241027T030000\r\nRRULE:FREQ=YEARLY;BYMONTH=10;BYDAY=-1SU\r\n",
+		"UID:weekly@groundcover\r\nDTSTAMP:20240301T090000Z\r\nSUMMARY:DB maintenance\\; nightly\\, mostly\r\n",
+		`DESCRIPTION:Matchers: env="prod"\, workload=~"db-.*"` + "\r\n",
+		"DTSTART;TZID=Europe/Berlin:20240303T220000\r\nDTEND;TZID=Europe/Berlin:20240304T020000\r\nRRULE:FREQ=WEEKLY;BYDAY=SU;UNTIL=20240901T090000Z\r\n",
+		"UID:once@groundcover\r\nDTSTAMP:20240301T090000Z\r\nSUMMARY:Silence\r\n",
+		"DTSTART:20240301T090000Z\r\nDTEND:20240301T100000Z\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		require.Contains(t, unfolded, want)
+	}
+	require.NotContains(t, out, "off@groundcover")
+	require.Equal(t, 1, strings.Count(out, "BEGIN:VTIMEZONE"))
+}
@@ -0,0 +1,276 @@
+// Package silence provides helpers for defining groundcover silences.
+//
+// The API knows silences in three shapes: one-off silences
+// (models.CreateSilenceRequest), recurring silences
+// (models.CreateRecurringSilenceRequest) and the v2 endpoint that accepts
+// both (models.V2CreateSilenceRequest). Builder describes a silence once,
+// validates its matchers and schedule locally and produces whichever request
+// is needed; Create sends it to the v2 endpoint unless told otherwise.
+// Schedule evaluates when a silence is active and Calendar exports schedules
+// to iCalendar.
+package silence
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+)
+
+// Silence types.
+const (
+	TypeOneTime   = models.V2CreateSilenceRequestTypeOneTime
+	TypeRecurring = models.V2CreateSilenceRequestTypeRecurring
+)
+
+// Recurrence types of recurring silences.
+const (
+	RecurrenceDaily   = models.V2CreateSilenceRequestRecurrenceTypeDaily
+	RecurrenceWeekly  = models.V2CreateSilenceRequestRecurrenceTypeWeekly
+	RecurrenceMonthly = models.V2CreateSilenceRequestRecurrenceTypeMonthly
+)
+
+// EveryDay is the timeframe key of daily recurring silences. Weekly silences
+// are keyed by lower-case weekday names and monthly ones by day of month.
+const EveryDay = "every_day"
+
+// Builder builds a silence. Methods record problems rather than failing
+// immediately; Build reports all of them at once. A Builder should not be
+// reused after Build.
+type Builder struct {
+	matchers   models.Matchers
+	comment    string
+	startsAt   time.Time
+	endsAt     time.Time
+	recurrence string
+	timeframes map[string][]models.TimeRange
+	timezone   string
+	enabled    *bool
+	errs       []error
+}
+
+// New starts a silence with the given matchers.
+func New(matchers ...*models.SilenceMatcher) *Builder {
+	return &Builder{matchers: append(models.Matchers(nil), matchers...)}
+}
+
+func (b *Builder) addError(format string, args ...interface{}) {
+	b.errs = append(b.errs, fmt.Errorf(format, args...))
+}
+
+// Match adds matchers to the silence.
+func (b *Builder) Match(matchers ...*models.SilenceMatcher) *Builder {
+	b.matchers = append(b.matchers, matchers...)
+	return b
+}
+
+// MatchString parses matchers such as `env=~"prod.*"` and adds them.
+func (b *Builder) MatchString(matchers ...string) *Builder {
+	parsed, err := ParseMatchers(matchers...)
+	if err != nil {
+		b.errs = append(b.errs, err)
+		return b
+	}
+	return b.Match(parsed...)
+}
+
+// Comment sets the comment shown with the silence.
+func (b *Builder) Comment(comment string) *Builder {
+	b.comment = comment
+	return b
+}
+
+// Between makes a one-off silence active from start until end. On a
+// recurring silence it bounds the period in which the timeframes apply; the
+// v2 endpoint reads a recurring silence without bounds as already expired,
+// so Build requires them.
+func (b *Builder) Between(start, end time.Time) *Builder {
+	if !end.After(start) {
+		b.addError("silence must end after it starts")
+	}
+	b.startsAt, b.endsAt = start, end
+	return b
+}
+
+// For makes a one-off silence active from now for d.
+func (b *Builder) For(d time.Duration) *Builder {
+	if d <= 0 {
+		b.addError("silence duration must be positive, got %s", d)
+		return b
+	}
+	now := time.Now()
+	return b.Between(now, now.Add(d))
+}
+
+// Daily makes the silence recur every day between start and end, given as
+// "HH:MM" in the silence timezone.
+func (b *Builder) Daily(start, end string) *Builder {
+	return b.recur(RecurrenceDaily, EveryDay, start, end)
+}
+
+// Weekly makes the silence recur on day between start and end. Call it once
+// per weekday to silence several days.
+func (b *Builder) Weekly(day time.Weekday, start, end string) *Builder {
+	return b.recur(RecurrenceWeekly, strings.ToLower(day.String()), start, end)
+}
+
+// Monthly makes the silence recur on the given day of month between start
+// and end.
+func (b *Builder) Monthly(day int, start, end string) *Builder {
+	if day < 1 || day > 31 {
+		b.addError("day of month must be between 1 and 31, got %d", day)
+		return b
+	}
+	return b.recur(RecurrenceMonthly, strconv.Itoa(day), start, end)
+}
+
+func (b *Builder) recur(recurrence, key, start, end string) *Builder {
+	if b.recurrence != "" && b.recurrence != recurrence {
+		b.addError("cannot mix %s and %s timeframes in one silence", b.recurrence, recurrence)
+		return b
+	}
+	for _, v := range []string{start, end} {
+		if _, err := ParseClock(v); err != nil {
+			b.errs = append(b.errs, err)
+			return b
+		}
+	}
+	if b.timeframes == nil {
+		b.timeframes = map[string][]models.TimeRange{}
+	}
+	b.recurrence = recurrence
+	b.timeframes[key] = append(b.timeframes[key], models.TimeRange{StartTime: swag.String(start), EndTime: swag.String(end)})
+	return b
+}
+
+// Timezone sets the IANA timezone the timeframes of a recurring silence are
+// read in. It defaults to UTC.
+func (b *Builder) Timezone(name string) *Builder {
+	if _, err := time.LoadLocation(name); err != nil {
+		b.addError("invalid timezone %q: %v", name, err)
+		return b
+	}
+	b.timezone = name
+	return b
+}
+
+// Enabled sets whether a recurring silence is enabled. New recurring
+// silences are enabled by default.
+func (b *Builder) Enabled(enabled bool) *Builder {
+	b.enabled = swag.Bool(enabled)
+	return b
+}
+
+// Recurring reports whether the silence has recurring timeframes.
+func (b *Builder) Recurring() bool {
+	return b.recurrence != ""
+}
+
+// validate checks the definition. bounded requires a recurring silence to
+// have a start and end, as the v2 request carries them.
+func (b *Builder) validate(bounded bool) error {
+	errs := append([]error(nil), b.errs...)
+	if err := ValidateMatchers(b.matchers); err != nil {
+		errs = append(errs, err)
+	}
+	switch {
+	case !b.Recurring() && b.endsAt.IsZero():
+		errs = append(errs, fmt.Errorf("one-off silence needs Between or For"))
+	case b.Recurring() && bounded && b.endsAt.IsZero():
+		errs = append(errs, fmt.Errorf("recurring silence needs Between"))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("invalid silence definition: %w", err)
+	}
+	return nil
+}
+
+// Build checks the definition and returns the v2 request, which covers
+// one-off and recurring silences alike.
+func (b *Builder) Build() (*models.V2CreateSilenceRequest, error) {
+	if err := b.validate(true); err != nil {
+		return nil, err
+	}
+	req := &models.V2CreateSilenceRequest{
+		Comment:  b.comment,
+		Matchers: b.matchers,
+		StartsAt: strfmt.DateTime(b.startsAt),
+		EndsAt:   strfmt.DateTime(b.endsAt),
+		Type:     swag.String(TypeOneTime),
+	}
+	if b.Recurring() {
+		req.Type = swag.String(TypeRecurring)
+		req.RecurrenceType = b.recurrence
+		req.Timeframes = b.timeframes
+		req.Timezone = b.timezoneName()
+		req.Enabled = b.enabledValue()
+	}
+	return req, nil
+}
+
+// BuildOneTime returns the request of the legacy one-off silence endpoint.
+func (b *Builder) BuildOneTime() (*models.CreateSilenceRequest, error) {
+	if err := b.validate(false); err != nil {
+		return nil, err
+	}
+	if b.Recurring() {
+		return nil, fmt.Errorf("silence is recurring; use BuildRecurring")
+	}
+	startsAt, endsAt := strfmt.DateTime(b.startsAt), strfmt.DateTime(b.endsAt)
+	return &models.CreateSilenceRequest{
+		Comment:  b.comment,
+		Matchers: b.matchers,
+		StartsAt: &startsAt,
+		EndsAt:   &endsAt,
+	}, nil
+}
+
+// BuildRecurring returns the request of the legacy recurring silence endpoint.
+func (b *Builder) BuildRecurring() (*models.CreateRecurringSilenceRequest, error) {
+	if err := b.validate(false); err != nil {
+		return nil, err
+	}
+	if !b.Recurring() {
+		return nil, fmt.Errorf("silence has no recurring timeframes; use BuildOneTime")
+	}
+	return &models.CreateRecurringSilenceRequest{
+		Comment:        b.comment,
+		Matchers:       b.matchers,
+		RecurrenceType: swag.String(b.recurrence),
+		Timeframes:     b.timeframes,
+		Timezone:       swag.String(b.timezoneName()),
+		Enabled:        b.enabledValue(),
+	}, nil
+}
+
+func (b *Builder) timezoneName() string {
+	if b.timezone == "" {
+		return "UTC"
+	}
+	return b.timezone
+}
+
+func (b *Builder) enabledValue() *bool {
+	if b.enabled == nil {
+		return swag.Bool(true)
+	}
+	return b.enabled
+}
+
+// ParseClock parses a time of day written as "HH:MM" and returns it as an
+// offset from midnight. "24:00" is accepted as the end of the day.
+func ParseClock(s string) (time.Duration, error) {
+	hours, minutes, ok := strings.Cut(s, ":")
+	h, herr := strconv.Atoi(hours)
+	m, merr := strconv.Atoi(minutes)
+	if !ok || len(hours) != 2 || len(minutes) != 2 || herr != nil || merr != nil ||
+		h < 0 || m < 0 || m > 59 || h > 24 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("invalid time of day %q: expected HH:MM", s)
+	}
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute, nil
+}
@@ -0,0 +1,208 @@
+package silence
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/groundcover-com/groundcover-sdk-go/internal/testutil"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSilences struct {
+	mu       sync.Mutex
+	silences map[string]*models.V2SilenceResponse
+	paths    []string
+}
+
+func (f *fakeSilences) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.paths = append(f.paths, r.Method+" "+r.URL.Path)
+	id := strings.TrimPrefix(r.URL.Path, "/api/monitors/v2/silences/")
+	var resp interface{}
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/api/monitors/v2/silences":
+		var req models.V2CreateSilenceRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		s := &models.V2SilenceResponse{
+			UUID:           strfmt.UUID(fmt.Sprint(len(f.silences))),
+			Type:           swag.StringValue(req.Type),
+			Comment:        req.Comment,
+			Enabled:        req.Enabled == nil || *req.Enabled,
+			StartsAt:       req.StartsAt,
+			EndsAt:         req.EndsAt,
+			RecurrenceType: req.RecurrenceType,
+			Timeframes:     req.Timeframes,
+			Timezone:       req.Timezone,
+			Matchers:       req.Matchers,
+		}
+		f.silences[string(s.UUID)] = s
+		resp = s
+	case r.Method == http.MethodPost && r.URL.Path == "/api/monitors/silences":
+		var req models.CreateSilenceRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		resp = &models.Silence{UUID: "legacy", Comment: req.Comment, EndsAt: *req.EndsAt, Matchers: req.Matchers}
+	case r.Method == http.MethodGet:
+		resp = f.silences[id]
+	case r.Method == http.MethodPut:
+		var req models.V2UpdateSilenceRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		s := f.silences[id]
+		s.StartsAt, s.EndsAt, s.Comment, s.Matchers = req.StartsAt, req.EndsAt, req.Comment, req.Matchers
+		s.Enabled = swag.BoolValue(req.Enabled)
+		resp = s
+	case r.Method == http.MethodDelete:
+		delete(f.silences, id)
+		resp = map[string]string{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func TestParseMatcher(t *testing.T) {
+	cases := map[string]types.MatchType{
+		`env="prod"`:         types.MatchEqual,
+		`env!=prod`:          types.MatchNotEqual,
+		`env=~"prod|stage"`:  types.MatchRegexp,
+		`env !~ "dev-.*"`:    types.MatchNotRegexp,
+		`path=~"/api/v[12]"`: types.MatchRegexp,
+	}
+	for s, want := range cases {
+		m, err := ParseMatcher(s)
+		require.NoError(t, err, s)
+		require.Equal(t, want, MatchTypeOf(m), s)
+
+		again, err := ParseMatcher(FormatMatcher(m))
+		require.NoError(t, err, s)
+		require.Equal(t, m, again, s)
+	}
+
+	for _, s := range []string{`env`, `=prod`, `env=~"*prod"`, `env="unterminated`} {
+		_, err := ParseMatcher(s)
+		require.Error(t, err, s)
+	}
+
+	// Matchers without flags are literal equality matchers.
+	require.Equal(t, types.MatchEqual, MatchTypeOf(&models.SilenceMatcher{Name: "a"}))
+}
+
+func TestBuilderOneTimeAndRecurring(t *testing.T) {
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	req, err := New(Equal("env", "prod")).MatchString(`workload=~"api-.*"`).
+		Comment("deploy").Between(start, start.Add(time.Hour)).Build()
+	require.NoError(t, err)
+	require.Equal(t, TypeOneTime, *req.Type)
+	require.Len(t, req.Matchers, 2)
+	require.True(t, *req.Matchers[1].IsRegex)
+	require.NoError(t, req.Validate(strfmt.Default))
+
+	_, err = New(Equal("env", "prod")).Between(start, start.Add(time.Hour)).BuildRecurring()
+	require.Error(t, err)
+
+	rec := New(Equal("team", "payments")).Weekly(time.Saturday, "22:00", "24:00").Weekly(time.Sunday, "00:00", "06:00").
+		Timezone("Europe/Berlin").Between(start, start.AddDate(1, 0, 0))
+	req, err = rec.Build()
+	require.NoError(t, err)
+	require.Equal(t, TypeRecurring, *req.Type)
+	require.Equal(t, RecurrenceWeekly, req.RecurrenceType)
+	require.Equal(t, []string{"saturday", "sunday"}, slices.Sorted(maps.Keys(req.Timeframes)))
+	require.Equal(t, "Europe/Berlin", req.Timezone)
+	require.True(t, *req.Enabled)
+
+	legacy, err := rec.BuildRecurring()
+	require.NoError(t, err)
+	require.Equal(t, RecurrenceWeekly, *legacy.RecurrenceType)
+	require.NoError(t, legacy.Validate(strfmt.Default))
+}
+
+func TestBuilderRequiresBoundsOfRecurringSilences(t *testing.T) {
+	daily := New(Equal("env", "prod")).Daily("01:00", "02:00")
+	_, err := daily.Build()
+	require.ErrorContains(t, err, "recurring silence needs Between")
+	// The legacy endpoint has no bounds.
+	_, err = daily.BuildRecurring()
+	require.NoError(t, err)
+
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	req, err := daily.Between(start, start.AddDate(0, 1, 0)).Build()
+	require.NoError(t, err)
+	body, err := json.Marshal(req)
+	require.NoError(t, err)
+	require.Contains(t, string(body), `"startsAt":"2024-03-01T00:00:00.000Z"`)
+	require.Contains(t, string(body), `"endsAt":"2024-04-01T00:00:00.000Z"`)
+	require.NotContains(t, string(body), "0001-01-01")
+}
+
+func TestBuilderReportsAllProblems(t *testing.T) {
+	_, err := New(Regexp("env", "(prod"), Equal("", "x")).
+		Monthly(3, "01:00", "02:00").Monthly(4, "25:00", "06:00").Daily("01:00", "02:00").Timezone("Mars/Olympus").Build()
+	require.Error(t, err)
+	for _, want := range []string{"invalid regex", "no label name", `"25:00"`, "cannot mix", "Mars/Olympus"} {
+		require.Contains(t, err.Error(), want)
+	}
+
+	_, err = New(Equal("env", "prod")).Build()
+	require.ErrorContains(t, err, "needs Between or For")
+	_, err = New().For(time.Hour).Build()
+	require.ErrorContains(t, err, "at least one matcher")
+}
+
+func TestSilenceForExtendAndExpire(t *testing.T) {
+	f := &fakeSilences{silences: map[string]*models.V2SilenceResponse{}}
+	api := testutil.NewAPI(t, f)
+	ctx := context.Background()
+
+	s, err := SilenceFor(ctx, api, models.Matchers{Equal("env", "prod")}, time.Hour, "maintenance")
+	require.NoError(t, err)
+	require.Equal(t, []string{"POST /api/monitors/v2/silences"}, f.paths)
+	end := time.Time(s.EndsAt)
+	require.WithinDuration(t, time.Now().Add(time.Hour), end, time.Minute)
+
+	extended, err := ExtendSilence(ctx, api, string(s.UUID), 30*time.Minute)
+	require.NoError(t, err)
+	require.WithinDuration(t, end.Add(30*time.Minute), time.Time(extended.EndsAt), time.Millisecond)
+	require.Equal(t, "maintenance", extended.Comment)
+	require.Len(t, extended.Matchers, 1)
+
+	require.NoError(t, ExpireSilence(ctx, api, string(s.UUID)))
+	require.WithinDuration(t, time.Now(), time.Time(f.silences[string(s.UUID)].EndsAt), time.Minute)
+
+	// A silence that has not started yet is deleted instead.
+	start := time.Now().Add(time.Hour)
+	later, err := New(Equal("env", "prod")).Between(start, start.Add(time.Hour)).Create(ctx, api)
+	require.NoError(t, err)
+	require.NoError(t, ExpireSilence(ctx, api, string(later.UUID)))
+	require.NotContains(t, f.silences, string(later.UUID))
+
+	// Recurring silences are disabled and cannot be extended.
+	rec, err := New(Equal("env", "prod")).Daily("01:00", "02:00").Between(time.Now(), time.Now().AddDate(0, 1, 0)).Create(ctx, api)
+	require.NoError(t, err)
+	_, err = ExtendSilence(ctx, api, string(rec.UUID), time.Hour)
+	require.ErrorContains(t, err, "recurring")
+	require.NoError(t, ExpireSilence(ctx, api, string(rec.UUID)))
+	require.False(t, f.silences[string(rec.UUID)].Enabled)
+}
+
+func TestCreateWithLegacyEndpoints(t *testing.T) {
+	f := &fakeSilences{silences: map[string]*models.V2SilenceResponse{}}
+	api := testutil.NewAPI(t, f)
+
+	s, err := New(NotEqual("env", "dev")).Comment("legacy").For(time.Hour).Create(context.Background(), api, WithLegacyEndpoints(true))
+	require.NoError(t, err)
+	require.Equal(t, []string{"POST /api/monitors/silences"}, f.paths)
+	require.Equal(t, TypeOneTime, s.Type)
+	require.Equal(t, strfmt.UUID("legacy"), s.UUID)
+	require.Equal(t, types.MatchNotEqual, MatchTypeOf(s.Matchers[0]))
+}
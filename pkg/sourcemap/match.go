@@ -0,0 +1,225 @@
+package sourcemap
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SourceMappingURL returns the URL of the last sourceMappingURL comment of a
+// script, in either the "//#" or the legacy "//@" form.
+func SourceMappingURL(script []byte) (string, bool) {
+	for _, prefix := range [][]byte{[]byte("//# sourceMappingURL="), []byte("//@ sourceMappingURL=")} {
+		i := bytes.LastIndex(script, prefix)
+		if i < 0 {
+			continue
+		}
+		rest := script[i+len(prefix):]
+		if end := bytes.IndexAny(rest, "\r\n"); end >= 0 {
+			rest = rest[:end]
+		}
+		if u := strings.TrimSpace(string(rest)); u != "" {
+			return u, true
+		}
+	}
+	return "", false
+}
+
+// Script is a minified script of a build with the map its
+// sourceMappingURL comment refers to.
+type Script struct {
+	// Path is the script relative to the build directory.
+	Path string
+	// MapURL is the URL of the sourceMappingURL comment.
+	MapURL string
+	// MapPath is the map relative to the build directory. It is empty for
+	// inline maps and maps that were not found.
+	MapPath string
+	// Map is nil if the map is missing or does not parse.
+	Map *Map
+	// Problems holds the issues of the pairing and those Validate found in
+	// the map.
+	Problems Problems
+}
+
+// Build is the result of MatchDir.
+type Build struct {
+	Dir     string
+	Scripts []*Script
+	// UnreferencedMaps lists the maps no script refers to, relative to the
+	// build directory.
+	UnreferencedMaps []string
+}
+
+// Err lists the problems of every script, prefixed with its path, or
+// returns nil if there are none.
+func (b *Build) Err() error {
+	var ps Problems
+	for _, s := range b.Scripts {
+		for _, p := range s.Problems {
+			ps = append(ps, &Problem{Path: s.Path + ": " + p.Path, Message: p.Message})
+		}
+	}
+	return ps.Err()
+}
+
+// Resolver returns a resolver for the scripts of the build that have a
+// map, keyed by their path.
+func (b *Build) Resolver() *Resolver {
+	r := NewResolver()
+	for _, s := range b.Scripts {
+		if s.Map != nil {
+			r.Add(s.Path, s.Map)
+		}
+	}
+	return r
+}
+
+// MatchDir pairs the minified scripts below dir (.js, .mjs and .cjs files)
+// with their source maps. Maps are looked up relative to their script, and
+// maps referred to by absolute URL are looked up by file name next to the
+// script. Each map is parsed and validated, and checked to name the script
+// it belongs to.
+func MatchDir(dir string) (*Build, error) {
+	var scripts, maps []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		switch {
+		case isScript(rel):
+			scripts = append(scripts, rel)
+		case isScriptMap(rel):
+			maps = append(maps, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find scripts in %s: %w", dir, err)
+	}
+	sort.Strings(scripts)
+
+	b := &Build{Dir: dir}
+	referenced := map[string]bool{}
+	for _, rel := range scripts {
+		s, err := matchScript(dir, rel)
+		if err != nil {
+			return nil, err
+		}
+		if s.MapPath != "" {
+			referenced[s.MapPath] = true
+		}
+		b.Scripts = append(b.Scripts, s)
+	}
+	for _, m := range maps {
+		if !referenced[m] {
+			b.UnreferencedMaps = append(b.UnreferencedMaps, m)
+		}
+	}
+	sort.Strings(b.UnreferencedMaps)
+	return b, nil
+}
+
+func matchScript(dir, rel string) (*Script, error) {
+	data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(rel)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", rel, err)
+	}
+	s := &Script{Path: rel}
+	add := func(path, format string, args ...interface{}) {
+		s.Problems = append(s.Problems, &Problem{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	u, ok := SourceMappingURL(data)
+	if !ok {
+		add("sourceMappingURL", "script has no sourceMappingURL comment")
+		return s, nil
+	}
+	s.MapURL = u
+
+	var mapData []byte
+	if strings.HasPrefix(u, "data:") {
+		if mapData, err = decodeDataURL(u); err != nil {
+			add("sourceMappingURL", "invalid inline map: %v", err)
+			return s, nil
+		}
+	} else {
+		mapPath, err := mapPath(rel, u)
+		if err != nil {
+			add("sourceMappingURL", "%v", err)
+			return s, nil
+		}
+		if mapData, err = os.ReadFile(filepath.Join(dir, filepath.FromSlash(mapPath))); err != nil {
+			add("sourceMappingURL", "map %s not found", mapPath)
+			return s, nil
+		}
+		s.MapPath = mapPath
+	}
+
+	m, err := Parse(mapData)
+	if err != nil {
+		add("map", "%v", err)
+		return s, nil
+	}
+	s.Map = m
+	if m.File != "" && path.Base(m.File) != path.Base(rel) {
+		add("file", "map is for %s, not %s", m.File, path.Base(rel))
+	}
+	s.Problems = append(s.Problems, m.Validate()...)
+	return s, nil
+}
+
+// mapPath resolves the sourceMappingURL of a script to a path relative to
+// the build directory.
+func mapPath(script, ref string) (string, error) {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return "", fmt.Errorf("invalid sourceMappingURL %q: %w", ref, err)
+	}
+	if u.IsAbs() || strings.HasPrefix(u.Path, "/") {
+		return path.Join(path.Dir(script), path.Base(u.Path)), nil
+	}
+	p := path.Join(path.Dir(script), u.Path)
+	if p == ".." || strings.HasPrefix(p, "../") {
+		return "", fmt.Errorf("sourceMappingURL %q points outside the build directory", ref)
+	}
+	return p, nil
+}
+
+// decodeDataURL decodes the JSON of an inline map, a data URL that is
+// either base64 or percent encoded.
+func decodeDataURL(u string) ([]byte, error) {
+	meta, data, ok := strings.Cut(strings.TrimPrefix(u, "data:"), ",")
+	if !ok {
+		return nil, fmt.Errorf("data URL has no data")
+	}
+	if strings.HasSuffix(meta, ";base64") {
+		return base64.StdEncoding.DecodeString(data)
+	}
+	s, err := url.PathUnescape(data)
+	return []byte(s), err
+}
+
+func isScript(p string) bool {
+	switch path.Ext(p) {
+	case ".js", ".mjs", ".cjs":
+		return true
+	}
+	return false
+}
+
+func isScriptMap(p string) bool {
+	return strings.HasSuffix(p, ".map") && isScript(strings.TrimSuffix(p, ".map"))
+}
@@ -0,0 +1,75 @@
+package sourcemap
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// releaseIDLength is the number of hex digits of a release ID.
+const releaseIDLength = 16
+
+// ReleaseID derives a release ID from the scripts and source maps below dir.
+// It hashes their paths and contents, so the same build output always gets
+// the same ID, wherever and whenever it is built, and any change to a
+// script or map gets a new one.
+func ReleaseID(dir string) (string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if isScript(rel) || isScriptMap(rel) {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to find build artifacts in %s: %w", dir, err)
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("no scripts or source maps found in %s", dir)
+	}
+	sort.Strings(files)
+
+	h := sha256.New()
+	for _, rel := range files {
+		if err := hashFile(h, dir, rel); err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))[:releaseIDLength], nil
+}
+
+// hashFile writes the path, size and content of a file to h. The path and
+// size delimit the content so that moving bytes between files changes the
+// hash.
+func hashFile(h io.Writer, dir, rel string) error {
+	f, err := os.Open(filepath.Join(dir, filepath.FromSlash(rel)))
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", rel, err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", rel, err)
+	}
+	if _, err := io.WriteString(h, rel+"\x00"+strconv.FormatInt(info.Size(), 10)+"\x00"); err != nil {
+		return err
+	}
+	if _, err := io.Copy(h, f); err != nil {
+		return fmt.Errorf("failed to read %s: %w", rel, err)
+	}
+	return nil
+}
@@ -0,0 +1,271 @@
+// Package sourcemap checks Source Map v3 files before they are uploaded for
+// groundcover Real User Monitoring.
+//
+// Parse reads a map and decodes its mappings, and Validate reports what would
+// keep the map from producing readable stack traces, such as missing sources
+// content. MatchDir pairs the minified scripts of a build with their maps
+// through their sourceMappingURL comments, Resolver maps minified stack
+// frames back to original locations locally, and ReleaseID derives a release
+// ID from the build artifacts. The maps can then be uploaded with the rum
+// package.
+package sourcemap
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Map is a parsed Source Map v3 file. A map is either a regular map with
+// its own mappings or an index map made of sections.
+type Map struct {
+	Version    int      `json:"version"`
+	File       string   `json:"file,omitempty"`
+	SourceRoot string   `json:"sourceRoot,omitempty"`
+	Sources    []string `json:"sources"`
+	// SourcesContent holds the original source of each entry of Sources. A
+	// nil entry means the content is missing.
+	SourcesContent []*string  `json:"sourcesContent,omitempty"`
+	Names          []string   `json:"names,omitempty"`
+	Mappings       string     `json:"mappings"`
+	Sections       []*Section `json:"sections,omitempty"`
+
+	lines [][]segment
+}
+
+// Section is a part of an index map, applying Map from Offset on.
+type Section struct {
+	Offset Offset `json:"offset"`
+	Map    *Map   `json:"map,omitempty"`
+	// URL refers to the map of the section instead of embedding it. Such
+	// sections cannot be resolved.
+	URL string `json:"url,omitempty"`
+}
+
+// Offset is a zero-based position in the generated file.
+type Offset struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// segment is one decoded mapping. Fields that are absent are -1.
+type segment struct {
+	genColumn int
+	source    int
+	line      int
+	column    int
+	name      int
+}
+
+// Position is a location in an original source. Line and Column are
+// one-based, as in stack traces.
+type Position struct {
+	Source string
+	Line   int
+	Column int
+	// Name is the original name of the symbol at the location, if the map
+	// records it.
+	Name string
+	// Content is the original source, if the map embeds it.
+	Content *string
+}
+
+// String formats the position as source:line:column.
+func (p Position) String() string {
+	return fmt.Sprintf("%s:%d:%d", p.Source, p.Line, p.Column)
+}
+
+// Parse parses a source map and decodes its mappings.
+func Parse(data []byte) (*Map, error) {
+	var m Map
+	if err := json.Unmarshal(stripXSSIPrefix(data), &m); err != nil {
+		return nil, fmt.Errorf("failed to parse source map: %w", err)
+	}
+	if err := m.decode(); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// stripXSSIPrefix drops the ")]}'" line maps may start with to keep them
+// from being executed as scripts.
+func stripXSSIPrefix(data []byte) []byte {
+	if s := string(data); strings.HasPrefix(s, ")]}'") {
+		if i := strings.IndexByte(s, '\n'); i >= 0 {
+			return data[i+1:]
+		}
+		return nil
+	}
+	return data
+}
+
+func (m *Map) decode() error {
+	if m.Version != 3 {
+		return fmt.Errorf("unsupported source map version %d", m.Version)
+	}
+	if len(m.Sections) > 0 {
+		for i, s := range m.Sections {
+			if i > 0 {
+				prev := m.Sections[i-1].Offset
+				if s.Offset.Line < prev.Line || s.Offset.Line == prev.Line && s.Offset.Column < prev.Column {
+					return fmt.Errorf("section %d starts before section %d", i, i-1)
+				}
+			}
+			if s.Map == nil {
+				continue
+			}
+			if err := s.Map.decode(); err != nil {
+				return fmt.Errorf("section %d: %w", i, err)
+			}
+		}
+		return nil
+	}
+	lines, err := decodeMappings(m.Mappings)
+	if err != nil {
+		return err
+	}
+	m.lines = lines
+	return nil
+}
+
+// SourceURL returns source i of the map with the source root applied.
+func (m *Map) SourceURL(i int) string {
+	source := m.Sources[i]
+	if m.SourceRoot == "" || strings.Contains(source, "://") || strings.HasPrefix(source, "/") {
+		return source
+	}
+	return strings.TrimSuffix(m.SourceRoot, "/") + "/" + source
+}
+
+// Resolve returns the original position of a one-based line and column of
+// the generated file, as reported in stack traces. It reports false if the
+// map has no mapping for the position.
+func (m *Map) Resolve(line, column int) (Position, bool) {
+	return m.resolve(line-1, column-1)
+}
+
+func (m *Map) resolve(line, column int) (Position, bool) {
+	if line < 0 || column < 0 {
+		return Position{}, false
+	}
+	if len(m.Sections) > 0 {
+		i := sort.Search(len(m.Sections), func(i int) bool {
+			o := m.Sections[i].Offset
+			return o.Line > line || o.Line == line && o.Column > column
+		}) - 1
+		if i < 0 || m.Sections[i].Map == nil {
+			return Position{}, false
+		}
+		o := m.Sections[i].Offset
+		if line == o.Line {
+			column -= o.Column
+		}
+		return m.Sections[i].Map.resolve(line-o.Line, column)
+	}
+
+	if line >= len(m.lines) {
+		return Position{}, false
+	}
+	segs := m.lines[line]
+	i := sort.Search(len(segs), func(i int) bool { return segs[i].genColumn > column }) - 1
+	if i < 0 || segs[i].source < 0 || segs[i].source >= len(m.Sources) {
+		return Position{}, false
+	}
+	seg := segs[i]
+	pos := Position{Source: m.SourceURL(seg.source), Line: seg.line + 1, Column: seg.column + 1}
+	if seg.name >= 0 && seg.name < len(m.Names) {
+		pos.Name = m.Names[seg.name]
+	}
+	if seg.source < len(m.SourcesContent) {
+		pos.Content = m.SourcesContent[seg.source]
+	}
+	return pos, true
+}
+
+// decodeMappings decodes the base64 VLQ mappings of a map into segments per
+// generated line, sorted by column.
+func decodeMappings(mappings string) ([][]segment, error) {
+	var (
+		lines                           [][]segment
+		current                         []segment
+		source, line, column, name, pos int
+	)
+	fields := make([]int, 0, 5)
+	for pos <= len(mappings) {
+		if pos == len(mappings) || mappings[pos] == ';' {
+			sort.SliceStable(current, func(i, j int) bool { return current[i].genColumn < current[j].genColumn })
+			lines = append(lines, current)
+			current = nil
+			pos++
+			continue
+		}
+		if mappings[pos] == ',' {
+			pos++
+			continue
+		}
+
+		fields = fields[:0]
+		for pos < len(mappings) && mappings[pos] != ',' && mappings[pos] != ';' {
+			v, n, err := decodeVLQ(mappings[pos:])
+			if err != nil {
+				return nil, fmt.Errorf("invalid mappings at offset %d: %w", pos, err)
+			}
+			fields = append(fields, v)
+			pos += n
+		}
+
+		seg := segment{source: -1, line: -1, column: -1, name: -1}
+		genColumn := 0
+		if len(current) > 0 {
+			genColumn = current[len(current)-1].genColumn
+		}
+		switch len(fields) {
+		case 1, 4, 5:
+		default:
+			return nil, fmt.Errorf("invalid mappings at offset %d: segment has %d fields", pos, len(fields))
+		}
+		seg.genColumn = genColumn + fields[0]
+		if len(fields) >= 4 {
+			source += fields[1]
+			line += fields[2]
+			column += fields[3]
+			seg.source, seg.line, seg.column = source, line, column
+		}
+		if len(fields) == 5 {
+			name += fields[4]
+			seg.name = name
+		}
+		if seg.genColumn < 0 || len(fields) >= 4 && (source < 0 || line < 0 || column < 0) || len(fields) == 5 && name < 0 {
+			return nil, fmt.Errorf("invalid mappings at offset %d: negative index", pos)
+		}
+		current = append(current, seg)
+	}
+	return lines, nil
+}
+
+const base64Chars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+// decodeVLQ decodes one base64 VLQ value from the start of s and returns it
+// with the number of characters it used.
+func decodeVLQ(s string) (int, int, error) {
+	var value, shift int
+	for i := 0; i < len(s); i++ {
+		digit := strings.IndexByte(base64Chars, s[i])
+		if digit < 0 {
+			return 0, 0, fmt.Errorf("invalid base64 character %q", s[i])
+		}
+		value += (digit & 0x1f) << shift
+		if digit&0x20 == 0 {
+			if value&1 == 1 {
+				return -(value >> 1), i + 1, nil
+			}
+			return value >> 1, i + 1, nil
+		}
+		shift += 5
+		if shift > 30 {
+			return 0, 0, fmt.Errorf("value too large")
+		}
+	}
+	return 0, 0, fmt.Errorf("unterminated value")
+}
@@ -0,0 +1,166 @@
+package sourcemap
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/groundcover-com/groundcover-sdk-go/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// appMap maps two lines of main.js to src/app.ts: column 4 of the first line
+// is the name "greet" at 1:5, and the second line starts at 2:1.
+const appMap = `{
+	"version": 3,
+	"file": "main.js",
+	"sourceRoot": "webpack:///",
+	"sources": ["src/app.ts"],
+	"sourcesContent": ["function greet() {\n  throw new Error()\n}"],
+	"names": ["greet"],
+	"mappings": "AAAA,IAAIA;AACJ"
+}`
+
+func TestParseAndResolve(t *testing.T) {
+	m, err := Parse([]byte(")]}'\n" + appMap))
+	require.NoError(t, err)
+	require.Empty(t, m.Validate())
+
+	pos, ok := m.Resolve(1, 7)
+	require.True(t, ok)
+	require.Equal(t, "webpack:///src/app.ts:1:5", pos.String())
+	require.Equal(t, "greet", pos.Name)
+	require.NotNil(t, pos.Content)
+
+	pos, ok = m.Resolve(2, 10)
+	require.True(t, ok)
+	require.Equal(t, "webpack:///src/app.ts:2:1", pos.String())
+	require.Empty(t, pos.Name)
+
+	_, ok = m.Resolve(3, 1)
+	require.False(t, ok)
+
+	_, err = Parse([]byte(`{"version": 2, "mappings": ""}`))
+	require.EqualError(t, err, "unsupported source map version 2")
+	_, err = Parse([]byte(`{"version": 3, "mappings": "AA!A"}`))
+	require.EqualError(t, err, `invalid mappings at offset 2: invalid base64 character '!'`)
+	_, err = Parse([]byte(`{"version": 3, "mappings": "AAA"}`))
+	require.ErrorContains(t, err, "segment has 3 fields")
+}
+
+func TestResolveIndexMap(t *testing.T) {
+	m, err := Parse([]byte(`{
+		"version": 3,
+		"sections": [
+			{"offset": {"line": 0, "column": 0}, "map": ` + appMap + `},
+			{"offset": {"line": 10, "column": 20}, "map": {"version": 3, "sources": ["b.ts"], "sourcesContent": ["b"], "mappings": "AAAA"}}
+		]
+	}`))
+	require.NoError(t, err)
+	require.Empty(t, m.Validate())
+
+	pos, ok := m.Resolve(1, 5)
+	require.True(t, ok)
+	require.Equal(t, "webpack:///src/app.ts:1:5", pos.String())
+	pos, ok = m.Resolve(11, 25)
+	require.True(t, ok)
+	require.Equal(t, "b.ts:1:1", pos.String())
+}
+
+func TestValidate(t *testing.T) {
+	m, err := Parse([]byte(`{"version": 3, "sources": ["a.ts", "b.ts"], "sourcesContent": ["a", null], "mappings": "AAAA,EEAA"}`))
+	require.NoError(t, err)
+	require.EqualError(t, m.Validate().Err(), "sourcesContent[1]: content of b.ts is missing\nmappings: line 1 refers to source 2 of 2")
+
+	m, err = Parse([]byte(`{"version": 3, "sources": [], "mappings": ";;"}`))
+	require.NoError(t, err)
+	require.EqualError(t, m.Validate().Err(), "sources: map lists no sources\nmappings: map has no mappings")
+
+	m, err = Parse([]byte(`{"version": 3, "sources": ["a.ts"], "mappings": "AAAA"}`))
+	require.NoError(t, err)
+	require.EqualError(t, m.Validate().Err(), "sourcesContent: map embeds no sources content")
+}
+
+func TestSourceMappingURL(t *testing.T) {
+	u, ok := SourceMappingURL([]byte("a()\n//# sourceMappingURL=old.js.map\nb()\n//# sourceMappingURL=main.js.map \n"))
+	require.True(t, ok)
+	require.Equal(t, "main.js.map", u)
+
+	u, ok = SourceMappingURL([]byte("a()\n//@ sourceMappingURL=legacy.js.map"))
+	require.True(t, ok)
+	require.Equal(t, "legacy.js.map", u)
+
+	_, ok = SourceMappingURL([]byte("a()"))
+	require.False(t, ok)
+}
+
+func TestMatchDir(t *testing.T) {
+	inline := base64.StdEncoding.EncodeToString([]byte(`{"version":3,"file":"inline.js","sources":["i.ts"],"sourcesContent":["i"],"mappings":"AAAA"}`))
+	dir := testutil.WriteFiles(t, map[string]string{
+		"static/main.js":       "greet()\n//# sourceMappingURL=main.js.map",
+		"static/main.js.map":   appMap,
+		"static/cdn.js":        "x()\n//# sourceMappingURL=https://cdn.example.com/assets/cdn.js.map",
+		"static/cdn.js.map":    `{"version":3,"file":"other.js","sources":["c.ts"],"sourcesContent":["c"],"mappings":"AAAA"}`,
+		"static/inline.js":     "//# sourceMappingURL=data:application/json;base64," + inline,
+		"static/missing.js":    "//# sourceMappingURL=missing.js.map",
+		"static/plain.js":      "plain()",
+		"static/orphan.js.map": appMap,
+		"static/site.css.map":  appMap,
+	})
+
+	b, err := MatchDir(dir)
+	require.NoError(t, err)
+	require.Equal(t, []string{"static/orphan.js.map"}, b.UnreferencedMaps)
+
+	scripts := map[string]*Script{}
+	for _, s := range b.Scripts {
+		scripts[s.Path] = s
+	}
+	require.Len(t, scripts, 5)
+	require.Equal(t, "static/main.js.map", scripts["static/main.js"].MapPath)
+	require.Empty(t, scripts["static/main.js"].Problems)
+	require.Equal(t, "static/cdn.js.map", scripts["static/cdn.js"].MapPath)
+	require.EqualError(t, scripts["static/cdn.js"].Problems.Err(), "file: map is for other.js, not cdn.js")
+	require.NotNil(t, scripts["static/inline.js"].Map)
+	require.Empty(t, scripts["static/inline.js"].Problems)
+	require.EqualError(t, scripts["static/missing.js"].Problems.Err(), "sourceMappingURL: map static/missing.js.map not found")
+	require.EqualError(t, scripts["static/plain.js"].Problems.Err(), "sourceMappingURL: script has no sourceMappingURL comment")
+	require.ErrorContains(t, b.Err(), "static/plain.js: sourceMappingURL: script has no sourceMappingURL comment")
+
+	stack := "Error: boom\n    at greet (https://shop.example.com/static/main.js:1:7)\n    at https://shop.example.com/static/unknown.js:3:4\ngreet@https://shop.example.com/static/main.js:2:3"
+	require.Equal(t,
+		"Error: boom\n    at greet (webpack:///src/app.ts:1:5)\n    at https://shop.example.com/static/unknown.js:3:4\ngreet@webpack:///src/app.ts:2:1",
+		b.Resolver().ResolveStack(stack))
+}
+
+func TestParseFrame(t *testing.T) {
+	f, ok := ParseFrame("    at Object.run (http://localhost:8080/js/app.min.js:12:345)")
+	require.True(t, ok)
+	require.Equal(t, Frame{File: "http://localhost:8080/js/app.min.js", Line: 12, Column: 345}, f)
+
+	f, ok = ParseFrame("run@http://localhost:8080/js/app.min.js:1:2")
+	require.True(t, ok)
+	require.Equal(t, Frame{File: "http://localhost:8080/js/app.min.js", Line: 1, Column: 2}, f)
+
+	_, ok = ParseFrame("Error: boom")
+	require.False(t, ok)
+}
+
+func TestReleaseID(t *testing.T) {
+	files := map[string]string{"main.js": "a()", "main.js.map": appMap, "index.html": "<html>"}
+	id, err := ReleaseID(testutil.WriteFiles(t, files))
+	require.NoError(t, err)
+	require.Len(t, id, 16)
+
+	files["index.html"] = "<html lang=en>"
+	same, err := ReleaseID(testutil.WriteFiles(t, files))
+	require.NoError(t, err)
+	require.Equal(t, id, same)
+
+	files["main.js"] = "b()"
+	changed, err := ReleaseID(testutil.WriteFiles(t, files))
+	require.NoError(t, err)
+	require.NotEqual(t, id, changed)
+
+	_, err = ReleaseID(t.TempDir())
+	require.ErrorContains(t, err, "no scripts or source maps found")
+}
@@ -0,0 +1,120 @@
+package sourcemap
+
+import (
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Frame is the location of a stack frame in a generated script. Line and
+// Column are one-based.
+type Frame struct {
+	File   string
+	Line   int
+	Column int
+}
+
+// frameLocation matches the file:line:column part of a stack frame, both in
+// the "at fn (file:1:2)" form of Chrome and the "fn@file:1:2" form of
+// Firefox and Safari.
+var frameLocation = regexp.MustCompile(`([^\s(@]+):(\d+):(\d+)`)
+
+// ParseFrame parses the last file:line:column location of a stack trace
+// line.
+func ParseFrame(line string) (Frame, bool) {
+	f, _, ok := parseFrame(line)
+	return f, ok
+}
+
+func parseFrame(line string) (Frame, []int, bool) {
+	all := frameLocation.FindAllStringSubmatchIndex(line, -1)
+	if len(all) == 0 {
+		return Frame{}, nil, false
+	}
+	m := all[len(all)-1]
+	l, err1 := strconv.Atoi(line[m[4]:m[5]])
+	c, err2 := strconv.Atoi(line[m[6]:m[7]])
+	if err1 != nil || err2 != nil {
+		return Frame{}, nil, false
+	}
+	return Frame{File: line[m[2]:m[3]], Line: l, Column: c}, m[:2], true
+}
+
+// Resolver resolves stack frames of generated scripts with their maps.
+type Resolver struct {
+	byPath map[string]*Map
+	byName map[string][]*Map
+}
+
+// NewResolver returns a resolver without maps.
+func NewResolver() *Resolver {
+	return &Resolver{byPath: map[string]*Map{}, byName: map[string][]*Map{}}
+}
+
+// Add registers the map of the script at a path or URL.
+func (r *Resolver) Add(script string, m *Map) {
+	p := scriptPath(script)
+	r.byPath[p] = m
+	name := path.Base(p)
+	r.byName[name] = append(r.byName[name], m)
+}
+
+// lookup finds the map of a frame file by its path, ignoring the scheme and
+// host of URLs, or else by its file name if only one map has that name.
+func (r *Resolver) lookup(file string) *Map {
+	p := scriptPath(file)
+	for candidate := p; candidate != "" && candidate != "."; {
+		if m, ok := r.byPath[candidate]; ok {
+			return m
+		}
+		_, rest, ok := strings.Cut(candidate, "/")
+		if !ok {
+			break
+		}
+		candidate = rest
+	}
+	if ms := r.byName[path.Base(p)]; len(ms) == 1 {
+		return ms[0]
+	}
+	return nil
+}
+
+// scriptPath reduces a script URL to its path, without leading slashes.
+func scriptPath(s string) string {
+	if u, err := url.Parse(s); err == nil && u.Path != "" {
+		s = u.Path
+	}
+	return strings.TrimLeft(path.Clean("/"+s), "/")
+}
+
+// ResolveFrame returns the original position of a frame. It reports false
+// if no map is registered for the file or the map has no mapping for the
+// position.
+func (r *Resolver) ResolveFrame(f Frame) (Position, bool) {
+	m := r.lookup(f.File)
+	if m == nil {
+		return Position{}, false
+	}
+	return m.Resolve(f.Line, f.Column)
+}
+
+// ResolveStack rewrites the frame locations of a stack trace to original
+// positions. Lines that are not frames, or that cannot be resolved, are
+// kept as they are.
+func (r *Resolver) ResolveStack(stack string) string {
+	lines := strings.Split(stack, "\n")
+	for i, line := range lines {
+		f, loc, ok := parseFrame(line)
+		if !ok {
+			continue
+		}
+		pos, ok := r.ResolveFrame(f)
+		if !ok {
+			continue
+		}
+		lines[i] = line[:loc[0]] + pos.String() + line[loc[1]:]
+	}
+	return strings.Join(lines, "\n")
+}
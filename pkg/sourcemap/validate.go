@@ -0,0 +1,108 @@
+package sourcemap
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Problem is a single issue found in a source map.
+type Problem struct {
+	// Path locates the offending field, for example "sourcesContent[2]".
+	Path    string
+	Message string
+}
+
+// Error implements the error interface.
+func (p *Problem) Error() string {
+	return p.Path + ": " + p.Message
+}
+
+// Problems is a list of issues found in a source map.
+type Problems []*Problem
+
+// Error implements the error interface, listing one problem per line.
+func (ps Problems) Error() string {
+	lines := make([]string, len(ps))
+	for i, p := range ps {
+		lines[i] = p.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Err returns the problems as an error, or nil if there are none.
+func (ps Problems) Err() error {
+	if len(ps) == 0 {
+		return nil
+	}
+	return ps
+}
+
+// Validate reports what keeps the map from resolving stack traces to
+// readable sources: no sources, missing or empty sources content, no
+// mappings, and mappings that refer to sources or names the map does not
+// have. Sections of index maps are checked with a "sections[i].map." prefix.
+func (m *Map) Validate() Problems {
+	return m.validate("")
+}
+
+func (m *Map) validate(prefix string) Problems {
+	var ps Problems
+	add := func(path, format string, args ...interface{}) {
+		ps = append(ps, &Problem{Path: prefix + path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(m.Sections) > 0 {
+		for i, s := range m.Sections {
+			path := fmt.Sprintf("sections[%d]", i)
+			if s.Map == nil {
+				add(path, "section refers to %q instead of embedding its map", s.URL)
+				continue
+			}
+			ps = append(ps, s.Map.validate(prefix+path+".map.")...)
+		}
+		return ps
+	}
+
+	if len(m.Sources) == 0 {
+		add("sources", "map lists no sources")
+	}
+	switch {
+	case len(m.SourcesContent) == 0 && len(m.Sources) > 0:
+		add("sourcesContent", "map embeds no sources content")
+	case len(m.SourcesContent) != len(m.Sources):
+		add("sourcesContent", "map has %d sources but %d sources content entries", len(m.Sources), len(m.SourcesContent))
+	default:
+		for i, content := range m.SourcesContent {
+			if content == nil || *content == "" {
+				add(fmt.Sprintf("sourcesContent[%d]", i), "content of %s is missing", m.Sources[i])
+			}
+		}
+	}
+	for i, source := range m.Sources {
+		if source == "" {
+			add(fmt.Sprintf("sources[%d]", i), "source has no name")
+		}
+	}
+
+	mapped := false
+	for line, segs := range m.lines {
+		for _, seg := range segs {
+			if seg.source < 0 {
+				continue
+			}
+			mapped = true
+			if seg.source >= len(m.Sources) {
+				add("mappings", "line %d refers to source %d of %d", line+1, seg.source, len(m.Sources))
+				return ps
+			}
+			if seg.name >= len(m.Names) {
+				add("mappings", "line %d refers to name %d of %d", line+1, seg.name, len(m.Names))
+				return ps
+			}
+		}
+	}
+	if !mapped {
+		add("mappings", "map has no mappings")
+	}
+	return ps
+}
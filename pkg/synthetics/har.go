@@ -0,0 +1,211 @@
+package synthetics
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type harFile struct {
+	Log struct {
+		Pages []struct {
+			ID    string `json:"id"`
+			Title string `json:"title"`
+		} `json:"pages"`
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harEntry struct {
+	Pageref      string  `json:"pageref"`
+	Time         float64 `json:"time"`
+	ResourceType string  `json:"_resourceType"`
+	Request      struct {
+		Method      string         `json:"method"`
+		URL         string         `json:"url"`
+		Headers     []harNameValue `json:"headers"`
+		QueryString []harNameValue `json:"queryString"`
+		Cookies     []harNameValue `json:"cookies"`
+		PostData    *struct {
+			MimeType string         `json:"mimeType"`
+			Text     string         `json:"text"`
+			Params   []harNameValue `json:"params"`
+		} `json:"postData"`
+	} `json:"request"`
+	Response struct {
+		Status  int `json:"status"`
+		Content struct {
+			MimeType string `json:"mimeType"`
+		} `json:"content"`
+	} `json:"response"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Headers of a recording that the check computes itself or that only make
+// sense in the recording browser.
+var harDroppedHeaders = map[string]bool{
+	"host": true, "content-length": true, "connection": true, "keep-alive": true,
+	"accept-encoding": true, "transfer-encoding": true, "te": true, "upgrade": true,
+	"cookie": true, "proxy-authorization": true, "if-none-match": true, "if-modified-since": true,
+}
+
+// Headers of a recording that carry credentials.
+var harSecretHeaders = map[string]bool{
+	"x-api-key": true, "api-key": true, "x-auth-token": true, "x-access-token": true,
+}
+
+var harStaticResources = map[string]bool{
+	"image": true, "stylesheet": true, "script": true, "font": true, "media": true, "manifest": true,
+}
+
+// ImportHAR converts the requests of a HAR recording into HTTP synthetic
+// tests, in the order they were recorded. Static assets, failed and repeated
+// requests are skipped. Each test asserts the recorded status and a response
+// time limit; credentials in the Authorization header, API key headers and
+// cookies are replaced by secrets from WithSecret. Tests are named by the
+// title of their page, method and path, and labelled with the page title.
+func ImportHAR(har []byte, opts ...ImportOption) (*Import, error) {
+	var f harFile
+	if err := json.Unmarshal(har, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse HAR file: %w", err)
+	}
+	im := newImporter(opts)
+	pages := map[string]string{}
+	for _, p := range f.Log.Pages {
+		pages[p.ID] = p.Title
+	}
+	seen := map[string]bool{}
+	for _, e := range f.Log.Entries {
+		method := strings.ToUpper(e.Request.Method)
+		source := method + " " + e.Request.URL
+		u, err := url.Parse(e.Request.URL)
+		if err != nil {
+			im.skip(source, "invalid URL: %v", err)
+			continue
+		}
+		switch {
+		case !im.cfg.methods[method]:
+			im.skip(source, "method %s is not imported, see WithMethods", method)
+			continue
+		case isStaticAsset(e):
+			im.skip(source, "static asset")
+			continue
+		case e.Response.Status == 0:
+			im.skip(source, "request did not complete")
+			continue
+		case seen[source]:
+			im.skip(source, "repeats an earlier request")
+			continue
+		}
+		seen[source] = true
+
+		c, err := im.harCheck(e, method, u)
+		if err != nil {
+			im.skip(source, "%v", err)
+			continue
+		}
+		latency := im.cfg.latency
+		if latency == 0 {
+			latency = max(time.Second, (3 * time.Duration(e.Time*float64(time.Millisecond))).Round(100*time.Millisecond))
+		}
+		c.AssertStatus(e.Response.Status).AssertResponseTimeBelow(latency)
+		c.Label(LabelImportSource, "har")
+		name := method + " " + u.Path
+		if title := pages[e.Pageref]; title != "" {
+			c.Label(LabelFlow, title)
+			name = title + ": " + name
+		}
+		im.add(source, name, c)
+	}
+	return im.result, nil
+}
+
+func (im *importer) harCheck(e harEntry, method string, u *url.URL) (*importedCheck, error) {
+	target := *u
+	target.RawQuery, target.Fragment = "", ""
+	if im.cfg.baseURL != "" {
+		base, err := url.Parse(im.cfg.baseURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base URL: %w", err)
+		}
+		target.Scheme, target.Host = base.Scheme, base.Host
+	}
+
+	c := im.newCheck(method, target.String())
+	query := e.Request.QueryString
+	if len(query) == 0 {
+		for name, values := range u.Query() {
+			query = append(query, harNameValue{Name: name, Value: values[0]})
+		}
+	}
+	for _, q := range query {
+		c.Query(q.Name, q.Value)
+	}
+	for _, h := range e.Request.Headers {
+		name := strings.ToLower(h.Name)
+		switch {
+		case strings.HasPrefix(name, ":"), harDroppedHeaders[name]:
+		case name == "authorization":
+			scheme, _, _ := strings.Cut(h.Value, " ")
+			switch strings.ToLower(scheme) {
+			case "bearer":
+				c.BearerAuth(c.secret(name))
+			case "basic":
+				c.basicAuth(name)
+			default:
+				c.Header(h.Name, c.secret(name))
+			}
+		case harSecretHeaders[name]:
+			c.Header(h.Name, c.secret(name))
+		default:
+			c.Header(h.Name, h.Value)
+		}
+	}
+	for _, ck := range e.Request.Cookies {
+		c.Cookie(ck.Name, c.secret("cookie_"+ck.Name))
+	}
+	if p := e.Request.PostData; p != nil {
+		mimeType, _, _ := strings.Cut(p.MimeType, ";")
+		switch {
+		case strings.HasSuffix(mimeType, "json"):
+			c.Body(BodyJSON, p.Text)
+		case mimeType == "application/x-www-form-urlencoded":
+			text := p.Text
+			if text == "" {
+				values := url.Values{}
+				for _, param := range p.Params {
+					values.Add(param.Name, param.Value)
+				}
+				text = values.Encode()
+			}
+			c.Body(BodyForm, text)
+		case p.Text != "":
+			c.Body(BodyText, p.Text)
+		}
+	}
+	if e.Response.Status >= 300 && e.Response.Status < 400 {
+		// The recording holds the redirect response, so check it rather
+		// than where it leads.
+		c.FollowRedirects(false)
+	}
+	return c, nil
+}
+
+func isStaticAsset(e harEntry) bool {
+	if harStaticResources[e.ResourceType] {
+		return true
+	}
+	mimeType := e.Response.Content.MimeType
+	for _, prefix := range []string{"image/", "font/", "audio/", "video/", "text/css", "text/javascript", "application/javascript"} {
+		if strings.HasPrefix(mimeType, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,184 @@
+package synthetics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/swag"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/timerange"
+)
+
+// Authentication types of HTTP checks.
+const (
+	AuthBasic  = "basic"
+	AuthBearer = "bearer"
+)
+
+// Body types of HTTP checks.
+const (
+	BodyJSON = "json"
+	BodyText = "text"
+	BodyForm = "form"
+)
+
+var httpMethods = map[string]bool{
+	http.MethodGet: true, http.MethodHead: true, http.MethodPost: true, http.MethodPut: true,
+	http.MethodPatch: true, http.MethodDelete: true, http.MethodOptions: true,
+}
+
+// HTTPCheck builds an HTTP synthetic test.
+type HTTPCheck struct {
+	check[*HTTPCheck]
+	req *models.HTTPRequest
+}
+
+// HTTP starts an HTTP check that sends a GET request to rawURL.
+func HTTP(rawURL string) *HTTPCheck {
+	c := &HTTPCheck{req: &models.HTTPRequest{
+		Kind:   KindHTTP,
+		URL:    rawURL,
+		Method: http.MethodGet,
+	}}
+	c.init(c)
+	if u, err := url.Parse(rawURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		c.addError("invalid HTTP URL %q", rawURL)
+	}
+	return c
+}
+
+func (c *HTTPCheck) kind() string             { return KindHTTP }
+func (c *HTTPCheck) request() *models.Request { return &models.Request{HTTP: c.req} }
+func (c *HTTPCheck) defaultName() string      { return c.req.Method + " " + c.req.URL }
+
+func (c *HTTPCheck) defaultAssertion() *models.Assertion {
+	return assertion(SourceStatusCode, "", OpEquals, "200")
+}
+
+// Method sets the HTTP method.
+func (c *HTTPCheck) Method(method string) *HTTPCheck {
+	method = strings.ToUpper(method)
+	if !httpMethods[method] {
+		c.addError("unsupported HTTP method %q", method)
+		return c
+	}
+	c.req.Method = method
+	return c
+}
+
+// Header sets a request header.
+func (c *HTTPCheck) Header(name, value string) *HTTPCheck {
+	if c.req.Headers == nil {
+		c.req.Headers = map[string]string{}
+	}
+	c.req.Headers[name] = value
+	return c
+}
+
+// Query sets a query parameter.
+func (c *HTTPCheck) Query(name, value string) *HTTPCheck {
+	if c.req.QueryParams == nil {
+		c.req.QueryParams = map[string]string{}
+	}
+	c.req.QueryParams[name] = value
+	return c
+}
+
+// Cookie adds a cookie to the request.
+func (c *HTTPCheck) Cookie(name, value string) *HTTPCheck {
+	c.req.Cookies = append(c.req.Cookies, &models.HTTPRequestCookiesItems0{Name: name, Value: value})
+	return c
+}
+
+// BasicAuth authenticates with a username and password.
+func (c *HTTPCheck) BasicAuth(username, password string) *HTTPCheck {
+	c.req.Auth = &models.Auth{Type: AuthBasic, Username: username, Password: password}
+	return c
+}
+
+// BearerAuth authenticates with a bearer token.
+func (c *HTTPCheck) BearerAuth(token string) *HTTPCheck {
+	c.req.Auth = &models.Auth{Type: AuthBearer, Token: token}
+	return c
+}
+
+// Body sets the request body and its type, one of the Body constants.
+func (c *HTTPCheck) Body(bodyType, content string) *HTTPCheck {
+	c.req.Body = &models.Body{Type: models.HTTPRequestBodyType(bodyType), Content: content}
+	return c
+}
+
+// JSONBody encodes v as the JSON request body.
+func (c *HTTPCheck) JSONBody(v interface{}) *HTTPCheck {
+	data, err := json.Marshal(v)
+	if err != nil {
+		c.addError("failed to encode JSON body: %v", err)
+		return c
+	}
+	return c.Body(BodyJSON, string(data))
+}
+
+// FormBody sends form values as the request body.
+func (c *HTTPCheck) FormBody(values url.Values) *HTTPCheck {
+	return c.Body(BodyForm, values.Encode())
+}
+
+// FollowRedirects sets whether redirects are followed.
+func (c *HTTPCheck) FollowRedirects(follow bool) *HTTPCheck {
+	c.req.FollowRedirects = swag.Bool(follow)
+	return c
+}
+
+// AllowInsecure sets whether invalid TLS certificates are accepted.
+func (c *HTTPCheck) AllowInsecure(allow bool) *HTTPCheck {
+	c.req.AllowInsecure = swag.Bool(allow)
+	return c
+}
+
+// HTTPVersion pins the HTTP version, such as "1.1" or "2".
+func (c *HTTPCheck) HTTPVersion(version string) *HTTPCheck {
+	c.req.HTTPVersion = version
+	return c
+}
+
+// Timeout sets how long the request may take.
+func (c *HTTPCheck) Timeout(d time.Duration) *HTTPCheck {
+	c.req.Timeout = timerange.FormatDuration(d)
+	return c
+}
+
+// AssertStatus asserts the response status code.
+func (c *HTTPCheck) AssertStatus(code int) *HTTPCheck {
+	if code < 100 || code > 599 {
+		c.addError("invalid HTTP status code %d", code)
+		return c
+	}
+	return c.Assert(SourceStatusCode, OpEquals, strconv.Itoa(code))
+}
+
+// AssertBodyContains asserts that the response body contains s.
+func (c *HTTPCheck) AssertBodyContains(s string) *HTTPCheck {
+	return c.Assert(SourceBody, OpContains, s)
+}
+
+// AssertBodyMatches asserts that the response body matches a regular
+// expression.
+func (c *HTTPCheck) AssertBodyMatches(pattern string) *HTTPCheck {
+	c.validRegexp(pattern)
+	return c.Assert(SourceBody, OpMatches, pattern)
+}
+
+// AssertHeader asserts that a response header equals value.
+func (c *HTTPCheck) AssertHeader(name, value string) *HTTPCheck {
+	return c.AssertProperty(SourceHeader, name, OpEquals, value)
+}
+
+// AssertJSON asserts that the value at a JSON path of the response body
+// equals value, as in AssertJSON("$.status", "ok").
+func (c *HTTPCheck) AssertJSON(path, value string) *HTTPCheck {
+	return c.AssertProperty(SourceJSONBody, path, OpEquals, value)
+}
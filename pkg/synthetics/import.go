@@ -0,0 +1,335 @@
+package synthetics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/client"
+	synthclient "github.com/groundcover-com/groundcover-sdk-go/pkg/client/synthetics"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+)
+
+// Labels the importers put on the tests they produce.
+const (
+	LabelImportSource = "import_source"
+	LabelAPI          = "api"
+	LabelOperation    = "operation"
+	LabelTag          = "tag"
+	LabelFlow         = "flow"
+)
+
+// defaultLatencyLimit is the response time assertion of imported tests when
+// neither WithLatencyLimit nor a recording suggests one.
+const defaultLatencyLimit = 2 * time.Second
+
+// SecretPlaceholder is the value imported tests use for a credential that no
+// WithSecret option maps to a secret. Replace it before creating the tests;
+// Import.Create refuses tests that still hold one, see WithPlaceholders.
+func SecretPlaceholder(name string) string {
+	return "${secret:" + name + "}"
+}
+
+// ImportedTest is a synthetic test produced by an importer.
+type ImportedTest struct {
+	// Source identifies what the test was imported from, such as
+	// "GET /orders/{id}" or the URL of a HAR entry.
+	Source string
+	Test   *models.SyntheticTestCreateRequest
+	// Warnings point out what to review before creating the test, such as
+	// secret placeholders.
+	Warnings []string
+	// Placeholders are the credentials the test holds a SecretPlaceholder
+	// for.
+	Placeholders []string
+}
+
+// SkippedImport is an operation or recorded request that was not imported.
+type SkippedImport struct {
+	Source string
+	Reason string
+}
+
+// Import is the outcome of an importer: the tests it produced and what it
+// left out.
+type Import struct {
+	Tests   []*ImportedTest
+	Skipped []SkippedImport
+}
+
+// String lists the tests that would be created, with their warnings, and
+// the skipped entries.
+func (im *Import) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d synthetic tests to create\n", len(im.Tests))
+	for _, t := range im.Tests {
+		h := t.Test.CheckConfig.Request.HTTP
+		fmt.Fprintf(&b, "+ %s\n    %s %s\n", t.Test.Name, h.Method, h.URL)
+		for _, a := range t.Test.CheckConfig.ExecutionPolicy.Assertions {
+			fmt.Fprintf(&b, "    assert %s %s %s\n", assertionSubject(a), a.Operator, a.Target)
+		}
+		for _, w := range t.Warnings {
+			fmt.Fprintf(&b, "    ! %s\n", w)
+		}
+	}
+	if len(im.Skipped) > 0 {
+		fmt.Fprintf(&b, "%d skipped\n", len(im.Skipped))
+		for _, s := range im.Skipped {
+			fmt.Fprintf(&b, "- %s: %s\n", s.Source, s.Reason)
+		}
+	}
+	return b.String()
+}
+
+// ImportOption configures ImportOpenAPI and ImportHAR.
+type ImportOption func(*importConfig)
+
+type importConfig struct {
+	baseURL     string
+	namePrefix  string
+	labels      map[string]string
+	secrets     map[string]string
+	methods     map[string]bool
+	latency     time.Duration
+	interval    time.Duration
+	monitorOpts []MonitorOption
+}
+
+func newImportConfig(opts []ImportOption) *importConfig {
+	cfg := &importConfig{
+		labels:   map[string]string{},
+		secrets:  map[string]string{},
+		methods:  map[string]bool{http.MethodGet: true, http.MethodHead: true},
+		interval: DefaultInterval,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithBaseURL sends the imported requests to baseURL instead of the server
+// of the spec or the host of the recording, for example to test staging.
+func WithBaseURL(baseURL string) ImportOption {
+	return func(c *importConfig) {
+		c.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// WithNamePrefix prefixes the names of the imported tests.
+func WithNamePrefix(prefix string) ImportOption {
+	return func(c *importConfig) {
+		c.namePrefix = prefix
+	}
+}
+
+// WithLabels adds labels to every imported test.
+func WithLabels(labels map[string]string) ImportOption {
+	return func(c *importConfig) {
+		for k, v := range labels {
+			c.labels[k] = v
+		}
+	}
+}
+
+// WithSecret uses ref, the ID of a groundcover secret, for the credential
+// called name: a security scheme of the spec, a header such as
+// "authorization", or "cookie_<name>" for a recorded cookie. Basic auth
+// takes "<name>_username" and "<name>_password". Credentials without a
+// secret get a SecretPlaceholder.
+func WithSecret(name, ref string) ImportOption {
+	return func(c *importConfig) {
+		c.secrets[name] = ref
+	}
+}
+
+// WithMethods sets the HTTP methods to import. Only GET and HEAD are
+// imported by default, so that checks do not change data.
+func WithMethods(methods ...string) ImportOption {
+	return func(c *importConfig) {
+		c.methods = map[string]bool{}
+		for _, m := range methods {
+			c.methods[strings.ToUpper(m)] = true
+		}
+	}
+}
+
+// WithLatencyLimit sets the response time every imported test asserts. By
+// default OpenAPI operations get 2 seconds and recorded requests three times
+// their recorded time, at least a second.
+func WithLatencyLimit(d time.Duration) ImportOption {
+	return func(c *importConfig) {
+		c.latency = d
+	}
+}
+
+// WithCheckInterval sets how often the imported tests run.
+func WithCheckInterval(d time.Duration) ImportOption {
+	return func(c *importConfig) {
+		c.interval = d
+	}
+}
+
+// WithMonitorOptions attaches a monitor configured by opts to every
+// imported test.
+func WithMonitorOptions(opts ...MonitorOption) ImportOption {
+	return func(c *importConfig) {
+		c.monitorOpts = append(c.monitorOpts, opts...)
+	}
+}
+
+// importer collects tests and skipped entries, keeping names unique.
+type importer struct {
+	cfg    *importConfig
+	result *Import
+	names  map[string]int
+}
+
+func newImporter(opts []ImportOption) *importer {
+	return &importer{cfg: newImportConfig(opts), result: &Import{}, names: map[string]int{}}
+}
+
+func (im *importer) skip(source, format string, args ...interface{}) {
+	im.result.Skipped = append(im.result.Skipped, SkippedImport{Source: source, Reason: fmt.Sprintf(format, args...)})
+}
+
+// importedCheck is an HTTP check being imported, with the warnings it
+// collects.
+type importedCheck struct {
+	*HTTPCheck
+	cfg          *importConfig
+	warnings     []string
+	placeholders []string
+}
+
+func (im *importer) newCheck(method, rawURL string) *importedCheck {
+	c := &importedCheck{HTTPCheck: HTTP(rawURL).Method(method), cfg: im.cfg}
+	c.Interval(im.cfg.interval)
+	for k, v := range im.cfg.labels {
+		c.Label(k, v)
+	}
+	if len(im.cfg.monitorOpts) > 0 {
+		c.Monitor(im.cfg.monitorOpts...)
+	}
+	return c
+}
+
+// secret returns the secret mapped to name, or a placeholder and a warning.
+func (c *importedCheck) secret(name string) string {
+	if ref, ok := c.cfg.secrets[name]; ok {
+		return ref
+	}
+	placeholder := SecretPlaceholder(name)
+	c.placeholders = append(c.placeholders, name)
+	c.warnings = append(c.warnings, fmt.Sprintf("replace %s with a secret (WithSecret(%q, ...))", placeholder, name))
+	return placeholder
+}
+
+func (c *importedCheck) basicAuth(name string) {
+	c.BasicAuth(c.secret(name+"_username"), c.secret(name+"_password"))
+}
+
+// add builds the check under a unique name.
+func (im *importer) add(source, name string, c *importedCheck) {
+	name = im.cfg.namePrefix + name
+	im.names[name]++
+	if n := im.names[name]; n > 1 {
+		name = fmt.Sprintf("%s #%d", name, n)
+	}
+	req, err := c.Name(name).Build()
+	if err != nil {
+		im.skip(source, "%v", errors.Unwrap(err))
+		return
+	}
+	im.result.Tests = append(im.result.Tests, &ImportedTest{Source: source, Test: req, Warnings: c.warnings, Placeholders: c.placeholders})
+}
+
+// CreateOption configures Import.Create.
+type CreateOption func(*createConfig)
+
+type createConfig struct {
+	dryRun       bool
+	placeholders bool
+	out          io.Writer
+}
+
+// WithDryRun makes Create report the tests it would create without creating
+// them.
+func WithDryRun(dryRun bool) CreateOption {
+	return func(c *createConfig) {
+		c.dryRun = dryRun
+	}
+}
+
+// WithPlaceholders creates tests that still hold secret placeholders, which
+// are otherwise refused because they would send the placeholder as the
+// credential.
+func WithPlaceholders(allow bool) CreateOption {
+	return func(c *createConfig) {
+		c.placeholders = allow
+	}
+}
+
+// WithOutput writes a line per test to w as it is created, or the whole
+// import in a dry run.
+func WithOutput(w io.Writer) CreateOption {
+	return func(c *createConfig) {
+		c.out = w
+	}
+}
+
+// CreatedTest is a test created from an import.
+type CreatedTest struct {
+	Name string
+	// ID is empty in a dry run.
+	ID string
+}
+
+// CreateResult is the outcome of Import.Create.
+type CreateResult struct {
+	Created []CreatedTest
+	DryRun  bool
+}
+
+// Create creates the imported tests. Tests that still hold secret
+// placeholders are refused unless WithPlaceholders allows them. A failure
+// does not stop the remaining tests; the returned error joins every failure.
+func (im *Import) Create(ctx context.Context, api *client.GroundcoverAPI, opts ...CreateOption) (*CreateResult, error) {
+	cfg := &createConfig{out: io.Discard}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	result := &CreateResult{DryRun: cfg.dryRun}
+	if cfg.dryRun {
+		fmt.Fprint(cfg.out, im.String())
+	}
+
+	var errs []error
+	for _, t := range im.Tests {
+		if len(t.Placeholders) > 0 && !cfg.placeholders {
+			err := fmt.Errorf("synthetic test %q still has secret placeholders for %s; map them with WithSecret or allow them with WithPlaceholders", t.Test.Name, strings.Join(t.Placeholders, ", "))
+			errs = append(errs, err)
+			if !cfg.dryRun {
+				fmt.Fprintf(cfg.out, "refused %s: %v\n", t.Test.Name, err)
+			}
+			continue
+		}
+		if cfg.dryRun {
+			result.Created = append(result.Created, CreatedTest{Name: t.Test.Name})
+			continue
+		}
+		resp, err := api.Synthetics.CreateSyntheticTest(synthclient.NewCreateSyntheticTestParams().WithContext(ctx).WithBody(t.Test), nil)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to create synthetic test %q: %w", t.Test.Name, err))
+			fmt.Fprintf(cfg.out, "failed %s: %v\n", t.Test.Name, err)
+			continue
+		}
+		result.Created = append(result.Created, CreatedTest{Name: t.Test.Name, ID: resp.Payload.ID})
+		fmt.Fprintf(cfg.out, "created %s (%s)\n", t.Test.Name, resp.Payload.ID)
+	}
+	return result, errors.Join(errs...)
+}
@@ -0,0 +1,312 @@
+package synthetics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/groundcover-com/groundcover-sdk-go/internal/testutil"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+const ordersSpec = `
+openapi: 3.0.3
+info:
+  title: Orders API
+servers:
+  - url: https://{env}.example.com/v1
+    variables:
+      env:
+        default: api
+security:
+  - bearer: []
+components:
+  parameters:
+    Page:
+      name: page
+      in: query
+      schema:
+        type: integer
+        default: 1
+  securitySchemes:
+    bearer:
+      type: http
+      scheme: bearer
+    key:
+      type: apiKey
+      in: header
+      name: X-Api-Key
+paths:
+  /orders:
+    get:
+      operationId: listOrders
+      tags: [orders]
+      parameters:
+        - $ref: '#/components/parameters/Page'
+        - name: status
+          in: query
+          schema:
+            enum: [open, closed]
+      responses:
+        200:
+          description: ok
+    post:
+      operationId: createOrder
+      requestBody:
+        content:
+          application/json:
+            example: {sku: "42", quantity: 1}
+      responses:
+        "201":
+          description: created
+  /orders/{id}:
+    parameters:
+      - name: id
+        in: path
+        required: true
+        example: ord_1
+    get:
+      security:
+        - key: []
+      responses:
+        2XX:
+          description: ok
+  /search:
+    get:
+      parameters:
+        - name: q
+          in: query
+          required: true
+      responses:
+        "200":
+          description: ok
+  /health:
+    get:
+      operationId: health
+      security: []
+      responses:
+        "204":
+          description: up
+`
+
+func TestImportOpenAPI(t *testing.T) {
+	im, err := ImportOpenAPI([]byte(ordersSpec),
+		WithNamePrefix("orders/"),
+		WithSecret("key", "secretRef::store::abc"),
+		WithLabels(map[string]string{"team": "payments"}),
+		WithMonitorOptions(WithSeverity("S2")),
+	)
+	require.NoError(t, err)
+
+	tests := map[string]*ImportedTest{}
+	for _, imported := range im.Tests {
+		tests[imported.Test.Name] = imported
+	}
+	require.Len(t, tests, 3)
+
+	list := tests["orders/listOrders"].Test
+	h := list.CheckConfig.Request.HTTP
+	require.Equal(t, "https://api.example.com/v1/orders", h.URL)
+	require.Equal(t, map[string]string{"page": "1", "status": "open"}, h.QueryParams)
+	require.Equal(t, &models.Auth{Type: AuthBearer, Token: SecretPlaceholder("bearer")}, h.Auth)
+	require.Equal(t, map[string]string{
+		"team": "payments", LabelImportSource: "openapi", LabelAPI: "Orders API", LabelOperation: "listOrders", LabelTag: "orders",
+	}, list.LabelSettings.ExtraLabels)
+	require.Equal(t, []*models.Assertion{
+		{Source: SourceStatusCode, Operator: OpEquals, Target: "200"},
+		{Source: SourceResponseTime, Operator: OpLessThan, Target: "2000"},
+	}, list.CheckConfig.ExecutionPolicy.Assertions)
+	require.True(t, *list.CreateMonitor)
+	require.Len(t, tests["orders/listOrders"].Warnings, 1)
+
+	get := tests["orders/GET /orders/{id}"]
+	require.Equal(t, "https://api.example.com/v1/orders/ord_1", get.Test.CheckConfig.Request.HTTP.URL)
+	require.Equal(t, "secretRef::store::abc", get.Test.CheckConfig.Request.HTTP.Headers["X-Api-Key"])
+	require.Nil(t, get.Test.CheckConfig.Request.HTTP.Auth)
+	require.Empty(t, get.Warnings)
+
+	health := tests["orders/health"].Test
+	require.Nil(t, health.CheckConfig.Request.HTTP.Auth)
+	require.Equal(t, "204", health.CheckConfig.ExecutionPolicy.Assertions[0].Target)
+
+	require.Equal(t, []SkippedImport{
+		{Source: "POST /orders", Reason: "method POST is not imported, see WithMethods"},
+		{Source: "GET /search", Reason: `no example for required query parameter "q"`},
+	}, im.Skipped)
+
+	require.Contains(t, im.String(), "+ orders/listOrders\n    GET https://api.example.com/v1/orders\n")
+	require.Contains(t, im.String(), "! replace ${secret:bearer} with a secret")
+}
+
+func TestImportOpenAPIMethodsAndBody(t *testing.T) {
+	im, err := ImportOpenAPI([]byte(ordersSpec), WithMethods("post"), WithBaseURL("http://localhost:8080/"), WithLatencyLimit(time.Second))
+	require.NoError(t, err)
+	require.Len(t, im.Tests, 1)
+	req := im.Tests[0].Test
+	require.Equal(t, "createOrder", req.Name)
+	h := req.CheckConfig.Request.HTTP
+	require.Equal(t, "http://localhost:8080/orders", h.URL)
+	require.Equal(t, &models.Body{Type: BodyJSON, Content: `{"quantity":1,"sku":"42"}`}, h.Body)
+	require.Equal(t, "201", req.CheckConfig.ExecutionPolicy.Assertions[0].Target)
+	require.Equal(t, "1000", req.CheckConfig.ExecutionPolicy.Assertions[1].Target)
+}
+
+func TestImportSwagger2(t *testing.T) {
+	spec := `{
+	  "swagger": "2.0",
+	  "info": {"title": "Legacy"},
+	  "host": "legacy.example.com",
+	  "basePath": "/api/",
+	  "schemes": ["http", "https"],
+	  "securityDefinitions": {"basic": {"type": "basic"}},
+	  "security": [{"basic": []}],
+	  "paths": {"/users/{id}": {"get": {"parameters": [{"name": "id", "in": "path", "required": true, "type": "string", "x-example": "u1"}]}}}
+	}`
+	im, err := ImportOpenAPI([]byte(spec), WithSecret("basic_username", "user-ref"))
+	require.NoError(t, err)
+	require.Len(t, im.Tests, 1)
+	h := im.Tests[0].Test.CheckConfig.Request.HTTP
+	require.Equal(t, "https://legacy.example.com/api/users/u1", h.URL)
+	require.Equal(t, &models.Auth{Type: AuthBasic, Username: "user-ref", Password: SecretPlaceholder("basic_password")}, h.Auth)
+
+	_, err = ImportOpenAPI([]byte("openapi: 3.0.0\npaths: {}\n"))
+	require.ErrorContains(t, err, "WithBaseURL")
+	_, err = ImportOpenAPI([]byte("{}"))
+	require.ErrorContains(t, err, "no openapi or swagger version")
+}
+
+const checkoutHAR = `{
+  "log": {
+    "pages": [{"id": "page_1", "title": "Checkout"}],
+    "entries": [
+      {
+        "pageref": "page_1",
+        "time": 120.5,
+        "request": {
+          "method": "GET",
+          "url": "https://shop.example.com/cart?session=x",
+          "headers": [
+            {"name": ":authority", "value": "shop.example.com"},
+            {"name": "Accept", "value": "application/json"},
+            {"name": "Authorization", "value": "Bearer eyJhbGciOi"},
+            {"name": "Cookie", "value": "sid=123"}
+          ],
+          "queryString": [{"name": "session", "value": "x"}],
+          "cookies": [{"name": "sid", "value": "123"}]
+        },
+        "response": {"status": 200, "content": {"mimeType": "application/json"}}
+      },
+      {
+        "pageref": "page_1",
+        "time": 10,
+        "request": {"method": "GET", "url": "https://shop.example.com/logo.png", "headers": []},
+        "response": {"status": 200, "content": {"mimeType": "image/png"}}
+      },
+      {
+        "pageref": "page_1",
+        "time": 900,
+        "request": {"method": "GET", "url": "https://shop.example.com/checkout", "headers": []},
+        "response": {"status": 302, "content": {"mimeType": "text/html"}}
+      },
+      {
+        "pageref": "page_1",
+        "time": 80,
+        "request": {"method": "GET", "url": "https://shop.example.com/cart?session=x", "headers": []},
+        "response": {"status": 200, "content": {"mimeType": "application/json"}}
+      },
+      {
+        "pageref": "page_1",
+        "time": 300,
+        "request": {
+          "method": "POST",
+          "url": "https://shop.example.com/pay",
+          "headers": [{"name": "X-Api-Key", "value": "live_key"}],
+          "postData": {"mimeType": "application/json; charset=utf-8", "text": "{\"amount\":10}"}
+        },
+        "response": {"status": 201, "content": {"mimeType": "application/json"}}
+      }
+    ]
+  }
+}`
+
+func TestImportHAR(t *testing.T) {
+	im, err := ImportHAR([]byte(checkoutHAR), WithSecret("authorization", "secretRef::store::tok"))
+	require.NoError(t, err)
+	require.Len(t, im.Tests, 2)
+
+	cart := im.Tests[0]
+	require.Equal(t, "Checkout: GET /cart", cart.Test.Name)
+	h := cart.Test.CheckConfig.Request.HTTP
+	require.Equal(t, "https://shop.example.com/cart", h.URL)
+	require.Equal(t, map[string]string{"session": "x"}, h.QueryParams)
+	require.Equal(t, map[string]string{"Accept": "application/json"}, h.Headers)
+	require.Equal(t, &models.Auth{Type: AuthBearer, Token: "secretRef::store::tok"}, h.Auth)
+	require.Equal(t, []*models.HTTPRequestCookiesItems0{{Name: "sid", Value: SecretPlaceholder("cookie_sid")}}, h.Cookies)
+	require.Equal(t, "1000", cart.Test.CheckConfig.ExecutionPolicy.Assertions[1].Target)
+	require.Equal(t, map[string]string{LabelImportSource: "har", LabelFlow: "Checkout"}, cart.Test.LabelSettings.ExtraLabels)
+	require.Len(t, cart.Warnings, 1)
+
+	checkout := im.Tests[1].Test
+	require.Equal(t, "302", checkout.CheckConfig.ExecutionPolicy.Assertions[0].Target)
+	require.Equal(t, "2700", checkout.CheckConfig.ExecutionPolicy.Assertions[1].Target)
+	require.False(t, *checkout.CheckConfig.Request.HTTP.FollowRedirects)
+
+	reasons := map[string]string{}
+	for _, s := range im.Skipped {
+		reasons[s.Source] = s.Reason
+	}
+	require.Equal(t, map[string]string{
+		"GET https://shop.example.com/logo.png":       "static asset",
+		"GET https://shop.example.com/cart?session=x": "repeats an earlier request",
+		"POST https://shop.example.com/pay":           "method POST is not imported, see WithMethods",
+	}, reasons)
+
+	im, err = ImportHAR([]byte(checkoutHAR), WithMethods("POST"), WithBaseURL("http://staging.local"))
+	require.NoError(t, err)
+	require.Len(t, im.Tests, 1)
+	pay := im.Tests[0].Test.CheckConfig.Request.HTTP
+	require.Equal(t, "http://staging.local/pay", pay.URL)
+	require.Equal(t, SecretPlaceholder("x-api-key"), pay.Headers["X-Api-Key"])
+	require.Equal(t, &models.Body{Type: BodyJSON, Content: `{"amount":10}`}, pay.Body)
+}
+
+func TestImportCreate(t *testing.T) {
+	var created []*models.SyntheticTestCreateRequest
+	api := testutil.NewAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req models.SyntheticTestCreateRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		created = append(created, &req)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(models.SyntheticTestCreateResponse{ID: "id-" + req.Name})
+	}))
+
+	im, err := ImportHAR([]byte(checkoutHAR))
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	dry, err := im.Create(context.Background(), api, WithDryRun(true), WithOutput(&out))
+	require.ErrorContains(t, err, `synthetic test "Checkout: GET /cart" still has secret placeholders for authorization, cookie_sid`)
+	require.True(t, dry.DryRun)
+	require.Equal(t, []CreatedTest{{Name: "Checkout: GET /checkout"}}, dry.Created)
+	require.Empty(t, created)
+	require.Contains(t, out.String(), "2 synthetic tests to create")
+
+	result, err := im.Create(context.Background(), api)
+	require.Error(t, err)
+	require.Equal(t, []CreatedTest{{Name: "Checkout: GET /checkout", ID: "id-Checkout: GET /checkout"}}, result.Created)
+	require.Len(t, created, 1)
+
+	created = nil
+	result, err = im.Create(context.Background(), api, WithPlaceholders(true))
+	require.NoError(t, err)
+	require.Equal(t, []CreatedTest{
+		{Name: "Checkout: GET /cart", ID: "id-Checkout: GET /cart"},
+		{Name: "Checkout: GET /checkout", ID: "id-Checkout: GET /checkout"},
+	}, result.Created)
+	require.Len(t, created, 2)
+}
@@ -0,0 +1,266 @@
+package synthetics
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/timerange"
+)
+
+// DNS record types a DNS check can query.
+var dnsRecordTypes = map[string]bool{
+	"A": true, "AAAA": true, "CNAME": true, "MX": true, "NS": true,
+	"PTR": true, "SOA": true, "SRV": true, "TXT": true, "CAA": true,
+}
+
+// TLS versions an SSL check can require.
+var tlsVersions = map[string]bool{"1.0": true, "1.1": true, "1.2": true, "1.3": true}
+
+// DNSCheck builds a DNS synthetic test.
+type DNSCheck struct {
+	check[*DNSCheck]
+	req *models.DNSRequest
+}
+
+// DNS starts a check that resolves the A record of domain.
+func DNS(domain string) *DNSCheck {
+	c := &DNSCheck{req: &models.DNSRequest{Kind: KindDNS, Domain: domain, RecordType: "A"}}
+	c.init(c)
+	if domain == "" {
+		c.addError("DNS check needs a domain")
+	}
+	return c
+}
+
+func (c *DNSCheck) kind() string             { return KindDNS }
+func (c *DNSCheck) request() *models.Request { return &models.Request{DNS: c.req} }
+
+func (c *DNSCheck) defaultName() string {
+	return fmt.Sprintf("DNS %s %s", c.req.RecordType, c.req.Domain)
+}
+
+func (c *DNSCheck) defaultAssertion() *models.Assertion {
+	return assertion(SourceDNSAnswer, "", OpExists, "true")
+}
+
+// Record sets the record type to query, such as "AAAA" or "MX".
+func (c *DNSCheck) Record(recordType string) *DNSCheck {
+	recordType = strings.ToUpper(recordType)
+	if !dnsRecordTypes[recordType] {
+		c.addError("unsupported DNS record type %q", recordType)
+		return c
+	}
+	c.req.RecordType = models.DNSRequestRecordType(recordType)
+	return c
+}
+
+// Resolver queries the given name server, on port 53 unless Port is called.
+func (c *DNSCheck) Resolver(server string) *DNSCheck {
+	c.req.Resolver = server
+	if c.req.Port == 0 {
+		c.req.Port = 53
+	}
+	return c
+}
+
+// Port sets the port of the resolver.
+func (c *DNSCheck) Port(port int) *DNSCheck {
+	c.req.Port = c.validPort(port)
+	return c
+}
+
+// DNSSEC sets whether DNSSEC validation is requested.
+func (c *DNSCheck) DNSSEC(enabled bool) *DNSCheck {
+	c.req.Dnssec = enabled
+	return c
+}
+
+// Timeout sets how long the query may take.
+func (c *DNSCheck) Timeout(d time.Duration) *DNSCheck {
+	c.req.Timeout = timerange.FormatDuration(d)
+	return c
+}
+
+// AssertAnswerContains asserts that one of the answers equals value, such as
+// an expected IP address.
+func (c *DNSCheck) AssertAnswerContains(value string) *DNSCheck {
+	return c.Assert(SourceDNSAnswer, OpContains, value)
+}
+
+// TCPCheck builds a TCP synthetic test.
+type TCPCheck struct {
+	check[*TCPCheck]
+	req *models.TCPRequest
+}
+
+// TCP starts a check that connects to host on port.
+func TCP(host string, port int) *TCPCheck {
+	c := &TCPCheck{req: &models.TCPRequest{Kind: KindTCP, Host: host}}
+	c.init(c)
+	c.req.Port = c.validPort(port)
+	if host == "" {
+		c.addError("TCP check needs a host")
+	}
+	return c
+}
+
+func (c *TCPCheck) kind() string             { return KindTCP }
+func (c *TCPCheck) request() *models.Request { return &models.Request{TCP: c.req} }
+
+func (c *TCPCheck) defaultName() string {
+	return "TCP " + net.JoinHostPort(c.req.Host, strconv.FormatInt(c.req.Port, 10))
+}
+
+func (c *TCPCheck) defaultAssertion() *models.Assertion {
+	return assertion(SourceTCP, "", OpExists, "true")
+}
+
+// Send writes data after connecting.
+func (c *TCPCheck) Send(data string) *TCPCheck {
+	c.req.Send = data
+	return c
+}
+
+// ExpectResponse waits for a response of up to maxBytes after connecting.
+func (c *TCPCheck) ExpectResponse(maxBytes int) *TCPCheck {
+	if maxBytes <= 0 {
+		c.addError("response size must be positive, got %d", maxBytes)
+		return c
+	}
+	c.req.ExpectResponse = true
+	c.req.ReceiveMaxBytes = int64(maxBytes)
+	return c
+}
+
+// Timeout sets how long the check may take.
+func (c *TCPCheck) Timeout(d time.Duration) *TCPCheck {
+	c.req.Timeout = timerange.FormatDuration(d)
+	return c
+}
+
+// AssertResponseContains asserts that the response contains s. It implies
+// ExpectResponse with a 1 KiB limit unless that was called.
+func (c *TCPCheck) AssertResponseContains(s string) *TCPCheck {
+	if !c.req.ExpectResponse {
+		c.ExpectResponse(1024)
+	}
+	return c.AssertProperty(SourceTCP, "response", OpContains, s)
+}
+
+// UDPCheck builds a UDP synthetic test.
+type UDPCheck struct {
+	check[*UDPCheck]
+	req *models.UDPRequest
+}
+
+// UDP starts a check that sends a datagram to host on port.
+func UDP(host string, port int) *UDPCheck {
+	c := &UDPCheck{req: &models.UDPRequest{Kind: KindUDP, Host: host}}
+	c.init(c)
+	c.req.Port = c.validPort(port)
+	if host == "" {
+		c.addError("UDP check needs a host")
+	}
+	return c
+}
+
+func (c *UDPCheck) kind() string             { return KindUDP }
+func (c *UDPCheck) request() *models.Request { return &models.Request{UDP: c.req} }
+
+func (c *UDPCheck) defaultName() string {
+	return "UDP " + net.JoinHostPort(c.req.Host, strconv.FormatInt(c.req.Port, 10))
+}
+
+func (c *UDPCheck) defaultAssertion() *models.Assertion {
+	return assertion(SourceUDP, "", OpExists, "true")
+}
+
+// Payload sets the datagram to send.
+func (c *UDPCheck) Payload(payload string) *UDPCheck {
+	c.req.Payload = payload
+	return c
+}
+
+// ReceiveTimeout sets how long to wait for a reply.
+func (c *UDPCheck) ReceiveTimeout(d time.Duration) *UDPCheck {
+	c.req.ReceiveTimeout = timerange.FormatDuration(d)
+	return c
+}
+
+// AssertResponseContains asserts that the reply contains s.
+func (c *UDPCheck) AssertResponseContains(s string) *UDPCheck {
+	return c.AssertProperty(SourceUDP, "response", OpContains, s)
+}
+
+// SSLCheck builds an SSL synthetic test, which checks the certificate a
+// server presents.
+type SSLCheck struct {
+	check[*SSLCheck]
+	req *models.SslRequest
+}
+
+// SSL starts a check of the certificate host presents on port 443.
+func SSL(host string) *SSLCheck {
+	c := &SSLCheck{req: &models.SslRequest{Kind: KindSSL, Host: host, Port: 443, Verify: true}}
+	c.init(c)
+	if host == "" {
+		c.addError("SSL check needs a host")
+	}
+	return c
+}
+
+func (c *SSLCheck) kind() string             { return KindSSL }
+func (c *SSLCheck) request() *models.Request { return &models.Request{Ssl: c.req} }
+
+func (c *SSLCheck) defaultName() string {
+	return "SSL " + net.JoinHostPort(c.req.Host, strconv.FormatInt(c.req.Port, 10))
+}
+
+func (c *SSLCheck) defaultAssertion() *models.Assertion {
+	return assertion(SourceSSL, "", OpEquals, "true")
+}
+
+// Port sets the port to connect to.
+func (c *SSLCheck) Port(port int) *SSLCheck {
+	c.req.Port = c.validPort(port)
+	return c
+}
+
+// SNI sets the server name sent in the TLS handshake.
+func (c *SSLCheck) SNI(serverName string) *SSLCheck {
+	c.req.Sni = serverName
+	return c
+}
+
+// MinVersion requires at least the given TLS version, such as "1.2".
+func (c *SSLCheck) MinVersion(version string) *SSLCheck {
+	version = strings.TrimPrefix(strings.ToLower(version), "tls")
+	if !tlsVersions[version] {
+		c.addError("unsupported TLS version %q", version)
+		return c
+	}
+	c.req.MinVersion = version
+	return c
+}
+
+// Verify sets whether the certificate chain is verified. It is on by default.
+func (c *SSLCheck) Verify(verify bool) *SSLCheck {
+	c.req.Verify = verify
+	return c
+}
+
+// Timeout sets how long the handshake may take.
+func (c *SSLCheck) Timeout(d time.Duration) *SSLCheck {
+	c.req.Timeout = timerange.FormatDuration(d)
+	return c
+}
+
+// AssertExpiresAfter asserts that the certificate stays valid for at least
+// d. The target is sent in whole days.
+func (c *SSLCheck) AssertExpiresAfter(d time.Duration) *SSLCheck {
+	return c.AssertProperty(SourceSSL, "daysUntilExpiry", OpGreaterThan, strconv.Itoa(int(d/(24*time.Hour))))
+}
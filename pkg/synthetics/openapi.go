@@ -0,0 +1,427 @@
+package synthetics
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/groundcover-com/groundcover-sdk-go/internal/yamlutil"
+	"gopkg.in/yaml.v2"
+)
+
+type openAPISpec struct {
+	Swagger string `yaml:"swagger"`
+	OpenAPI string `yaml:"openapi"`
+	Info    struct {
+		Title string `yaml:"title"`
+	} `yaml:"info"`
+	Servers []struct {
+		URL       string `yaml:"url"`
+		Variables map[string]struct {
+			Default string `yaml:"default"`
+		} `yaml:"variables"`
+	} `yaml:"servers"`
+	Host       string                           `yaml:"host"`
+	BasePath   string                           `yaml:"basePath"`
+	Schemes    []string                         `yaml:"schemes"`
+	Paths      map[string]openAPIPathItem       `yaml:"paths"`
+	Security   []map[string][]string            `yaml:"security"`
+	Parameters map[string]openAPIParameter      `yaml:"parameters"`
+	SecDefs    map[string]openAPISecurityScheme `yaml:"securityDefinitions"`
+	Components struct {
+		Parameters      map[string]openAPIParameter      `yaml:"parameters"`
+		SecuritySchemes map[string]openAPISecurityScheme `yaml:"securitySchemes"`
+	} `yaml:"components"`
+}
+
+type openAPIPathItem struct {
+	Parameters []openAPIParameter `yaml:"parameters"`
+	Get        *openAPIOperation  `yaml:"get"`
+	Head       *openAPIOperation  `yaml:"head"`
+	Options    *openAPIOperation  `yaml:"options"`
+	Post       *openAPIOperation  `yaml:"post"`
+	Put        *openAPIOperation  `yaml:"put"`
+	Patch      *openAPIOperation  `yaml:"patch"`
+	Delete     *openAPIOperation  `yaml:"delete"`
+}
+
+func (p openAPIPathItem) operations() map[string]*openAPIOperation {
+	return map[string]*openAPIOperation{
+		http.MethodGet: p.Get, http.MethodHead: p.Head, http.MethodOptions: p.Options, http.MethodPost: p.Post,
+		http.MethodPut: p.Put, http.MethodPatch: p.Patch, http.MethodDelete: p.Delete,
+	}
+}
+
+type openAPIOperation struct {
+	OperationID string                 `yaml:"operationId"`
+	Tags        []string               `yaml:"tags"`
+	Deprecated  bool                   `yaml:"deprecated"`
+	Parameters  []openAPIParameter     `yaml:"parameters"`
+	Security    *[]map[string][]string `yaml:"security"`
+	RequestBody *struct {
+		Content map[string]openAPIMediaType `yaml:"content"`
+	} `yaml:"requestBody"`
+	Responses map[string]interface{} `yaml:"responses"`
+}
+
+type openAPIParameter struct {
+	Ref      string                  `yaml:"$ref"`
+	Name     string                  `yaml:"name"`
+	In       string                  `yaml:"in"`
+	Required bool                    `yaml:"required"`
+	Example  interface{}             `yaml:"example"`
+	Examples map[string]openAPIValue `yaml:"examples"`
+	Schema   *openAPISchema          `yaml:"schema"`
+	// Swagger 2.0 keeps these on the parameter itself.
+	Default  interface{}   `yaml:"default"`
+	Enum     []interface{} `yaml:"enum"`
+	XExample interface{}   `yaml:"x-example"`
+}
+
+type openAPIMediaType struct {
+	Example  interface{}             `yaml:"example"`
+	Examples map[string]openAPIValue `yaml:"examples"`
+	Schema   *openAPISchema          `yaml:"schema"`
+}
+
+type openAPIValue struct {
+	Value interface{} `yaml:"value"`
+}
+
+type openAPISchema struct {
+	Example interface{}   `yaml:"example"`
+	Default interface{}   `yaml:"default"`
+	Enum    []interface{} `yaml:"enum"`
+}
+
+type openAPISecurityScheme struct {
+	Type   string `yaml:"type"`
+	Scheme string `yaml:"scheme"`
+	In     string `yaml:"in"`
+	Name   string `yaml:"name"`
+}
+
+// ImportOpenAPI converts the operations of an OpenAPI 3 or Swagger 2.0 spec,
+// in YAML or JSON, into HTTP synthetic tests. Parameters are filled from
+// their examples, defaults or first enum value; operations with a required
+// parameter that has none are skipped. Each test asserts the first success
+// status the operation documents and a response time limit, and
+// authenticates as its security requirement says, with credentials taken
+// from WithSecret. Tests are named by operation ID, or by method and path,
+// and labelled with the API title, operation and first tag.
+func ImportOpenAPI(spec []byte, opts ...ImportOption) (*Import, error) {
+	var s openAPISpec
+	if err := yaml.Unmarshal(spec, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI spec: %w", err)
+	}
+	if s.OpenAPI == "" && s.Swagger == "" {
+		return nil, fmt.Errorf("failed to parse OpenAPI spec: no openapi or swagger version")
+	}
+	im := newImporter(opts)
+	base := im.cfg.baseURL
+	if base == "" {
+		base = s.serverURL()
+	}
+	if u, err := url.Parse(base); err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("OpenAPI spec has no absolute server URL, set one with WithBaseURL")
+	}
+	latency := im.cfg.latency
+	if latency == 0 {
+		latency = defaultLatencyLimit
+	}
+
+	paths := make([]string, 0, len(s.Paths))
+	for p := range s.Paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		item := s.Paths[path]
+		for _, method := range []string{http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+			op := item.operations()[method]
+			if op == nil {
+				continue
+			}
+			source := method + " " + path
+			if !im.cfg.methods[method] {
+				im.skip(source, "method %s is not imported, see WithMethods", method)
+				continue
+			}
+			if op.Deprecated {
+				im.skip(source, "operation is deprecated")
+				continue
+			}
+			c, err := s.operationCheck(im, base, path, method, item, op)
+			if err != nil {
+				im.skip(source, "%v", err)
+				continue
+			}
+			c.AssertStatus(successStatus(op.Responses)).AssertResponseTimeBelow(latency)
+			c.Label(LabelImportSource, "openapi")
+			if s.Info.Title != "" {
+				c.Label(LabelAPI, s.Info.Title)
+			}
+			name := source
+			if op.OperationID != "" {
+				name = op.OperationID
+				c.Label(LabelOperation, op.OperationID)
+			}
+			if len(op.Tags) > 0 {
+				c.Label(LabelTag, op.Tags[0])
+			}
+			im.add(source, name, c)
+		}
+	}
+	return im.result, nil
+}
+
+func (s *openAPISpec) serverURL() string {
+	if len(s.Servers) > 0 {
+		u := s.Servers[0].URL
+		for name, v := range s.Servers[0].Variables {
+			u = strings.ReplaceAll(u, "{"+name+"}", v.Default)
+		}
+		return strings.TrimSuffix(u, "/")
+	}
+	if s.Host == "" {
+		return ""
+	}
+	scheme := "https"
+	if len(s.Schemes) > 0 && !slices.Contains(s.Schemes, "https") {
+		scheme = s.Schemes[0]
+	}
+	return scheme + "://" + s.Host + strings.TrimSuffix(s.BasePath, "/")
+}
+
+func (s *openAPISpec) operationCheck(im *importer, base, path, method string, item openAPIPathItem, op *openAPIOperation) (*importedCheck, error) {
+	// Operation parameters override path item parameters of the same name
+	// and location.
+	params := map[string]openAPIParameter{}
+	var order []string
+	for _, p := range append(append([]openAPIParameter(nil), item.Parameters...), op.Parameters...) {
+		p, err := s.resolve(p)
+		if err != nil {
+			return nil, err
+		}
+		key := p.In + ":" + p.Name
+		if _, ok := params[key]; !ok {
+			order = append(order, key)
+		}
+		params[key] = p
+	}
+
+	query := map[string]string{}
+	headers := map[string]string{}
+	cookies := map[string]string{}
+	var body interface{}
+	for _, key := range order {
+		p := params[key]
+		value, ok := p.example()
+		if !ok {
+			if p.Required || p.In == "path" {
+				return nil, fmt.Errorf("no example for required %s parameter %q", p.In, p.Name)
+			}
+			continue
+		}
+		switch p.In {
+		case "path":
+			path = strings.ReplaceAll(path, "{"+p.Name+"}", url.PathEscape(exampleString(value)))
+		case "query":
+			query[p.Name] = exampleString(value)
+		case "header":
+			headers[p.Name] = exampleString(value)
+		case "cookie":
+			cookies[p.Name] = exampleString(value)
+		case "body":
+			body = value
+		}
+	}
+	if op.RequestBody != nil {
+		if mt, ok := op.RequestBody.Content["application/json"]; ok {
+			if v, ok := mt.example(); ok {
+				body = v
+			}
+		}
+	}
+
+	c := im.newCheck(method, base+path)
+	for k, v := range query {
+		c.Query(k, v)
+	}
+	for k, v := range headers {
+		c.Header(k, v)
+	}
+	for _, k := range slices.Sorted(maps.Keys(cookies)) {
+		c.Cookie(k, cookies[k])
+	}
+	if body != nil {
+		data, err := json.Marshal(yamlutil.JSONCompatible(body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request body example: %w", err)
+		}
+		c.Body(BodyJSON, string(data))
+	} else if op.RequestBody != nil && method != http.MethodGet {
+		c.warnings = append(c.warnings, "request body has no JSON example, the request is sent without one")
+	}
+
+	security := s.Security
+	if op.Security != nil {
+		security = *op.Security
+	}
+	if len(security) > 0 {
+		if err := s.authenticate(c, security[0]); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (s *openAPISpec) resolve(p openAPIParameter) (openAPIParameter, error) {
+	if p.Ref == "" {
+		return p, nil
+	}
+	for prefix, defs := range map[string]map[string]openAPIParameter{
+		"#/components/parameters/": s.Components.Parameters,
+		"#/parameters/":            s.Parameters,
+	} {
+		if name, ok := strings.CutPrefix(p.Ref, prefix); ok {
+			if def, ok := defs[name]; ok {
+				return def, nil
+			}
+		}
+	}
+	return p, fmt.Errorf("unresolved parameter reference %q", p.Ref)
+}
+
+func (s *openAPISpec) authenticate(c *importedCheck, requirement map[string][]string) error {
+	names := make([]string, 0, len(requirement))
+	for name := range requirement {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		scheme, ok := s.Components.SecuritySchemes[name]
+		if !ok {
+			if scheme, ok = s.SecDefs[name]; !ok {
+				return fmt.Errorf("unknown security scheme %q", name)
+			}
+		}
+		switch {
+		case scheme.Type == "basic" || (scheme.Type == "http" && strings.EqualFold(scheme.Scheme, "basic")):
+			c.basicAuth(name)
+		case scheme.Type == "http" && strings.EqualFold(scheme.Scheme, "bearer"), scheme.Type == "oauth2", scheme.Type == "openIdConnect":
+			c.BearerAuth(c.secret(name))
+		case scheme.Type == "apiKey" && scheme.In == "header":
+			c.Header(scheme.Name, c.secret(name))
+		case scheme.Type == "apiKey" && scheme.In == "query":
+			c.Query(scheme.Name, c.secret(name))
+		case scheme.Type == "apiKey" && scheme.In == "cookie":
+			c.Cookie(scheme.Name, c.secret(name))
+		default:
+			return fmt.Errorf("unsupported security scheme %q of type %q", name, scheme.Type)
+		}
+	}
+	return nil
+}
+
+// example picks the value a parameter is sent with.
+func (p openAPIParameter) example() (interface{}, bool) {
+	if p.Example != nil {
+		return p.Example, true
+	}
+	if v, ok := firstExample(p.Examples); ok {
+		return v, true
+	}
+	if p.XExample != nil {
+		return p.XExample, true
+	}
+	if v, ok := p.Schema.example(); ok {
+		return v, true
+	}
+	if p.Default != nil {
+		return p.Default, true
+	}
+	if len(p.Enum) > 0 {
+		return p.Enum[0], true
+	}
+	return nil, false
+}
+
+func (m openAPIMediaType) example() (interface{}, bool) {
+	if m.Example != nil {
+		return m.Example, true
+	}
+	if v, ok := firstExample(m.Examples); ok {
+		return v, true
+	}
+	return m.Schema.example()
+}
+
+func (s *openAPISchema) example() (interface{}, bool) {
+	switch {
+	case s == nil:
+		return nil, false
+	case s.Example != nil:
+		return s.Example, true
+	case s.Default != nil:
+		return s.Default, true
+	case len(s.Enum) > 0:
+		return s.Enum[0], true
+	}
+	return nil, false
+}
+
+// firstExample returns the value of the first named example, by name.
+func firstExample(examples map[string]openAPIValue) (interface{}, bool) {
+	names := make([]string, 0, len(examples))
+	for name, e := range examples {
+		if e.Value != nil {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, false
+	}
+	sort.Strings(names)
+	return examples[names[0]].Value, true
+}
+
+// successStatus returns the lowest documented 2xx status, or 200.
+func successStatus(responses map[string]interface{}) int {
+	best := 0
+	for code := range responses {
+		n, err := strconv.Atoi(strings.Replace(strings.ToUpper(code), "XX", "00", 1))
+		if err == nil && n >= 200 && n < 300 && (best == 0 || n < best) {
+			best = n
+		}
+	}
+	if best == 0 {
+		return http.StatusOK
+	}
+	return best
+}
+
+// exampleString formats an example value as a parameter, joining arrays
+// with commas.
+func exampleString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []interface{}:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = exampleString(item)
+		}
+		return strings.Join(parts, ",")
+	case map[interface{}]interface{}:
+		data, _ := json.Marshal(yamlutil.JSONCompatible(v))
+		return string(data)
+	}
+	return fmt.Sprint(v)
+}
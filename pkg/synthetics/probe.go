@@ -0,0 +1,431 @@
+package synthetics
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+)
+
+const (
+	// maxBodySize bounds how much of an HTTP response body is kept.
+	maxBodySize = 1 << 20
+	// udpReplyTimeout bounds the wait for a UDP reply when the definition
+	// sets none.
+	udpReplyTimeout = 5 * time.Second
+	// tcpIdleTimeout ends a TCP read once the server stops sending.
+	tcpIdleTimeout = 200 * time.Millisecond
+)
+
+// probe performs one kind of check.
+type probe interface {
+	kind() string
+	target() string
+	run(ctx context.Context, t *Timings) (observation, error)
+}
+
+func newProbe(r *models.Request, cfg *runConfig) (probe, error) {
+	switch {
+	case r.HTTP != nil:
+		return &httpProbe{req: r.HTTP, cfg: cfg}, nil
+	case r.DNS != nil:
+		return &dnsProbe{req: r.DNS, cfg: cfg}, nil
+	case r.TCP != nil:
+		return &tcpProbe{req: r.TCP, cfg: cfg}, nil
+	case r.UDP != nil:
+		return &udpProbe{req: r.UDP}, nil
+	case r.Ssl != nil:
+		return &sslProbe{req: r.Ssl, cfg: cfg}, nil
+	case r.Websocket != nil:
+		return &websocketProbe{req: r.Websocket, cfg: cfg}, nil
+	}
+	return nil, fmt.Errorf("check request has no HTTP, DNS, TCP, UDP, SSL or WebSocket part")
+}
+
+// withTimeout bounds ctx by the timeout of a definition, or the run default.
+func withTimeout(ctx context.Context, timeout string, cfg *runConfig) (context.Context, context.CancelFunc, error) {
+	d, err := parseDuration(timeout, cfg.timeout)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid timeout: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, d)
+	return ctx, cancel, nil
+}
+
+type httpProbe struct {
+	req *models.HTTPRequest
+	cfg *runConfig
+}
+
+func (p *httpProbe) kind() string   { return KindHTTP }
+func (p *httpProbe) target() string { return p.req.URL }
+
+func (p *httpProbe) run(ctx context.Context, t *Timings) (observation, error) {
+	ctx, cancel, err := withTimeout(ctx, p.req.Timeout, p.cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	u, err := url.Parse(p.req.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	if len(p.req.QueryParams) > 0 {
+		q := u.Query()
+		for k, v := range p.req.QueryParams {
+			q.Set(k, v)
+		}
+		u.RawQuery = q.Encode()
+	}
+	var body io.Reader
+	var contentType string
+	if b := p.req.Body; b != nil && b.Content != "" {
+		body = strings.NewReader(b.Content)
+		contentType = map[string]string{
+			BodyJSON: "application/json",
+			BodyForm: "application/x-www-form-urlencoded",
+			BodyText: "text/plain; charset=utf-8",
+		}[string(b.Type)]
+	}
+	method := p.req.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	start := time.Now()
+	var dnsStart, connectStart, tlsStart time.Time
+	trace := &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { dnsStart = time.Now() },
+		DNSDone:              func(httptrace.DNSDoneInfo) { t.DNS = time.Since(dnsStart) },
+		ConnectStart:         func(string, string) { connectStart = time.Now() },
+		ConnectDone:          func(string, string, error) { t.Connect = time.Since(connectStart) },
+		TLSHandshakeStart:    func() { tlsStart = time.Now() },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { t.TLS = time.Since(tlsStart) },
+		GotFirstResponseByte: func() { t.FirstByte = time.Since(start) },
+	}
+	r, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range p.req.Headers {
+		r.Header.Set(k, v)
+	}
+	if contentType != "" && r.Header.Get("Content-Type") == "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	for _, c := range p.req.Cookies {
+		r.AddCookie(&http.Cookie{Name: c.Name, Value: c.Value})
+	}
+	if a := p.req.Auth; a != nil {
+		switch string(a.Type) {
+		case AuthBasic:
+			r.SetBasicAuth(a.Username, a.Password)
+		case AuthBearer:
+			r.Header.Set("Authorization", "Bearer "+a.Token)
+		}
+	}
+
+	client := &http.Client{Transport: &http.Transport{
+		Proxy:             http.ProxyFromEnvironment,
+		DisableKeepAlives: true,
+		ForceAttemptHTTP2: p.req.HTTPVersion != "1.1",
+		TLSClientConfig: &tls.Config{
+			RootCAs:            p.cfg.rootCAs,
+			InsecureSkipVerify: p.req.AllowInsecure != nil && *p.req.AllowInsecure, //nolint:gosec // requested by the check
+		},
+	}}
+	if p.req.FollowRedirects != nil && !*p.req.FollowRedirects {
+		client.CheckRedirect = func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+	resp, err := client.Do(r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+
+	obs := observation{
+		SourceStatusCode: {strconv.Itoa(resp.StatusCode)},
+		SourceBody:       {string(data)},
+	}
+	for k, v := range resp.Header {
+		obs[SourceHeader+"."+strings.ToLower(k)] = v
+	}
+	return obs, err
+}
+
+type dnsProbe struct {
+	req *models.DNSRequest
+	cfg *runConfig
+}
+
+func (p *dnsProbe) kind() string   { return KindDNS }
+func (p *dnsProbe) target() string { return string(p.req.RecordType) + " " + p.req.Domain }
+
+func (p *dnsProbe) run(ctx context.Context, t *Timings) (observation, error) {
+	ctx, cancel, err := withTimeout(ctx, p.req.Timeout, p.cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	resolver := &net.Resolver{PreferGo: true}
+	if p.req.Resolver != "" {
+		port := p.req.Port
+		if port == 0 {
+			port = 53
+		}
+		server := net.JoinHostPort(p.req.Resolver, strconv.FormatInt(port, 10))
+		resolver.Dial = func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, server)
+		}
+	}
+
+	start := time.Now()
+	answers, err := lookup(ctx, resolver, strings.ToUpper(string(p.req.RecordType)), p.req.Domain)
+	t.DNS = time.Since(start)
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		err = nil
+	}
+	return observation{SourceDNSAnswer: answers}, err
+}
+
+func lookup(ctx context.Context, r *net.Resolver, recordType, domain string) ([]string, error) {
+	var answers []string
+	switch recordType {
+	case "", "A", "AAAA":
+		network := "ip4"
+		if recordType == "AAAA" {
+			network = "ip6"
+		}
+		ips, err := r.LookupIP(ctx, network, domain)
+		for _, ip := range ips {
+			answers = append(answers, ip.String())
+		}
+		return answers, err
+	case "CNAME":
+		cname, err := r.LookupCNAME(ctx, domain)
+		if err != nil {
+			return nil, err
+		}
+		return []string{strings.TrimSuffix(cname, ".")}, nil
+	case "MX":
+		mxs, err := r.LookupMX(ctx, domain)
+		for _, mx := range mxs {
+			answers = append(answers, strings.TrimSuffix(mx.Host, "."))
+		}
+		return answers, err
+	case "NS":
+		nss, err := r.LookupNS(ctx, domain)
+		for _, ns := range nss {
+			answers = append(answers, strings.TrimSuffix(ns.Host, "."))
+		}
+		return answers, err
+	case "TXT":
+		return r.LookupTXT(ctx, domain)
+	case "SRV":
+		_, srvs, err := r.LookupSRV(ctx, "", "", domain)
+		for _, srv := range srvs {
+			answers = append(answers, net.JoinHostPort(strings.TrimSuffix(srv.Target, "."), strconv.Itoa(int(srv.Port))))
+		}
+		return answers, err
+	case "PTR":
+		names, err := r.LookupAddr(ctx, domain)
+		for _, name := range names {
+			answers = append(answers, strings.TrimSuffix(name, "."))
+		}
+		return answers, err
+	}
+	return nil, fmt.Errorf("%s records cannot be resolved by the local runner", recordType)
+}
+
+type tcpProbe struct {
+	req *models.TCPRequest
+	cfg *runConfig
+}
+
+func (p *tcpProbe) kind() string { return KindTCP }
+
+func (p *tcpProbe) target() string {
+	return net.JoinHostPort(p.req.Host, strconv.FormatInt(p.req.Port, 10))
+}
+
+func (p *tcpProbe) run(ctx context.Context, t *Timings) (observation, error) {
+	ctx, cancel, err := withTimeout(ctx, p.req.Timeout, p.cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	start := time.Now()
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", p.target())
+	t.Connect = time.Since(start)
+	if err != nil {
+		return observation{SourceTCP: {"false"}}, err
+	}
+	defer conn.Close()
+	obs := observation{SourceTCP: {"true"}}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	if p.req.Send != "" {
+		if _, err := io.WriteString(conn, p.req.Send); err != nil {
+			return obs, err
+		}
+	}
+	if p.req.ExpectResponse {
+		data, err := readResponse(conn, int(p.req.ReceiveMaxBytes), func() { t.FirstByte = time.Since(start) })
+		obs[SourceTCP+".response"] = []string{string(data)}
+		return obs, err
+	}
+	return obs, nil
+}
+
+// readResponse reads up to maxBytes, waiting for the first byte until the
+// connection deadline and for the rest only while the peer keeps sending.
+func readResponse(conn net.Conn, maxBytes int, firstByte func()) ([]byte, error) {
+	if maxBytes <= 0 {
+		maxBytes = 1024
+	}
+	buf := make([]byte, maxBytes)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+	firstByte()
+	for n < maxBytes {
+		_ = conn.SetReadDeadline(time.Now().Add(tcpIdleTimeout))
+		m, err := conn.Read(buf[n:])
+		n += m
+		if err != nil {
+			break
+		}
+	}
+	return buf[:n], nil
+}
+
+type udpProbe struct {
+	req *models.UDPRequest
+}
+
+func (p *udpProbe) kind() string { return KindUDP }
+
+func (p *udpProbe) target() string {
+	return net.JoinHostPort(p.req.Host, strconv.FormatInt(p.req.Port, 10))
+}
+
+func (p *udpProbe) run(ctx context.Context, t *Timings) (observation, error) {
+	timeout, err := parseDuration(p.req.ReceiveTimeout, udpReplyTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid receive timeout: %w", err)
+	}
+	start := time.Now()
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", p.target())
+	if err != nil {
+		return observation{SourceUDP: {"false"}}, err
+	}
+	defer conn.Close()
+	if _, err := io.WriteString(conn, p.req.Payload); err != nil {
+		return observation{SourceUDP: {"false"}}, err
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(timeout))
+	buf := make([]byte, 64<<10)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return observation{SourceUDP: {"false"}}, fmt.Errorf("no reply: %w", err)
+	}
+	t.FirstByte = time.Since(start)
+	return observation{SourceUDP: {"true"}, SourceUDP + ".response": {string(buf[:n])}}, nil
+}
+
+type sslProbe struct {
+	req *models.SslRequest
+	cfg *runConfig
+}
+
+func (p *sslProbe) kind() string { return KindSSL }
+
+func (p *sslProbe) target() string {
+	port := p.req.Port
+	if port == 0 {
+		port = 443
+	}
+	return net.JoinHostPort(p.req.Host, strconv.FormatInt(port, 10))
+}
+
+var tlsVersionIDs = map[string]uint16{
+	"1.0": tls.VersionTLS10, "1.1": tls.VersionTLS11, "1.2": tls.VersionTLS12, "1.3": tls.VersionTLS13,
+}
+
+func (p *sslProbe) run(ctx context.Context, t *Timings) (observation, error) {
+	ctx, cancel, err := withTimeout(ctx, p.req.Timeout, p.cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	start := time.Now()
+	var d net.Dialer
+	raw, err := d.DialContext(ctx, "tcp", p.target())
+	t.Connect = time.Since(start)
+	if err != nil {
+		return observation{SourceSSL: {"false"}}, err
+	}
+	defer raw.Close()
+
+	serverName := p.req.Sni
+	if serverName == "" {
+		serverName = p.req.Host
+	}
+	// The chain is verified below so that an invalid certificate can still be
+	// inspected.
+	conn := tls.Client(raw, &tls.Config{
+		ServerName:         serverName,
+		MinVersion:         tlsVersionIDs[p.req.MinVersion],
+		InsecureSkipVerify: true, //nolint:gosec // verified explicitly
+	})
+	tlsStart := time.Now()
+	err = conn.HandshakeContext(ctx)
+	t.TLS = time.Since(tlsStart)
+	if err != nil {
+		return observation{SourceSSL: {"false"}}, err
+	}
+	state := conn.ConnectionState()
+	leaf := state.PeerCertificates[0]
+	obs := observation{
+		SourceSSL + ".daysUntilExpiry": {strconv.Itoa(int(time.Until(leaf.NotAfter) / (24 * time.Hour)))},
+		SourceSSL + ".notAfter":        {leaf.NotAfter.UTC().Format(time.RFC3339)},
+		SourceSSL + ".issuer":          {leaf.Issuer.String()},
+		SourceSSL + ".subject":         {leaf.Subject.String()},
+		SourceSSL + ".version":         {strings.TrimPrefix(tls.VersionName(state.Version), "TLS ")},
+	}
+	if p.req.Verify {
+		intermediates := x509.NewCertPool()
+		for _, c := range state.PeerCertificates[1:] {
+			intermediates.AddCert(c)
+		}
+		_, err = leaf.Verify(x509.VerifyOptions{DNSName: serverName, Roots: p.cfg.rootCAs, Intermediates: intermediates})
+	} else if now := time.Now(); now.After(leaf.NotAfter) || now.Before(leaf.NotBefore) {
+		err = fmt.Errorf("certificate is valid from %s to %s", leaf.NotBefore.Format(time.RFC3339), leaf.NotAfter.Format(time.RFC3339))
+	}
+	obs[SourceSSL] = []string{strconv.FormatBool(err == nil)}
+	return obs, err
+}
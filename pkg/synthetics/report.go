@@ -0,0 +1,648 @@
+package synthetics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/client"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/client/logs"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/client/metrics"
+	synthclient "github.com/groundcover-com/groundcover-sdk-go/pkg/client/synthetics"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/timerange"
+	"golang.org/x/sync/errgroup"
+)
+
+// Status is the normalized state of a synthetic test.
+type Status string
+
+// Test states.
+const (
+	StatusPassing Status = "passing"
+	StatusFailing Status = "failing"
+	StatusWarning Status = "warning"
+	StatusPaused  Status = "paused"
+	StatusUnknown Status = "unknown"
+)
+
+// ParseStatus normalizes the status string of the synthetics API. Statuses it
+// does not recognize are unknown.
+func ParseStatus(s string) Status {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "passing", "passed", "pass", "success", "succeeded", "ok", "up", "healthy":
+		return StatusPassing
+	case "failing", "failed", "fail", "failure", "error", "down", "unhealthy", "critical":
+		return StatusFailing
+	case "warning", "warn", "degraded":
+		return StatusWarning
+	case "paused", "disabled", "inactive":
+		return StatusPaused
+	}
+	return StatusUnknown
+}
+
+// TestStatus is a synthetic test as the list API reports it, with its
+// string fields parsed.
+type TestStatus struct {
+	ID         string
+	Name       string
+	Kind       string
+	Target     string
+	Interval   time.Duration
+	Status     Status
+	HasMonitor bool
+	// LastCheckLatency and LastCheckTime are zero when the test has not run.
+	LastCheckLatency time.Duration
+	LastCheckTime    time.Time
+}
+
+// ParseListItem parses the status, latency, time and interval strings of a
+// list item. Empty fields are left zero.
+func ParseListItem(item *models.SyntheticTestListItem) (*TestStatus, error) {
+	st := &TestStatus{
+		ID:         item.ID,
+		Name:       item.Name,
+		Kind:       item.SyntheticType,
+		Target:     item.Target,
+		Status:     ParseStatus(item.Status),
+		HasMonitor: item.Monitor != nil && item.Monitor.UUID != "",
+	}
+	var err error
+	if item.Interval != "" {
+		if st.Interval, err = timerange.ParseDuration(item.Interval); err != nil {
+			return nil, fmt.Errorf("invalid interval of test %s: %w", item.Name, err)
+		}
+	}
+	if item.LastCheckLatency != "" {
+		if st.LastCheckLatency, err = parseLatency(item.LastCheckLatency); err != nil {
+			return nil, fmt.Errorf("invalid last check latency of test %s: %w", item.Name, err)
+		}
+	}
+	if item.LastCheckTime != "" {
+		if st.LastCheckTime, err = parseCheckTime(item.LastCheckTime); err != nil {
+			return nil, fmt.Errorf("invalid last check time of test %s: %w", item.Name, err)
+		}
+	}
+	return st, nil
+}
+
+// parseLatency accepts durations ("120ms") and bare numbers, which are
+// milliseconds.
+func parseLatency(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if ms, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Duration(ms * float64(time.Millisecond)), nil
+	}
+	return time.ParseDuration(s)
+}
+
+// parseCheckTime accepts RFC 3339 times and Unix timestamps in seconds or
+// milliseconds.
+func parseCheckTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if n > 1e12 {
+			return time.UnixMilli(n).UTC(), nil
+		}
+		return time.Unix(n, 0).UTC(), nil
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05Z07:00", "2006-01-02 15:04:05"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized time %q", s)
+}
+
+const (
+	defaultSLOTarget         = 0.999
+	defaultReportConcurrency = 4
+	// maxReportPoints bounds the samples a report reads per test and metric,
+	// below the limit of the metrics backend.
+	maxReportPoints = 10000
+)
+
+// TestReport is the availability and latency of one test over the report
+// window.
+type TestReport struct {
+	*TestStatus
+	// Samples is the number of result samples in the window. Availability,
+	// latency and the error budget are zero when it is zero.
+	Samples int
+	// Availability is the fraction of successful checks, from 0 to 1.
+	Availability float64
+	LatencyP50   time.Duration
+	LatencyP95   time.Duration
+	LatencyP99   time.Duration
+	LatencyMax   time.Duration
+	// Downtime is the part of the window the test was failing, estimated
+	// from its availability.
+	Downtime time.Duration
+	// BudgetBurned is the fraction of the error budget the window used up.
+	// It exceeds 1 when the test missed its target.
+	BudgetBurned float64
+}
+
+// NoData reports whether the window held no results for the test.
+func (t *TestReport) NoData() bool {
+	return t.Samples == 0
+}
+
+// MetTarget reports whether the test met the SLO target of the report.
+// Tests without data are not counted as meeting it.
+func (t *TestReport) MetTarget() bool {
+	return !t.NoData() && t.BudgetBurned <= 1
+}
+
+// Report is the SLA report of a set of synthetic tests over a window.
+type Report struct {
+	Range timerange.Range
+	// Target is the availability objective, for example 0.999.
+	Target float64
+	// Step is the resolution set by WithReportStep, or zero when each test
+	// was read at its own.
+	Step  time.Duration
+	Tests []*TestReport
+}
+
+// AllowedDowntime is how long a test may fail within the window while still
+// meeting the target.
+func (r *Report) AllowedDowntime() time.Duration {
+	return time.Duration((1 - r.Target) * float64(r.Range.Duration()))
+}
+
+// ReportOption configures BuildReport.
+type ReportOption func(*reportConfig)
+
+type reportConfig struct {
+	target          float64
+	step            time.Duration
+	successSelector string
+	latencySelector string
+	logsQuery       string
+	successField    string
+	durationField   string
+	filter          func(*TestStatus) bool
+	concurrency     int
+}
+
+// WithSLOTarget sets the availability objective as a fraction, for example
+// 0.995. It defaults to 0.999.
+func WithSLOTarget(target float64) ReportOption {
+	return func(c *reportConfig) {
+		c.target = target
+	}
+}
+
+// WithReportStep sets the resolution results are read at. It defaults to the
+// smallest step that keeps the window within the sample limit of the
+// backend, and at least the interval of each test. Latency percentiles are
+// computed over per-step averages when the step is larger than the interval.
+func WithReportStep(step time.Duration) ReportOption {
+	return func(c *reportConfig) {
+		c.step = step
+	}
+}
+
+// WithSuccessSelector reads check results from metrics: selector selects a
+// series that is 1 for a successful check and 0 for a failed one. {id} and
+// {name} in the selector are replaced by the ID and name of each test, for
+// example `my_check_success{test_id="{id}"}`.
+func WithSuccessSelector(selector string) ReportOption {
+	return func(c *reportConfig) {
+		c.successSelector = selector
+	}
+}
+
+// WithLatencySelector sets the metric selector of check durations, in
+// seconds, with the placeholders of WithSuccessSelector. Without it, metrics
+// reports leave latency empty.
+func WithLatencySelector(selector string) ReportOption {
+	return func(c *reportConfig) {
+		c.latencySelector = selector
+	}
+}
+
+// WithResultLogs reads check results from logs instead of metrics: query is
+// a logs search query matching one log per check, with the placeholders of
+// WithSuccessSelector. successField names the field that holds whether the
+// check passed, as a boolean or a number that is non-zero on success, and
+// durationField, if not empty, the field that holds its duration, in seconds
+// or as a duration string.
+func WithResultLogs(query, successField, durationField string) ReportOption {
+	return func(c *reportConfig) {
+		c.logsQuery = query
+		c.successField = successField
+		c.durationField = durationField
+	}
+}
+
+// WithReportFilter limits the report to the tests keep returns true for.
+func WithReportFilter(keep func(*TestStatus) bool) ReportOption {
+	return func(c *reportConfig) {
+		c.filter = keep
+	}
+}
+
+// WithReportConcurrency sets how many tests are queried at once. It
+// defaults to 4.
+func WithReportConcurrency(n int) ReportOption {
+	return func(c *reportConfig) {
+		c.concurrency = n
+	}
+}
+
+// BuildReport lists the synthetic tests of the backend and computes the
+// availability, latency percentiles and error budget burn of each over r
+// from their check results. The results are read from the metrics set with
+// WithSuccessSelector or the logs set with WithResultLogs; one of them is
+// required. Tests are ordered by name.
+func BuildReport(ctx context.Context, api *client.GroundcoverAPI, r timerange.Range, opts ...ReportOption) (*Report, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+	cfg := &reportConfig{
+		target:      defaultSLOTarget,
+		concurrency: defaultReportConcurrency,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.target <= 0 || cfg.target >= 1 {
+		return nil, fmt.Errorf("SLO target must be between 0 and 1, got %v", cfg.target)
+	}
+	switch {
+	case cfg.logsQuery != "" && cfg.successSelector != "":
+		return nil, fmt.Errorf("check results are read from either metrics or logs, not both")
+	case cfg.logsQuery != "" && cfg.successField == "":
+		return nil, fmt.Errorf("result logs need a success field")
+	case cfg.logsQuery == "" && cfg.successSelector == "":
+		return nil, fmt.Errorf("no check results to read, set WithSuccessSelector or WithResultLogs")
+	}
+	if cfg.concurrency <= 0 {
+		cfg.concurrency = defaultReportConcurrency
+	}
+
+	resp, err := api.Synthetics.ListSyntheticTests(synthclient.NewListSyntheticTestsParams().WithContext(ctx), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list synthetic tests: %w", err)
+	}
+	var tests []*TestReport
+	if resp.Payload != nil {
+		for _, item := range resp.Payload.Synthetics {
+			if item == nil {
+				continue
+			}
+			st, err := ParseListItem(item)
+			if err != nil {
+				return nil, err
+			}
+			if cfg.filter == nil || cfg.filter(st) {
+				tests = append(tests, &TestReport{TestStatus: st})
+			}
+		}
+	}
+	sort.SliceStable(tests, func(i, j int) bool { return tests[i].Name < tests[j].Name })
+
+	minStep := r.Duration() / maxReportPoints
+	report := &Report{Range: r, Target: cfg.target, Step: cfg.step, Tests: tests}
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(cfg.concurrency)
+	for _, t := range tests {
+		g.Go(func() error {
+			step := cfg.step
+			if step <= 0 {
+				step = max(t.Interval, minStep, time.Second).Round(time.Second)
+			}
+			if err := t.measure(gctx, api, r, step, cfg); err != nil {
+				return fmt.Errorf("failed to query results of test %s: %w", t.Name, err)
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (t *TestReport) measure(ctx context.Context, api *client.GroundcoverAPI, r timerange.Range, step time.Duration, cfg *reportConfig) error {
+	success, latency, err := t.results(ctx, api, r, step, cfg)
+	if err != nil {
+		return err
+	}
+
+	t.Samples = len(success)
+	if t.Samples > 0 {
+		var sum float64
+		for _, v := range success {
+			sum += v
+		}
+		t.Availability = sum / float64(len(success))
+		t.Downtime = time.Duration((1 - t.Availability) * float64(r.Duration())).Round(time.Second)
+		t.BudgetBurned = (1 - t.Availability) / (1 - cfg.target)
+	}
+	if len(latency) > 0 {
+		sort.Float64s(latency)
+		t.LatencyP50 = seconds(percentile(latency, 50))
+		t.LatencyP95 = seconds(percentile(latency, 95))
+		t.LatencyP99 = seconds(percentile(latency, 99))
+		t.LatencyMax = seconds(latency[len(latency)-1])
+	}
+	return nil
+}
+
+// results returns the success values of the test's checks in r, 1 or 0 or
+// per-step averages of them, and their durations in seconds.
+func (t *TestReport) results(ctx context.Context, api *client.GroundcoverAPI, r timerange.Range, step time.Duration, cfg *reportConfig) ([]float64, []float64, error) {
+	if cfg.logsQuery != "" {
+		return queryLogResults(ctx, api, r, t.selector(cfg.logsQuery), cfg.successField, cfg.durationField)
+	}
+	success, err := queryValues(ctx, api, r, step, t.selector(cfg.successSelector))
+	if err != nil || cfg.latencySelector == "" {
+		return success, nil, err
+	}
+	latency, err := queryValues(ctx, api, r, step, t.selector(cfg.latencySelector))
+	return success, latency, err
+}
+
+// selector fills the placeholders of a selector template for the test.
+func (t *TestReport) selector(tmpl string) string {
+	return strings.NewReplacer("{id}", escapeLabelValue(t.ID), "{name}", escapeLabelValue(t.Name)).Replace(tmpl)
+}
+
+func escapeLabelValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// queryValues averages the selected series over each step of r and returns
+// every value of every series.
+func queryValues(ctx context.Context, api *client.GroundcoverAPI, r timerange.Range, step time.Duration, selector string) ([]float64, error) {
+	body := &models.QueryRequest{
+		Promql:    fmt.Sprintf("avg_over_time(%s[%s])", selector, timerange.FormatDuration(step)),
+		QueryType: "range",
+		Step:      timerange.FormatDuration(step),
+	}
+	r.ApplyToQuery(body)
+	resp, err := api.Metrics.MetricsQuery(metrics.NewMetricsQueryParamsWithContext(ctx).WithBody(body), nil)
+	if err != nil {
+		return nil, err
+	}
+	return matrixValues(resp.Payload)
+}
+
+// queryLogResults searches the logs of r and reads the success and duration
+// fields of every log. Logs without a readable success field are skipped.
+func queryLogResults(ctx context.Context, api *client.GroundcoverAPI, r timerange.Range, query, successField, durationField string) ([]float64, []float64, error) {
+	body := &models.LogsSearchRequest{Query: query}
+	r.ApplyToLogs(body)
+	resp, err := api.Logs.SearchLogs(logs.NewSearchLogsParamsWithContext(ctx).WithBody(body), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	raw, err := json.Marshal(resp.Payload)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read logs response: %w", err)
+	}
+	var rows []map[string]interface{}
+	if err := json.Unmarshal(raw, &rows); err != nil {
+		return nil, nil, fmt.Errorf("failed to read logs response: %w", err)
+	}
+
+	var success, latency []float64
+	for _, row := range rows {
+		ok, found := fieldSuccess(row[successField])
+		if !found {
+			continue
+		}
+		if ok {
+			success = append(success, 1)
+		} else {
+			success = append(success, 0)
+		}
+		if durationField == "" {
+			continue
+		}
+		if d, found := fieldSeconds(row[durationField]); found {
+			latency = append(latency, d)
+		}
+	}
+	return success, latency, nil
+}
+
+func fieldSuccess(v interface{}) (bool, bool) {
+	switch v := v.(type) {
+	case bool:
+		return v, true
+	case float64:
+		return v != 0, true
+	case string:
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b, true
+		}
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f != 0, true
+		}
+	}
+	return false, false
+}
+
+func fieldSeconds(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case string:
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f, true
+		}
+		if d, err := time.ParseDuration(v); err == nil {
+			return d.Seconds(), true
+		}
+	}
+	return 0, false
+}
+
+// matrixValues reads the values of a Prometheus matrix or vector response.
+func matrixValues(payload interface{}) ([]float64, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metrics response: %w", err)
+	}
+	type result struct {
+		Result []struct {
+			Value  []interface{}   `json:"value"`
+			Values [][]interface{} `json:"values"`
+		} `json:"result"`
+	}
+	var envelope struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+		Data   result `json:"data"`
+		result
+	}
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		return nil, fmt.Errorf("failed to read metrics response: %w", err)
+	}
+	if envelope.Status == "error" {
+		return nil, fmt.Errorf("metrics query failed: %s", envelope.Error)
+	}
+	res := envelope.Data
+	if len(res.Result) == 0 {
+		res = envelope.result
+	}
+
+	var values []float64
+	for _, s := range res.Result {
+		samples := s.Values
+		if len(samples) == 0 && len(s.Value) == 2 {
+			samples = [][]interface{}{s.Value}
+		}
+		for _, sample := range samples {
+			if len(sample) != 2 {
+				continue
+			}
+			str, _ := sample[1].(string)
+			v, err := strconv.ParseFloat(str, 64)
+			if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+				continue
+			}
+			values = append(values, v)
+		}
+	}
+	return values, nil
+}
+
+// percentile returns the nearest-rank percentile p of sorted values.
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[min(max(rank, 1), len(sorted))-1]
+}
+
+func seconds(v float64) time.Duration {
+	return time.Duration(v * float64(time.Second))
+}
+
+// WriteMarkdown renders the report as a Markdown table, ready to be posted
+// as is.
+func (r *Report) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	met := 0
+	for _, t := range r.Tests {
+		if t.MetTarget() {
+			met++
+		}
+	}
+	fmt.Fprintf(&b, "## Synthetic tests: %s to %s\n\n", r.Range.Start.UTC().Format("2006-01-02 15:04"), r.Range.End.UTC().Format("2006-01-02 15:04 MST"))
+	fmt.Fprintf(&b, "SLO target %s, allowing %s of downtime. %d of %d tests met the target.\n\n",
+		formatPercent(r.Target), r.AllowedDowntime().Round(time.Second), met, len(r.Tests))
+	b.WriteString("| Test | Kind | Status | Availability | p50 | p95 | p99 | Downtime | Budget burned |\n")
+	b.WriteString("|---|---|---|---:|---:|---:|---:|---:|---:|\n")
+	for _, t := range r.Tests {
+		name := strings.ReplaceAll(t.Name, "|", `\|`)
+		if t.NoData() {
+			fmt.Fprintf(&b, "| %s | %s | %s | no data | | | | | |\n", name, t.Kind, t.Status)
+			continue
+		}
+		burned := formatPercent(t.BudgetBurned)
+		if !t.MetTarget() {
+			burned = "**" + burned + "**"
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s | %s | %s | %s |\n", name, t.Kind, t.Status,
+			formatPercent(t.Availability), formatLatency(t.LatencyP50), formatLatency(t.LatencyP95), formatLatency(t.LatencyP99),
+			t.Downtime, burned)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// formatPercent formats a fraction as a percentage with up to three decimals.
+func formatPercent(f float64) string {
+	s := strconv.FormatFloat(f*100, 'f', 3, 64)
+	return strings.TrimSuffix(strings.TrimRight(s, "0"), ".") + "%"
+}
+
+func formatLatency(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return d.Round(time.Millisecond).String()
+}
+
+type jsonReport struct {
+	Start                  time.Time        `json:"start"`
+	End                    time.Time        `json:"end"`
+	Target                 float64          `json:"target"`
+	AllowedDowntimeSeconds float64          `json:"allowedDowntimeSeconds"`
+	Tests                  []jsonTestReport `json:"tests"`
+}
+
+type jsonTestReport struct {
+	ID                 string     `json:"id"`
+	Name               string     `json:"name"`
+	Kind               string     `json:"kind,omitempty"`
+	Target             string     `json:"target,omitempty"`
+	Status             Status     `json:"status"`
+	LastCheckTime      *time.Time `json:"lastCheckTime,omitempty"`
+	LastCheckLatencyMs float64    `json:"lastCheckLatencyMs,omitempty"`
+	NoData             bool       `json:"noData,omitempty"`
+	Samples            int        `json:"samples"`
+	Availability       float64    `json:"availability"`
+	LatencyP50Ms       float64    `json:"latencyP50Ms"`
+	LatencyP95Ms       float64    `json:"latencyP95Ms"`
+	LatencyP99Ms       float64    `json:"latencyP99Ms"`
+	LatencyMaxMs       float64    `json:"latencyMaxMs"`
+	DowntimeSeconds    float64    `json:"downtimeSeconds"`
+	BudgetBurned       float64    `json:"budgetBurned"`
+	MetTarget          bool       `json:"metTarget"`
+}
+
+// WriteJSON renders the report as indented JSON. Durations are in
+// milliseconds for latencies and seconds for downtime.
+func (r *Report) WriteJSON(w io.Writer) error {
+	out := jsonReport{
+		Start:                  r.Range.Start.UTC(),
+		End:                    r.Range.End.UTC(),
+		Target:                 r.Target,
+		AllowedDowntimeSeconds: r.AllowedDowntime().Seconds(),
+		Tests:                  make([]jsonTestReport, 0, len(r.Tests)),
+	}
+	for _, t := range r.Tests {
+		jt := jsonTestReport{
+			ID:                 t.ID,
+			Name:               t.Name,
+			Kind:               t.Kind,
+			Target:             t.TestStatus.Target,
+			Status:             t.Status,
+			LastCheckLatencyMs: milliseconds(t.LastCheckLatency),
+			NoData:             t.NoData(),
+			Samples:            t.Samples,
+			Availability:       t.Availability,
+			LatencyP50Ms:       milliseconds(t.LatencyP50),
+			LatencyP95Ms:       milliseconds(t.LatencyP95),
+			LatencyP99Ms:       milliseconds(t.LatencyP99),
+			LatencyMaxMs:       milliseconds(t.LatencyMax),
+			DowntimeSeconds:    t.Downtime.Seconds(),
+			BudgetBurned:       t.BudgetBurned,
+			MetTarget:          t.MetTarget(),
+		}
+		if !t.LastCheckTime.IsZero() {
+			lt := t.LastCheckTime
+			jt.LastCheckTime = &lt
+		}
+		out.Tests = append(out.Tests, jt)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
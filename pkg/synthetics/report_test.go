@@ -0,0 +1,206 @@
+package synthetics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/groundcover-com/groundcover-sdk-go/internal/testutil"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/timerange"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseListItem(t *testing.T) {
+	st, err := ParseListItem(&models.SyntheticTestListItem{
+		ID:               "abc",
+		Name:             "Checkout",
+		SyntheticType:    "http",
+		Interval:         "30s",
+		Status:           "Failed",
+		LastCheckLatency: "123.5",
+		LastCheckTime:    "2024-05-01T10:00:00Z",
+		Monitor:          &models.SyntheticTestMonitor{UUID: "m-1"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, StatusFailing, st.Status)
+	require.Equal(t, 30*time.Second, st.Interval)
+	require.Equal(t, 123500*time.Microsecond, st.LastCheckLatency)
+	require.Equal(t, time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), st.LastCheckTime)
+	require.True(t, st.HasMonitor)
+
+	st, err = ParseListItem(&models.SyntheticTestListItem{Name: "New", LastCheckLatency: "1.2s", LastCheckTime: "1714557600000"})
+	require.NoError(t, err)
+	require.Equal(t, StatusUnknown, st.Status)
+	require.Equal(t, 1200*time.Millisecond, st.LastCheckLatency)
+	require.Equal(t, time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), st.LastCheckTime)
+
+	_, err = ParseListItem(&models.SyntheticTestListItem{Name: "Bad", LastCheckTime: "yesterday"})
+	require.ErrorContains(t, err, "invalid last check time of test Bad")
+}
+
+func TestBuildReport(t *testing.T) {
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	r := timerange.New(start, start.Add(100*time.Minute))
+
+	// The checkout test failed 1 of 100 checks, the search test none.
+	results := map[string][]float64{}
+	for i := 0; i < 100; i++ {
+		v := 1.0
+		if i == 42 {
+			v = 0
+		}
+		results["checkout-success"] = append(results["checkout-success"], v)
+		results["checkout-latency"] = append(results["checkout-latency"], float64(i+1)/1000)
+		results["search-success"] = append(results["search-success"], 1)
+	}
+
+	var (
+		mu      sync.Mutex
+		queries []models.QueryRequest
+	)
+	api := testutil.NewAPI(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch req.URL.Path {
+		case "/api/synthetics/v1/rules":
+			_ = json.NewEncoder(w).Encode(models.SyntheticTestListResponse{Synthetics: []*models.SyntheticTestListItem{
+				{ID: "search", Name: "Search", SyntheticType: "http", Interval: "1m", Status: "success"},
+				{ID: "checkout", Name: "Checkout", SyntheticType: "http", Interval: "1m", Status: "failure"},
+				{ID: "legacy", Name: "Legacy", SyntheticType: "tcp", Interval: "1m"},
+			}})
+		case "/api/metrics/query":
+			var q models.QueryRequest
+			require.NoError(t, json.NewDecoder(req.Body).Decode(&q))
+			mu.Lock()
+			queries = append(queries, q)
+			mu.Unlock()
+
+			key := ""
+			for _, id := range []string{"checkout", "search", "legacy"} {
+				if strings.Contains(q.Promql, `"`+id+`"`) {
+					key = id
+				}
+			}
+			if strings.Contains(q.Promql, "success") {
+				key += "-success"
+			} else {
+				key += "-latency"
+			}
+			var values [][2]interface{}
+			for i, v := range results[key] {
+				values = append(values, [2]interface{}{float64(start.Add(time.Duration(i) * time.Minute).Unix()), fmt.Sprint(v)})
+			}
+			series := []interface{}{}
+			if len(values) > 0 {
+				series = append(series, map[string]interface{}{"metric": map[string]string{}, "values": values})
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"status": "success",
+				"data":   map[string]interface{}{"resultType": "matrix", "result": series},
+			})
+		default:
+			t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+		}
+	}))
+
+	report, err := BuildReport(context.Background(), api, r,
+		WithSLOTarget(0.995),
+		WithSuccessSelector(`probe_success{test_id="{id}"}`),
+		WithLatencySelector(`probe_duration_seconds{test_id="{id}"}`),
+	)
+	require.NoError(t, err)
+	require.Len(t, queries, 6)
+	require.Equal(t, "1m", queries[0].Step)
+	require.Equal(t, "range", queries[0].QueryType)
+	require.Contains(t, queries[0].Promql, "avg_over_time(probe_")
+	require.Contains(t, queries[0].Promql, "[1m])")
+
+	require.Len(t, report.Tests, 3)
+	checkout, legacy, search := report.Tests[0], report.Tests[1], report.Tests[2]
+	require.Equal(t, "Checkout", checkout.Name)
+	require.Equal(t, 100, checkout.Samples)
+	require.InDelta(t, 0.99, checkout.Availability, 1e-9)
+	require.InDelta(t, 2.0, checkout.BudgetBurned, 1e-9)
+	require.Equal(t, time.Minute, checkout.Downtime)
+	require.Equal(t, 50*time.Millisecond, checkout.LatencyP50)
+	require.Equal(t, 95*time.Millisecond, checkout.LatencyP95)
+	require.Equal(t, 99*time.Millisecond, checkout.LatencyP99)
+	require.Equal(t, 100*time.Millisecond, checkout.LatencyMax)
+	require.False(t, checkout.MetTarget())
+
+	require.True(t, legacy.NoData())
+	require.False(t, legacy.MetTarget())
+	require.Equal(t, 1.0, search.Availability)
+	require.True(t, search.MetTarget())
+	require.Equal(t, 30*time.Second, report.AllowedDowntime())
+
+	var md bytes.Buffer
+	require.NoError(t, report.WriteMarkdown(&md))
+	require.Contains(t, md.String(), "SLO target 99.5%, allowing 30s of downtime. 1 of 3 tests met the target.")
+	require.Contains(t, md.String(), "| Checkout | http | failing | 99% | 50ms | 95ms | 99ms | 1m0s | **200%** |")
+	require.Contains(t, md.String(), "| Legacy | tcp | unknown | no data | | | | | |")
+
+	var out bytes.Buffer
+	require.NoError(t, report.WriteJSON(&out))
+	var decoded struct {
+		Tests []map[string]interface{} `json:"tests"`
+	}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	require.Equal(t, 95.0, decoded.Tests[0]["latencyP95Ms"])
+	require.Equal(t, true, decoded.Tests[1]["noData"])
+	require.Equal(t, true, decoded.Tests[2]["metTarget"])
+}
+
+func TestBuildReportFromLogs(t *testing.T) {
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	var query string
+	api := testutil.NewAPI(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch req.URL.Path {
+		case "/api/synthetics/v1/rules":
+			_ = json.NewEncoder(w).Encode(models.SyntheticTestListResponse{Synthetics: []*models.SyntheticTestListItem{
+				{ID: "checkout", Name: "Checkout", SyntheticType: "http", Interval: "1m"},
+			}})
+		case "/api/logs/v2/search":
+			var body models.LogsSearchRequest
+			require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+			query = body.Query
+			_ = json.NewEncoder(w).Encode([]map[string]interface{}{
+				{"passed": true, "duration": 0.1},
+				{"passed": "false", "duration": "300ms"},
+				{"passed": 1, "duration": "0.2"},
+				{"passed": true, "duration": 0.4},
+				{"message": "no result"},
+			})
+		default:
+			t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+		}
+	}))
+
+	report, err := BuildReport(context.Background(), api, timerange.New(start, start.Add(time.Hour)),
+		WithResultLogs(`synthetic_id:"{id}"`, "passed", "duration"))
+	require.NoError(t, err)
+	require.Equal(t, `synthetic_id:"checkout"`, query)
+	checkout := report.Tests[0]
+	require.Equal(t, 4, checkout.Samples)
+	require.InDelta(t, 0.75, checkout.Availability, 1e-9)
+	require.Equal(t, 200*time.Millisecond, checkout.LatencyP50)
+	require.Equal(t, 400*time.Millisecond, checkout.LatencyMax)
+}
+
+func TestBuildReportRejectsInvalidOptions(t *testing.T) {
+	_, err := BuildReport(context.Background(), nil, timerange.Last(time.Hour), WithSLOTarget(99.9), WithSuccessSelector("up"))
+	require.ErrorContains(t, err, "SLO target must be between 0 and 1")
+
+	_, err = BuildReport(context.Background(), nil, timerange.Last(time.Hour))
+	require.ErrorContains(t, err, "no check results to read")
+
+	_, err = BuildReport(context.Background(), nil, timerange.Last(time.Hour), WithResultLogs("x", "", ""))
+	require.ErrorContains(t, err, "result logs need a success field")
+}
@@ -0,0 +1,366 @@
+package synthetics
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+)
+
+// Assertion severities. A failed warning is reported but does not fail the
+// run; assertions without a severity are critical.
+const (
+	SeverityCritical = "critical"
+	SeverityWarning  = "warning"
+)
+
+// defaultRunTimeout bounds checks whose definition has no timeout.
+const defaultRunTimeout = 30 * time.Second
+
+// Timings break down how long an attempt spent in each phase. Phases that
+// do not apply to a check kind are zero.
+type Timings struct {
+	DNS       time.Duration
+	Connect   time.Duration
+	TLS       time.Duration
+	FirstByte time.Duration
+	Total     time.Duration
+}
+
+// AssertionResult is the outcome of one assertion in one attempt.
+type AssertionResult struct {
+	Assertion *models.Assertion
+	Passed    bool
+	// Actual is what the check observed for the assertion source.
+	Actual string
+	// Message explains a failure.
+	Message string
+}
+
+// Critical reports whether a failure of the assertion fails the run.
+func (r AssertionResult) Critical() bool {
+	return r.Assertion.Severity == "" || r.Assertion.Severity == SeverityCritical
+}
+
+// Attempt is one execution of a check.
+type Attempt struct {
+	Start      time.Time
+	Timings    Timings
+	Assertions []AssertionResult
+	// Err is the error the check ran into, such as a refused connection.
+	// Assertions are still evaluated against whatever was observed.
+	Err    error
+	Passed bool
+}
+
+// Result is the outcome of running a check locally.
+type Result struct {
+	Kind     string
+	Target   string
+	Passed   bool
+	Attempts []*Attempt
+	Duration time.Duration
+}
+
+// Last returns the final attempt.
+func (r *Result) Last() *Attempt {
+	return r.Attempts[len(r.Attempts)-1]
+}
+
+func (r *Result) String() string {
+	var b strings.Builder
+	status := "PASS"
+	if !r.Passed {
+		status = "FAIL"
+	}
+	fmt.Fprintf(&b, "%s %s %s (%d attempts, %s)\n", status, r.Kind, r.Target, len(r.Attempts), r.Duration.Round(time.Millisecond))
+	last := r.Last()
+	if last.Err != nil {
+		fmt.Fprintf(&b, "  error: %v\n", last.Err)
+	}
+	for _, a := range last.Assertions {
+		mark := "ok"
+		if !a.Passed {
+			mark = "FAILED"
+			if !a.Critical() {
+				mark = "WARN"
+			}
+		}
+		fmt.Fprintf(&b, "  %-6s %s %s %s", mark, assertionSubject(a.Assertion), a.Assertion.Operator, a.Assertion.Target)
+		if !a.Passed {
+			fmt.Fprintf(&b, ": %s", a.Message)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// RunOption configures Run.
+type RunOption func(*runConfig)
+
+type runConfig struct {
+	rootCAs *x509.CertPool
+	timeout time.Duration
+}
+
+// WithRootCAs trusts the given certificate authorities instead of the system
+// ones, for example those of an httptest.NewTLSServer.
+func WithRootCAs(pool *x509.CertPool) RunOption {
+	return func(c *runConfig) {
+		c.rootCAs = pool
+	}
+}
+
+// WithDefaultTimeout bounds checks whose definition sets no timeout. It
+// defaults to 30 seconds.
+func WithDefaultTimeout(d time.Duration) RunOption {
+	return func(c *runConfig) {
+		c.timeout = d
+	}
+}
+
+// Run performs a check from the machine running the SDK, the way a
+// synthetics worker would, and evaluates its assertions. Failed attempts are
+// retried as the execution policy says. The error is reserved for checks
+// that cannot run at all; a failing check is reported in the result.
+func Run(ctx context.Context, req *models.WorkerRequest, opts ...RunOption) (*Result, error) {
+	cfg := runConfig{timeout: defaultRunTimeout}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if req == nil || req.Request == nil {
+		return nil, fmt.Errorf("check has no request")
+	}
+	p, err := newProbe(req.Request, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var assertions []*models.Assertion
+	var retries models.Retries
+	if policy := req.ExecutionPolicy; policy != nil {
+		assertions = policy.Assertions
+		if policy.Retries != nil {
+			retries = *policy.Retries
+		}
+	}
+	retryInterval, err := parseDuration(retries.Interval, 0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid retry interval: %w", err)
+	}
+
+	result := &Result{Kind: p.kind(), Target: p.target()}
+	start := time.Now()
+	for i := int64(0); i <= retries.Count; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(retryInterval):
+			}
+		}
+		attempt := runAttempt(ctx, p, assertions)
+		result.Attempts = append(result.Attempts, attempt)
+		if attempt.Passed {
+			break
+		}
+	}
+	result.Duration = time.Since(start)
+	result.Passed = result.Last().Passed
+	return result, nil
+}
+
+// RunTest runs the check of a synthetic test definition.
+func RunTest(ctx context.Context, req *models.SyntheticTestCreateRequest, opts ...RunOption) (*Result, error) {
+	if req == nil {
+		return nil, fmt.Errorf("synthetic test is nil")
+	}
+	return Run(ctx, req.CheckConfig, opts...)
+}
+
+// Run builds the test and runs its check locally.
+func (c *check[B]) Run(ctx context.Context, opts ...RunOption) (*Result, error) {
+	req, err := c.Build()
+	if err != nil {
+		return nil, err
+	}
+	return RunTest(ctx, req, opts...)
+}
+
+func runAttempt(ctx context.Context, p probe, assertions []*models.Assertion) *Attempt {
+	attempt := &Attempt{Start: time.Now()}
+	obs, err := p.run(ctx, &attempt.Timings)
+	attempt.Timings.Total = time.Since(attempt.Start)
+	attempt.Err = err
+	if obs == nil {
+		obs = observation{}
+	}
+	obs[SourceResponseTime] = []string{strconv.FormatInt(attempt.Timings.Total.Milliseconds(), 10)}
+
+	attempt.Passed = err == nil
+	for _, a := range assertions {
+		r := evaluate(a, obs)
+		attempt.Assertions = append(attempt.Assertions, r)
+		if !r.Passed && r.Critical() {
+			attempt.Passed = false
+		}
+	}
+	return attempt
+}
+
+// observation holds what a check saw, keyed by assertion source, or by
+// source and property joined with a dot. Sources with properties that cannot
+// be listed up front, such as JSON paths, are resolved by lookup.
+type observation map[string][]string
+
+var lookups = map[string]func(obs observation, property string) ([]string, bool){
+	SourceHeader: func(obs observation, property string) ([]string, bool) {
+		v, ok := obs[SourceHeader+"."+strings.ToLower(property)]
+		return v, ok
+	},
+	SourceJSONBody: func(obs observation, property string) ([]string, bool) {
+		body, ok := obs[SourceBody]
+		if !ok || len(body) == 0 {
+			return nil, false
+		}
+		return jsonPath(body[0], property)
+	},
+}
+
+func (obs observation) get(source, property string) ([]string, bool) {
+	if property == "" {
+		v, ok := obs[source]
+		return v, ok
+	}
+	if lookup, ok := lookups[source]; ok {
+		return lookup(obs, property)
+	}
+	v, ok := obs[source+"."+property]
+	return v, ok
+}
+
+func evaluate(a *models.Assertion, obs observation) AssertionResult {
+	r := AssertionResult{Assertion: a}
+	values, found := obs.get(string(a.Source), a.Property)
+	r.Actual = strings.Join(values, ", ")
+	target := a.Target
+
+	anyValue := func(pred func(v string) bool) bool {
+		for _, v := range values {
+			if pred(v) {
+				return true
+			}
+		}
+		return false
+	}
+	equal := func(v string) bool {
+		if x, err := strconv.ParseFloat(v, 64); err == nil {
+			if y, err := strconv.ParseFloat(target, 64); err == nil {
+				return x == y
+			}
+		}
+		return v == target
+	}
+	compare := func(less bool) (bool, string) {
+		y, err := strconv.ParseFloat(target, 64)
+		if err != nil {
+			return false, fmt.Sprintf("target %q is not a number", target)
+		}
+		return anyValue(func(v string) bool {
+			x, err := strconv.ParseFloat(v, 64)
+			return err == nil && (x < y) == less && x != y
+		}), ""
+	}
+
+	switch string(a.Operator) {
+	case OpExists:
+		want := target != "false"
+		r.Passed = (found && len(values) > 0 && values[0] != "false") == want
+	case OpEquals:
+		r.Passed = anyValue(equal)
+	case OpNotEquals:
+		r.Passed = found && !anyValue(equal)
+	case OpContains:
+		r.Passed = anyValue(func(v string) bool { return strings.Contains(v, target) })
+	case OpNotContains:
+		r.Passed = found && !anyValue(func(v string) bool { return strings.Contains(v, target) })
+	case OpMatches:
+		re, err := regexp.Compile(target)
+		if err != nil {
+			r.Message = fmt.Sprintf("invalid regex %q: %v", target, err)
+			return r
+		}
+		r.Passed = anyValue(re.MatchString)
+	case OpGreaterThan, OpLessThan:
+		r.Passed, r.Message = compare(string(a.Operator) == OpLessThan)
+	default:
+		r.Message = fmt.Sprintf("unsupported operator %q", a.Operator)
+		return r
+	}
+	if !r.Passed && r.Message == "" {
+		if !found {
+			r.Message = fmt.Sprintf("%s was not observed", assertionSubject(a))
+		} else {
+			r.Message = fmt.Sprintf("got %q", r.Actual)
+		}
+	}
+	return r
+}
+
+func assertionSubject(a *models.Assertion) string {
+	if a.Property == "" {
+		return string(a.Source)
+	}
+	return string(a.Source) + "[" + a.Property + "]"
+}
+
+// jsonPath resolves a path such as "$.items[0].id" or "items.0.id" in a JSON
+// document. Strings resolve to their content, other values to their JSON.
+func jsonPath(doc, path string) ([]string, bool) {
+	var v interface{}
+	if err := json.Unmarshal([]byte(doc), &v); err != nil {
+		return nil, false
+	}
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	path = strings.NewReplacer("[", ".", "]", "").Replace(path)
+	if path != "" {
+		for _, part := range strings.Split(path, ".") {
+			switch node := v.(type) {
+			case map[string]interface{}:
+				child, ok := node[part]
+				if !ok {
+					return nil, false
+				}
+				v = child
+			case []interface{}:
+				i, err := strconv.Atoi(part)
+				if err != nil || i < 0 || i >= len(node) {
+					return nil, false
+				}
+				v = node[i]
+			default:
+				return nil, false
+			}
+		}
+	}
+	if s, ok := v.(string); ok {
+		return []string{s}, true
+	}
+	data, _ := json.Marshal(v)
+	return []string{string(data)}, true
+}
+
+// parseDuration parses a duration as written in check definitions, using
+// fallback when it is empty.
+func parseDuration(s string, fallback time.Duration) (time.Duration, error) {
+	if s == "" {
+		return fallback, nil
+	}
+	return time.ParseDuration(s)
+}
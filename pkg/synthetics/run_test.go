@@ -0,0 +1,314 @@
+package synthetics
+
+import (
+	"bufio"
+	"context"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunHTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" || r.URL.Query().Get("page") != "2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Request-Id", "abc")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"status":"ok","items":[{"id":7}],"echo":%s}`, body)
+	}))
+	defer srv.Close()
+
+	result, err := HTTP(srv.URL).
+		Method("POST").
+		Query("page", "2").
+		BearerAuth("secret").
+		JSONBody(map[string]int{"n": 1}).
+		AssertStatus(201).
+		AssertHeader("x-request-id", "abc").
+		AssertJSON("$.status", "ok").
+		AssertJSON("$.items[0].id", "7").
+		AssertJSON("echo.n", "1").
+		AssertResponseTimeBelow(5 * time.Second).
+		Run(context.Background())
+	require.NoError(t, err)
+	require.True(t, result.Passed, result.String())
+	require.Len(t, result.Attempts, 1)
+	require.Equal(t, KindHTTP, result.Kind)
+	require.Positive(t, result.Last().Timings.FirstByte)
+	require.Positive(t, result.Last().Timings.Connect)
+}
+
+func TestRunHTTPFailureAndRetries(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	result, err := HTTP(srv.URL).AssertStatus(200).Retries(2, time.Millisecond).Run(context.Background())
+	require.NoError(t, err)
+	require.False(t, result.Passed)
+	require.Len(t, result.Attempts, 3)
+	require.Equal(t, int32(3), calls.Load())
+	require.Equal(t, `got "503"`, result.Last().Assertions[0].Message)
+	require.Contains(t, result.String(), "FAIL http")
+}
+
+func TestRunRetriesUntilPass(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 2 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	result, err := HTTP(srv.URL).Retries(3, 0).Run(context.Background())
+	require.NoError(t, err)
+	require.True(t, result.Passed)
+	require.Len(t, result.Attempts, 2)
+}
+
+func TestRunWarningSeverity(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "slow path")
+	}))
+	defer srv.Close()
+
+	result, err := HTTP(srv.URL).
+		AssertStatus(200).
+		Assertion(&models.Assertion{Source: SourceBody, Operator: OpNotContains, Target: "slow", Severity: SeverityWarning}).
+		Run(context.Background())
+	require.NoError(t, err)
+	require.True(t, result.Passed)
+	require.False(t, result.Last().Assertions[1].Passed)
+	require.Contains(t, result.String(), "WARN")
+}
+
+func TestRunHTTPSAndSSL(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	pool := x509.NewCertPool()
+	pool.AddCert(srv.Certificate())
+
+	// Without the test CA the certificate is rejected.
+	result, err := HTTP(srv.URL).Run(context.Background())
+	require.NoError(t, err)
+	require.False(t, result.Passed)
+	require.Error(t, result.Last().Err)
+
+	result, err = HTTP(srv.URL).Run(context.Background(), WithRootCAs(pool))
+	require.NoError(t, err)
+	require.True(t, result.Passed, result.String())
+	require.Positive(t, result.Last().Timings.TLS)
+
+	host, port := splitHostPort(t, srv.Listener.Addr().String())
+	result, err = SSL(host).Port(port).SNI("example.com").AssertExpiresAfter(24*time.Hour).
+		Run(context.Background(), WithRootCAs(pool))
+	require.NoError(t, err)
+	require.True(t, result.Passed, result.String())
+
+	result, err = SSL(host).Port(port).SNI("wrong.invalid").Run(context.Background(), WithRootCAs(pool))
+	require.NoError(t, err)
+	require.False(t, result.Passed)
+	require.Equal(t, "false", result.Last().Assertions[0].Actual)
+}
+
+func TestRunTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			line, _ := bufio.NewReader(conn).ReadString('\n')
+			if line == "PING\r\n" {
+				_, _ = io.WriteString(conn, "+PONG\r\n")
+			}
+			conn.Close()
+		}
+	}()
+	host, port := splitHostPort(t, ln.Addr().String())
+
+	result, err := TCP(host, port).Send("PING\r\n").AssertResponseContains("PONG").Run(context.Background())
+	require.NoError(t, err)
+	require.True(t, result.Passed, result.String())
+
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	_, closedPort := splitHostPort(t, closed.Addr().String())
+	closed.Close()
+	result, err = TCP(host, closedPort).Run(context.Background())
+	require.NoError(t, err)
+	require.False(t, result.Passed)
+	require.Error(t, result.Last().Err)
+}
+
+func TestRunUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			_, _ = conn.WriteTo(append([]byte("echo:"), buf[:n]...), addr)
+		}
+	}()
+	host, port := splitHostPort(t, conn.LocalAddr().String())
+
+	result, err := UDP(host, port).Payload("hello").AssertResponseContains("echo:hello").Run(context.Background())
+	require.NoError(t, err)
+	require.True(t, result.Passed, result.String())
+}
+
+func TestRunWebSocket(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+			"Sec-WebSocket-Accept: %s\r\nSec-WebSocket-Protocol: chat\r\n\r\n", acceptKey(r.Header.Get("Sec-WebSocket-Key")))
+		_ = rw.Flush()
+		_ = writeFrame(conn, opText, []byte("welcome"), false)
+		for {
+			op, msg, err := readMessage(rw, conn)
+			if err != nil {
+				return
+			}
+			_ = writeFrame(conn, op, append([]byte("echo:"), msg...), false)
+		}
+	}))
+	defer srv.Close()
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	wsURL := "ws://" + u.Host + "/ws"
+
+	result, err := WebSocket(wsURL).
+		Subprotocols("chat").
+		SendText("hi").
+		SendBinary([]byte("raw")).
+		ExpectMessage("echo:hi", time.Second).
+		ExpectMessage("echo:raw", time.Second).
+		Run(context.Background())
+	require.NoError(t, err)
+	require.True(t, result.Passed, result.String())
+
+	result, err = WebSocket(wsURL).ExpectMessage("never", 100*time.Millisecond).Run(context.Background())
+	require.NoError(t, err)
+	require.False(t, result.Passed)
+	require.ErrorContains(t, result.Last().Err, `no message containing "never"`)
+}
+
+func TestRunRefusedConnection(t *testing.T) {
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	_, tcpPort := splitHostPort(t, tcp.Addr().String())
+	tcp.Close()
+	udp, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	_, udpPort := splitHostPort(t, udp.LocalAddr().String())
+	udp.Close()
+
+	// Only a response time assertion, which a failed request trivially meets.
+	below := 5 * time.Second
+	checks := map[string]func() (*Result, error){
+		KindHTTP: func() (*Result, error) {
+			return HTTP(fmt.Sprintf("http://127.0.0.1:%d/", tcpPort)).AssertResponseTimeBelow(below).Run(context.Background())
+		},
+		KindTCP: func() (*Result, error) {
+			return TCP("127.0.0.1", tcpPort).AssertResponseTimeBelow(below).Run(context.Background())
+		},
+		KindUDP: func() (*Result, error) {
+			return UDP("127.0.0.1", udpPort).Payload("hello").AssertResponseTimeBelow(below).Run(context.Background())
+		},
+		KindSSL: func() (*Result, error) {
+			return SSL("127.0.0.1").Port(tcpPort).AssertResponseTimeBelow(below).Run(context.Background())
+		},
+		KindWebSocket: func() (*Result, error) {
+			return WebSocket(fmt.Sprintf("ws://127.0.0.1:%d/", tcpPort)).AssertResponseTimeBelow(below).Run(context.Background())
+		},
+		KindDNS: func() (*Result, error) {
+			return DNS("example.com").Resolver("127.0.0.1").Port(udpPort).Timeout(time.Second).AssertResponseTimeBelow(below).Run(context.Background())
+		},
+	}
+	for kind, run := range checks {
+		result, err := run()
+		require.NoError(t, err, kind)
+		require.Error(t, result.Last().Err, kind)
+		require.False(t, result.Passed, kind)
+		require.True(t, result.Last().Assertions[0].Passed, kind)
+	}
+}
+
+func TestRunRequiresRequest(t *testing.T) {
+	_, err := Run(context.Background(), &models.WorkerRequest{Request: &models.Request{}})
+	require.Error(t, err)
+	_, err = Run(context.Background(), nil)
+	require.Error(t, err)
+}
+
+func TestEvaluate(t *testing.T) {
+	obs := observation{
+		SourceStatusCode:          {"200"},
+		SourceBody:                {`{"a":{"b":[1,"x"]},"ok":true}`},
+		SourceHeader + ".x-trace": {"1", "2"},
+		SourceTCP:                 {"false"},
+	}
+	cases := []struct {
+		source, property, op, target string
+		want                         bool
+	}{
+		{SourceStatusCode, "", OpEquals, "200.0", true},
+		{SourceStatusCode, "", OpNotEquals, "200", false},
+		{SourceStatusCode, "", OpLessThan, "300", true},
+		{SourceStatusCode, "", OpGreaterThan, "200", false},
+		{SourceBody, "", OpMatches, `"ok":\s*true`, true},
+		{SourceHeader, "X-Trace", OpEquals, "2", true},
+		{SourceHeader, "X-Missing", OpExists, "false", true},
+		{SourceHeader, "X-Missing", OpNotContains, "a", false},
+		{SourceJSONBody, "$.a.b[1]", OpEquals, "x", true},
+		{SourceJSONBody, "a.b", OpEquals, `[1,"x"]`, true},
+		{SourceJSONBody, "ok", OpEquals, "true", true},
+		{SourceJSONBody, "a.c", OpExists, "true", false},
+		{SourceTCP, "", OpExists, "true", false},
+	}
+	for _, tc := range cases {
+		r := evaluate(assertion(tc.source, tc.property, tc.op, tc.target), obs)
+		require.Equal(t, tc.want, r.Passed, "%s[%s] %s %s: %s", tc.source, tc.property, tc.op, tc.target, r.Message)
+	}
+	require.Equal(t, `unsupported operator "between"`, evaluate(assertion(SourceBody, "", "between", ""), obs).Message)
+}
+
+func splitHostPort(t *testing.T, addr string) (string, int) {
+	t.Helper()
+	host, portStr, err := net.SplitHostPort(addr)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(portStr)
+	require.NoError(t, err)
+	return host, port
+}
@@ -0,0 +1,370 @@
+// Package synthetics provides typed builders for groundcover synthetic tests.
+//
+// The API describes a synthetic test as a models.SyntheticTestCreateRequest
+// whose CheckConfig holds one of six loosely typed requests (HTTP, DNS, TCP,
+// UDP, SSL or WebSocket), each with its own kind and free-form strings for
+// durations. HTTP, DNS, TCP, UDP, SSL and WebSocket start a builder for the
+// respective check. The builders share methods for the schedule, assertions,
+// retries, labels and the attached monitor, and Build checks the definition
+// locally before returning a request the API accepts.
+package synthetics
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/timerange"
+)
+
+// Kinds of synthetic checks.
+const (
+	KindHTTP      = "http"
+	KindDNS       = "dns"
+	KindTCP       = "tcp"
+	KindUDP       = "udp"
+	KindSSL       = "ssl"
+	KindWebSocket = "websocket"
+)
+
+// Assertion sources, the part of a check result an assertion looks at.
+const (
+	SourceStatusCode   = "statusCode"
+	SourceResponseTime = "responseTime"
+	SourceBody         = "body"
+	SourceHeader       = "header"
+	SourceJSONBody     = "jsonBody"
+	SourceDNSAnswer    = "dnsAnswer"
+	SourceSSL          = "ssl"
+	SourceTCP          = "tcp"
+	SourceUDP          = "udp"
+	SourceWebSocket    = "websocket"
+)
+
+// Assertion operators.
+const (
+	OpEquals      = "eq"
+	OpNotEquals   = "neq"
+	OpContains    = "contains"
+	OpNotContains = "notContains"
+	OpMatches     = "matches"
+	OpGreaterThan = "gt"
+	OpLessThan    = "lt"
+	OpExists      = "exists"
+)
+
+// Notification methods of the monitor attached to a synthetic test.
+const (
+	NotifyNotificationRoutes = "notificationRoutes"
+	NotifyConnectedApps      = "connectedApps"
+	NotifyNone               = "noNotifications"
+)
+
+// DefaultInterval is how often a check runs unless Interval is called.
+const DefaultInterval = time.Minute
+
+// requester is implemented by the check builders; it provides what differs
+// between kinds to the shared check methods.
+type requester interface {
+	kind() string
+	request() *models.Request
+	defaultName() string
+	defaultAssertion() *models.Assertion
+}
+
+// check holds what every kind of synthetic test has in common. Its methods
+// return the embedding builder, so calls chain across shared and
+// kind-specific methods. Methods record problems rather than failing
+// immediately; Build reports all of them at once.
+type check[B requester] struct {
+	self          B
+	name          string
+	interval      time.Duration
+	enabled       bool
+	assertions    []*models.Assertion
+	retries       *models.Retries
+	labels        *models.LabelSettings
+	createMonitor *bool
+	monitor       *models.SyntheticMonitorConfig
+	errs          []error
+}
+
+func (c *check[B]) init(self B) {
+	c.self = self
+	c.interval = DefaultInterval
+	c.enabled = true
+}
+
+func (c *check[B]) addError(format string, args ...interface{}) {
+	c.errs = append(c.errs, fmt.Errorf(format, args...))
+}
+
+// Name sets the name of the test. It defaults to a description of the target.
+func (c *check[B]) Name(name string) B {
+	c.name = name
+	return c.self
+}
+
+// Interval sets how often the check runs.
+func (c *check[B]) Interval(d time.Duration) B {
+	if d < time.Second {
+		c.addError("interval must be at least a second, got %s", d)
+		return c.self
+	}
+	c.interval = d
+	return c.self
+}
+
+// Enabled sets whether the test runs. Tests are enabled by default.
+func (c *check[B]) Enabled(enabled bool) B {
+	c.enabled = enabled
+	return c.self
+}
+
+// Retries retries a failed check count times, interval apart, before the
+// run counts as failed.
+func (c *check[B]) Retries(count int, interval time.Duration) B {
+	if count < 0 || interval < 0 {
+		c.addError("retries need a non-negative count and interval")
+		return c.self
+	}
+	c.retries = &models.Retries{Count: int64(count), Interval: timerange.FormatDuration(interval)}
+	return c.self
+}
+
+// Assertion adds a fully specified assertion.
+func (c *check[B]) Assertion(a *models.Assertion) B {
+	if a == nil || a.Source == "" || a.Operator == "" {
+		c.addError("assertion needs a source and an operator")
+		return c.self
+	}
+	c.assertions = append(c.assertions, a)
+	return c.self
+}
+
+// Assert adds an assertion comparing a source of the check result with
+// target using operator.
+func (c *check[B]) Assert(source, operator, target string) B {
+	return c.Assertion(assertion(source, "", operator, target))
+}
+
+// AssertProperty is like Assert for sources with several properties, such
+// as a header of an HTTP response.
+func (c *check[B]) AssertProperty(source, property, operator, target string) B {
+	return c.Assertion(assertion(source, property, operator, target))
+}
+
+// AssertResponseTimeBelow asserts that the check completes within d. The
+// target is sent in milliseconds.
+func (c *check[B]) AssertResponseTimeBelow(d time.Duration) B {
+	if d <= 0 {
+		c.addError("response time limit must be positive, got %s", d)
+		return c.self
+	}
+	return c.Assert(SourceResponseTime, OpLessThan, strconv.FormatInt(d.Milliseconds(), 10))
+}
+
+// Label adds a label to the metrics the test produces.
+func (c *check[B]) Label(key, value string) B {
+	if c.labels == nil {
+		c.labels = &models.LabelSettings{}
+	}
+	if c.labels.ExtraLabels == nil {
+		c.labels.ExtraLabels = map[string]string{}
+	}
+	c.labels.ExtraLabels[key] = value
+	return c.self
+}
+
+// DropLabels removes labels from the metrics the test produces.
+func (c *check[B]) DropLabels(names ...string) B {
+	if c.labels == nil {
+		c.labels = &models.LabelSettings{}
+	}
+	c.labels.DropLabels = append(c.labels.DropLabels, names...)
+	return c.self
+}
+
+// Monitor attaches a monitor that alerts when the test fails. Without
+// options the backend defaults apply.
+func (c *check[B]) Monitor(opts ...MonitorOption) B {
+	if c.monitor == nil {
+		c.monitor = &models.SyntheticMonitorConfig{}
+	}
+	for _, opt := range opts {
+		opt(c.monitor)
+	}
+	c.createMonitor = swag.Bool(true)
+	return c.self
+}
+
+// NoMonitor creates the test without a monitor. By default the backend
+// creates one.
+func (c *check[B]) NoMonitor() B {
+	c.monitor = nil
+	c.createMonitor = swag.Bool(false)
+	return c.self
+}
+
+// Build checks the definition and returns the create request. A test without
+// assertions gets the basic assertion of its kind, such as a 200 status code
+// for HTTP checks.
+func (c *check[B]) Build() (*models.SyntheticTestCreateRequest, error) {
+	errs := append([]error(nil), c.errs...)
+	name := c.name
+	if name == "" {
+		name = c.self.defaultName()
+	}
+	assertions := c.assertions
+	if len(assertions) == 0 {
+		assertions = []*models.Assertion{c.self.defaultAssertion()}
+	}
+	if m := c.monitor; m != nil {
+		if m.NotificationMethod == NotifyConnectedApps && len(m.ConnectedApps) == 0 {
+			errs = append(errs, fmt.Errorf("monitor notifies connected apps but lists none"))
+		}
+	}
+
+	req := &models.SyntheticTestCreateRequest{
+		Name:          name,
+		Version:       1,
+		Enabled:       c.enabled,
+		Interval:      timerange.FormatDuration(c.interval),
+		CreateMonitor: c.createMonitor,
+		LabelSettings: c.labels,
+		Monitor:       c.monitor,
+		CheckConfig: &models.WorkerRequest{
+			Kind:     models.WorkerRequestKind(c.self.kind()),
+			Metadata: &models.Metadata{SyntheticName: name},
+			Request:  c.self.request(),
+			ExecutionPolicy: &models.ExecutionPolicy{
+				Assertions: assertions,
+				Retries:    c.retries,
+			},
+			Tracing: &models.Tracing{},
+		},
+	}
+	if err := req.Validate(strfmt.Default); err != nil {
+		errs = append(errs, err)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("invalid %s synthetic test %q: %w", c.self.kind(), name, err)
+	}
+	return req, nil
+}
+
+// MonitorOption configures the monitor attached to a synthetic test.
+type MonitorOption func(*models.SyntheticMonitorConfig)
+
+// WithMonitorName sets the name of the monitor.
+func WithMonitorName(name string) MonitorOption {
+	return func(m *models.SyntheticMonitorConfig) {
+		m.MonitorName = name
+	}
+}
+
+// WithSeverity sets the severity of the issues the monitor opens.
+func WithSeverity(severity string) MonitorOption {
+	return func(m *models.SyntheticMonitorConfig) {
+		m.Severity = severity
+	}
+}
+
+// WithIssue sets the summary and description of the issues the monitor opens.
+func WithIssue(summary, description string) MonitorOption {
+	return func(m *models.SyntheticMonitorConfig) {
+		m.IssueSummary = summary
+		m.IssueDescription = description
+	}
+}
+
+// WithEvaluationInterval sets how often the monitor evaluates and for how
+// long the test has to fail before the monitor fires.
+func WithEvaluationInterval(interval, pendingFor time.Duration) MonitorOption {
+	return func(m *models.SyntheticMonitorConfig) {
+		m.EvaluationInterval = &models.SyntheticMonitorEvalInterval{
+			Interval:   timerange.FormatDuration(interval),
+			PendingFor: timerange.FormatDuration(pendingFor),
+		}
+	}
+}
+
+// WithLookbehindWindow sets how far back the monitor looks at test results.
+func WithLookbehindWindow(d time.Duration) MonitorOption {
+	return func(m *models.SyntheticMonitorConfig) {
+		m.LookbehindWindow = timerange.FormatDuration(d)
+	}
+}
+
+// WithNoDataState sets the state the monitor enters when the test reports
+// nothing, one of the monitor.State constants.
+func WithNoDataState(state string) MonitorOption {
+	return func(m *models.SyntheticMonitorConfig) {
+		m.NoDataState = state
+	}
+}
+
+// WithExecutionErrorState sets the state the monitor enters when its query
+// fails.
+func WithExecutionErrorState(state string) MonitorOption {
+	return func(m *models.SyntheticMonitorConfig) {
+		m.ExecutionErrorState = state
+	}
+}
+
+// WithConnectedApps delivers notifications directly to the given connected
+// apps instead of through notification routes.
+func WithConnectedApps(ids ...string) MonitorOption {
+	return func(m *models.SyntheticMonitorConfig) {
+		m.NotificationMethod = NotifyConnectedApps
+		m.ConnectedApps = append(m.ConnectedApps, ids...)
+	}
+}
+
+// WithoutNotifications keeps the monitor from notifying anyone.
+func WithoutNotifications() MonitorOption {
+	return func(m *models.SyntheticMonitorConfig) {
+		m.NotificationMethod = NotifyNone
+	}
+}
+
+// WithRenotification repeats notifications for an open issue every interval.
+// A zero interval disables renotification.
+func WithRenotification(interval time.Duration) MonitorOption {
+	return func(m *models.SyntheticMonitorConfig) {
+		m.DisableRenotification = interval == 0
+		m.RenotificationInterval = ""
+		if interval > 0 {
+			m.RenotificationInterval = timerange.FormatDuration(interval)
+		}
+	}
+}
+
+// validPort checks a port number and records a problem if it is out of range.
+func (c *check[B]) validPort(port int) int64 {
+	if port < 1 || port > 65535 {
+		c.addError("port must be between 1 and 65535, got %d", port)
+	}
+	return int64(port)
+}
+
+// validRegexp records a problem if pattern does not compile.
+func (c *check[B]) validRegexp(pattern string) {
+	if _, err := regexp.Compile(pattern); err != nil {
+		c.addError("invalid regex %q: %v", pattern, err)
+	}
+}
+
+func assertion(source, property, operator, target string) *models.Assertion {
+	return &models.Assertion{
+		Source:   models.AssertionSource(source),
+		Property: property,
+		Operator: models.AssertionOperator(operator),
+		Target:   target,
+	}
+}
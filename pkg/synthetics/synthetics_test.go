@@ -0,0 +1,125 @@
+package synthetics
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/monitor"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPCheck(t *testing.T) {
+	req, err := HTTP("https://api.example.com/orders").
+		Method("post").
+		Header("X-Env", "prod").
+		BearerAuth("token").
+		JSONBody(map[string]string{"sku": "42"}).
+		FollowRedirects(false).
+		Timeout(10*time.Second).
+		AssertStatus(201).
+		AssertBodyContains(`"id"`).
+		AssertResponseTimeBelow(1500*time.Millisecond).
+		Interval(5*time.Minute).
+		Retries(2, 30*time.Second).
+		Label("team", "payments").
+		Monitor(WithSeverity(monitor.SeverityCritical), WithEvaluationInterval(time.Minute, 3*time.Minute)).
+		Build()
+	require.NoError(t, err)
+
+	require.Equal(t, "POST https://api.example.com/orders", req.Name)
+	require.Equal(t, "5m", req.Interval)
+	require.True(t, req.Enabled)
+	require.True(t, *req.CreateMonitor)
+	require.Equal(t, models.WorkerRequestKind(KindHTTP), req.CheckConfig.Kind)
+	require.Equal(t, req.Name, req.CheckConfig.Metadata.SyntheticName)
+
+	h := req.CheckConfig.Request.HTTP
+	require.Equal(t, models.HTTPRequestKind(KindHTTP), h.Kind)
+	require.Equal(t, "POST", h.Method)
+	require.Equal(t, "10s", h.Timeout)
+	require.Equal(t, AuthBearer, string(h.Auth.Type))
+	require.Equal(t, `{"sku":"42"}`, h.Body.Content)
+	require.False(t, *h.FollowRedirects)
+
+	policy := req.CheckConfig.ExecutionPolicy
+	require.Equal(t, []*models.Assertion{
+		{Source: SourceStatusCode, Operator: OpEquals, Target: "201"},
+		{Source: SourceBody, Operator: OpContains, Target: `"id"`},
+		{Source: SourceResponseTime, Operator: OpLessThan, Target: "1500"},
+	}, policy.Assertions)
+	require.Equal(t, &models.Retries{Count: 2, Interval: "30s"}, policy.Retries)
+	require.Equal(t, map[string]string{"team": "payments"}, req.LabelSettings.ExtraLabels)
+	require.Equal(t, monitor.SeverityCritical, req.Monitor.Severity)
+	require.Equal(t, "3m", req.Monitor.EvaluationInterval.PendingFor)
+
+	data, err := json.Marshal(req)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"followRedirects":false`)
+}
+
+func TestChecksDefaultToBasicAssertion(t *testing.T) {
+	cases := map[string]struct {
+		build  func() (*models.SyntheticTestCreateRequest, error)
+		name   string
+		source string
+	}{
+		"http":      {HTTP("http://example.com").Build, "GET http://example.com", SourceStatusCode},
+		"dns":       {DNS("example.com").Record("aaaa").Resolver("1.1.1.1").Build, "DNS AAAA example.com", SourceDNSAnswer},
+		"tcp":       {TCP("db.internal", 5432).Build, "TCP db.internal:5432", SourceTCP},
+		"udp":       {UDP("ntp.example.com", 123).Payload("ping").Build, "UDP ntp.example.com:123", SourceUDP},
+		"ssl":       {SSL("example.com").MinVersion("TLS1.2").Build, "SSL example.com:443", SourceSSL},
+		"websocket": {WebSocket("wss://example.com/ws").SendText("hi").ExpectMessage("hello", 5*time.Second).Build, "WebSocket wss://example.com/ws", SourceWebSocket},
+	}
+	for kind, tc := range cases {
+		req, err := tc.build()
+		require.NoError(t, err, kind)
+		require.Equal(t, tc.name, req.Name, kind)
+		require.Equal(t, models.WorkerRequestKind(kind), req.CheckConfig.Kind, kind)
+		require.Len(t, req.CheckConfig.ExecutionPolicy.Assertions, 1, kind)
+		require.Equal(t, models.AssertionSource(tc.source), req.CheckConfig.ExecutionPolicy.Assertions[0].Source, kind)
+		require.Equal(t, "1m", req.Interval, kind)
+		require.Nil(t, req.CreateMonitor, kind)
+	}
+
+	dns, err := DNS("example.com").Resolver("1.1.1.1").Build()
+	require.NoError(t, err)
+	require.Equal(t, int64(53), dns.CheckConfig.Request.DNS.Port)
+	ssl, err := SSL("example.com").MinVersion("1.3").Build()
+	require.NoError(t, err)
+	require.Equal(t, "1.3", ssl.CheckConfig.Request.Ssl.MinVersion)
+	require.True(t, ssl.CheckConfig.Request.Ssl.Verify)
+}
+
+func TestBuildReportsAllProblems(t *testing.T) {
+	_, err := HTTP("ftp://example.com").Method("BREW").AssertStatus(999).AssertBodyMatches("(").
+		Interval(time.Millisecond).Monitor(WithConnectedApps()).Build()
+	require.Error(t, err)
+	for _, want := range []string{"invalid HTTP URL", `"BREW"`, "status code 999", "invalid regex", "interval", "connected apps"} {
+		require.Contains(t, err.Error(), want)
+	}
+
+	_, err = TCP("", 70000).Build()
+	require.ErrorContains(t, err, "port must be between")
+	require.ErrorContains(t, err, "needs a host")
+	_, err = DNS("example.com").Record("BOGUS").Build()
+	require.ErrorContains(t, err, "record type")
+	_, err = SSL("example.com").MinVersion("0.9").Build()
+	require.ErrorContains(t, err, "TLS version")
+	_, err = WebSocket("https://example.com").Build()
+	require.ErrorContains(t, err, "invalid WebSocket URL")
+}
+
+func TestNoMonitorAndLabels(t *testing.T) {
+	req, err := TCP("redis", 6379).Send("PING\r\n").AssertResponseContains("PONG").
+		DropLabels("pod").NoMonitor().Enabled(false).Build()
+	require.NoError(t, err)
+	require.False(t, *req.CreateMonitor)
+	require.Nil(t, req.Monitor)
+	require.False(t, req.Enabled)
+	require.Equal(t, []string{"pod"}, req.LabelSettings.DropLabels)
+	require.True(t, req.CheckConfig.Request.TCP.ExpectResponse)
+	require.Equal(t, int64(1024), req.CheckConfig.Request.TCP.ReceiveMaxBytes)
+	require.Equal(t, "response", req.CheckConfig.ExecutionPolicy.Assertions[0].Property)
+}
@@ -0,0 +1,77 @@
+package synthetics
+
+import (
+	"encoding/base64"
+	"net/url"
+	"time"
+
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/timerange"
+)
+
+// WebSocketCheck builds a WebSocket synthetic test.
+type WebSocketCheck struct {
+	check[*WebSocketCheck]
+	req *models.WebsocketRequest
+}
+
+// WebSocket starts a check that opens a WebSocket connection to rawURL.
+func WebSocket(rawURL string) *WebSocketCheck {
+	c := &WebSocketCheck{req: &models.WebsocketRequest{Kind: KindWebSocket, URL: rawURL}}
+	c.init(c)
+	if u, err := url.Parse(rawURL); err != nil || (u.Scheme != "ws" && u.Scheme != "wss") || u.Host == "" {
+		c.addError("invalid WebSocket URL %q", rawURL)
+	}
+	return c
+}
+
+func (c *WebSocketCheck) kind() string             { return KindWebSocket }
+func (c *WebSocketCheck) request() *models.Request { return &models.Request{Websocket: c.req} }
+func (c *WebSocketCheck) defaultName() string      { return "WebSocket " + c.req.URL }
+
+func (c *WebSocketCheck) defaultAssertion() *models.Assertion {
+	return assertion(SourceWebSocket, "", OpExists, "true")
+}
+
+// Header sets a header of the handshake request.
+func (c *WebSocketCheck) Header(name, value string) *WebSocketCheck {
+	if c.req.Headers == nil {
+		c.req.Headers = map[string]string{}
+	}
+	c.req.Headers[name] = value
+	return c
+}
+
+// Subprotocols offers subprotocols in the handshake.
+func (c *WebSocketCheck) Subprotocols(protocols ...string) *WebSocketCheck {
+	c.req.Subprotocols = append(c.req.Subprotocols, protocols...)
+	return c
+}
+
+// SendText sends a text message once connected. Messages are sent in order.
+func (c *WebSocketCheck) SendText(message string) *WebSocketCheck {
+	c.req.SendMessages = append(c.req.SendMessages, &models.WebsocketRequestSendMessagesItems0{Text: message})
+	return c
+}
+
+// SendBinary sends a binary message once connected; it is transmitted
+// base64-encoded.
+func (c *WebSocketCheck) SendBinary(message []byte) *WebSocketCheck {
+	c.req.SendMessages = append(c.req.SendMessages, &models.WebsocketRequestSendMessagesItems0{
+		Binary: base64.StdEncoding.EncodeToString(message),
+	})
+	return c
+}
+
+// ExpectMessage requires a message containing s to arrive within d.
+func (c *WebSocketCheck) ExpectMessage(s string, within time.Duration) *WebSocketCheck {
+	if within <= 0 {
+		c.addError("message wait must be positive, got %s", within)
+		return c
+	}
+	c.req.ExpectMessages = append(c.req.ExpectMessages, &models.WebsocketRequestExpectMessagesItems0{
+		Contains: s,
+		Within:   timerange.FormatDuration(within),
+	})
+	return c
+}
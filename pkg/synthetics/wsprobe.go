@@ -0,0 +1,280 @@
+package synthetics
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1" //nolint:gosec // mandated by the WebSocket handshake
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+)
+
+// WebSocket opcodes (RFC 6455, section 5.2).
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+// websocketGUID is appended to the handshake key to compute the accept value.
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// maxMessageSize bounds a WebSocket message the runner accepts.
+const maxMessageSize = 1 << 20
+
+var errWebSocketClosed = errors.New("connection closed by server")
+
+type websocketProbe struct {
+	req *models.WebsocketRequest
+	cfg *runConfig
+}
+
+func (p *websocketProbe) kind() string   { return KindWebSocket }
+func (p *websocketProbe) target() string { return p.req.URL }
+
+func (p *websocketProbe) run(ctx context.Context, t *Timings) (observation, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.timeout)
+	defer cancel()
+	failed := observation{SourceWebSocket: {"false"}}
+
+	u, err := url.Parse(p.req.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	addr := u.Host
+	if u.Port() == "" {
+		port := "80"
+		if u.Scheme == "wss" {
+			port = "443"
+		}
+		addr = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	start := time.Now()
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	t.Connect = time.Since(start)
+	if err != nil {
+		return failed, err
+	}
+	defer conn.Close()
+	if u.Scheme == "wss" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: u.Hostname(), RootCAs: p.cfg.rootCAs})
+		tlsStart := time.Now()
+		err := tlsConn.HandshakeContext(ctx)
+		t.TLS = time.Since(tlsStart)
+		if err != nil {
+			return failed, err
+		}
+		conn = tlsConn
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := p.handshake(conn, br, u)
+	if err != nil {
+		return failed, err
+	}
+	t.FirstByte = time.Since(start)
+	obs := observation{SourceWebSocket: {"true"}}
+	if proto := resp.Header.Get("Sec-WebSocket-Protocol"); proto != "" {
+		obs[SourceWebSocket+".subprotocol"] = []string{proto}
+	}
+
+	for _, m := range p.req.SendMessages {
+		op, payload := byte(opText), []byte(m.Text)
+		if m.Binary != "" {
+			if payload, err = base64.StdEncoding.DecodeString(m.Binary); err != nil {
+				return nil, fmt.Errorf("invalid binary message: %w", err)
+			}
+			op = opBinary
+		}
+		if err := writeFrame(conn, op, payload, true); err != nil {
+			return failed, err
+		}
+	}
+
+	var received []string
+	for _, e := range p.req.ExpectMessages {
+		within, err := parseDuration(e.Within, p.cfg.timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid message wait: %w", err)
+		}
+		deadline := time.Now().Add(within)
+		if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+			deadline = ctxDeadline
+		}
+		_ = conn.SetReadDeadline(deadline)
+		for {
+			_, msg, err := readMessage(br, conn)
+			if err != nil {
+				obs[SourceWebSocket] = []string{"false"}
+				obs[SourceWebSocket+".messages"] = received
+				return obs, fmt.Errorf("no message containing %q within %s: %w", e.Contains, within, err)
+			}
+			received = append(received, string(msg))
+			if strings.Contains(string(msg), e.Contains) {
+				break
+			}
+		}
+	}
+	obs[SourceWebSocket+".messages"] = received
+	_ = writeFrame(conn, opClose, []byte{0x03, 0xE8}, true)
+	return obs, nil
+}
+
+func (p *websocketProbe) handshake(conn net.Conn, br *bufio.Reader, u *url.URL) (*http.Response, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "GET %s HTTP/1.1\r\nHost: %s\r\n", u.RequestURI(), u.Host)
+	b.WriteString("Upgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Version: 13\r\n")
+	fmt.Fprintf(&b, "Sec-WebSocket-Key: %s\r\n", key)
+	if len(p.req.Subprotocols) > 0 {
+		fmt.Fprintf(&b, "Sec-WebSocket-Protocol: %s\r\n", strings.Join(p.req.Subprotocols, ", "))
+	}
+	for k, v := range p.req.Headers {
+		fmt.Fprintf(&b, "%s: %s\r\n", k, v)
+	}
+	b.WriteString("\r\n")
+	if _, err := io.WriteString(conn, b.String()); err != nil {
+		return nil, err
+	}
+
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read handshake response: %w", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return nil, fmt.Errorf("handshake failed: %s", resp.Status)
+	}
+	if got := resp.Header.Get("Sec-WebSocket-Accept"); got != acceptKey(key) {
+		return nil, fmt.Errorf("handshake failed: unexpected Sec-WebSocket-Accept %q", got)
+	}
+	return resp, nil
+}
+
+func acceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + websocketGUID)) //nolint:gosec // mandated by the WebSocket handshake
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// writeFrame writes a single final frame. Clients must mask their frames,
+// servers must not.
+func writeFrame(w io.Writer, op byte, payload []byte, mask bool) error {
+	header := []byte{0x80 | op}
+	var maskBit byte
+	if mask {
+		maskBit = 0x80
+	}
+	switch n := len(payload); {
+	case n < 126:
+		header = append(header, maskBit|byte(n))
+	case n <= 0xFFFF:
+		header = append(header, maskBit|126)
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header = append(header, maskBit|127)
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+	data := payload
+	if mask {
+		key := make([]byte, 4)
+		if _, err := rand.Read(key); err != nil {
+			return err
+		}
+		header = append(header, key...)
+		data = make([]byte, len(payload))
+		for i, c := range payload {
+			data[i] = c ^ key[i%4]
+		}
+	}
+	_, err := w.Write(append(header, data...))
+	return err
+}
+
+// readMessage reads frames until a complete data message arrives, answering
+// pings on w along the way.
+func readMessage(r io.Reader, w io.Writer) (byte, []byte, error) {
+	var msgOp byte
+	var msg []byte
+	for {
+		var hdr [2]byte
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			return 0, nil, err
+		}
+		fin, op := hdr[0]&0x80 != 0, hdr[0]&0x0F
+		masked, n := hdr[1]&0x80 != 0, uint64(hdr[1]&0x7F)
+		switch n {
+		case 126:
+			var ext [2]byte
+			if _, err := io.ReadFull(r, ext[:]); err != nil {
+				return 0, nil, err
+			}
+			n = uint64(binary.BigEndian.Uint16(ext[:]))
+		case 127:
+			var ext [8]byte
+			if _, err := io.ReadFull(r, ext[:]); err != nil {
+				return 0, nil, err
+			}
+			n = binary.BigEndian.Uint64(ext[:])
+		}
+		if n > maxMessageSize || uint64(len(msg))+n > maxMessageSize {
+			return 0, nil, fmt.Errorf("message exceeds %d bytes", maxMessageSize)
+		}
+		var key [4]byte
+		if masked {
+			if _, err := io.ReadFull(r, key[:]); err != nil {
+				return 0, nil, err
+			}
+		}
+		payload := make([]byte, n)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return 0, nil, err
+		}
+		if masked {
+			for i := range payload {
+				payload[i] ^= key[i%4]
+			}
+		}
+
+		switch op {
+		case opClose:
+			return 0, nil, errWebSocketClosed
+		case opPing:
+			if err := writeFrame(w, opPong, payload, true); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case opPong:
+			continue
+		case opContinuation:
+			msg = append(msg, payload...)
+		default:
+			msgOp, msg = op, payload
+		}
+		if fin {
+			return msgOp, msg, nil
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package syntheticsync
+
+import "github.com/groundcover-com/groundcover-sdk-go/internal/syncplan"
+
+// FieldDiff is a single field that differs between the remote and the desired
+// definition of a test. List items are identified by index, for example
+// "checkConfig.executionPolicy.assertions[0].target".
+type FieldDiff = syncplan.FieldDiff
+
+// unorderedLists are the paths of test lists whose order has no meaning.
+var unorderedLists = [][]string{
+	{"exporters"},
+	{"labelSettings", "dropLabels"},
+	{"monitor", "connectedApps"},
+}
+
+// encode converts a value into a normalized tree using its JSON form, with
+// the unordered lists sorted.
+func encode(v interface{}) (map[string]interface{}, error) {
+	return syncplan.Encode(v, unorderedLists...)
+}
@@ -0,0 +1,56 @@
+package syntheticsync
+
+import (
+	"fmt"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/groundcover-com/groundcover-sdk-go/internal/syncplan"
+	"github.com/groundcover-com/groundcover-sdk-go/internal/yamlutil"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+)
+
+// Definition is a synthetic test loaded from a local YAML or JSON file.
+type Definition struct {
+	// Path is the file the definition was loaded from, relative to the loaded
+	// directory.
+	Path string
+	Test *models.SyntheticTestCreateRequest
+}
+
+// LoadDir loads every .yaml, .yml and .json file below dir, one test per
+// file. Files are returned sorted by path.
+func LoadDir(dir string) ([]*Definition, error) {
+	defs, err := syncplan.LoadDir(dir, []string{".yaml", ".yml", ".json"}, ParseDefinition)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load synthetic tests from %s: %w", dir, err)
+	}
+	return defs, nil
+}
+
+// ParseDefinition parses a single test definition, in YAML or JSON, with the
+// field names of the synthetics API. Unknown fields are rejected so that
+// typos do not go unnoticed. The path identifies the definition in errors
+// and plans, and derives its key when the definition does not carry one.
+func ParseDefinition(path string, data []byte) (*Definition, error) {
+	var test models.SyntheticTestCreateRequest
+	if err := yamlutil.UnmarshalStrict(data, &test); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if test.Name == "" {
+		return nil, fmt.Errorf("%s: name is required", path)
+	}
+	if test.CheckConfig == nil || test.CheckConfig.Request == nil {
+		return nil, fmt.Errorf("%s: checkConfig.request is required", path)
+	}
+	if err := test.Validate(strfmt.Default); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if test.CheckConfig.Metadata == nil {
+		test.CheckConfig.Metadata = &models.Metadata{}
+	}
+	if test.CheckConfig.Metadata.SyntheticName == "" {
+		test.CheckConfig.Metadata.SyntheticName = test.Name
+	}
+	return &Definition{Path: path, Test: &test}, nil
+}
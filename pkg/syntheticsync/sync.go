@@ -0,0 +1,461 @@
+// Package syntheticsync manages groundcover synthetic tests as code: it loads
+// test definitions from a directory of YAML or JSON files, matches them to
+// the tests of a backend, and computes and applies a plan that brings the
+// backend in line.
+//
+// Definitions are matched to tests by a key. By default the key is the value
+// of the DefaultLabelKey extra label, which Plan adds to every definition that
+// does not set it, derived from the file path. Alternatively MatchByName uses
+// the test name. Tests without a key are not managed and are never deleted; a
+// definition only takes one over when it has the same name, see
+// WithAdoptByName.
+//
+// The monitor attached to a test follows the createMonitor field of its
+// definition: unless it is false, the test has a monitor. Plans report a
+// test whose monitor was added or removed on the backend, and updates always
+// send createMonitor so that the backend restores the desired state.
+package syntheticsync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-openapi/swag"
+	"github.com/groundcover-com/groundcover-sdk-go/internal/syncplan"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/client"
+	synthclient "github.com/groundcover-com/groundcover-sdk-go/pkg/client/synthetics"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+	"golang.org/x/sync/errgroup"
+)
+
+// DefaultLabelKey is the extra label that identifies managed tests.
+const DefaultLabelKey = "sync_id"
+
+const defaultConcurrency = 4
+
+// Action is what a plan does to a single test.
+type Action = syncplan.Action
+
+// Plan actions.
+const (
+	ActionCreate = syncplan.ActionCreate
+	ActionUpdate = syncplan.ActionUpdate
+	ActionDelete = syncplan.ActionDelete
+	ActionNoop   = syncplan.ActionNoop
+)
+
+// monitorPath is the pseudo field under which plans report a monitor that is
+// attached or removed.
+const monitorPath = "createMonitor"
+
+// Change is the planned action for a single test.
+type Change struct {
+	syncplan.Change
+
+	desired *models.SyntheticTestCreateRequest
+	version int64
+	monitor bool
+}
+
+// Plan is the list of changes that brings a backend in line with a set of
+// definitions, sorted by key.
+type Plan = syncplan.Plan[*Change]
+
+// Result reports what Apply did. In a dry run Applied lists the changes that
+// would have been made.
+type Result = syncplan.Result[*Change]
+
+// ChangeError is the failure to apply a single change.
+type ChangeError = syncplan.ChangeError[*Change]
+
+// Syncer plans and applies synthetic test changes against a backend.
+type Syncer struct {
+	api          *client.GroundcoverAPI
+	labelKey     string
+	byName       bool
+	prune        bool
+	dryRun       bool
+	concurrency  int
+	adopt        bool
+	ignoreFields [][]string
+}
+
+// Option configures a Syncer.
+type Option func(*Syncer)
+
+// WithLabelKey sets the extra label that identifies managed tests.
+func WithLabelKey(key string) Option {
+	return func(s *Syncer) {
+		s.labelKey = key
+		s.byName = false
+	}
+}
+
+// MatchByName matches definitions to tests by name instead of a label. Every
+// test of the backend is then managed, so WithPrune deletes all tests without
+// a definition.
+func MatchByName() Option {
+	return func(s *Syncer) {
+		s.byName = true
+	}
+}
+
+// WithPrune makes plans delete managed tests that have no local definition.
+func WithPrune(prune bool) Option {
+	return func(s *Syncer) {
+		s.prune = prune
+	}
+}
+
+// WithAdoptByName sets whether a definition without a matching managed test
+// takes over an unmanaged test with the same name, instead of creating a new
+// one. Adoption is enabled by default; the adopting update adds the key to
+// the test. A name shared by several unmanaged tests is never adopted, since
+// the one taken over would depend on list order.
+func WithAdoptByName(adopt bool) Option {
+	return func(s *Syncer) {
+		s.adopt = adopt
+	}
+}
+
+// WithDryRun makes Apply report the changes it would make without making them.
+func WithDryRun(dryRun bool) Option {
+	return func(s *Syncer) {
+		s.dryRun = dryRun
+	}
+}
+
+// WithConcurrency sets how many tests are fetched or changed in parallel.
+func WithConcurrency(n int) Option {
+	return func(s *Syncer) {
+		s.concurrency = n
+	}
+}
+
+// WithIgnoreFields keeps the remote value of the given dotted field paths, for
+// example "enabled" or "checkConfig.request.http.headers", so that they
+// neither show up in plans nor are overwritten by updates.
+func WithIgnoreFields(paths ...string) Option {
+	return func(s *Syncer) {
+		for _, p := range paths {
+			s.ignoreFields = append(s.ignoreFields, strings.Split(p, "."))
+		}
+	}
+}
+
+// New creates a Syncer backed by the given client.
+func New(api *client.GroundcoverAPI, opts ...Option) *Syncer {
+	s := &Syncer{
+		api:         api,
+		labelKey:    DefaultLabelKey,
+		concurrency: defaultConcurrency,
+		adopt:       true,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.concurrency <= 0 {
+		s.concurrency = 1
+	}
+	return s
+}
+
+// Sync loads the definitions in dir, plans and applies the changes.
+func (s *Syncer) Sync(ctx context.Context, dir string) (*Plan, *Result, error) {
+	defs, err := LoadDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	plan, err := s.Plan(ctx, defs)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := s.Apply(ctx, plan)
+	return plan, result, err
+}
+
+// remoteTest is a test of the backend as GetSyntheticTest returns it.
+type remoteTest struct {
+	id         string
+	test       *models.SyntheticTestCreateRequest
+	hasMonitor bool
+}
+
+// Plan compares the definitions with the tests of the backend.
+func (s *Syncer) Plan(ctx context.Context, defs []*Definition) (*Plan, error) {
+	desired := map[string]*Definition{}
+	for _, def := range defs {
+		key := s.definitionKey(def)
+		if prev, ok := desired[key]; ok {
+			return nil, fmt.Errorf("%s: key %q is already used by %s", def.Path, key, prev.Path)
+		}
+		desired[key] = def
+	}
+
+	remote, unmanaged, err := s.remoteTests(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(desired))
+	for key := range desired {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	plan := syncplan.NewPlan[*Change](ActionCreate, ActionUpdate, ActionDelete)
+	for _, key := range keys {
+		def := desired[key]
+		current := remote[key]
+		if current == nil && s.adopt {
+			if current = unmanaged[def.Test.Name]; current != nil {
+				delete(unmanaged, def.Test.Name)
+			}
+		}
+		change, err := s.planChange(key, def, current)
+		if err != nil {
+			return nil, err
+		}
+		plan.Changes = append(plan.Changes, change)
+	}
+	if s.prune {
+		for key, t := range remote {
+			if _, ok := desired[key]; !ok {
+				plan.Changes = append(plan.Changes, &Change{Change: syncplan.Change{
+					Action: ActionDelete,
+					Key:    key,
+					Name:   t.test.Name,
+					ID:     t.id,
+				}})
+			}
+		}
+	}
+	plan.Sort()
+	return plan, nil
+}
+
+// Apply makes the changes of a plan, up to the configured concurrency at a
+// time. Failed changes do not stop the others; they are reported in the
+// result and joined in the returned error.
+func (s *Syncer) Apply(ctx context.Context, plan *Plan) (*Result, error) {
+	a := &syncplan.Applier[*Change]{
+		Kind:        "synthetic test",
+		Concurrency: s.concurrency,
+		DryRun:      s.dryRun,
+		Apply:       s.apply,
+	}
+	return a.Run(ctx, plan)
+}
+
+func (s *Syncer) apply(ctx context.Context, c *Change) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	switch c.Action {
+	case ActionCreate:
+		body := *c.desired
+		if body.Version == 0 {
+			body.Version = 1
+		}
+		if !c.monitor {
+			body.Monitor = nil
+		}
+		resp, err := s.api.Synthetics.CreateSyntheticTest(synthclient.NewCreateSyntheticTestParams().WithContext(ctx).WithBody(&body), nil)
+		if err != nil {
+			return err
+		}
+		c.ID = resp.Payload.ID
+		return nil
+	case ActionUpdate:
+		// Updates carry the version of the remote test, never that of the
+		// definition, and always state whether the test keeps a monitor:
+		// without createMonitor the backend leaves the monitor as it is.
+		body := *c.desired
+		body.Version = c.version
+		body.CreateMonitor = swag.Bool(c.monitor)
+		if !c.monitor {
+			body.Monitor = nil
+		}
+		_, err := s.api.Synthetics.UpdateSyntheticTest(synthclient.NewUpdateSyntheticTestParams().WithContext(ctx).WithID(c.ID).WithBody(&body), nil)
+		return err
+	case ActionDelete:
+		_, err := s.api.Synthetics.DeleteSyntheticTest(synthclient.NewDeleteSyntheticTestParams().WithContext(ctx).WithID(c.ID), nil)
+		return err
+	}
+	return nil
+}
+
+// definitionKey returns the key of a definition, adding the key label to it
+// when matching by label and the definition does not set one.
+func (s *Syncer) definitionKey(def *Definition) string {
+	t := def.Test
+	if s.byName {
+		return t.Name
+	}
+	if t.LabelSettings != nil {
+		if key := t.LabelSettings.ExtraLabels[s.labelKey]; key != "" {
+			return key
+		}
+	}
+	key := syncplan.DefaultKey(def.Path)
+	if t.LabelSettings == nil {
+		t.LabelSettings = &models.LabelSettings{}
+	}
+	if t.LabelSettings.ExtraLabels == nil {
+		t.LabelSettings.ExtraLabels = map[string]string{}
+	}
+	t.LabelSettings.ExtraLabels[s.labelKey] = key
+	return key
+}
+
+func (s *Syncer) remoteKey(t *models.SyntheticTestCreateRequest) string {
+	if s.byName {
+		return t.Name
+	}
+	if t.LabelSettings == nil {
+		return ""
+	}
+	return t.LabelSettings.ExtraLabels[s.labelKey]
+}
+
+func (s *Syncer) planChange(key string, def *Definition, remote *remoteTest) (*Change, error) {
+	change := &Change{
+		Change: syncplan.Change{
+			Key:  key,
+			Name: def.Test.Name,
+			Path: def.Path,
+		},
+		desired: def.Test,
+		monitor: def.Test.CreateMonitor == nil || *def.Test.CreateMonitor,
+	}
+
+	want, err := encode(comparableFields(def.Test, remote == nil || remote.test.Monitor != nil))
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to encode synthetic test: %w", def.Path, err)
+	}
+	if remote == nil {
+		change.Action = ActionCreate
+		change.Diffs = syncplan.Diff(map[string]interface{}{}, want)
+		return change, nil
+	}
+
+	change.ID = remote.id
+	change.version = remote.test.Version
+	if change.version == 0 {
+		change.version = 1
+	}
+	have, err := encode(comparableFields(remote.test, true))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode synthetic test %s: %w", remote.id, err)
+	}
+	if !change.monitor {
+		delete(want, "monitor")
+	}
+	if len(s.ignoreFields) > 0 {
+		for _, path := range s.ignoreFields {
+			syncplan.SetPath(want, have, path)
+		}
+		if change.desired, err = reparse(want); err != nil {
+			return nil, fmt.Errorf("%s: %w", def.Path, err)
+		}
+		// The monitor configuration was left out of the comparison when the
+		// backend does not report it.
+		if remote.test.Monitor == nil {
+			change.desired.Monitor = def.Test.Monitor
+		}
+	}
+
+	change.Diffs = syncplan.Diff(have, want)
+	if remote.hasMonitor != change.monitor {
+		change.Diffs = append(change.Diffs, FieldDiff{Path: monitorPath, Old: remote.hasMonitor, New: change.monitor})
+	}
+	change.Action = ActionNoop
+	if len(change.Diffs) > 0 {
+		change.Action = ActionUpdate
+	}
+	return change, nil
+}
+
+// comparableFields returns the fields of a test that plans compare. The version
+// and createMonitor are handled separately, and the monitor configuration is
+// only compared when the backend reports it.
+func comparableFields(t *models.SyntheticTestCreateRequest, withMonitor bool) *models.SyntheticTestCreateRequest {
+	c := *t
+	c.Version = 0
+	c.CreateMonitor = nil
+	if !withMonitor {
+		c.Monitor = nil
+	}
+	return &c
+}
+
+func reparse(tree map[string]interface{}) (*models.SyntheticTestCreateRequest, error) {
+	data, err := json.Marshal(tree)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode synthetic test: %w", err)
+	}
+	var t models.SyntheticTestCreateRequest
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("failed to decode synthetic test: %w", err)
+	}
+	return &t, nil
+}
+
+// remoteTests fetches every test of the backend and returns the managed ones
+// by key and the unmanaged ones by name. Unmanaged tests that share a name are
+// left out, since a definition could not tell which one to adopt.
+func (s *Syncer) remoteTests(ctx context.Context) (map[string]*remoteTest, map[string]*remoteTest, error) {
+	list, err := s.api.Synthetics.ListSyntheticTests(synthclient.NewListSyntheticTestsParams().WithContext(ctx), nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list synthetic tests: %w", err)
+	}
+	var items []*models.SyntheticTestListItem
+	if list.Payload != nil {
+		items = list.Payload.Synthetics
+	}
+	fetched := make([]*remoteTest, len(items))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(s.concurrency)
+	for i, item := range items {
+		g.Go(func() error {
+			resp, err := s.api.Synthetics.GetSyntheticTest(synthclient.NewGetSyntheticTestParams().WithContext(gctx).WithID(item.ID), nil)
+			if err != nil {
+				return fmt.Errorf("failed to get synthetic test %s: %w", item.ID, err)
+			}
+			fetched[i] = &remoteTest{
+				id:         item.ID,
+				test:       resp.Payload,
+				hasMonitor: item.Monitor != nil && item.Monitor.UUID != "",
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, nil, err
+	}
+
+	managed := map[string]*remoteTest{}
+	unmanaged := map[string]*remoteTest{}
+	ambiguous := map[string]bool{}
+	for _, t := range fetched {
+		key := s.remoteKey(t.test)
+		if key == "" {
+			if _, ok := unmanaged[t.test.Name]; ok {
+				ambiguous[t.test.Name] = true
+			}
+			unmanaged[t.test.Name] = t
+			continue
+		}
+		if prev, ok := managed[key]; ok {
+			return nil, nil, fmt.Errorf("synthetic tests %s and %s share the key %q", prev.id, t.id, key)
+		}
+		managed[key] = t
+	}
+	for name := range ambiguous {
+		delete(unmanaged, name)
+	}
+	return managed, unmanaged, nil
+}
@@ -0,0 +1,327 @@
+package syntheticsync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-openapi/swag"
+	"github.com/groundcover-com/groundcover-sdk-go/internal/testutil"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSynthetics is an in-memory synthetics API. Like the backend, it keeps
+// a monitor for every test unless createMonitor is false, and leaves it as
+// it is on updates without createMonitor.
+type fakeSynthetics struct {
+	mu       sync.Mutex
+	next     int
+	tests    map[string]*models.SyntheticTestCreateRequest
+	monitors map[string]bool
+	updates  map[string]*models.SyntheticTestCreateRequest
+	calls    []string
+}
+
+func newFakeSynthetics() *fakeSynthetics {
+	return &fakeSynthetics{
+		tests:    map[string]*models.SyntheticTestCreateRequest{},
+		monitors: map[string]bool{},
+		updates:  map[string]*models.SyntheticTestCreateRequest{},
+	}
+}
+
+func (f *fakeSynthetics) add(t *models.SyntheticTestCreateRequest) string {
+	f.next++
+	id := fmt.Sprintf("00000000-0000-0000-0000-%012d", f.next)
+	f.tests[id] = t
+	f.monitors[id] = t.CreateMonitor == nil || *t.CreateMonitor
+	t.CreateMonitor = nil
+	return id
+}
+
+func (f *fakeSynthetics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, r.Method+" "+r.URL.Path)
+	w.Header().Set("Content-Type", "application/json")
+
+	const base = "/api/synthetics/v1/rules"
+	id := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, base), "/")
+	switch {
+	case r.Method == http.MethodGet && id == "":
+		resp := models.SyntheticTestListResponse{Synthetics: []*models.SyntheticTestListItem{}}
+		for id, t := range f.tests {
+			item := &models.SyntheticTestListItem{ID: id, Name: t.Name}
+			if f.monitors[id] {
+				item.Monitor = &models.SyntheticTestMonitor{UUID: "monitor-" + id}
+			}
+			resp.Synthetics = append(resp.Synthetics, item)
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	case r.Method == http.MethodPost && id == "":
+		var t models.SyntheticTestCreateRequest
+		_ = json.NewDecoder(r.Body).Decode(&t)
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(models.SyntheticTestCreateResponse{ID: f.add(&t)})
+	case f.tests[id] == nil:
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message":"not found"}`))
+	case r.Method == http.MethodGet:
+		_ = json.NewEncoder(w).Encode(f.tests[id])
+	case r.Method == http.MethodPut:
+		var t models.SyntheticTestCreateRequest
+		_ = json.NewDecoder(r.Body).Decode(&t)
+		f.updates[id] = &t
+		if t.CreateMonitor != nil {
+			f.monitors[id] = *t.CreateMonitor
+		}
+		stored := t
+		stored.CreateMonitor = nil
+		stored.Version = f.tests[id].Version + 1
+		f.tests[id] = &stored
+		_, _ = w.Write([]byte(`"updated"`))
+	case r.Method == http.MethodDelete:
+		delete(f.tests, id)
+		delete(f.monitors, id)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+const healthYAML = `
+name: API health
+enabled: true
+interval: 1m
+checkConfig:
+  kind: http
+  request:
+    http:
+      kind: http
+      method: GET
+      url: https://api.example.com/health
+  executionPolicy:
+    assertions:
+      - source: statusCode
+        operator: eq
+        target: "200"
+`
+
+const dnsJSON = `{
+  "name": "DNS",
+  "createMonitor": false,
+  "checkConfig": {"kind": "dns", "request": {"dns": {"kind": "dns", "domain": "example.com", "recordType": "A"}}}
+}`
+
+func TestLoadDir(t *testing.T) {
+	dir := testutil.WriteFiles(t, map[string]string{
+		"api/health.yaml": healthYAML,
+		"dns.json":        dnsJSON,
+		"README.md":       "not a definition",
+	})
+	defs, err := LoadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, defs, 2)
+	require.Equal(t, "api/health.yaml", defs[0].Path)
+	require.Equal(t, "API health", defs[0].Test.CheckConfig.Metadata.SyntheticName)
+	require.Equal(t, "dns.json", defs[1].Path)
+	require.False(t, *defs[1].Test.CreateMonitor)
+
+	_, err = ParseDefinition("typo.yaml", []byte("name: x\nintervall: 1m\n"))
+	require.ErrorContains(t, err, `unknown field "intervall"`)
+	_, err = ParseDefinition("empty.yaml", []byte("name: x\n"))
+	require.ErrorContains(t, err, "checkConfig.request is required")
+}
+
+func TestSyncCreatesUpdatesAndDetectsDrift(t *testing.T) {
+	fake := newFakeSynthetics()
+	api := testutil.NewAPI(t, fake)
+	dir := testutil.WriteFiles(t, map[string]string{"api/health.yaml": healthYAML, "dns.json": dnsJSON})
+	syncer := New(api, WithPrune(true))
+
+	plan, result, err := syncer.Sync(context.Background(), dir)
+	require.NoError(t, err)
+	require.Equal(t, 2, plan.Count(ActionCreate))
+	require.Len(t, result.Applied, 2)
+	require.Len(t, fake.tests, 2)
+
+	healthID := result.Applied[0].ID
+	dnsID := result.Applied[1].ID
+	require.True(t, fake.monitors[healthID])
+	require.False(t, fake.monitors[dnsID])
+	require.Equal(t, "api/health", fake.tests[healthID].LabelSettings.ExtraLabels[DefaultLabelKey])
+	require.Equal(t, int64(1), fake.tests[healthID].Version)
+
+	// Nothing changed: the plan is empty.
+	defs, err := LoadDir(dir)
+	require.NoError(t, err)
+	plan, err = syncer.Plan(context.Background(), defs)
+	require.NoError(t, err)
+	require.False(t, plan.HasChanges(), plan.String())
+
+	// Drift: someone edits the interval and deletes the monitor in the UI.
+	fake.tests[healthID].Interval = "5m"
+	fake.tests[healthID].Version = 4
+	fake.monitors[healthID] = false
+	plan, err = syncer.Plan(context.Background(), defs)
+	require.NoError(t, err)
+	require.True(t, plan.HasChanges())
+	change := plan.Changes[0]
+	require.Equal(t, ActionUpdate, change.Action)
+	require.Equal(t, []FieldDiff{
+		{Path: "interval", Old: "5m", New: "1m"},
+		{Path: "createMonitor", Old: false, New: true},
+	}, change.Diffs)
+	require.Contains(t, plan.String(), "~ interval: \"5m\" -> \"1m\"")
+
+	_, err = syncer.Apply(context.Background(), plan)
+	require.NoError(t, err)
+	update := fake.updates[healthID]
+	require.Equal(t, int64(4), update.Version)
+	require.True(t, *update.CreateMonitor)
+	require.True(t, fake.monitors[healthID])
+	require.Equal(t, "1m", fake.tests[healthID].Interval)
+}
+
+func TestSyncRemovesMonitorAndPrunes(t *testing.T) {
+	fake := newFakeSynthetics()
+	api := testutil.NewAPI(t, fake)
+	dnsID := fake.add(&models.SyntheticTestCreateRequest{
+		Name:          "DNS",
+		Monitor:       &models.SyntheticMonitorConfig{Severity: "S2"},
+		LabelSettings: &models.LabelSettings{ExtraLabels: map[string]string{DefaultLabelKey: "dns"}},
+		CheckConfig:   &models.WorkerRequest{Kind: "dns", Metadata: &models.Metadata{SyntheticName: "DNS"}, Request: &models.Request{DNS: &models.DNSRequest{Kind: "dns", Domain: "example.com", RecordType: "A"}}},
+	})
+	goneID := fake.add(&models.SyntheticTestCreateRequest{
+		Name:          "Gone",
+		LabelSettings: &models.LabelSettings{ExtraLabels: map[string]string{DefaultLabelKey: "gone"}},
+	})
+	unmanagedID := fake.add(&models.SyntheticTestCreateRequest{Name: "Hand made"})
+
+	dir := testutil.WriteFiles(t, map[string]string{"dns.json": dnsJSON})
+	plan, _, err := New(api, WithPrune(true)).Sync(context.Background(), dir)
+	require.NoError(t, err)
+	require.Equal(t, 1, plan.Count(ActionUpdate))
+	require.Equal(t, 1, plan.Count(ActionDelete))
+	require.Contains(t, plan.String(), `- monitor: {"severity":"S2"}`)
+	require.Contains(t, plan.String(), "~ createMonitor: true -> false")
+
+	update := fake.updates[dnsID]
+	require.False(t, *update.CreateMonitor)
+	require.Nil(t, update.Monitor)
+	require.False(t, fake.monitors[dnsID])
+	require.NotContains(t, fake.tests, goneID)
+	require.Contains(t, fake.tests, unmanagedID)
+}
+
+func TestSyncAdoptsByNameAndDryRun(t *testing.T) {
+	fake := newFakeSynthetics()
+	api := testutil.NewAPI(t, fake)
+	id := fake.add(&models.SyntheticTestCreateRequest{
+		Name:     "API health",
+		Enabled:  true,
+		Interval: "1m",
+		CheckConfig: &models.WorkerRequest{
+			Kind:     "http",
+			Metadata: &models.Metadata{SyntheticName: "API health"},
+			Request:  &models.Request{HTTP: &models.HTTPRequest{Kind: "http", Method: "GET", URL: "https://api.example.com/health"}},
+			ExecutionPolicy: &models.ExecutionPolicy{Assertions: []*models.Assertion{
+				{Source: "statusCode", Operator: "eq", Target: "200"},
+			}},
+		},
+	})
+	defs := []*Definition{testutil.MustParse(t, ParseDefinition, "health.yaml", healthYAML)}
+
+	dry := New(api, WithDryRun(true))
+	plan, err := dry.Plan(context.Background(), defs)
+	require.NoError(t, err)
+	require.Equal(t, []FieldDiff{{
+		Path: "labelSettings",
+		New:  map[string]interface{}{"extraLabels": map[string]interface{}{"sync_id": "health"}},
+	}}, plan.Changes[0].Diffs)
+	result, err := dry.Apply(context.Background(), plan)
+	require.NoError(t, err)
+	require.True(t, result.DryRun)
+	require.Len(t, result.Applied, 1)
+	require.Empty(t, fake.updates)
+
+	plan, err = New(api, MatchByName()).Plan(context.Background(), []*Definition{testutil.MustParse(t, ParseDefinition, "health.yaml", healthYAML)})
+	require.NoError(t, err)
+	require.Equal(t, ActionNoop, plan.Changes[0].Action)
+	require.Equal(t, id, plan.Changes[0].ID)
+
+	plan, err = New(api, WithAdoptByName(false), WithIgnoreFields("interval")).Plan(context.Background(), defs)
+	require.NoError(t, err)
+	require.Equal(t, ActionCreate, plan.Changes[0].Action)
+}
+
+func TestPlanDoesNotAdoptAmbiguousNames(t *testing.T) {
+	fake := newFakeSynthetics()
+	api := testutil.NewAPI(t, fake)
+	for i := 0; i < 2; i++ {
+		fake.add(&models.SyntheticTestCreateRequest{Name: "API health", Interval: "1m"})
+	}
+	plan, err := New(api).Plan(context.Background(), []*Definition{testutil.MustParse(t, ParseDefinition, "health.yaml", healthYAML)})
+	require.NoError(t, err)
+	require.Equal(t, ActionCreate, plan.Changes[0].Action)
+	require.Empty(t, plan.Changes[0].ID)
+}
+
+func TestPlanRejectsDuplicateKeys(t *testing.T) {
+	api := testutil.NewAPI(t, newFakeSynthetics())
+	_, err := New(api, MatchByName()).Plan(context.Background(), []*Definition{
+		testutil.MustParse(t, ParseDefinition, "a.yaml", healthYAML),
+		testutil.MustParse(t, ParseDefinition, "b.yaml", healthYAML),
+	})
+	require.ErrorContains(t, err, `key "API health" is already used by a.yaml`)
+}
+
+func TestIgnoreFields(t *testing.T) {
+	fake := newFakeSynthetics()
+	api := testutil.NewAPI(t, fake)
+	def := testutil.MustParse(t, ParseDefinition, "health.yaml", healthYAML)
+	plan, _, err := New(api).Sync(context.Background(), testutil.WriteFiles(t, map[string]string{"health.yaml": healthYAML}))
+	require.NoError(t, err)
+	id := plan.Changes[0].ID
+	fake.tests[id].Enabled = false
+	fake.tests[id].Interval = "10m"
+
+	plan, err = New(api, WithIgnoreFields("enabled")).Plan(context.Background(), []*Definition{def})
+	require.NoError(t, err)
+	require.Equal(t, []FieldDiff{{Path: "interval", Old: "10m", New: "1m"}}, plan.Changes[0].Diffs)
+	_, err = New(api, WithIgnoreFields("enabled")).Apply(context.Background(), plan)
+	require.NoError(t, err)
+	require.False(t, fake.tests[id].Enabled)
+	require.Equal(t, "1m", fake.tests[id].Interval)
+	require.Equal(t, swag.Bool(true), fake.updates[id].CreateMonitor)
+}
+
+func TestPlanComparesExporters(t *testing.T) {
+	fake := newFakeSynthetics()
+	api := testutil.NewAPI(t, fake)
+	doc := healthYAML + "exporters: [old]\n"
+	plan, _, err := New(api).Sync(context.Background(), testutil.WriteFiles(t, map[string]string{"health.yaml": doc}))
+	require.NoError(t, err)
+	id := plan.Changes[0].ID
+	require.Equal(t, []string{"old"}, fake.tests[id].Exporters)
+
+	def := testutil.MustParse(t, ParseDefinition, "health.yaml", strings.Replace(doc, "[old]", "[new, other]", 1))
+	plan, err = New(api).Plan(context.Background(), []*Definition{def})
+	require.NoError(t, err)
+	require.Equal(t, []FieldDiff{{Path: "exporters[0]", Old: "old", New: "new"}, {Path: "exporters[1]", New: "other"}}, plan.Changes[0].Diffs)
+
+	fake.tests[id].Enabled = false
+	plan, err = New(api, WithIgnoreFields("enabled")).Plan(context.Background(), []*Definition{def})
+	require.NoError(t, err)
+	_, err = New(api, WithIgnoreFields("enabled")).Apply(context.Background(), plan)
+	require.NoError(t, err)
+	require.Equal(t, []string{"new", "other"}, fake.updates[id].Exporters)
+	require.False(t, fake.tests[id].Enabled)
+
+	plan, err = New(api).Plan(context.Background(), []*Definition{testutil.MustParse(t, ParseDefinition, "health.yaml", strings.Replace(doc, "[old]", "[other, new]", 1))})
+	require.NoError(t, err)
+	require.Equal(t, []FieldDiff{{Path: "enabled", Old: nil, New: true}}, plan.Changes[0].Diffs)
+}
@@ -0,0 +1,107 @@
+package timerange
+
+import (
+	"fmt"
+	"time"
+)
+
+// Align widens the range so that both ends fall on multiples of step: the start
+// is rounded down and the end is rounded up. Steps are aligned to the Unix
+// epoch, which matches how range queries bucket their samples. A non-positive
+// step returns the range unchanged.
+func (r Range) Align(step time.Duration) Range {
+	if step <= 0 {
+		return r
+	}
+	start := floor(r.Start, step)
+	end := floor(r.End, step)
+	if end.Before(r.End) {
+		end = end.Add(step)
+	}
+	return Range{Start: start, End: end}
+}
+
+// Split divides the range into consecutive shards no longer than size, for
+// example to run a long query as several parallel requests. The last shard may
+// be shorter than size.
+func (r Range) Split(size time.Duration) ([]Range, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("shard size must be positive, got %s", size)
+	}
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	var shards []Range
+	for start := r.Start; start.Before(r.End); start = start.Add(size) {
+		end := start.Add(size)
+		if end.After(r.End) {
+			end = r.End
+		}
+		shards = append(shards, Range{Start: start, End: end})
+	}
+	return shards, nil
+}
+
+// SplitAligned divides the range into shards whose inner boundaries fall on
+// multiples of size, so that shards of overlapping ranges share cache-friendly
+// boundaries. The first and last shards may be shorter than size.
+func (r Range) SplitAligned(size time.Duration) ([]Range, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("shard size must be positive, got %s", size)
+	}
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	var shards []Range
+	start := r.Start
+	for start.Before(r.End) {
+		end := floor(start, size).Add(size)
+		if end.After(r.End) {
+			end = r.End
+		}
+		shards = append(shards, Range{Start: start, End: end})
+		start = end
+	}
+	return shards, nil
+}
+
+// SplitN divides the range into n shards of equal length.
+func (r Range) SplitN(n int) ([]Range, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("shard count must be positive, got %d", n)
+	}
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	size := r.Duration() / time.Duration(n)
+	if size <= 0 {
+		return nil, fmt.Errorf("time range %s is too short to split into %d shards", r, n)
+	}
+
+	shards := make([]Range, n)
+	start := r.Start
+	for i := range shards {
+		end := start.Add(size)
+		if i == n-1 {
+			end = r.End
+		}
+		shards[i] = Range{Start: start, End: end}
+		start = end
+	}
+	return shards, nil
+}
+
+// floor rounds t down to a multiple of step counted from the Unix epoch.
+// time.Truncate counts from the zero time instead, which puts weekly
+// boundaries on Mondays rather than on the epoch's Thursdays.
+func floor(t time.Time, step time.Duration) time.Time {
+	ns := t.UnixNano()
+	rem := ns % int64(step)
+	if rem < 0 {
+		rem += int64(step)
+	}
+	return time.Unix(0, ns-rem).In(t.Location())
+}
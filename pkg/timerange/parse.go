@@ -0,0 +1,211 @@
+package timerange
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+)
+
+const (
+	keywordNow  = "now"
+	keywordLast = "last "
+	rangeSep    = " to "
+)
+
+// Parse parses a range expression relative to the current time.
+// See ParseAt for the accepted syntax.
+func Parse(expr string) (Range, error) {
+	return ParseAt(expr, time.Now())
+}
+
+// ParseAt parses a range expression relative to now. Accepted forms are:
+//
+//	last 2h                   the last two hours up to now
+//	now-15m                   from fifteen minutes ago up to now
+//	now-1d/d                  from the start of yesterday up to now
+//	now-1d/d to now-1d/d      all of yesterday
+//	now-1d/d to now/d         all of yesterday and today
+//	2024-01-02T15:04:05Z to now
+//
+// A single time expression is used as the start of a range that ends now.
+// When a range is given with " to ", rounding on the end expression rounds up
+// to the end of the unit: "now/d" as an end means the end of today, not its start.
+func ParseAt(expr string, now time.Time) (Range, error) {
+	s := strings.TrimSpace(expr)
+	if s == "" {
+		return Range{}, fmt.Errorf("empty time range expression")
+	}
+
+	if strings.HasPrefix(strings.ToLower(s), keywordLast) {
+		d, err := ParseDuration(strings.TrimSpace(s[len(keywordLast):]))
+		if err != nil {
+			return Range{}, fmt.Errorf("invalid time range %q: %w", expr, err)
+		}
+		if d <= 0 {
+			return Range{}, fmt.Errorf("invalid time range %q: duration must be positive", expr)
+		}
+		return LastAt(d, now), nil
+	}
+
+	startExpr, endExpr := s, keywordNow
+	if i := strings.Index(s, rangeSep); i >= 0 {
+		startExpr, endExpr = strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+len(rangeSep):])
+	}
+
+	start, err := parseTime(startExpr, now, false)
+	if err != nil {
+		return Range{}, fmt.Errorf("invalid time range %q: %w", expr, err)
+	}
+	end, err := parseTime(endExpr, now, true)
+	if err != nil {
+		return Range{}, fmt.Errorf("invalid time range %q: %w", expr, err)
+	}
+
+	r := Range{Start: start, End: end}
+	if err := r.Validate(); err != nil {
+		return Range{}, fmt.Errorf("invalid time range %q: %w", expr, err)
+	}
+	return r, nil
+}
+
+// ParseTime parses a single point in time relative to now. It accepts RFC3339
+// timestamps and expressions starting with "now" followed by any number of
+// offsets ("-15m", "+1h") and roundings ("/d"), applied left to right.
+// Supported units are s, m, h, d, w, M (month) and y. Rounding truncates to the
+// start of the unit in now's location.
+func ParseTime(expr string, now time.Time) (time.Time, error) {
+	return parseTime(expr, now, false)
+}
+
+func parseTime(expr string, now time.Time, roundUp bool) (time.Time, error) {
+	s := strings.TrimSpace(expr)
+	if !strings.HasPrefix(s, keywordNow) {
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("time %q is neither RFC3339 nor a relative expression", expr)
+		}
+		return t, nil
+	}
+
+	t := now
+	rest := s[len(keywordNow):]
+	for rest != "" {
+		op := rest[0]
+		rest = rest[1:]
+
+		switch op {
+		case '+', '-':
+			i := 0
+			for i < len(rest) && rest[i] >= '0' && rest[i] <= '9' {
+				i++
+			}
+			if i == 0 || i == len(rest) {
+				return time.Time{}, fmt.Errorf("invalid offset in %q", expr)
+			}
+			n, err := strconv.Atoi(rest[:i])
+			if err != nil {
+				return time.Time{}, fmt.Errorf("invalid offset in %q: %w", expr, err)
+			}
+			if op == '-' {
+				n = -n
+			}
+			if t, err = addUnit(t, n, rest[i]); err != nil {
+				return time.Time{}, fmt.Errorf("invalid offset in %q: %w", expr, err)
+			}
+			rest = rest[i+1:]
+		case '/':
+			if rest == "" {
+				return time.Time{}, fmt.Errorf("missing rounding unit in %q", expr)
+			}
+			var err error
+			if t, err = roundToUnit(t, rest[0], roundUp); err != nil {
+				return time.Time{}, fmt.Errorf("invalid rounding in %q: %w", expr, err)
+			}
+			rest = rest[1:]
+		default:
+			return time.Time{}, fmt.Errorf("unexpected %q in %q", op, expr)
+		}
+	}
+	return t, nil
+}
+
+// ParseDuration parses a duration string. In addition to the Go duration
+// syntax it accepts the day, week and year units used by prometheus ("1d", "2w").
+func ParseDuration(s string) (time.Duration, error) {
+	var d models.Duration
+	if err := d.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return 0, err
+	}
+	return time.Duration(d), nil
+}
+
+// FormatDuration formats a duration in the largest whole unit, the way the
+// API writes durations, as in "30s" or "5m".
+func FormatDuration(d time.Duration) string {
+	switch {
+	case d == 0:
+		return "0s"
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	case d%time.Second == 0:
+		return fmt.Sprintf("%ds", d/time.Second)
+	}
+	return fmt.Sprintf("%dms", d/time.Millisecond)
+}
+
+func addUnit(t time.Time, n int, unit byte) (time.Time, error) {
+	switch unit {
+	case 's':
+		return t.Add(time.Duration(n) * time.Second), nil
+	case 'm':
+		return t.Add(time.Duration(n) * time.Minute), nil
+	case 'h':
+		return t.Add(time.Duration(n) * time.Hour), nil
+	case 'd':
+		return t.AddDate(0, 0, n), nil
+	case 'w':
+		return t.AddDate(0, 0, 7*n), nil
+	case 'M':
+		return t.AddDate(0, n, 0), nil
+	case 'y':
+		return t.AddDate(n, 0, 0), nil
+	}
+	return time.Time{}, fmt.Errorf("unknown unit %q", unit)
+}
+
+func roundToUnit(t time.Time, unit byte, roundUp bool) (time.Time, error) {
+	y, mo, d := t.Date()
+	loc := t.Location()
+
+	var start time.Time
+	switch unit {
+	case 's':
+		start = t.Truncate(time.Second)
+	case 'm':
+		start = time.Date(y, mo, d, t.Hour(), t.Minute(), 0, 0, loc)
+	case 'h':
+		start = time.Date(y, mo, d, t.Hour(), 0, 0, 0, loc)
+	case 'd':
+		start = time.Date(y, mo, d, 0, 0, 0, 0, loc)
+	case 'w':
+		// Weeks start on Monday.
+		offset := (int(t.Weekday()) + 6) % 7
+		start = time.Date(y, mo, d-offset, 0, 0, 0, 0, loc)
+	case 'M':
+		start = time.Date(y, mo, 1, 0, 0, 0, 0, loc)
+	case 'y':
+		start = time.Date(y, time.January, 1, 0, 0, 0, 0, loc)
+	default:
+		return time.Time{}, fmt.Errorf("unknown unit %q", unit)
+	}
+
+	if !roundUp {
+		return start, nil
+	}
+	return addUnit(start, 1, unit)
+}
@@ -0,0 +1,160 @@
+// Package timerange provides helpers for building and converting the time ranges
+// accepted by the groundcover API.
+//
+// Requests express time ranges in several shapes: *strfmt.DateTime start/end
+// pairs (logs, traces and events search), strfmt.DateTime values
+// (models.QueryRequest), models.TimeRange with string times, and
+// models.RelativeTimerange with durations relative to now. Range is a single
+// absolute representation that converts to and from all of them.
+package timerange
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+)
+
+// Range is an absolute, half-open time range [Start, End).
+type Range struct {
+	Start time.Time
+	End   time.Time
+}
+
+// New creates a Range from start and end times.
+func New(start, end time.Time) Range {
+	return Range{Start: start, End: end}
+}
+
+// Last returns the range covering the duration d up to now.
+func Last(d time.Duration) Range {
+	return LastAt(d, time.Now())
+}
+
+// LastAt returns the range covering the duration d up to the given time.
+func LastAt(d time.Duration, now time.Time) Range {
+	return Range{Start: now.Add(-d), End: now}
+}
+
+// Duration returns the length of the range.
+func (r Range) Duration() time.Duration {
+	return r.End.Sub(r.Start)
+}
+
+// IsZero reports whether both ends of the range are unset.
+func (r Range) IsZero() bool {
+	return r.Start.IsZero() && r.End.IsZero()
+}
+
+// Validate returns an error if the range is empty or inverted.
+func (r Range) Validate() error {
+	if r.Start.IsZero() || r.End.IsZero() {
+		return fmt.Errorf("time range start and end are required")
+	}
+	if !r.End.After(r.Start) {
+		return fmt.Errorf("time range end %s must be after start %s", r.End.Format(time.RFC3339), r.Start.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// Contains reports whether t falls within the range.
+func (r Range) Contains(t time.Time) bool {
+	return !t.Before(r.Start) && t.Before(r.End)
+}
+
+// String returns the range formatted as "start/end" in RFC3339.
+func (r Range) String() string {
+	return r.Start.Format(time.RFC3339) + "/" + r.End.Format(time.RFC3339)
+}
+
+// DateTimes returns the range as strfmt.DateTime values, as used by
+// models.QueryRequest.
+func (r Range) DateTimes() (start, end strfmt.DateTime) {
+	return strfmt.DateTime(r.Start), strfmt.DateTime(r.End)
+}
+
+// DateTimePointers returns the range as *strfmt.DateTime values, as used by
+// the logs, traces and events search requests.
+func (r Range) DateTimePointers() (start, end *strfmt.DateTime) {
+	s, e := r.DateTimes()
+	return &s, &e
+}
+
+// TimeRange returns the range as a models.TimeRange with RFC3339 times.
+func (r Range) TimeRange() *models.TimeRange {
+	start := r.Start.Format(time.RFC3339)
+	end := r.End.Format(time.RFC3339)
+	return &models.TimeRange{StartTime: &start, EndTime: &end}
+}
+
+// Relative returns the range as a models.RelativeTimerange, with From and To
+// expressed as offsets from now (negative values lie in the past).
+func (r Range) Relative(now time.Time) *models.RelativeTimerange {
+	return &models.RelativeTimerange{
+		From: strfmt.Duration(r.Start.Sub(now)),
+		To:   strfmt.Duration(r.End.Sub(now)),
+	}
+}
+
+// ApplyToQuery sets the Start and End of a metrics query request.
+func (r Range) ApplyToQuery(req *models.QueryRequest) {
+	req.Start, req.End = r.DateTimes()
+}
+
+// ApplyToLogs sets the Start and End of a logs search request.
+func (r Range) ApplyToLogs(req *models.LogsSearchRequest) {
+	req.Start, req.End = r.DateTimePointers()
+}
+
+// ApplyToTraces sets the Start and End of a traces search request.
+func (r Range) ApplyToTraces(req *models.TracesSearchRequest) {
+	req.Start, req.End = r.DateTimePointers()
+}
+
+// ApplyToEvents sets the Start and End of an events search request.
+func (r Range) ApplyToEvents(req *models.EventsSearchRequest) {
+	req.Start, req.End = r.DateTimePointers()
+}
+
+// FromDateTimes creates a Range from a *strfmt.DateTime start/end pair.
+func FromDateTimes(start, end *strfmt.DateTime) (Range, error) {
+	if start == nil || end == nil {
+		return Range{}, fmt.Errorf("time range start and end are required")
+	}
+	return Range{Start: time.Time(*start), End: time.Time(*end)}, nil
+}
+
+// FromQuery creates a Range from the Start and End of a metrics query request.
+func FromQuery(req *models.QueryRequest) Range {
+	return Range{Start: time.Time(req.Start), End: time.Time(req.End)}
+}
+
+// FromTimeRange creates a Range from a models.TimeRange whose times are
+// RFC3339 timestamps or relative expressions accepted by ParseTime.
+func FromTimeRange(tr *models.TimeRange, now time.Time) (Range, error) {
+	if tr == nil || tr.StartTime == nil || tr.EndTime == nil {
+		return Range{}, fmt.Errorf("time range start and end are required")
+	}
+	start, err := ParseTime(*tr.StartTime, now)
+	if err != nil {
+		return Range{}, fmt.Errorf("invalid start time: %w", err)
+	}
+	end, err := parseTime(*tr.EndTime, now, true)
+	if err != nil {
+		return Range{}, fmt.Errorf("invalid end time: %w", err)
+	}
+	return Range{Start: start, End: end}, nil
+}
+
+// FromRelative creates a Range from a models.RelativeTimerange evaluated at now.
+// A zero To is treated as now.
+func FromRelative(rel *models.RelativeTimerange, now time.Time) (Range, error) {
+	if rel == nil {
+		return Range{}, fmt.Errorf("relative time range is required")
+	}
+	return Range{
+		Start: now.Add(time.Duration(rel.From)),
+		End:   now.Add(time.Duration(rel.To)),
+	}, nil
+}
@@ -0,0 +1,213 @@
+package timerange
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/groundcover-com/groundcover-sdk-go/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+// 2024-03-13 is a Wednesday.
+var testNow = time.Date(2024, time.March, 13, 15, 42, 17, 0, time.UTC)
+
+func TestParseAt(t *testing.T) {
+	tests := []struct {
+		name      string
+		expr      string
+		wantStart time.Time
+		wantEnd   time.Time
+	}{
+		{name: "last hours", expr: "last 2h", wantStart: testNow.Add(-2 * time.Hour), wantEnd: testNow},
+		{name: "last days", expr: "last 1d", wantStart: testNow.Add(-24 * time.Hour), wantEnd: testNow},
+		{name: "last is case insensitive", expr: "Last 30m", wantStart: testNow.Add(-30 * time.Minute), wantEnd: testNow},
+		{name: "now minus minutes", expr: "now-15m", wantStart: testNow.Add(-15 * time.Minute), wantEnd: testNow},
+		{name: "start of yesterday", expr: "now-1d/d", wantStart: time.Date(2024, time.March, 12, 0, 0, 0, 0, time.UTC), wantEnd: testNow},
+		{
+			name:      "yesterday and today",
+			expr:      "now-1d/d to now/d",
+			wantStart: time.Date(2024, time.March, 12, 0, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2024, time.March, 14, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "all of yesterday",
+			expr:      "now-1d/d to now-1d/d",
+			wantStart: time.Date(2024, time.March, 12, 0, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2024, time.March, 13, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "this week",
+			expr:      "now/w",
+			wantStart: time.Date(2024, time.March, 11, 0, 0, 0, 0, time.UTC),
+			wantEnd:   testNow,
+		},
+		{
+			name:      "previous month",
+			expr:      "now-1M/M to now-1M/M",
+			wantStart: time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "chained offsets",
+			expr:      "now-1h+15m",
+			wantStart: testNow.Add(-45 * time.Minute),
+			wantEnd:   testNow,
+		},
+		{
+			name:      "absolute to relative",
+			expr:      "2024-03-13T12:00:00Z to now-1h/h",
+			wantStart: time.Date(2024, time.March, 13, 12, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2024, time.March, 13, 15, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := ParseAt(tt.expr, testNow)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantStart, r.Start)
+			require.Equal(t, tt.wantEnd, r.End)
+		})
+	}
+}
+
+func TestParseAtRejectsInvalidExpressions(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"yesterday",
+		"now-",
+		"now-15",
+		"now-15q",
+		"now/",
+		"now/q",
+		"now*2",
+		"last",
+		"last -1h",
+		"last forever",
+		"now to now-1h",
+	} {
+		t.Run(expr, func(t *testing.T) {
+			_, err := ParseAt(expr, testNow)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestParseDurationAcceptsPrometheusUnits(t *testing.T) {
+	d, err := ParseDuration("1d2h")
+	require.NoError(t, err)
+	require.Equal(t, 26*time.Hour, d)
+}
+
+func TestFormatDuration(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		0:                       "0s",
+		90 * time.Second:        "90s",
+		2 * time.Hour:           "2h",
+		15 * time.Minute:        "15m",
+		1500 * time.Millisecond: "1500ms",
+	} {
+		require.Equal(t, want, FormatDuration(d))
+		back, err := ParseDuration(want)
+		require.NoError(t, err)
+		require.Equal(t, d, back)
+	}
+}
+
+func TestConversionsRoundTrip(t *testing.T) {
+	r := New(testNow.Add(-time.Hour), testNow)
+
+	start, end := r.DateTimePointers()
+	fromPointers, err := FromDateTimes(start, end)
+	require.NoError(t, err)
+	require.True(t, r.Start.Equal(fromPointers.Start))
+	require.True(t, r.End.Equal(fromPointers.End))
+
+	query := &models.QueryRequest{}
+	r.ApplyToQuery(query)
+	require.Equal(t, strfmt.DateTime(r.Start), query.Start)
+	require.Equal(t, r, FromQuery(query))
+
+	logs := &models.LogsSearchRequest{}
+	r.ApplyToLogs(logs)
+	require.Equal(t, strfmt.DateTime(r.End), *logs.End)
+
+	fromTimeRange, err := FromTimeRange(r.TimeRange(), testNow)
+	require.NoError(t, err)
+	require.True(t, r.Start.Equal(fromTimeRange.Start))
+	require.True(t, r.End.Equal(fromTimeRange.End))
+
+	rel := r.Relative(testNow)
+	require.Equal(t, strfmt.Duration(-time.Hour), rel.From)
+	require.Equal(t, strfmt.Duration(0), rel.To)
+	fromRelative, err := FromRelative(rel, testNow)
+	require.NoError(t, err)
+	require.Equal(t, r, fromRelative)
+}
+
+func TestFromDateTimesRequiresBothEnds(t *testing.T) {
+	start := strfmt.DateTime(testNow)
+	_, err := FromDateTimes(&start, nil)
+	require.Error(t, err)
+}
+
+func TestAlign(t *testing.T) {
+	r := New(testNow.Add(-time.Hour), testNow).Align(5 * time.Minute)
+	require.Equal(t, time.Date(2024, time.March, 13, 14, 40, 0, 0, time.UTC), r.Start)
+	require.Equal(t, time.Date(2024, time.March, 13, 15, 45, 0, 0, time.UTC), r.End)
+
+	aligned := New(r.Start, r.End)
+	require.Equal(t, aligned, aligned.Align(5*time.Minute))
+	require.Equal(t, aligned, aligned.Align(0))
+}
+
+func TestAlignWeeksToUnixEpoch(t *testing.T) {
+	// The Unix epoch fell on a Thursday, so weekly buckets start on Thursdays.
+	r := New(testNow.Add(-time.Hour), testNow).Align(7 * 24 * time.Hour)
+	require.Equal(t, time.Date(2024, time.March, 7, 0, 0, 0, 0, time.UTC), r.Start)
+	require.Equal(t, time.Date(2024, time.March, 14, 0, 0, 0, 0, time.UTC), r.End)
+}
+
+func TestSplit(t *testing.T) {
+	r := New(testNow.Add(-150*time.Minute), testNow)
+
+	shards, err := r.Split(time.Hour)
+	require.NoError(t, err)
+	require.Len(t, shards, 3)
+	require.Equal(t, r.Start, shards[0].Start)
+	require.Equal(t, time.Hour, shards[0].Duration())
+	require.Equal(t, shards[0].End, shards[1].Start)
+	require.Equal(t, 30*time.Minute, shards[2].Duration())
+	require.Equal(t, r.End, shards[2].End)
+
+	_, err = r.Split(0)
+	require.Error(t, err)
+}
+
+func TestSplitAligned(t *testing.T) {
+	r := New(testNow.Add(-2*time.Hour), testNow)
+
+	shards, err := r.SplitAligned(time.Hour)
+	require.NoError(t, err)
+	require.Len(t, shards, 3)
+	require.Equal(t, r.Start, shards[0].Start)
+	require.Equal(t, time.Date(2024, time.March, 13, 14, 0, 0, 0, time.UTC), shards[0].End)
+	require.Equal(t, time.Date(2024, time.March, 13, 15, 0, 0, 0, time.UTC), shards[1].End)
+	require.Equal(t, r.End, shards[2].End)
+}
+
+func TestSplitN(t *testing.T) {
+	r := New(testNow.Add(-time.Hour), testNow)
+
+	shards, err := r.SplitN(4)
+	require.NoError(t, err)
+	require.Len(t, shards, 4)
+	for i, shard := range shards {
+		require.Equal(t, 15*time.Minute, shard.Duration(), "shard %d", i)
+	}
+	require.Equal(t, r.End, shards[3].End)
+
+	_, err = New(testNow, testNow.Add(time.Nanosecond)).SplitN(2)
+	require.Error(t, err)
+}
@@ -14,12 +14,16 @@ const (
 	ConditionKeyType      = "type"
 	ConditionKeyEnv       = "env"
 	ConditionKeyInstance  = "instance"
+	ConditionKeyCluster   = "cluster"
+	ConditionKeyLevel     = "level"
 )
 
 // Condition values
 const (
 	ConditionValueOOMKilled          = "OOMKilled"
 	ConditionValueTypeContainerCrash = "container_crash"
+	ConditionValueCrashLoopBackOff   = "CrashLoopBackOff"
+	ConditionValueLevelError         = "error"
 )
 
 // Filter operators